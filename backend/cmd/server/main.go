@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"dsi_interna_sys/internal/config"
 	"dsi_interna_sys/internal/database"
+	"dsi_interna_sys/internal/jobs"
 	"dsi_interna_sys/internal/routes"
 	"dsi_interna_sys/internal/services"
 
@@ -39,8 +41,14 @@ func main() {
 		log.Fatalf("Failed to create upload directories: %v", err)
 	}
 
-	// Start Scheduler
-	services.StartAgendaScheduler(db)
+	// Start background jobs
+	queue := jobs.New(db, cfg.Jobs.Workers)
+	if err := services.RegisterJobs(queue, db); err != nil {
+		log.Fatalf("Failed to register jobs: %v", err)
+	}
+	if err := queue.Start(); err != nil {
+		log.Fatalf("Failed to start job queue: %v", err)
+	}
 
 	// Setup router
 	router := mux.NewRouter()
@@ -83,6 +91,21 @@ func main() {
 	<-quit
 
 	log.Println("Server shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+
+	// Let running jobs finish; whatever is still running afterwards is
+	// re-queued for the next start
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.Jobs.DrainTimeout)
+	defer drainCancel()
+	if err := queue.Shutdown(drainCtx); err != nil {
+		log.Printf("Job queue drain: %v", err)
+	}
+
 	log.Println("Server stopped")
 }
 
@@ -0,0 +1,37 @@
+-- Announcements broadcast to interns, with per-recipient read receipts.
+-- notifications.type is widened to VARCHAR so new subsystems can add their own
+-- notification types without rewriting the enum each time.
+ALTER TABLE notifications MODIFY COLUMN `type` VARCHAR(50) DEFAULT 'info';
+
+CREATE TABLE IF NOT EXISTS announcements (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    target_type ENUM('all', 'supervisor', 'school', 'department') NOT NULL DEFAULT 'all',
+    target_value VARCHAR(255) DEFAULT NULL,
+    created_by BIGINT NOT NULL,
+    is_pinned TINYINT(1) DEFAULT 0,
+    requires_ack TINYINT(1) DEFAULT 0,
+    publish_at DATETIME NOT NULL,
+    expires_at DATETIME DEFAULT NULL,
+    published_at DATETIME DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY idx_publish (published_at, publish_at),
+    CONSTRAINT fk_announcements_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS announcement_recipients (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    announcement_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    intern_id BIGINT DEFAULT NULL,
+    delivered_at DATETIME NOT NULL,
+    read_at DATETIME DEFAULT NULL,
+    acknowledged_at DATETIME DEFAULT NULL,
+    UNIQUE KEY uniq_announcement_user (announcement_id, user_id),
+    KEY idx_user (user_id),
+    CONSTRAINT fk_announcement_recipients_announcement FOREIGN KEY (announcement_id) REFERENCES announcements(id) ON DELETE CASCADE,
+    CONSTRAINT fk_announcement_recipients_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_announcement_recipients_intern FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,29 @@
+-- Threaded comments on tasks, reports and leave requests. Review feedback used
+-- to overwrite tasks.admin_feedback / reports.feedback; every round is now kept
+-- here as well so the conversation survives status changes.
+CREATE TABLE IF NOT EXISTS comments (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    entity_type ENUM('task', 'report', 'leave') NOT NULL,
+    entity_id BIGINT NOT NULL,
+    parent_id BIGINT DEFAULT NULL,
+    author_id BIGINT NOT NULL,
+    body TEXT NOT NULL,
+    attachment_path VARCHAR(500) DEFAULT NULL,
+    attachment_name VARCHAR(255) DEFAULT NULL,
+    edited_at DATETIME DEFAULT NULL,
+    deleted_at DATETIME DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_entity (entity_type, entity_id, created_at),
+    KEY idx_parent (parent_id),
+    CONSTRAINT fk_comments_parent FOREIGN KEY (parent_id) REFERENCES comments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    PRIMARY KEY (comment_id, user_id),
+    KEY idx_user (user_id),
+    CONSTRAINT fk_comment_mentions_comment FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_comment_mentions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,48 @@
+-- Immutable submission versions and review records for tasks. The submission_*
+-- and admin_feedback columns on tasks keep mirroring the latest round.
+CREATE TABLE IF NOT EXISTS task_submissions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    submitted_by BIGINT NOT NULL,
+    submission_notes TEXT DEFAULT NULL,
+    submission_links JSON DEFAULT NULL,
+    submission_file VARCHAR(255) DEFAULT NULL,
+    is_late TINYINT(1) DEFAULT 0,
+    submitted_at DATETIME NOT NULL,
+    UNIQUE KEY uniq_task_version (task_id, version),
+    CONSTRAINT fk_task_submissions_task FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_submissions_user FOREIGN KEY (submitted_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS task_reviews (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_id BIGINT NOT NULL,
+    submission_id BIGINT DEFAULT NULL,
+    reviewer_id BIGINT NOT NULL,
+    action ENUM('approve', 'revision') NOT NULL,
+    score INT DEFAULT NULL,
+    feedback TEXT DEFAULT NULL,
+    reviewed_at DATETIME NOT NULL,
+    KEY idx_task (task_id, reviewed_at),
+    CONSTRAINT fk_task_reviews_task FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_reviews_submission FOREIGN KEY (submission_id) REFERENCES task_submissions(id) ON DELETE SET NULL,
+    CONSTRAINT fk_task_reviews_reviewer FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Backfill the current submission of every task as version 1
+INSERT INTO task_submissions (task_id, version, submitted_by, submission_notes, submission_links, submission_file, is_late, submitted_at)
+SELECT t.id, 1, i.user_id, t.submission_notes, t.submission_links, t.submission_file, t.is_late, t.submitted_at
+FROM tasks t
+JOIN interns i ON t.intern_id = i.id
+WHERE t.submitted_at IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM task_submissions s WHERE s.task_id = t.id);
+
+-- ...and the latest review of every reviewed task
+INSERT INTO task_reviews (task_id, submission_id, reviewer_id, action, score, feedback, reviewed_at)
+SELECT t.id, s.id, COALESCE(t.assigner_id, t.assigned_by),
+       IF(t.status = 'completed', 'approve', 'revision'), t.score, t.admin_feedback, t.approved_at
+FROM tasks t
+LEFT JOIN task_submissions s ON s.task_id = t.id AND s.version = 1
+WHERE t.approved_at IS NOT NULL AND t.status IN ('completed', 'revision')
+  AND NOT EXISTS (SELECT 1 FROM task_reviews r WHERE r.task_id = t.id);
@@ -0,0 +1,28 @@
+-- Checklists, parent/child subtasks and "blocked by" dependencies for tasks.
+-- A task with unfinished prerequisites stays 'scheduled' until they complete.
+ALTER TABLE tasks ADD COLUMN parent_task_id BIGINT DEFAULT NULL AFTER task_assignment_id;
+ALTER TABLE tasks ADD CONSTRAINT fk_tasks_parent FOREIGN KEY (parent_task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS task_checklist_items (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_id BIGINT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    is_done TINYINT(1) DEFAULT 0,
+    done_at DATETIME DEFAULT NULL,
+    done_by BIGINT DEFAULT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_task (task_id, position),
+    CONSTRAINT fk_task_checklist_task FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_checklist_done_by FOREIGN KEY (done_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_id BIGINT NOT NULL,
+    depends_on_task_id BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, depends_on_task_id),
+    KEY idx_depends_on (depends_on_task_id),
+    CONSTRAINT fk_task_dependencies_task FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_dependencies_depends_on FOREIGN KEY (depends_on_task_id) REFERENCES tasks(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,43 @@
+-- Reusable task templates and recurrence rules that a scheduler expands into
+-- task_assignments. Offsets are relative to the date a template is instantiated.
+CREATE TABLE IF NOT EXISTS task_templates (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    description TEXT DEFAULT NULL,
+    submission_method ENUM('links', 'files', 'both') DEFAULT 'both',
+    priority ENUM('low', 'medium', 'high') DEFAULT 'medium',
+    start_offset_days INT NOT NULL DEFAULT 0,
+    deadline_offset_days INT DEFAULT NULL,
+    deadline_time TIME DEFAULT NULL,
+    checklist JSON DEFAULT NULL,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_task_templates_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS task_recurrences (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    template_id BIGINT NOT NULL,
+    frequency ENUM('daily', 'weekly', 'monthly') NOT NULL,
+    weekday TINYINT DEFAULT NULL,
+    day_of_month TINYINT DEFAULT NULL,
+    assign_to ENUM('all', 'selected') NOT NULL DEFAULT 'all',
+    intern_ids JSON DEFAULT NULL,
+    starts_on DATE NOT NULL,
+    ends_on DATE DEFAULT NULL,
+    next_run_on DATE NOT NULL,
+    last_run_on DATE DEFAULT NULL,
+    is_active TINYINT(1) DEFAULT 1,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    KEY idx_due (is_active, next_run_on),
+    CONSTRAINT fk_task_recurrences_template FOREIGN KEY (template_id) REFERENCES task_templates(id) ON DELETE CASCADE,
+    CONSTRAINT fk_task_recurrences_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE task_assignments ADD COLUMN template_id BIGINT DEFAULT NULL;
+ALTER TABLE task_assignments ADD COLUMN recurrence_id BIGINT DEFAULT NULL;
+ALTER TABLE task_assignments ADD CONSTRAINT fk_task_assignments_template FOREIGN KEY (template_id) REFERENCES task_templates(id) ON DELETE SET NULL;
+ALTER TABLE task_assignments ADD CONSTRAINT fk_task_assignments_recurrence FOREIGN KEY (recurrence_id) REFERENCES task_recurrences(id) ON DELETE SET NULL;
@@ -0,0 +1,10 @@
+-- Tamper-evident certificates: an HMAC over the certificate fields is stored
+-- with each certificate and checked by the public /verify/{number} endpoint.
+ALTER TABLE certificates
+    ADD COLUMN status ENUM('issued', 'revoked') NOT NULL DEFAULT 'issued' AFTER final_score,
+    ADD COLUMN signature VARCHAR(128) DEFAULT NULL AFTER status,
+    ADD COLUMN signed_at DATETIME DEFAULT NULL AFTER signature,
+    ADD COLUMN revoked_at DATETIME DEFAULT NULL,
+    ADD COLUMN revoked_by BIGINT DEFAULT NULL,
+    ADD COLUMN revoked_reason TEXT DEFAULT NULL,
+    ADD CONSTRAINT fk_certificates_revoked_by FOREIGN KEY (revoked_by) REFERENCES users(id) ON DELETE SET NULL;
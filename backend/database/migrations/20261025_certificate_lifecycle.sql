@@ -0,0 +1,66 @@
+-- Single certificate lifecycle: draft -> issued -> revoked / reissued.
+-- Numbers are only assigned at issuance, so drafts have no number yet.
+ALTER TABLE certificates
+    MODIFY certificate_number VARCHAR(100) NULL,
+    MODIFY status ENUM('draft', 'issued', 'revoked', 'reissued') NOT NULL DEFAULT 'draft',
+    ADD COLUMN sequence_year INT DEFAULT NULL AFTER certificate_number,
+    ADD COLUMN sequence_no INT DEFAULT NULL AFTER sequence_year,
+    ADD COLUMN issued_at DATETIME DEFAULT NULL,
+    ADD COLUMN issued_by BIGINT DEFAULT NULL,
+    ADD COLUMN reissued_from BIGINT DEFAULT NULL,
+    ADD COLUMN created_by BIGINT DEFAULT NULL,
+    ADD CONSTRAINT fk_certificates_issued_by FOREIGN KEY (issued_by) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_certificates_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_certificates_reissued_from FOREIGN KEY (reissued_from) REFERENCES certificates(id) ON DELETE SET NULL;
+
+-- Last sequence number handed out per year
+CREATE TABLE IF NOT EXISTS certificate_sequences (
+    year INT PRIMARY KEY,
+    last_value INT NOT NULL DEFAULT 0
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Every lifecycle transition with who did it and why
+CREATE TABLE IF NOT EXISTS certificate_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    certificate_id BIGINT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    from_status VARCHAR(20) DEFAULT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    actor_id BIGINT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_certificate_events_certificate (certificate_id),
+    CONSTRAINT fk_certificate_events_certificate FOREIGN KEY (certificate_id) REFERENCES certificates(id) ON DELETE CASCADE,
+    CONSTRAINT fk_certificate_events_actor FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Existing rows were issued when they were created
+UPDATE certificates SET issued_at = created_at WHERE status = 'issued' AND issued_at IS NULL;
+
+-- The two old generators could both create a certificate for the same
+-- intern; keep the newest one and mark the others as superseded.
+UPDATE certificates c
+JOIN certificates newer ON newer.intern_id = c.intern_id AND newer.id > c.id AND newer.status = 'issued'
+SET c.status = 'reissued'
+WHERE c.status = 'issued';
+
+-- Continue the yearly sequence after the highest legacy MG-DSI number
+INSERT INTO certificate_sequences (year, last_value)
+SELECT YEAR(issue_date), MAX(CAST(SUBSTRING_INDEX(certificate_number, '/', -1) AS UNSIGNED))
+FROM certificates
+WHERE certificate_number LIKE 'MG-DSI/%'
+GROUP BY YEAR(issue_date)
+ON DUPLICATE KEY UPDATE last_value = GREATEST(last_value, VALUES(last_value));
+
+-- interns.certificate_number always mirrors the current issued certificate
+UPDATE interns i
+LEFT JOIN certificates c ON c.intern_id = i.id AND c.status = 'issued'
+SET i.certificate_number = c.certificate_number,
+    i.certificate_issued_at = c.issued_at;
+
+INSERT INTO settings (`key`, `value`, `type`, description) VALUES
+    ('certificate_number_prefix', 'MG-DSI', 'string', 'Prefix of certificate numbers (<prefix>/<year>/<sequence>)'),
+    ('certificate_number_padding', '4', 'integer', 'Zero padding of the yearly certificate sequence'),
+    ('certificate_min_attendance_rate', '0', 'integer', 'Minimum attendance rate (%) required to issue a certificate'),
+    ('certificate_min_final_score', '0', 'integer', 'Minimum final score required to issue a certificate')
+ON DUPLICATE KEY UPDATE `key` = `key`;
@@ -0,0 +1,22 @@
+-- Admin-managed PDF layouts for certificates and official letters.
+-- layout holds the JSON field placements (see models.DocumentLayout).
+CREATE TABLE IF NOT EXISTS document_templates (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    document_type ENUM('certificate', 'acceptance_letter', 'completion_letter', 'recommendation_letter') NOT NULL,
+    orientation ENUM('P', 'L') NOT NULL DEFAULT 'P',
+    page_size VARCHAR(20) NOT NULL DEFAULT 'A4',
+    background_path VARCHAR(500) DEFAULT NULL,
+    logo_path VARCHAR(500) DEFAULT NULL,
+    signature_path VARCHAR(500) DEFAULT NULL,
+    signatory_name VARCHAR(255) DEFAULT NULL,
+    signatory_title VARCHAR(255) DEFAULT NULL,
+    layout JSON NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by BIGINT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_document_templates_type (document_type, is_default),
+    CONSTRAINT fk_document_templates_created_by FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,32 @@
+-- Batch generation of certificates and intern reports. A background worker
+-- renders one item per intern and packs the results into a single ZIP.
+CREATE TABLE IF NOT EXISTS document_jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    kind ENUM('certificates', 'reports', 'both') NOT NULL,
+    filters JSON DEFAULT NULL,
+    status ENUM('queued', 'running', 'completed', 'failed', 'cancelled') NOT NULL DEFAULT 'queued',
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    error TEXT DEFAULT NULL,
+    result_path VARCHAR(500) DEFAULT NULL,
+    requested_by BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    started_at DATETIME DEFAULT NULL,
+    finished_at DATETIME DEFAULT NULL,
+    INDEX idx_document_jobs_status (status),
+    CONSTRAINT fk_document_jobs_requested_by FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS document_job_items (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    job_id BIGINT NOT NULL,
+    intern_id BIGINT NOT NULL,
+    status ENUM('pending', 'done', 'failed') NOT NULL DEFAULT 'pending',
+    error TEXT DEFAULT NULL,
+    processed_at DATETIME DEFAULT NULL,
+    INDEX idx_document_job_items_job (job_id, status),
+    CONSTRAINT fk_document_job_items_job FOREIGN KEY (job_id) REFERENCES document_jobs(id) ON DELETE CASCADE,
+    CONSTRAINT fk_document_job_items_intern FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,39 @@
+-- Generic persistent job queue. Workers on every replica claim due rows with
+-- SELECT ... FOR UPDATE SKIP LOCKED (MySQL 8+), so a job runs only once.
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    payload JSON DEFAULT NULL,
+    status ENUM('pending', 'running', 'completed', 'failed', 'cancelled') NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    run_at DATETIME NOT NULL,
+    locked_by VARCHAR(100) DEFAULT NULL,
+    locked_at DATETIME DEFAULT NULL,
+    last_error TEXT DEFAULT NULL,
+    schedule_name VARCHAR(100) DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    finished_at DATETIME DEFAULT NULL,
+    INDEX idx_jobs_due (status, run_at),
+    INDEX idx_jobs_type (type, status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Recurring jobs. Schedules are declared in code and upserted at startup;
+-- next_run_at is advanced under a row lock so only one replica enqueues.
+CREATE TABLE IF NOT EXISTS job_schedules (
+    name VARCHAR(100) PRIMARY KEY,
+    spec VARCHAR(100) NOT NULL,
+    job_type VARCHAR(100) NOT NULL,
+    payload JSON DEFAULT NULL,
+    next_run_at DATETIME NOT NULL,
+    last_run_at DATETIME DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Batch document jobs are now run through the queue
+INSERT INTO jobs (type, payload, run_at)
+SELECT 'document_job', JSON_OBJECT('document_job_id', id), NOW()
+FROM document_jobs
+WHERE status IN ('queued', 'running');
@@ -0,0 +1,11 @@
+-- Report drafts generated from attendance, task and assessment data
+ALTER TABLE reports
+    ADD COLUMN auto_generated BOOLEAN NOT NULL DEFAULT FALSE AFTER feedback,
+    ADD COLUMN activity_summary JSON DEFAULT NULL AFTER auto_generated,
+    ADD INDEX idx_reports_period (intern_id, type, period_start, period_end);
+
+INSERT INTO settings (`key`, `value`, `type`, description) VALUES
+    ('report_auto_draft_weekly', 'false', 'boolean', 'Create weekly report drafts for active interns after each week'),
+    ('report_auto_draft_monthly', 'false', 'boolean', 'Create monthly report drafts for active interns after each month'),
+    ('report_auto_draft_final', 'false', 'boolean', 'Create a final report draft when an internship ends')
+ON DUPLICATE KEY UPDATE `key` = `key`;
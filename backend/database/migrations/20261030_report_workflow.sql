@@ -0,0 +1,36 @@
+-- Report approval workflow:
+-- draft -> submitted -> revision_requested | approved -> countersigned.
+-- Reports previously marked "reviewed" were accepted by their supervisor
+-- and become approved.
+ALTER TABLE reports
+    MODIFY status ENUM('draft', 'submitted', 'reviewed', 'revision_requested', 'approved', 'countersigned') DEFAULT 'draft';
+
+UPDATE reports SET status = 'approved' WHERE status = 'reviewed';
+
+ALTER TABLE reports
+    MODIFY status ENUM('draft', 'submitted', 'revision_requested', 'approved', 'countersigned') DEFAULT 'draft',
+    ADD COLUMN submitted_at DATETIME DEFAULT NULL AFTER activity_summary,
+    ADD COLUMN submitted_by BIGINT DEFAULT NULL AFTER submitted_at,
+    ADD COLUMN reviewed_at DATETIME DEFAULT NULL AFTER submitted_by,
+    ADD COLUMN reviewed_by BIGINT DEFAULT NULL AFTER reviewed_at,
+    ADD COLUMN countersigned_at DATETIME DEFAULT NULL AFTER reviewed_by,
+    ADD COLUMN countersigned_by BIGINT DEFAULT NULL AFTER countersigned_at,
+    ADD COLUMN signature VARCHAR(64) DEFAULT NULL AFTER countersigned_by,
+    ADD CONSTRAINT fk_reports_submitted_by FOREIGN KEY (submitted_by) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_reports_reviewed_by FOREIGN KEY (reviewed_by) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_reports_countersigned_by FOREIGN KEY (countersigned_by) REFERENCES users(id) ON DELETE SET NULL;
+
+-- Every workflow step, who took it and the note that came with it
+CREATE TABLE IF NOT EXISTS report_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    report_id BIGINT NOT NULL,
+    action ENUM('create', 'submit', 'request_revision', 'approve', 'countersign') NOT NULL,
+    from_status VARCHAR(30) DEFAULT NULL,
+    to_status VARCHAR(30) NOT NULL,
+    note TEXT DEFAULT NULL,
+    actor_id BIGINT DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_report_events_report (report_id, created_at),
+    CONSTRAINT fk_report_events_report FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE CASCADE,
+    CONSTRAINT fk_report_events_actor FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,34 @@
+-- Daily logbook (jurnal harian): one entry per intern per workday
+CREATE TABLE IF NOT EXISTS logbook_entries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    intern_id BIGINT NOT NULL,
+    date DATE NOT NULL,
+    attendance_id BIGINT NULL,
+    activities TEXT NOT NULL,
+    hours DECIMAL(4,2) NOT NULL DEFAULT 0,
+    attachment_name VARCHAR(255) NULL,
+    attachment_path VARCHAR(500) NULL,
+    status ENUM('submitted', 'acknowledged') NOT NULL DEFAULT 'submitted',
+    acknowledged_by BIGINT NULL,
+    acknowledged_at TIMESTAMP NULL,
+    supervisor_note TEXT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uniq_logbook_intern_date (intern_id, date),
+    INDEX idx_logbook_status (status),
+    FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE CASCADE,
+    FOREIGN KEY (attendance_id) REFERENCES attendances(id) ON DELETE SET NULL,
+    FOREIGN KEY (acknowledged_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS logbook_entry_tasks (
+    entry_id BIGINT NOT NULL,
+    task_id BIGINT NOT NULL,
+    PRIMARY KEY (entry_id, task_id),
+    FOREIGN KEY (entry_id) REFERENCES logbook_entries(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO settings (`key`, `value`, `type`, description) VALUES
+    ('logbook_reminder_enabled', 'true', 'boolean', 'Remind interns who have not filled in their daily logbook')
+ON DUPLICATE KEY UPDATE `key` = `key`;
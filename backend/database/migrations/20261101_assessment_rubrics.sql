@@ -0,0 +1,119 @@
+-- Configurable assessment rubrics. Criteria, scale and category thresholds
+-- live on an immutable rubric version; editing a rubric creates a new
+-- version so past assessments keep the form they were scored with.
+CREATE TABLE IF NOT EXISTS rubrics (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    description TEXT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    current_version INT NOT NULL DEFAULT 1,
+    created_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS rubric_versions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    rubric_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    scale_min DECIMAL(6,2) NOT NULL DEFAULT 0,
+    scale_max DECIMAL(6,2) NOT NULL DEFAULT 100,
+    thresholds JSON NOT NULL,
+    created_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uniq_rubric_version (rubric_id, version),
+    FOREIGN KEY (rubric_id) REFERENCES rubrics(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS rubric_criteria (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(150) NOT NULL,
+    description TEXT NULL,
+    weight DECIMAL(6,2) NOT NULL DEFAULT 1,
+    sort_order INT NOT NULL DEFAULT 0,
+    UNIQUE KEY uniq_rubric_criterion (version_id, code),
+    FOREIGN KEY (version_id) REFERENCES rubric_versions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- A rubric applies to interns of a school, a department, or both. The most
+-- specific match wins; the default rubric covers everyone else.
+CREATE TABLE IF NOT EXISTS rubric_assignments (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    rubric_id BIGINT NOT NULL,
+    school VARCHAR(255) NULL,
+    department VARCHAR(100) NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_rubric_assignment_target (school, department),
+    FOREIGN KEY (rubric_id) REFERENCES rubrics(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS assessment_scores (
+    assessment_id BIGINT NOT NULL,
+    criterion_id BIGINT NOT NULL,
+    score DECIMAL(6,2) NOT NULL,
+    PRIMARY KEY (assessment_id, criterion_id),
+    FOREIGN KEY (assessment_id) REFERENCES assessments(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES rubric_criteria(id) ON DELETE RESTRICT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- The category now comes from the rubric's thresholds instead of fixed
+-- cut-offs, so it is stored rather than generated. score stays a 0-100
+-- percentage; rubric_score is the weighted score on the rubric's scale.
+ALTER TABLE assessments
+    MODIFY COLUMN category VARCHAR(50) NULL,
+    ADD COLUMN category_label VARCHAR(100) NULL AFTER category,
+    ADD COLUMN rubric_version_id BIGINT NULL AFTER task_id,
+    ADD COLUMN rubric_score DECIMAL(6,2) NULL AFTER score,
+    ADD CONSTRAINT fk_assessments_rubric_version FOREIGN KEY (rubric_version_id) REFERENCES rubric_versions(id) ON DELETE RESTRICT;
+
+-- Default rubric matching the five criteria used so far
+INSERT INTO rubrics (name, description, is_default, is_active, current_version)
+VALUES ('Penilaian Standar', 'Lima kriteria standar dengan bobot yang sama', TRUE, TRUE, 1);
+SET @rubric_id := LAST_INSERT_ID();
+
+INSERT INTO rubric_versions (rubric_id, version, scale_min, scale_max, thresholds)
+VALUES (@rubric_id, 1, 0, 100, JSON_ARRAY(
+    JSON_OBJECT('category', 'very_good', 'label', 'Sangat Baik', 'min_score', 85),
+    JSON_OBJECT('category', 'good', 'label', 'Baik', 'min_score', 70),
+    JSON_OBJECT('category', 'not_good', 'label', 'Tidak Baik', 'min_score', 50),
+    JSON_OBJECT('category', 'very_bad', 'label', 'Sangat Tidak Baik', 'min_score', 0)
+));
+SET @version_id := LAST_INSERT_ID();
+
+INSERT INTO rubric_criteria (version_id, code, name, weight, sort_order) VALUES
+    (@version_id, 'quality', 'Kualitas Kerja', 1, 1),
+    (@version_id, 'speed', 'Kecepatan', 1, 2),
+    (@version_id, 'initiative', 'Inisiatif', 1, 3),
+    (@version_id, 'teamwork', 'Kerja Sama', 1, 4),
+    (@version_id, 'communication', 'Komunikasi', 1, 5);
+
+UPDATE assessments
+SET rubric_version_id = @version_id,
+    rubric_score = score,
+    category_label = CASE category
+        WHEN 'very_good' THEN 'Sangat Baik'
+        WHEN 'good' THEN 'Baik'
+        WHEN 'not_good' THEN 'Tidak Baik'
+        ELSE 'Sangat Tidak Baik'
+    END
+WHERE rubric_version_id IS NULL;
+
+INSERT INTO assessment_scores (assessment_id, criterion_id, score)
+SELECT id, criterion_id, score FROM (
+    SELECT a.id, c.id AS criterion_id,
+           CASE c.code
+               WHEN 'quality' THEN a.quality_score
+               WHEN 'speed' THEN a.speed_score
+               WHEN 'initiative' THEN a.initiative_score
+               WHEN 'teamwork' THEN a.teamwork_score
+               WHEN 'communication' THEN a.communication_score
+           END AS score
+    FROM assessments a
+    JOIN rubric_criteria c ON c.version_id = @version_id
+) legacy
+WHERE score IS NOT NULL;
@@ -0,0 +1,57 @@
+-- Evaluation cycles (mid-term, final, ...) opened for a cohort of interns.
+-- Each intern gets an evaluation: a self-assessment, then the supervisor's
+-- assessment against the same rubric version, then sign-off, which
+-- releases the result and records it as a regular assessment.
+CREATE TABLE IF NOT EXISTS evaluation_cycles (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(150) NOT NULL,
+    type ENUM('mid_term', 'final', 'custom') NOT NULL DEFAULT 'custom',
+    description TEXT NULL,
+    self_due_date DATE NOT NULL,
+    supervisor_due_date DATE NOT NULL,
+    cohort JSON NULL,
+    status ENUM('open', 'closed') NOT NULL DEFAULT 'open',
+    created_by BIGINT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS evaluations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    cycle_id BIGINT NOT NULL,
+    intern_id BIGINT NOT NULL,
+    rubric_version_id BIGINT NOT NULL,
+    status ENUM('pending_self', 'pending_supervisor', 'in_review', 'signed_off') NOT NULL DEFAULT 'pending_self',
+    self_comments TEXT NULL,
+    self_submitted_at TIMESTAMP NULL,
+    supervisor_comments TEXT NULL,
+    strengths TEXT NULL,
+    improvements TEXT NULL,
+    supervisor_submitted_at TIMESTAMP NULL,
+    supervisor_submitted_by BIGINT NULL,
+    signed_off_at TIMESTAMP NULL,
+    signed_off_by BIGINT NULL,
+    assessment_id BIGINT NULL,
+    last_reminded_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uniq_evaluation_cycle_intern (cycle_id, intern_id),
+    INDEX idx_evaluations_status (status),
+    FOREIGN KEY (cycle_id) REFERENCES evaluation_cycles(id) ON DELETE CASCADE,
+    FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE CASCADE,
+    FOREIGN KEY (rubric_version_id) REFERENCES rubric_versions(id) ON DELETE RESTRICT,
+    FOREIGN KEY (supervisor_submitted_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (signed_off_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (assessment_id) REFERENCES assessments(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS evaluation_scores (
+    evaluation_id BIGINT NOT NULL,
+    criterion_id BIGINT NOT NULL,
+    source ENUM('self', 'supervisor') NOT NULL,
+    score DECIMAL(6,2) NOT NULL,
+    PRIMARY KEY (evaluation_id, criterion_id, source),
+    FOREIGN KEY (evaluation_id) REFERENCES evaluations(id) ON DELETE CASCADE,
+    FOREIGN KEY (criterion_id) REFERENCES rubric_criteria(id) ON DELETE RESTRICT
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
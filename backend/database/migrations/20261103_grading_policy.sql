@@ -0,0 +1,8 @@
+-- Weighted final grade policy: component weights, late task penalty and the
+-- letter/predicate scale. Certificates, reports and exports use it instead
+-- of the plain assessment average.
+INSERT INTO settings (`key`, `value`, `type`, description) VALUES
+    ('grading_policy',
+     '{"weights":{"tasks":40,"attendance":20,"assessments":40},"punctuality_share":30,"late_penalty":10,"scale":[{"min_score":85,"letter":"A","predicate":"Sangat Baik"},{"min_score":75,"letter":"B","predicate":"Baik"},{"min_score":60,"letter":"C","predicate":"Cukup"},{"min_score":0,"letter":"D","predicate":"Kurang"}]}',
+     'json', 'Final grade weights, late penalty and grade scale')
+ON DUPLICATE KEY UPDATE `key` = `key`;
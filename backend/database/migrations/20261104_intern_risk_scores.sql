@@ -0,0 +1,15 @@
+-- Early-warning risk score per active intern, recomputed nightly from
+-- attendance, tasks, assessments and reports
+CREATE TABLE IF NOT EXISTS intern_risk_scores (
+    intern_id BIGINT PRIMARY KEY,
+    score INT NOT NULL DEFAULT 0,
+    level ENUM('low', 'medium', 'high') NOT NULL DEFAULT 'low',
+    reasons JSON NOT NULL,
+    computed_at DATETIME NOT NULL,
+    KEY idx_risk_score (score),
+    CONSTRAINT fk_risk_intern FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO settings (`key`, `value`, `type`, description) VALUES
+    ('risk_alert_threshold', '50', 'number', 'Notify the supervisor when an intern''s risk score reaches this value')
+ON DUPLICATE KEY UPDATE `key` = `key`;
@@ -0,0 +1,65 @@
+-- Precomputed dashboard statistics. Rows are refreshed by a queue job when
+-- attendance, tasks or leave change and reconciled nightly.
+
+-- Task counts and 30-day attendance per intern
+CREATE TABLE IF NOT EXISTS intern_dashboard_rollups (
+    intern_id BIGINT PRIMARY KEY,
+    tasks_total INT NOT NULL DEFAULT 0,
+    tasks_pending INT NOT NULL DEFAULT 0,
+    tasks_in_progress INT NOT NULL DEFAULT 0,
+    tasks_submitted INT NOT NULL DEFAULT 0,
+    tasks_revision INT NOT NULL DEFAULT 0,
+    tasks_completed INT NOT NULL DEFAULT 0,
+    tasks_completed_late INT NOT NULL DEFAULT 0,
+    tasks_open_late INT NOT NULL DEFAULT 0,
+    attendance_days_30d INT NOT NULL DEFAULT 0,
+    present_days_30d INT NOT NULL DEFAULT 0,
+    refreshed_at DATETIME NOT NULL,
+    CONSTRAINT fk_dashboard_rollup_intern FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Attendance per day and supervisor (0 for interns without one)
+CREATE TABLE IF NOT EXISTS attendance_daily_rollups (
+    date DATE NOT NULL,
+    supervisor_id BIGINT NOT NULL DEFAULT 0,
+    present INT NOT NULL DEFAULT 0,
+    late INT NOT NULL DEFAULT 0,
+    absent INT NOT NULL DEFAULT 0,
+    sick INT NOT NULL DEFAULT 0,
+    permission INT NOT NULL DEFAULT 0,
+    on_leave INT NOT NULL DEFAULT 0, -- on_leave and excused
+    total INT NOT NULL DEFAULT 0,
+    refreshed_at DATETIME NOT NULL,
+    PRIMARY KEY (date, supervisor_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO intern_dashboard_rollups (intern_id, tasks_total, tasks_pending, tasks_in_progress, tasks_submitted,
+                                      tasks_revision, tasks_completed, tasks_completed_late, tasks_open_late,
+                                      attendance_days_30d, present_days_30d, refreshed_at)
+SELECT i.id,
+       COALESCE(t.total, 0), COALESCE(t.pending, 0), COALESCE(t.in_progress, 0), COALESCE(t.submitted, 0),
+       COALESCE(t.revision, 0), COALESCE(t.completed, 0), COALESCE(t.completed_late, 0), COALESCE(t.open_late, 0),
+       COALESCE(a.total, 0), COALESCE(a.present, 0), NOW()
+FROM interns i
+LEFT JOIN (
+    SELECT intern_id, COUNT(*) AS total,
+           SUM(status = 'pending') AS pending, SUM(status = 'in_progress') AS in_progress,
+           SUM(status = 'submitted') AS submitted, SUM(status = 'revision') AS revision,
+           SUM(status = 'completed') AS completed, SUM(status = 'completed' AND is_late = 1) AS completed_late,
+           SUM(status <> 'completed' AND is_late = 1) AS open_late
+    FROM tasks GROUP BY intern_id
+) t ON t.intern_id = i.id
+LEFT JOIN (
+    SELECT intern_id, COUNT(*) AS total, SUM(status IN ('present', 'late')) AS present
+    FROM attendances WHERE date >= CURDATE() - INTERVAL 30 DAY GROUP BY intern_id
+) a ON a.intern_id = i.id
+ON DUPLICATE KEY UPDATE intern_id = intern_id;
+
+INSERT INTO attendance_daily_rollups (date, supervisor_id, present, late, absent, sick, permission, on_leave, total, refreshed_at)
+SELECT a.date, COALESCE(i.supervisor_id, 0),
+       SUM(a.status = 'present'), SUM(a.status = 'late'), SUM(a.status = 'absent'),
+       SUM(a.status = 'sick'), SUM(a.status = 'permission'), SUM(a.status IN ('on_leave', 'excused')), COUNT(*), NOW()
+FROM attendances a
+JOIN interns i ON a.intern_id = i.id
+GROUP BY a.date, COALESCE(i.supervisor_id, 0)
+ON DUPLICATE KEY UPDATE date = date;
@@ -0,0 +1,28 @@
+-- Review SLAs: a task's reviewer defaults to its assigner until an admin
+-- reassigns it. Each submission records when its reviewer was reminded and
+-- when the overdue review was escalated to admins.
+ALTER TABLE tasks ADD COLUMN reviewer_id BIGINT DEFAULT NULL AFTER assigner_id;
+ALTER TABLE tasks ADD CONSTRAINT fk_tasks_reviewer FOREIGN KEY (reviewer_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE task_submissions ADD COLUMN review_reminded_at DATETIME DEFAULT NULL;
+ALTER TABLE task_submissions ADD COLUMN review_escalated_at DATETIME DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS task_reviewer_changes (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    task_id BIGINT NOT NULL,
+    from_reviewer_id BIGINT DEFAULT NULL,
+    to_reviewer_id BIGINT NOT NULL,
+    changed_by BIGINT NOT NULL,
+    reason VARCHAR(255) DEFAULT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_task (task_id, created_at),
+    CONSTRAINT fk_reviewer_changes_task FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reviewer_changes_from FOREIGN KEY (from_reviewer_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_reviewer_changes_to FOREIGN KEY (to_reviewer_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reviewer_changes_by FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO settings (`key`, `value`, `type`, description) VALUES
+    ('review_sla_hours', '48', 'number', 'Hours a submitted task may wait for review before admins are alerted'),
+    ('review_reminder_hours', '24', 'number', 'Hours after submission at which the reviewer is reminded')
+ON DUPLICATE KEY UPDATE `key` = `key`;
@@ -0,0 +1,36 @@
+-- Out-of-office coverage: while a delegation is active, the supervisor's
+-- notifications are forwarded to the delegate, who may approve and review
+-- on the supervisor's behalf. Every such action is logged.
+CREATE TABLE IF NOT EXISTS supervisor_delegations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    supervisor_id BIGINT NOT NULL,
+    delegate_id BIGINT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    reason VARCHAR(255) DEFAULT NULL,
+    status ENUM('active', 'cancelled') NOT NULL DEFAULT 'active',
+    created_by BIGINT NOT NULL,
+    created_at DATETIME NOT NULL,
+    cancelled_at DATETIME DEFAULT NULL,
+    KEY idx_supervisor_period (supervisor_id, status, start_date, end_date),
+    KEY idx_delegate_period (delegate_id, status, start_date, end_date),
+    CONSTRAINT fk_delegations_supervisor FOREIGN KEY (supervisor_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delegations_delegate FOREIGN KEY (delegate_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delegations_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS delegated_actions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    delegation_id BIGINT NOT NULL,
+    actor_id BIGINT NOT NULL,
+    on_behalf_of BIGINT NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_delegation (delegation_id, created_at),
+    KEY idx_entity (entity_type, entity_id),
+    CONSTRAINT fk_delegated_actions_delegation FOREIGN KEY (delegation_id) REFERENCES supervisor_delegations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delegated_actions_actor FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_delegated_actions_owner FOREIGN KEY (on_behalf_of) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,25 @@
+-- Co-supervisors and campus supervisors. interns.supervisor_id stays the
+-- primary supervisor and routing key; intern_supervisors holds the
+-- secondary (company) and academic (university) supervisors.
+ALTER TABLE users MODIFY role ENUM('admin', 'supervisor', 'pembimbing', 'intern', 'new_user', 'campus_supervisor')
+    CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL;
+
+-- The institution a campus supervisor account belongs to
+ALTER TABLE users ADD COLUMN institution_id BIGINT DEFAULT NULL AFTER role;
+ALTER TABLE users ADD CONSTRAINT fk_users_institution FOREIGN KEY (institution_id) REFERENCES institutions(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS intern_supervisors (
+    intern_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    role ENUM('secondary', 'academic') NOT NULL,
+    assigned_by BIGINT DEFAULT NULL,
+    assigned_at DATETIME NOT NULL,
+    PRIMARY KEY (intern_id, user_id),
+    KEY idx_user (user_id),
+    CONSTRAINT fk_intern_supervisors_intern FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE CASCADE,
+    CONSTRAINT fk_intern_supervisors_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_intern_supervisors_assigner FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Academic assessments are submitted by the intern's university
+ALTER TABLE assessments ADD COLUMN source ENUM('company', 'academic') NOT NULL DEFAULT 'company';
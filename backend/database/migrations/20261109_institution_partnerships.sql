@@ -0,0 +1,63 @@
+-- Institutions as partners: MoU agreements, contact persons, an intern
+-- quota (interns active at once), and the name variants that resolve
+-- free-text school names to one institution.
+ALTER TABLE institutions
+    ADD COLUMN short_name VARCHAR(50) DEFAULT NULL AFTER name,
+    ADD COLUMN website VARCHAR(255) DEFAULT NULL AFTER email,
+    ADD COLUMN quota INT DEFAULT NULL AFTER website;
+
+-- Alternative spellings and abbreviations ("UNDIP", "Univ. Diponegoro").
+-- normalized_name is unique so a variant resolves to one institution only.
+CREATE TABLE IF NOT EXISTS institution_aliases (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    institution_id BIGINT NOT NULL,
+    alias VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_institution_alias (normalized_name),
+    KEY idx_institution (institution_id),
+    CONSTRAINT fk_institution_aliases_institution FOREIGN KEY (institution_id) REFERENCES institutions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS institution_agreements (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    institution_id BIGINT NOT NULL,
+    mou_number VARCHAR(100) NOT NULL,
+    title VARCHAR(255) DEFAULT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    notes TEXT,
+    created_by BIGINT DEFAULT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    KEY idx_institution_period (institution_id, start_date, end_date),
+    CONSTRAINT fk_institution_agreements_institution FOREIGN KEY (institution_id) REFERENCES institutions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_institution_agreements_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS institution_contacts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    institution_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    position VARCHAR(255) DEFAULT NULL,
+    email VARCHAR(255) DEFAULT NULL,
+    phone VARCHAR(50) DEFAULT NULL,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL,
+    KEY idx_institution (institution_id),
+    CONSTRAINT fk_institution_contacts_institution FOREIGN KEY (institution_id) REFERENCES institutions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Institutions folded into another by the merge tool
+CREATE TABLE IF NOT EXISTS institution_merges (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    source_id BIGINT NOT NULL,
+    source_name VARCHAR(255) NOT NULL,
+    target_id BIGINT NOT NULL,
+    interns_moved INT NOT NULL DEFAULT 0,
+    merged_by BIGINT DEFAULT NULL,
+    merged_at DATETIME NOT NULL,
+    KEY idx_target (target_id),
+    CONSTRAINT fk_institution_merges_target FOREIGN KEY (target_id) REFERENCES institutions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_institution_merges_user FOREIGN KEY (merged_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
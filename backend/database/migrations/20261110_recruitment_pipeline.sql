@@ -0,0 +1,139 @@
+-- Recruitment pipeline: applications move through configurable stages,
+-- carry required documents, book interview slots, and become interns on
+-- acceptance. Applicants sign in with the limited 'applicant' role.
+ALTER TABLE users MODIFY role ENUM('admin', 'supervisor', 'pembimbing', 'intern', 'new_user', 'campus_supervisor', 'applicant')
+    CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL;
+
+-- outcome marks the final stages; entering one decides the application.
+-- A stage with an email template mails the applicant on entry.
+CREATE TABLE IF NOT EXISTS application_stages (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    position INT NOT NULL,
+    outcome ENUM('accepted', 'rejected') DEFAULT NULL,
+    email_subject VARCHAR(255) DEFAULT NULL,
+    email_body TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    UNIQUE KEY uq_application_stage_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO application_stages (code, name, position, outcome, email_subject, email_body, created_at, updated_at) VALUES
+    ('applied', 'Mendaftar', 10, NULL,
+     'Pendaftaran magang diterima',
+     'Halo {{name}},\n\nTerima kasih telah mendaftar magang di {{organization}}. Lengkapi dokumen yang diminta agar lamaran Anda dapat kami proses.\n\nSalam,\n{{organization}}',
+     NOW(), NOW()),
+    ('screening', 'Seleksi Berkas', 20, NULL, NULL, NULL, NOW(), NOW()),
+    ('interview', 'Wawancara', 30, NULL,
+     'Undangan wawancara magang',
+     'Halo {{name}},\n\nLamaran Anda lolos seleksi berkas. Silakan pilih jadwal wawancara melalui akun Anda.\n\n{{note}}\n\nSalam,\n{{organization}}',
+     NOW(), NOW()),
+    ('offered', 'Penawaran', 40, NULL,
+     'Penawaran magang di {{organization}}',
+     'Halo {{name}},\n\nSelamat! Kami menawarkan posisi magang untuk periode {{start_date}} - {{end_date}}.\n\n{{note}}\n\nSalam,\n{{organization}}',
+     NOW(), NOW()),
+    ('accepted', 'Diterima', 50, 'accepted',
+     'Selamat bergabung di {{organization}}',
+     'Halo {{name}},\n\nAnda resmi diterima sebagai peserta magang mulai {{start_date}}. Akun Anda kini aktif sebagai intern; silakan masuk dengan email dan kata sandi yang sama.\n\nSalam,\n{{organization}}',
+     NOW(), NOW()),
+    ('rejected', 'Ditolak', 60, 'rejected',
+     'Hasil seleksi magang di {{organization}}',
+     'Halo {{name}},\n\nTerima kasih atas minat Anda. Setelah pertimbangan, kami belum dapat menerima lamaran Anda saat ini.\n\n{{note}}\n\nSalam,\n{{organization}}',
+     NOW(), NOW())
+ON DUPLICATE KEY UPDATE code = code;
+
+CREATE TABLE IF NOT EXISTS application_document_types (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    is_required BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL,
+    UNIQUE KEY uq_application_document_type_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO application_document_types (code, name, is_required, created_at) VALUES
+    ('cv', 'Curriculum Vitae', TRUE, NOW()),
+    ('cover_letter', 'Surat Pengantar Kampus/Sekolah', TRUE, NOW()),
+    ('transcript', 'Transkrip Nilai', FALSE, NOW())
+ON DUPLICATE KEY UPDATE code = code;
+
+CREATE TABLE IF NOT EXISTS applications (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    stage_id BIGINT NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(50) DEFAULT NULL,
+    school VARCHAR(255) NOT NULL,
+    department VARCHAR(255) NOT NULL,
+    student_id VARCHAR(50) DEFAULT NULL,
+    institution_id BIGINT DEFAULT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    motivation TEXT,
+    supervisor_id BIGINT DEFAULT NULL,
+    intern_id BIGINT DEFAULT NULL,
+    decided_at DATETIME DEFAULT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    KEY idx_stage (stage_id),
+    KEY idx_user (user_id),
+    CONSTRAINT fk_applications_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_applications_stage FOREIGN KEY (stage_id) REFERENCES application_stages(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_applications_institution FOREIGN KEY (institution_id) REFERENCES institutions(id) ON DELETE SET NULL,
+    CONSTRAINT fk_applications_supervisor FOREIGN KEY (supervisor_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_applications_intern FOREIGN KEY (intern_id) REFERENCES interns(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- One file per document type; a new upload replaces the old one
+CREATE TABLE IF NOT EXISTS application_documents (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    application_id BIGINT NOT NULL,
+    document_type_id BIGINT NOT NULL,
+    file_path VARCHAR(500) NOT NULL,
+    original_name VARCHAR(255) NOT NULL,
+    uploaded_at DATETIME NOT NULL,
+    UNIQUE KEY uq_application_document (application_id, document_type_id),
+    CONSTRAINT fk_application_documents_application FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    CONSTRAINT fk_application_documents_type FOREIGN KEY (document_type_id) REFERENCES application_document_types(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS application_stage_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    application_id BIGINT NOT NULL,
+    from_stage_id BIGINT DEFAULT NULL,
+    to_stage_id BIGINT NOT NULL,
+    note TEXT,
+    email_queued BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_by BIGINT DEFAULT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_application (application_id),
+    CONSTRAINT fk_application_history_application FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE CASCADE,
+    CONSTRAINT fk_application_history_from FOREIGN KEY (from_stage_id) REFERENCES application_stages(id) ON DELETE SET NULL,
+    CONSTRAINT fk_application_history_to FOREIGN KEY (to_stage_id) REFERENCES application_stages(id) ON DELETE CASCADE,
+    CONSTRAINT fk_application_history_user FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Interview slots offered by an interviewer. Booking one puts an agenda
+-- on both calendars; cancelling removes them.
+CREATE TABLE IF NOT EXISTS interview_slots (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    interviewer_id BIGINT NOT NULL,
+    starts_at DATETIME NOT NULL,
+    duration_minutes INT NOT NULL DEFAULT 30,
+    location VARCHAR(255) DEFAULT NULL,
+    application_id BIGINT DEFAULT NULL,
+    interviewer_agenda_id BIGINT DEFAULT NULL,
+    applicant_agenda_id BIGINT DEFAULT NULL,
+    booked_at DATETIME DEFAULT NULL,
+    created_by BIGINT DEFAULT NULL,
+    created_at DATETIME NOT NULL,
+    KEY idx_interviewer_time (interviewer_id, starts_at),
+    KEY idx_application (application_id),
+    CONSTRAINT fk_interview_slots_interviewer FOREIGN KEY (interviewer_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_interview_slots_application FOREIGN KEY (application_id) REFERENCES applications(id) ON DELETE SET NULL,
+    CONSTRAINT fk_interview_slots_interviewer_agenda FOREIGN KEY (interviewer_agenda_id) REFERENCES agendas(id) ON DELETE SET NULL,
+    CONSTRAINT fk_interview_slots_applicant_agenda FOREIGN KEY (applicant_agenda_id) REFERENCES agendas(id) ON DELETE SET NULL,
+    CONSTRAINT fk_interview_slots_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
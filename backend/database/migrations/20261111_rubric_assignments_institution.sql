@@ -0,0 +1,28 @@
+-- Rubric assignments target an institution instead of the free-text school
+-- name, which varies between interns of the same institution ("UNDIP" vs
+-- "Universitas Diponegoro"). Merging institutions carries them over.
+ALTER TABLE rubric_assignments
+    ADD COLUMN institution_id BIGINT NULL AFTER rubric_id,
+    ADD CONSTRAINT fk_rubric_assignments_institution FOREIGN KEY (institution_id) REFERENCES institutions(id) ON DELETE CASCADE;
+
+-- Resolve existing school names by institution name first, then by the
+-- institution the interns with that school name belong to
+UPDATE rubric_assignments ra
+JOIN institutions inst ON inst.name = ra.school OR inst.short_name = ra.school
+SET ra.institution_id = inst.id
+WHERE ra.school IS NOT NULL;
+
+UPDATE rubric_assignments ra
+JOIN (SELECT school, MIN(institution_id) AS institution_id FROM interns GROUP BY school) s ON s.school = ra.school
+SET ra.institution_id = s.institution_id
+WHERE ra.school IS NOT NULL AND ra.institution_id IS NULL;
+
+-- Schools that matched nothing stay in legacy_school for an admin to map.
+-- Until then the assignment is ignored instead of widening to the whole
+-- department.
+ALTER TABLE rubric_assignments
+    DROP INDEX idx_rubric_assignment_target,
+    CHANGE COLUMN school legacy_school VARCHAR(255) NULL,
+    ADD INDEX idx_rubric_assignment_target (institution_id, department);
+
+UPDATE rubric_assignments SET legacy_school = NULL WHERE institution_id IS NOT NULL;
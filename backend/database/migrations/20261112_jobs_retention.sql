@@ -0,0 +1,9 @@
+-- Finished jobs are pruned by the prune-jobs schedule, which looks them up
+-- by status and finish time. Schedules check for a queued or running run of
+-- themselves every tick, and rollup refreshes collapse into a pending job
+-- with the same dedupe key.
+ALTER TABLE jobs
+    ADD COLUMN dedupe_key VARCHAR(191) DEFAULT NULL AFTER schedule_name,
+    ADD INDEX idx_jobs_schedule (schedule_name, status),
+    ADD INDEX idx_jobs_dedupe (dedupe_key, status),
+    ADD INDEX idx_jobs_finished (status, finished_at);
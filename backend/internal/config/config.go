@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"log"
 	"os"
 	"strconv"
@@ -18,6 +19,7 @@ type Config struct {
 	App      AppConfig
 	OAuth    OAuthConfig
 	SMTP     SMTPConfig
+	Jobs     JobsConfig
 }
 
 type ServerConfig struct {
@@ -55,8 +57,13 @@ type OfficeConfig struct {
 }
 
 type AppConfig struct {
-	Name string
-	Env  string
+	Name      string
+	Env       string
+	PublicURL string // base URL of this API, used in links printed on documents
+
+	// Key for certificate and report signatures. Without it nothing is
+	// signed or verified; production refuses to start.
+	CertificateSigningKey string
 }
 
 type OAuthConfig struct {
@@ -66,6 +73,12 @@ type OAuthConfig struct {
 	FrontendURL        string
 }
 
+type JobsConfig struct {
+	Workers       int           // jobs run concurrently by this process
+	DrainTimeout  time.Duration // how long shutdown waits for running jobs
+	RetentionDays int           // completed and cancelled jobs are kept this long
+}
+
 type SMTPConfig struct {
 	Host     string
 	Port     string
@@ -77,6 +90,10 @@ type SMTPConfig struct {
 
 var Loaded *Config
 
+// insecureDefaultSecret is the placeholder secret of development setups.
+// It is public, so it is never accepted as a signing key.
+const insecureDefaultSecret = "change-this-secret-key"
+
 // Load loads configuration from environment variables
 func Load() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
@@ -113,6 +130,21 @@ func Load() (*Config, error) {
 		lateTolerance = 15
 	}
 
+	jobWorkers, err := strconv.Atoi(getEnv("JOB_WORKERS", "4"))
+	if err != nil {
+		jobWorkers = 4
+	}
+
+	jobDrainTimeout, err := time.ParseDuration(getEnv("JOB_DRAIN_TIMEOUT", "30s"))
+	if err != nil {
+		jobDrainTimeout = 30 * time.Second
+	}
+
+	jobRetentionDays, err := strconv.Atoi(getEnv("JOB_RETENTION_DAYS", "14"))
+	if err != nil || jobRetentionDays < 1 {
+		jobRetentionDays = 14
+	}
+
 	config := &Config{
 		Server: ServerConfig{
 			Port: getEnv("SERVER_PORT", "8080"),
@@ -126,7 +158,7 @@ func Load() (*Config, error) {
 			DBName:   getEnv("DB_NAME", "interna_db"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "change-this-secret-key"),
+			Secret: getEnv("JWT_SECRET", insecureDefaultSecret),
 			Expiry: jwtExpiry,
 		},
 		Upload: UploadConfig{
@@ -144,8 +176,10 @@ func Load() (*Config, error) {
 			LateToleranceMinutes: lateTolerance,
 		},
 		App: AppConfig{
-			Name: getEnv("APP_NAME", "INTERNA"),
-			Env:  getEnv("APP_ENV", "development"),
+			Name:                  getEnv("APP_NAME", "INTERNA"),
+			Env:                   getEnv("APP_ENV", "development"),
+			PublicURL:             getEnv("APP_PUBLIC_URL", "http://localhost:8080"),
+			CertificateSigningKey: getEnv("CERTIFICATE_SIGNING_KEY", ""),
 		},
 		OAuth: OAuthConfig{
 			GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
@@ -161,6 +195,21 @@ func Load() (*Config, error) {
 			From:     getEnv("SMTP_FROM", ""),
 			UseTLS:   getEnv("SMTP_USE_TLS", "false") == "true",
 		},
+		Jobs: JobsConfig{
+			Workers:       jobWorkers,
+			DrainTimeout:  jobDrainTimeout,
+			RetentionDays: jobRetentionDays,
+		},
+	}
+
+	if config.App.CertificateSigningKey == insecureDefaultSecret {
+		config.App.CertificateSigningKey = ""
+	}
+	if config.App.CertificateSigningKey == "" {
+		if config.App.Env == "production" {
+			return nil, errors.New("CERTIFICATE_SIGNING_KEY must be set in production")
+		}
+		log.Println("CERTIFICATE_SIGNING_KEY is not set; certificates and reports cannot be signed or verified")
 	}
 
 	Loaded = config
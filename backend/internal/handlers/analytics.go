@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/gorilla/mux"
@@ -21,7 +24,8 @@ func NewAnalyticsHandler(db *sql.DB) *AnalyticsHandler {
 	return &AnalyticsHandler{db: db}
 }
 
-// GetWeeklyTrends returns weekly attendance trends for an intern
+// GetWeeklyTrends returns weekly attendance trends for an intern. from/to
+// replace the week with a custom range.
 func (h *AnalyticsHandler) GetWeeklyTrends(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	internID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -29,6 +33,9 @@ func (h *AnalyticsHandler) GetWeeklyTrends(w http.ResponseWriter, r *http.Reques
 		utils.RespondBadRequest(w, "Invalid intern ID")
 		return
 	}
+	if !h.checkInternAccess(w, r, internID) {
+		return
+	}
 
 	// Get week parameter (default: current week)
 	weekOffset := 0
@@ -40,6 +47,12 @@ func (h *AnalyticsHandler) GetWeeklyTrends(w http.ResponseWriter, r *http.Reques
 	now := time.Now()
 	weekStart := startOfWeek(now.AddDate(0, 0, weekOffset*7))
 	weekEnd := weekStart.AddDate(0, 0, 6)
+	if from, to, ok, msg := analyticsRange(r); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	} else if ok {
+		weekStart, weekEnd = from, to
+	}
 
 	// Get intern name
 	var internName string
@@ -105,8 +118,7 @@ func (h *AnalyticsHandler) GetWeeklyTrends(w http.ResponseWriter, r *http.Reques
 
 	// Build daily records for entire week (including days without records)
 	var dailyRecords []models.DailyAttendanceStats
-	for i := 0; i < 7; i++ {
-		date := weekStart.AddDate(0, 0, i)
+	for date := weekStart; !date.After(weekEnd); date = date.AddDate(0, 0, 1) {
 		dateStr := date.Format("2006-01-02")
 
 		if record, exists := attendanceMap[dateStr]; exists {
@@ -144,6 +156,9 @@ func (h *AnalyticsHandler) GetCheckInPatterns(w http.ResponseWriter, r *http.Req
 		utils.RespondBadRequest(w, "Invalid intern ID")
 		return
 	}
+	if !h.checkInternAccess(w, r, internID) {
+		return
+	}
 
 	// Get date range (default: last 30 days)
 	days := 30
@@ -152,17 +167,26 @@ func (h *AnalyticsHandler) GetCheckInPatterns(w http.ResponseWriter, r *http.Req
 	}
 
 	startDate := time.Now().AddDate(0, 0, -days).Format("2006-01-02")
+	endDate := time.Now().Format("2006-01-02")
+	period := strconv.Itoa(days) + " days"
+	if from, to, ok, msg := analyticsRange(r); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	} else if ok {
+		startDate, endDate = from.Format("2006-01-02"), to.Format("2006-01-02")
+		period = startDate + " - " + endDate
+	}
 
 	// Query to get hourly distribution
 	query := `
 		SELECT HOUR(check_in_time) as hour, COUNT(*) as count
 		FROM attendances
-		WHERE intern_id = ? AND date >= ? AND check_in_time IS NOT NULL
+		WHERE intern_id = ? AND date BETWEEN ? AND ? AND check_in_time IS NOT NULL
 		GROUP BY HOUR(check_in_time)
 		ORDER BY hour ASC
 	`
 
-	rows, err := h.db.Query(query, internID, startDate)
+	rows, err := h.db.Query(query, internID, startDate, endDate)
 	if err != nil {
 		utils.RespondInternalError(w, "Database error")
 		return
@@ -200,7 +224,7 @@ func (h *AnalyticsHandler) GetCheckInPatterns(w http.ResponseWriter, r *http.Req
 
 	utils.RespondSuccess(w, "Check-in patterns retrieved successfully", map[string]interface{}{
 		"intern_id":       internID,
-		"period":          strconv.Itoa(days) + " days",
+		"period":          period,
 		"total_check_ins": total,
 		"patterns":        patterns,
 	})
@@ -214,6 +238,9 @@ func (h *AnalyticsHandler) GetPerformanceInsights(w http.ResponseWriter, r *http
 		utils.RespondBadRequest(w, "Invalid intern ID")
 		return
 	}
+	if !h.checkInternAccess(w, r, internID) {
+		return
+	}
 
 	// Get intern name
 	var internName string
@@ -223,9 +250,18 @@ func (h *AnalyticsHandler) GetPerformanceInsights(w http.ResponseWriter, r *http
 		return
 	}
 
-	// Analyze last 4 weeks
+	// Analyze last 4 weeks unless a range is given
 	weeks := 4
 	startDate := time.Now().AddDate(0, 0, -weeks*7).Format("2006-01-02")
+	endDate := time.Now().Format("2006-01-02")
+	period := "Last " + strconv.Itoa(weeks) + " weeks"
+	if from, to, ok, msg := analyticsRange(r); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	} else if ok {
+		startDate, endDate = from.Format("2006-01-02"), to.Format("2006-01-02")
+		period = startDate + " - " + endDate
+	}
 
 	// Get attendance statistics
 	var totalDays, presentDays, lateDays, absentDays int
@@ -241,10 +277,10 @@ func (h *AnalyticsHandler) GetPerformanceInsights(w http.ResponseWriter, r *http
 				THEN HOUR(check_in_time) * 60 + MINUTE(check_in_time) 
 				ELSE NULL END) as avg_check_in_minutes
 		FROM attendances
-		WHERE intern_id = ? AND date >= ?
+		WHERE intern_id = ? AND date BETWEEN ? AND ?
 	`
 
-	err = h.db.QueryRow(statsQuery, internID, startDate).Scan(
+	err = h.db.QueryRow(statsQuery, internID, startDate, endDate).Scan(
 		&totalDays, &presentDays, &lateDays, &absentDays, &avgCheckInMinutes,
 	)
 	if err != nil {
@@ -301,17 +337,27 @@ func (h *AnalyticsHandler) GetPerformanceInsights(w http.ResponseWriter, r *http
 		}
 	}
 
-	// Calculate overall score
-	overallScore := int((attendanceRate*0.5 + punctualityRate*0.5))
+	// The overall score is the intern's final grade, so it matches what
+	// certificates and exports show
+	overallScore := 0
+	grade, err := services.ComputeGrade(h.db, internID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to compute grade")
+		return
+	}
+	if grade.FinalScore != nil {
+		overallScore = int(math.Round(*grade.FinalScore))
+	}
 
 	insights := models.PerformanceInsights{
 		InternID:     internID,
 		InternName:   internName,
-		Period:       "Last " + strconv.Itoa(weeks) + " weeks",
+		Period:       period,
 		Strengths:    strengths,
 		Concerns:     concerns,
 		Suggestions:  suggestions,
 		OverallScore: overallScore,
+		Grade:        &grade,
 	}
 
 	utils.RespondSuccess(w, "Performance insights generated successfully", insights)
@@ -444,3 +490,340 @@ func startOfWeek(t time.Time) time.Time {
 	}
 	return time.Date(t.Year(), t.Month(), t.Day()-weekday+1, 0, 0, 0, 0, t.Location())
 }
+
+// analyticsRange reads the optional from/to query parameters (YYYY-MM-DD).
+// ok is false when neither is given. A missing to defaults to today and a
+// missing from to 30 days before to.
+func analyticsRange(r *http.Request) (from, to time.Time, ok bool, msg string) {
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+	if fromStr == "" && toStr == "" {
+		return from, to, false, ""
+	}
+
+	now := time.Now()
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if toStr != "" {
+		t, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return from, to, false, "to must be YYYY-MM-DD"
+		}
+		to = t
+	}
+	from = to.AddDate(0, 0, -30)
+	if fromStr != "" {
+		f, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return from, to, false, "from must be YYYY-MM-DD"
+		}
+		from = f
+	}
+	if to.Before(from) {
+		return from, to, false, "to cannot be before from"
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		return from, to, false, "range cannot be longer than a year"
+	}
+	return from, to, true, ""
+}
+
+// GetMonthlyTrends returns an intern's attendance for a month (?month=YYYY-MM,
+// default the current month) by week, compared with the previous month
+func (h *AnalyticsHandler) GetMonthlyTrends(w http.ResponseWriter, r *http.Request) {
+	internID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid intern ID")
+		return
+	}
+	if !h.checkInternAccess(w, r, internID) {
+		return
+	}
+
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if m := r.URL.Query().Get("month"); m != "" {
+		month, err = time.Parse("2006-01", m)
+		if err != nil {
+			utils.RespondBadRequest(w, "month must be YYYY-MM")
+			return
+		}
+	}
+
+	intern, err := h.loadInternPeriod(internID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	cal := services.LoadWorkCalendar(h.db)
+	current, err := h.periodRecords(intern, month, month.AddDate(0, 1, -1), cal)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	previousMonth := month.AddDate(0, -1, 0)
+	previous, err := h.periodRecords(intern, previousMonth, month.AddDate(0, 0, -1), cal)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	summary := h.summarizePeriod(current)
+	previousSummary := h.summarizePeriod(previous)
+	previousSummary.ImprovementTrend = ""
+	summary.ImprovementTrend = improvementTrend(summary, previousSummary)
+
+	utils.RespondSuccess(w, "Monthly trends retrieved successfully", models.MonthlyTrends{
+		InternID:       internID,
+		InternName:     intern.name,
+		Month:          month,
+		WeeklyData:     h.weeklyBreakdown(current),
+		MonthlySummary: summary,
+		PreviousMonth:  &previousSummary,
+	})
+}
+
+// GetRangeTrends returns an intern's attendance between from and to by
+// week, compared with the range of the same length right before it
+func (h *AnalyticsHandler) GetRangeTrends(w http.ResponseWriter, r *http.Request) {
+	internID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid intern ID")
+		return
+	}
+	if !h.checkInternAccess(w, r, internID) {
+		return
+	}
+	from, to, ok, msg := analyticsRange(r)
+	if msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+	if !ok {
+		utils.RespondBadRequest(w, "from or to is required")
+		return
+	}
+
+	intern, err := h.loadInternPeriod(internID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	cal := services.LoadWorkCalendar(h.db)
+	days := int(to.Sub(from).Hours()/24) + 1
+	previousTo := from.AddDate(0, 0, -1)
+	previousFrom := previousTo.AddDate(0, 0, -(days - 1))
+
+	current, err := h.periodRecords(intern, from, to, cal)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	previous, err := h.periodRecords(intern, previousFrom, previousTo, cal)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	summary := h.summarizePeriod(current)
+	previousSummary := h.summarizePeriod(previous)
+	previousSummary.ImprovementTrend = ""
+	summary.ImprovementTrend = improvementTrend(summary, previousSummary)
+
+	utils.RespondSuccess(w, "Attendance trends retrieved successfully", models.RangeTrends{
+		InternID:        internID,
+		InternName:      intern.name,
+		From:            from,
+		To:              to,
+		WeeklyData:      h.weeklyBreakdown(current),
+		Summary:         summary,
+		PreviousFrom:    previousFrom,
+		PreviousTo:      previousTo,
+		PreviousSummary: previousSummary,
+	})
+}
+
+type internPeriod struct {
+	id         int64
+	name       string
+	start, end sql.NullTime
+}
+
+// checkInternAccess limits an intern's analytics to the intern themselves
+// and to the supervisors who can see the intern
+func (h *AnalyticsHandler) checkInternAccess(w http.ResponseWriter, r *http.Request, internID int64) bool {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return false
+	}
+	var userID int64
+	var supervisorID sql.NullInt64
+	err := h.db.QueryRow("SELECT user_id, supervisor_id FROM interns WHERE id = ?", internID).Scan(&userID, &supervisorID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return false
+	}
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		if userID != claims.UserID {
+			utils.RespondForbidden(w, "You can only view your own analytics")
+			return false
+		}
+	case "pembimbing":
+		if !supervisesIntern(h.db, claims.UserID, internID, supervisorID) {
+			utils.RespondForbidden(w, "You can only view analytics of your assigned interns")
+			return false
+		}
+	}
+	return true
+}
+
+func (h *AnalyticsHandler) loadInternPeriod(internID int64) (internPeriod, error) {
+	p := internPeriod{id: internID}
+	err := h.db.QueryRow(
+		"SELECT full_name, start_date, end_date FROM interns WHERE id = ?", internID,
+	).Scan(&p.name, &p.start, &p.end)
+	return p, err
+}
+
+// periodRecords returns one record per working day from start to end,
+// limited to the internship and to days up to today. Working days without
+// attendance count as absent; records on off days are kept as they are.
+func (h *AnalyticsHandler) periodRecords(intern internPeriod, start, end time.Time, cal services.WorkCalendar) ([]models.DailyAttendanceStats, error) {
+	rows, err := h.db.Query(
+		`SELECT date, check_in_time, status, late_reason
+		 FROM attendances
+		 WHERE intern_id = ? AND date BETWEEN ? AND ?
+		 ORDER BY date ASC`,
+		intern.id, start.Format("2006-01-02"), end.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendanceMap := make(map[string]models.DailyAttendanceStats)
+	for rows.Next() {
+		var date time.Time
+		var checkInTime sql.NullTime
+		var status string
+		var lateReason sql.NullString
+		if err := rows.Scan(&date, &checkInTime, &status, &lateReason); err != nil {
+			continue
+		}
+
+		stats := models.DailyAttendanceStats{
+			Date:      date,
+			DayOfWeek: date.Weekday().String(),
+			Status:    status,
+		}
+		if checkInTime.Valid {
+			stats.CheckInTime = &checkInTime.Time
+			hour := checkInTime.Time.Hour()
+			minute := checkInTime.Time.Minute()
+			stats.CheckInHour = &hour
+			stats.CheckInMinute = &minute
+			if status == "late" {
+				minutesLate := h.calculateMinutesLate(checkInTime.Time)
+				stats.MinutesLate = &minutesLate
+				if lateReason.Valid {
+					stats.LateReason = &lateReason.String
+				}
+			}
+		}
+		attendanceMap[date.Format("2006-01-02")] = stats
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	today := time.Now().Format("2006-01-02")
+	records := []models.DailyAttendanceStats{}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day := d.Format("2006-01-02")
+		if day > today ||
+			(intern.start.Valid && day < intern.start.Time.Format("2006-01-02")) ||
+			(intern.end.Valid && day > intern.end.Time.Format("2006-01-02")) {
+			continue
+		}
+		if record, exists := attendanceMap[day]; exists {
+			records = append(records, record)
+			continue
+		}
+		if cal.IsOffDay(d) {
+			continue
+		}
+		records = append(records, models.DailyAttendanceStats{
+			Date:      d,
+			DayOfWeek: d.Weekday().String(),
+			Status:    "absent",
+		})
+	}
+	return records, nil
+}
+
+// summarizePeriod aggregates daily records into a MonthlySummary
+func (h *AnalyticsHandler) summarizePeriod(records []models.DailyAttendanceStats) models.MonthlySummary {
+	weekly := h.calculateWeeklySummary(records)
+	return models.MonthlySummary{
+		TotalWorkingDays: weekly.TotalDays,
+		PresentDays:      weekly.PresentDays,
+		LateDays:         weekly.LateDays,
+		AbsentDays:       weekly.AbsentDays,
+		OnLeaveDays:      weekly.OnLeaveDays,
+		AttendanceRate:   weekly.AttendanceRate,
+		PunctualityRate:  weekly.PunctualityRate,
+		OverallTendency:  weekly.Tendency,
+		ImprovementTrend: "stable",
+	}
+}
+
+// weeklyBreakdown splits records into Monday-Sunday weeks
+func (h *AnalyticsHandler) weeklyBreakdown(records []models.DailyAttendanceStats) []models.WeeklySummary {
+	weeks := []models.WeeklySummary{}
+	for i := 0; i < len(records); {
+		weekStart := startOfWeek(records[i].Date)
+		weekEnd := weekStart.AddDate(0, 0, 6)
+		j := i
+		for j < len(records) && records[j].Date.Format("2006-01-02") <= weekEnd.Format("2006-01-02") {
+			j++
+		}
+		summary := h.calculateWeeklySummary(records[i:j])
+		summary.WeekStart = &weekStart
+		summary.WeekEnd = &weekEnd
+		weeks = append(weeks, summary)
+		i = j
+	}
+	return weeks
+}
+
+// improvementTrend compares attendance and punctuality with the previous
+// period. A change of 5 points or more on average counts as a trend.
+func improvementTrend(current, previous models.MonthlySummary) string {
+	if current.TotalWorkingDays == 0 || previous.TotalWorkingDays == 0 {
+		return "stable"
+	}
+	delta := ((current.AttendanceRate - previous.AttendanceRate) + (current.PunctualityRate - previous.PunctualityRate)) / 2
+	switch {
+	case delta >= 5:
+		return "improving"
+	case delta <= -5:
+		return "declining"
+	default:
+		return "stable"
+	}
+}
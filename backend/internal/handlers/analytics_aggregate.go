@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+)
+
+// attendanceDimensions maps a group_by value to its key and label
+// expressions
+var attendanceDimensions = map[string][2]string{
+	"school":     {"COALESCE(NULLIF(TRIM(i.school), ''), '-')", "COALESCE(NULLIF(TRIM(i.school), ''), '-')"},
+	"department": {"COALESCE(NULLIF(TRIM(i.department), ''), '-')", "COALESCE(NULLIF(TRIM(i.department), ''), '-')"},
+	"supervisor": {"COALESCE(CAST(i.supervisor_id AS CHAR), '-')", "COALESCE(su.name, 'Tanpa Pembimbing')"},
+	"cohort":     {"DATE_FORMAT(i.start_date, '%Y-%m')", "DATE_FORMAT(i.start_date, '%Y-%m')"},
+}
+
+// aggregateScope checks that the caller may see aggregates and returns the
+// supervisor the data is limited to (0 for admins). from/to default to the
+// last 30 days.
+func aggregateScope(w http.ResponseWriter, r *http.Request) (supervisorID int64, from, to time.Time, ok bool) {
+	claims, authed := middleware.GetUserFromContext(r.Context())
+	if !authed {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return 0, from, to, false
+	}
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		utils.RespondForbidden(w, "Only admin or pembimbing can view aggregate analytics")
+		return 0, from, to, false
+	case "pembimbing":
+		supervisorID = claims.UserID
+	}
+
+	from, to, hasRange, msg := analyticsRange(r)
+	if msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return 0, from, to, false
+	}
+	if !hasRange {
+		now := time.Now()
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		from = to.AddDate(0, 0, -30)
+	}
+	return supervisorID, from, to, true
+}
+
+// GetAttendanceByGroup aggregates attendance and punctuality of the interns
+// active in the range by school, department, supervisor or intake cohort
+// (?group_by=, default school). Optional filters: school, department,
+// supervisor_id.
+func (h *AnalyticsHandler) GetAttendanceByGroup(w http.ResponseWriter, r *http.Request) {
+	supervisorID, from, to, ok := aggregateScope(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	groupBy := q.Get("group_by")
+	if groupBy == "" {
+		groupBy = "school"
+	}
+	dim, valid := attendanceDimensions[groupBy]
+	if !valid {
+		utils.RespondBadRequest(w, "group_by must be school, department, supervisor or cohort")
+		return
+	}
+
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+	where := []string{"i.start_date <= ?", "(i.end_date IS NULL OR i.end_date >= ?)"}
+	args := []interface{}{fromStr, toStr, toStr, fromStr}
+	if supervisorID > 0 {
+		where = append(where, "i.supervisor_id = ?")
+		args = append(args, supervisorID)
+	} else if v, err := strconv.ParseInt(q.Get("supervisor_id"), 10, 64); err == nil {
+		where = append(where, "i.supervisor_id = ?")
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Get("school")); v != "" {
+		where = append(where, "i.school = ?")
+		args = append(args, v)
+	}
+	if v := strings.TrimSpace(q.Get("department")); v != "" {
+		where = append(where, "i.department = ?")
+		args = append(args, v)
+	}
+
+	rows, err := h.db.Query(`
+		SELECT `+dim[0]+` AS group_key, `+dim[1]+` AS group_label,
+		       COUNT(DISTINCT i.id),
+		       COUNT(a.id),
+		       COALESCE(SUM(CASE WHEN a.status IN ('present', 'late') THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN a.status = 'late' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN a.status = 'absent' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN a.status IN ('on_leave', 'sick', 'permission') THEN 1 ELSE 0 END), 0)
+		FROM interns i
+		LEFT JOIN attendances a ON a.intern_id = i.id AND a.date BETWEEN ? AND ?
+		LEFT JOIN users su ON i.supervisor_id = su.id
+		WHERE `+strings.Join(where, " AND ")+`
+		GROUP BY group_key, group_label
+		ORDER BY group_label`, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to aggregate attendance")
+		return
+	}
+	defer rows.Close()
+
+	groups := []models.AttendanceGroupStats{}
+	for rows.Next() {
+		var g models.AttendanceGroupStats
+		if err := rows.Scan(&g.Key, &g.Label, &g.Interns, &g.Records, &g.PresentDays, &g.LateDays, &g.AbsentDays, &g.OnLeaveDays); err != nil {
+			continue
+		}
+		if g.Records > 0 {
+			g.AttendanceRate = float64(g.PresentDays) / float64(g.Records) * 100
+		}
+		if g.PresentDays > 0 {
+			g.PunctualityRate = float64(g.PresentDays-g.LateDays) / float64(g.PresentDays) * 100
+		}
+		groups = append(groups, g)
+	}
+
+	utils.RespondSuccess(w, "Attendance aggregates retrieved", map[string]interface{}{
+		"group_by": groupBy,
+		"range":    models.AnalyticsRange{From: from, To: to},
+		"groups":   groups,
+	})
+}
+
+// GetSupervisorStats returns task throughput of each supervisor's interns
+// and the supervisor's review turnaround (submission to review) in the
+// range. Pembimbing only get their own row.
+func (h *AnalyticsHandler) GetSupervisorStats(w http.ResponseWriter, r *http.Request) {
+	supervisorID, from, to, ok := aggregateScope(w, r)
+	if !ok {
+		return
+	}
+	fromStr, toStr := from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02")
+
+	scope := ""
+	args := []interface{}{fromStr, toStr, fromStr, toStr, fromStr, toStr}
+	if supervisorID > 0 {
+		scope = " AND i.supervisor_id = ?"
+		args = append(args, supervisorID)
+	}
+	rows, err := h.db.Query(`
+		SELECT i.supervisor_id, COALESCE(su.name, ''),
+		       COUNT(DISTINCT i.id),
+		       COALESCE(SUM(CASE WHEN t.created_at >= ? AND t.created_at < ? THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN t.status = 'completed' AND t.completed_at >= ? AND t.completed_at < ? THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN t.status = 'completed' AND t.is_late = 1 AND t.completed_at >= ? AND t.completed_at < ? THEN 1 ELSE 0 END), 0)
+		FROM interns i
+		JOIN users su ON i.supervisor_id = su.id
+		LEFT JOIN tasks t ON t.intern_id = i.id
+		WHERE i.supervisor_id IS NOT NULL`+scope+`
+		GROUP BY i.supervisor_id, su.name`, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to aggregate supervisor statistics")
+		return
+	}
+	stats := map[int64]*models.SupervisorStats{}
+	for rows.Next() {
+		var s models.SupervisorStats
+		if err := rows.Scan(&s.SupervisorID, &s.SupervisorName, &s.Interns, &s.TasksAssigned, &s.TasksCompleted, &s.TasksCompletedLate); err != nil {
+			continue
+		}
+		stats[s.SupervisorID] = &s
+	}
+	rows.Close()
+
+	reviewScope := ""
+	reviewArgs := []interface{}{fromStr, toStr}
+	if supervisorID > 0 {
+		reviewScope = " AND r.reviewer_id = ?"
+		reviewArgs = append(reviewArgs, supervisorID)
+	}
+	rows, err = h.db.Query(`
+		SELECT r.reviewer_id, COALESCE(ru.name, ''), COUNT(*),
+		       COALESCE(SUM(CASE WHEN r.action = 'revision' THEN 1 ELSE 0 END), 0),
+		       AVG(TIMESTAMPDIFF(MINUTE, s.submitted_at, r.reviewed_at))
+		FROM task_reviews r
+		LEFT JOIN task_submissions s ON r.submission_id = s.id
+		LEFT JOIN users ru ON r.reviewer_id = ru.id
+		WHERE r.reviewed_at >= ? AND r.reviewed_at < ?`+reviewScope+`
+		GROUP BY r.reviewer_id, ru.name`, reviewArgs...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to aggregate review statistics")
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var reviewerID int64
+		var name string
+		var reviews, revisions int
+		var minutes sql.NullFloat64
+		if err := rows.Scan(&reviewerID, &name, &reviews, &revisions, &minutes); err != nil {
+			continue
+		}
+		s := stats[reviewerID]
+		if s == nil {
+			// Admins review tasks too without supervising interns
+			s = &models.SupervisorStats{SupervisorID: reviewerID, SupervisorName: name}
+			stats[reviewerID] = s
+		}
+		s.Reviews = reviews
+		s.Revisions = revisions
+		if minutes.Valid {
+			hours := math.Round(minutes.Float64/60*10) / 10
+			s.AvgReviewTurnaroundHrs = &hours
+		}
+	}
+
+	list := []models.SupervisorStats{}
+	for _, s := range stats {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].SupervisorName < list[j].SupervisorName })
+
+	utils.RespondSuccess(w, "Supervisor statistics retrieved", map[string]interface{}{
+		"range":       models.AnalyticsRange{From: from, To: to},
+		"supervisors": list,
+	})
+}
+
+// GetAssignmentRevisions returns the revision rate of each task assignment
+// created in the range, highest first (?limit=, default 50)
+func (h *AnalyticsHandler) GetAssignmentRevisions(w http.ResponseWriter, r *http.Request) {
+	supervisorID, from, to, ok := aggregateScope(w, r)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	scope := ""
+	args := []interface{}{from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02")}
+	if supervisorID > 0 {
+		scope = " AND i.supervisor_id = ?"
+		args = append(args, supervisorID)
+	}
+	rows, err := h.db.Query(`
+		SELECT ta.id, ta.title, ta.deadline,
+		       COUNT(DISTINCT t.id),
+		       COUNT(DISTINCT CASE WHEN t.status = 'completed' THEN t.id END),
+		       COUNT(r.id),
+		       COALESCE(SUM(CASE WHEN r.action = 'revision' THEN 1 ELSE 0 END), 0),
+		       COUNT(DISTINCT CASE WHEN r.action = 'revision' THEN t.id END)
+		FROM task_assignments ta
+		JOIN tasks t ON t.task_assignment_id = ta.id
+		JOIN interns i ON t.intern_id = i.id
+		LEFT JOIN task_reviews r ON r.task_id = t.id
+		WHERE ta.created_at >= ? AND ta.created_at < ?`+scope+`
+		GROUP BY ta.id, ta.title, ta.deadline`, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to aggregate revision rates")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.AssignmentRevisionStats{}
+	for rows.Next() {
+		var a models.AssignmentRevisionStats
+		var deadline sql.NullTime
+		if err := rows.Scan(&a.AssignmentID, &a.Title, &deadline, &a.Tasks, &a.CompletedTasks, &a.Reviews, &a.Revisions, &a.RevisedTasks); err != nil {
+			continue
+		}
+		a.Deadline = ptrTimeFromNull(deadline)
+		if a.Reviews > 0 {
+			a.RevisionRate = float64(a.Revisions) / float64(a.Reviews) * 100
+		}
+		if a.Tasks > 0 {
+			a.RevisedTaskRate = float64(a.RevisedTasks) / float64(a.Tasks) * 100
+		}
+		list = append(list, a)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].RevisionRate > list[j].RevisionRate })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	utils.RespondSuccess(w, "Assignment revision rates retrieved", map[string]interface{}{
+		"range":       models.AnalyticsRange{From: from, To: to},
+		"assignments": list,
+	})
+}
@@ -0,0 +1,662 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type AnnouncementHandler struct {
+	db *sql.DB
+}
+
+func NewAnnouncementHandler(db *sql.DB) *AnnouncementHandler {
+	return &AnnouncementHandler{db: db}
+}
+
+type createAnnouncementRequest struct {
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	TargetType  string `json:"target_type"`  // all, supervisor, school, department
+	TargetValue string `json:"target_value"` // supervisor user_id, school or department name
+	IsPinned    bool   `json:"is_pinned"`
+	RequiresAck bool   `json:"requires_ack"`
+	PublishAt   string `json:"publish_at"` // empty = publish now
+	ExpiresAt   string `json:"expires_at"`
+}
+
+type updateAnnouncementRequest struct {
+	Title       *string `json:"title"`
+	Body        *string `json:"body"`
+	IsPinned    *bool   `json:"is_pinned"`
+	RequiresAck *bool   `json:"requires_ack"`
+	PublishAt   *string `json:"publish_at"`
+	ExpiresAt   *string `json:"expires_at"`
+}
+
+const announcementSelect = `
+	SELECT a.id, a.title, a.body, a.target_type, a.target_value, a.created_by, a.is_pinned, a.requires_ack,
+	       a.publish_at, a.expires_at, a.published_at, a.created_at, a.updated_at, u.name
+	FROM announcements a
+	LEFT JOIN users u ON a.created_by = u.id
+`
+
+func (h *AnnouncementHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 15
+	}
+	offset := (page - 1) * limit
+
+	role := normalizeRole(claims.Role)
+	now := time.Now()
+
+	if role == "intern" {
+		// Interns only see what was delivered to them and is still live
+		where := "WHERE ar.user_id = ? AND a.published_at IS NOT NULL AND (a.expires_at IS NULL OR a.expires_at > ?)"
+		args := []interface{}{claims.UserID, now}
+		if r.URL.Query().Get("unread") == "true" {
+			where += " AND ar.read_at IS NULL"
+		}
+
+		var total int64
+		if err := h.db.QueryRow(
+			"SELECT COUNT(*) FROM announcements a JOIN announcement_recipients ar ON ar.announcement_id = a.id "+where, args...,
+		).Scan(&total); err != nil {
+			utils.RespondInternalError(w, "Failed to count announcements")
+			return
+		}
+
+		query := `
+			SELECT a.id, a.title, a.body, a.target_type, a.target_value, a.created_by, a.is_pinned, a.requires_ack,
+			       a.publish_at, a.expires_at, a.published_at, a.created_at, a.updated_at, u.name,
+			       ar.read_at, ar.acknowledged_at
+			FROM announcements a
+			JOIN announcement_recipients ar ON ar.announcement_id = a.id
+			LEFT JOIN users u ON a.created_by = u.id
+		` + where + " ORDER BY a.is_pinned DESC, a.published_at DESC LIMIT ? OFFSET ?"
+		args = append(args, limit, offset)
+
+		rows, err := h.db.Query(query, args...)
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to fetch announcements")
+			return
+		}
+		defer rows.Close()
+
+		announcements := []models.Announcement{}
+		for rows.Next() {
+			var readAt, ackAt sql.NullTime
+			a, err := scanAnnouncement(rows, &readAt, &ackAt)
+			if err != nil {
+				continue
+			}
+			a.ReadAt = ptrTimeFromNull(readAt)
+			a.AcknowledgedAt = ptrTimeFromNull(ackAt)
+			announcements = append(announcements, a)
+		}
+
+		utils.RespondPaginated(w, announcements, utils.CalculatePagination(page, limit, total))
+		return
+	}
+
+	where := []string{}
+	args := []interface{}{}
+	if role == "pembimbing" {
+		where = append(where, "a.created_by = ?")
+		args = append(args, claims.UserID)
+	}
+	switch r.URL.Query().Get("state") {
+	case "scheduled":
+		where = append(where, "a.published_at IS NULL")
+	case "active":
+		where = append(where, "a.published_at IS NOT NULL AND (a.expires_at IS NULL OR a.expires_at > ?)")
+		args = append(args, now)
+	case "expired":
+		where = append(where, "a.expires_at IS NOT NULL AND a.expires_at <= ?")
+		args = append(args, now)
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM announcements a "+whereClause, args...).Scan(&total); err != nil {
+		utils.RespondInternalError(w, "Failed to count announcements")
+		return
+	}
+
+	args = append(args, limit, offset)
+	rows, err := h.db.Query(announcementSelect+" "+whereClause+" ORDER BY a.is_pinned DESC, a.publish_at DESC LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch announcements")
+		return
+	}
+	defer rows.Close()
+
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		a, err := scanAnnouncement(rows)
+		if err != nil {
+			continue
+		}
+		announcements = append(announcements, a)
+	}
+	for i := range announcements {
+		h.fillReceiptCounts(&announcements[i])
+	}
+
+	utils.RespondPaginated(w, announcements, utils.CalculatePagination(page, limit, total))
+}
+
+func (h *AnnouncementHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	a, err := scanAnnouncement(h.db.QueryRow(announcementSelect+" WHERE a.id = ?", id))
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Announcement not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	role := normalizeRole(claims.Role)
+	if role == "intern" {
+		var readAt, ackAt sql.NullTime
+		err := h.db.QueryRow(
+			"SELECT read_at, acknowledged_at FROM announcement_recipients WHERE announcement_id = ? AND user_id = ?",
+			id, claims.UserID,
+		).Scan(&readAt, &ackAt)
+		if err != nil {
+			utils.RespondNotFound(w, "Announcement not found")
+			return
+		}
+		a.ReadAt = ptrTimeFromNull(readAt)
+		a.AcknowledgedAt = ptrTimeFromNull(ackAt)
+	} else {
+		if role == "pembimbing" && a.CreatedBy != claims.UserID {
+			utils.RespondForbidden(w, "You do not have access to this announcement")
+			return
+		}
+		h.fillReceiptCounts(&a)
+	}
+
+	utils.RespondSuccess(w, "Announcement retrieved", a)
+}
+
+func (h *AnnouncementHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	role := normalizeRole(claims.Role)
+	if role != "admin" && role != "pembimbing" {
+		utils.RespondForbidden(w, "Only admin or pembimbing can create announcements")
+		return
+	}
+
+	var req createAnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Body) == "" {
+		utils.RespondBadRequest(w, "Title and body are required")
+		return
+	}
+	if req.TargetType == "" {
+		req.TargetType = models.AnnouncementTargetAll
+	}
+	switch req.TargetType {
+	case models.AnnouncementTargetAll:
+		req.TargetValue = ""
+	case models.AnnouncementTargetSupervisor, models.AnnouncementTargetSchool, models.AnnouncementTargetDepartment:
+		if strings.TrimSpace(req.TargetValue) == "" {
+			utils.RespondBadRequest(w, "target_value is required for target_type "+req.TargetType)
+			return
+		}
+	default:
+		utils.RespondBadRequest(w, "Invalid target_type")
+		return
+	}
+	if req.TargetType == models.AnnouncementTargetSupervisor {
+		if _, err := strconv.ParseInt(req.TargetValue, 10, 64); err != nil {
+			utils.RespondBadRequest(w, "target_value must be a supervisor user id")
+			return
+		}
+	}
+
+	publishAt := time.Now()
+	if req.PublishAt != "" {
+		parsed, ok := parseDateTimeInput(req.PublishAt)
+		if !ok {
+			utils.RespondBadRequest(w, "Invalid publish_at")
+			return
+		}
+		publishAt = parsed
+	}
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != "" {
+		parsed, ok := parseDateTimeInput(req.ExpiresAt)
+		if !ok {
+			utils.RespondBadRequest(w, "Invalid expires_at")
+			return
+		}
+		if !parsed.After(publishAt) {
+			utils.RespondBadRequest(w, "expires_at must be after publish_at")
+			return
+		}
+		expiresAt = sql.NullTime{Time: parsed, Valid: true}
+	}
+
+	res, err := h.db.Exec(
+		`INSERT INTO announcements (title, body, target_type, target_value, created_by, is_pinned, requires_ack, publish_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		req.Title, req.Body, req.TargetType, nullIfEmpty(req.TargetValue), claims.UserID, req.IsPinned, req.RequiresAck, publishAt, expiresAt,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create announcement")
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	delivered := 0
+	if !publishAt.After(time.Now()) {
+		delivered, err = services.PublishAnnouncement(h.db, id)
+		if err != nil {
+			utils.RespondInternalError(w, "Announcement saved but failed to publish")
+			return
+		}
+	}
+
+	utils.RespondCreated(w, "Announcement created", map[string]interface{}{
+		"id":         id,
+		"published":  !publishAt.After(time.Now()),
+		"recipients": delivered,
+	})
+}
+
+func (h *AnnouncementHandler) Update(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var current struct {
+		CreatedBy   int64
+		PublishAt   time.Time
+		PublishedAt sql.NullTime
+	}
+	err := h.db.QueryRow("SELECT created_by, publish_at, published_at FROM announcements WHERE id = ?", id).
+		Scan(&current.CreatedBy, &current.PublishAt, &current.PublishedAt)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Announcement not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !h.canManage(claims, current.CreatedBy) {
+		utils.RespondForbidden(w, "You can only update your own announcements")
+		return
+	}
+
+	var req updateAnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	updates := []string{}
+	args := []interface{}{}
+
+	if req.Title != nil {
+		if strings.TrimSpace(*req.Title) == "" {
+			utils.RespondBadRequest(w, "Title cannot be empty")
+			return
+		}
+		updates = append(updates, "title = ?")
+		args = append(args, *req.Title)
+	}
+	if req.Body != nil {
+		if strings.TrimSpace(*req.Body) == "" {
+			utils.RespondBadRequest(w, "Body cannot be empty")
+			return
+		}
+		updates = append(updates, "body = ?")
+		args = append(args, *req.Body)
+	}
+	if req.IsPinned != nil {
+		updates = append(updates, "is_pinned = ?")
+		args = append(args, *req.IsPinned)
+	}
+	if req.RequiresAck != nil {
+		updates = append(updates, "requires_ack = ?")
+		args = append(args, *req.RequiresAck)
+	}
+	publishAt := current.PublishAt
+	if req.PublishAt != nil {
+		if current.PublishedAt.Valid {
+			utils.RespondBadRequest(w, "Announcement has already been published")
+			return
+		}
+		parsed, ok := parseDateTimeInput(*req.PublishAt)
+		if !ok {
+			utils.RespondBadRequest(w, "Invalid publish_at")
+			return
+		}
+		publishAt = parsed
+		updates = append(updates, "publish_at = ?")
+		args = append(args, parsed)
+	}
+	if req.ExpiresAt != nil {
+		if *req.ExpiresAt == "" {
+			updates = append(updates, "expires_at = NULL")
+		} else {
+			parsed, ok := parseDateTimeInput(*req.ExpiresAt)
+			if !ok {
+				utils.RespondBadRequest(w, "Invalid expires_at")
+				return
+			}
+			if !parsed.After(publishAt) {
+				utils.RespondBadRequest(w, "expires_at must be after publish_at")
+				return
+			}
+			updates = append(updates, "expires_at = ?")
+			args = append(args, parsed)
+		}
+	}
+
+	if len(updates) == 0 {
+		utils.RespondBadRequest(w, "No updates provided")
+		return
+	}
+
+	args = append(args, id)
+	if _, err := h.db.Exec("UPDATE announcements SET "+strings.Join(updates, ", ")+" WHERE id = ?", args...); err != nil {
+		utils.RespondInternalError(w, "Failed to update announcement")
+		return
+	}
+
+	if !current.PublishedAt.Valid && !publishAt.After(time.Now()) {
+		if _, err := services.PublishAnnouncement(h.db, id); err != nil {
+			utils.RespondInternalError(w, "Announcement updated but failed to publish")
+			return
+		}
+	}
+
+	utils.RespondSuccess(w, "Announcement updated", nil)
+}
+
+func (h *AnnouncementHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var createdBy int64
+	err := h.db.QueryRow("SELECT created_by FROM announcements WHERE id = ?", id).Scan(&createdBy)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Announcement not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !h.canManage(claims, createdBy) {
+		utils.RespondForbidden(w, "You can only delete your own announcements")
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM announcements WHERE id = ?", id); err != nil {
+		utils.RespondInternalError(w, "Failed to delete announcement")
+		return
+	}
+
+	utils.RespondSuccess(w, "Announcement deleted", nil)
+}
+
+// MarkRead records that the current user has opened the announcement
+func (h *AnnouncementHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	res, err := h.db.Exec(
+		"UPDATE announcement_recipients SET read_at = COALESCE(read_at, ?) WHERE announcement_id = ? AND user_id = ?",
+		time.Now(), id, claims.UserID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to update announcement")
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		if !h.isRecipient(id, claims.UserID) {
+			utils.RespondNotFound(w, "Announcement not found")
+			return
+		}
+	}
+
+	utils.RespondSuccess(w, "Announcement marked as read", nil)
+}
+
+// Acknowledge confirms an announcement that requires acknowledgement
+func (h *AnnouncementHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var requiresAck bool
+	if err := h.db.QueryRow("SELECT requires_ack FROM announcements WHERE id = ?", id).Scan(&requiresAck); err != nil {
+		utils.RespondNotFound(w, "Announcement not found")
+		return
+	}
+	if !requiresAck {
+		utils.RespondBadRequest(w, "This announcement does not require acknowledgement")
+		return
+	}
+	if !h.isRecipient(id, claims.UserID) {
+		utils.RespondNotFound(w, "Announcement not found")
+		return
+	}
+
+	now := time.Now()
+	if _, err := h.db.Exec(
+		`UPDATE announcement_recipients
+		 SET read_at = COALESCE(read_at, ?), acknowledged_at = COALESCE(acknowledged_at, ?)
+		 WHERE announcement_id = ? AND user_id = ?`,
+		now, now, id, claims.UserID,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to acknowledge announcement")
+		return
+	}
+
+	utils.RespondSuccess(w, "Announcement acknowledged", nil)
+}
+
+// GetReceipts lists per-recipient read and acknowledgement status
+func (h *AnnouncementHandler) GetReceipts(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var createdBy int64
+	if err := h.db.QueryRow("SELECT created_by FROM announcements WHERE id = ?", id).Scan(&createdBy); err != nil {
+		utils.RespondNotFound(w, "Announcement not found")
+		return
+	}
+	if !h.canManage(claims, createdBy) {
+		utils.RespondForbidden(w, "You do not have access to these receipts")
+		return
+	}
+
+	query := `
+		SELECT ar.user_id, ar.intern_id, COALESCE(i.full_name, u.name, u.email), ar.delivered_at, ar.read_at, ar.acknowledged_at
+		FROM announcement_recipients ar
+		JOIN users u ON ar.user_id = u.id
+		LEFT JOIN interns i ON ar.intern_id = i.id
+		WHERE ar.announcement_id = ?
+	`
+	switch r.URL.Query().Get("status") {
+	case "unread":
+		query += " AND ar.read_at IS NULL"
+	case "read":
+		query += " AND ar.read_at IS NOT NULL"
+	case "unacknowledged":
+		query += " AND ar.acknowledged_at IS NULL"
+	case "acknowledged":
+		query += " AND ar.acknowledged_at IS NOT NULL"
+	}
+	query += " ORDER BY ar.read_at IS NULL DESC, u.name ASC"
+
+	rows, err := h.db.Query(query, id)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch receipts")
+		return
+	}
+	defer rows.Close()
+
+	receipts := []models.AnnouncementReceipt{}
+	for rows.Next() {
+		var rc models.AnnouncementReceipt
+		var internID sql.NullInt64
+		var readAt, ackAt sql.NullTime
+		if err := rows.Scan(&rc.UserID, &internID, &rc.Name, &rc.DeliveredAt, &readAt, &ackAt); err != nil {
+			continue
+		}
+		rc.InternID = ptrInt64FromNull(internID)
+		rc.ReadAt = ptrTimeFromNull(readAt)
+		rc.AcknowledgedAt = ptrTimeFromNull(ackAt)
+		receipts = append(receipts, rc)
+	}
+
+	utils.RespondSuccess(w, "Receipts retrieved", receipts)
+}
+
+func (h *AnnouncementHandler) canManage(claims *middleware.Claims, createdBy int64) bool {
+	role := normalizeRole(claims.Role)
+	if role == "admin" {
+		return true
+	}
+	return role == "pembimbing" && createdBy == claims.UserID
+}
+
+func (h *AnnouncementHandler) isRecipient(announcementID, userID int64) bool {
+	var exists int
+	err := h.db.QueryRow(
+		"SELECT 1 FROM announcement_recipients WHERE announcement_id = ? AND user_id = ?",
+		announcementID, userID,
+	).Scan(&exists)
+	return err == nil
+}
+
+func (h *AnnouncementHandler) fillReceiptCounts(a *models.Announcement) {
+	var recipients, read, acked sql.NullInt64
+	_ = h.db.QueryRow(
+		`SELECT COUNT(*),
+		        SUM(CASE WHEN read_at IS NOT NULL THEN 1 ELSE 0 END),
+		        SUM(CASE WHEN acknowledged_at IS NOT NULL THEN 1 ELSE 0 END)
+		 FROM announcement_recipients WHERE announcement_id = ?`, a.ID,
+	).Scan(&recipients, &read, &acked)
+	a.RecipientCount = int64OrZero(recipients)
+	a.ReadCount = int64OrZero(read)
+	a.AckCount = int64OrZero(acked)
+}
+
+func scanAnnouncement(scanner sqlScanner, extra ...interface{}) (models.Announcement, error) {
+	var a models.Announcement
+	var targetValue sql.NullString
+	var expiresAt, publishedAt sql.NullTime
+	var createdByName sql.NullString
+
+	dest := []interface{}{
+		&a.ID, &a.Title, &a.Body, &a.TargetType, &targetValue, &a.CreatedBy, &a.IsPinned, &a.RequiresAck,
+		&a.PublishAt, &expiresAt, &publishedAt, &a.CreatedAt, &a.UpdatedAt, &createdByName,
+	}
+	dest = append(dest, extra...)
+	if err := scanner.Scan(dest...); err != nil {
+		return a, err
+	}
+
+	a.TargetValue = ptrStringFromNull(targetValue)
+	a.ExpiresAt = ptrTimeFromNull(expiresAt)
+	a.PublishedAt = ptrTimeFromNull(publishedAt)
+	if createdByName.Valid {
+		a.CreatedByName = createdByName.String
+	}
+	return a, nil
+}
+
+// parseDateTimeInput accepts the datetime formats sent by the frontend
+// (datetime-local inputs, RFC3339 and plain dates) in local time.
+func parseDateTimeInput(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(time.Local), true
+	}
+	layouts := []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
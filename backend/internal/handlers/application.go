@@ -0,0 +1,479 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// ApplicationHandler runs the recruitment pipeline: applications, their
+// documents, stage changes and interview slots
+type ApplicationHandler struct {
+	db *sql.DB
+}
+
+func NewApplicationHandler(db *sql.DB) *ApplicationHandler {
+	return &ApplicationHandler{db: db}
+}
+
+// respondApplicationError maps service errors to responses
+func respondApplicationError(w http.ResponseWriter, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.RespondNotFound(w, notFound)
+	case errors.Is(err, services.ErrInvalidApplication), errors.Is(err, services.ErrInvalidInstitution):
+		utils.RespondBadRequest(w, err.Error())
+	default:
+		utils.RespondInternalError(w, failed)
+	}
+}
+
+// Apply is the public application form. It creates the applicant account
+// the applicant signs in with to upload documents and book an interview.
+func (h *ApplicationHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	id, err := services.CreateApplication(h.db, req)
+	if err != nil {
+		respondApplicationError(w, err, "Application not found", "Failed to submit application")
+		return
+	}
+	app, _ := services.LoadApplication(h.db, id)
+	utils.RespondCreated(w, "Application submitted. Sign in to upload your documents.", app)
+}
+
+// GetMine returns the signed-in applicant's latest application
+func (h *ApplicationHandler) GetMine(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	id, err := services.LatestApplicationForUser(h.db, claims.UserID)
+	if err != nil {
+		respondApplicationError(w, err, "No application found", "Failed to fetch application")
+		return
+	}
+	app, err := services.LoadApplication(h.db, id)
+	if err != nil {
+		respondApplicationError(w, err, "No application found", "Failed to fetch application")
+		return
+	}
+	utils.RespondSuccess(w, "Application retrieved", app)
+}
+
+// UploadDocument stores the applicant's file for a document type,
+// replacing an earlier upload of the same type
+func (h *ApplicationHandler) UploadDocument(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	id, err := services.LatestApplicationForUser(h.db, claims.UserID)
+	if err != nil {
+		respondApplicationError(w, err, "No application found", "Failed to fetch application")
+		return
+	}
+	app, err := services.LoadApplication(h.db, id)
+	if err != nil {
+		respondApplicationError(w, err, "No application found", "Failed to fetch application")
+		return
+	}
+	if app.Outcome != nil {
+		utils.RespondBadRequest(w, "The application has already been decided")
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		utils.RespondBadRequest(w, "Invalid multipart form")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.RespondBadRequest(w, "file is required")
+		return
+	}
+	defer file.Close()
+
+	if err := utils.ValidateFileType(header.Filename, []string{"pdf", "doc", "docx", "png", "jpg", "jpeg"}); err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+
+	path, err := utils.UploadFile(file, header, "applications")
+	if err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+	previous, err := services.SaveApplicationDocument(h.db, id, mux.Vars(r)["type"], path, header.Filename)
+	if err != nil {
+		_ = utils.DeleteFile(path)
+		respondApplicationError(w, err, "Application not found", "Failed to save document")
+		return
+	}
+	if previous != "" && previous != path {
+		_ = utils.DeleteFile(previous)
+	}
+
+	app, _ = services.LoadApplication(h.db, id)
+	utils.RespondSuccess(w, "Document uploaded", app)
+}
+
+// GetAll lists applications, filtered by ?stage_id= and ?search=
+func (h *ApplicationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	stageID, _ := strconv.ParseInt(r.URL.Query().Get("stage_id"), 10, 64)
+	list, err := services.ListApplications(h.db, stageID, r.URL.Query().Get("search"))
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch applications")
+		return
+	}
+	utils.RespondSuccess(w, "Applications retrieved", list)
+}
+
+// GetByID returns an application with its documents, history and
+// interviews
+func (h *ApplicationHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid application ID")
+	if !ok {
+		return
+	}
+	app, err := services.LoadApplication(h.db, id)
+	if err != nil {
+		respondApplicationError(w, err, "Application not found", "Failed to fetch application")
+		return
+	}
+	utils.RespondSuccess(w, "Application retrieved", app)
+}
+
+// Update sets the internship period, supervisor and institution an
+// accepted applicant will start with
+func (h *ApplicationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid application ID")
+	if !ok {
+		return
+	}
+	var req models.UpdateApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if err := services.UpdateApplication(h.db, id, req); err != nil {
+		respondApplicationError(w, err, "Application not found", "Failed to update application")
+		return
+	}
+	app, _ := services.LoadApplication(h.db, id)
+	utils.RespondSuccess(w, "Application updated", app)
+}
+
+// Move puts an application in another stage, mailing the applicant the
+// stage's template. Accepting creates the intern.
+func (h *ApplicationHandler) Move(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	id, ok := pathID(w, r, "id", "Invalid application ID")
+	if !ok {
+		return
+	}
+	var req models.MoveApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	stage, err := services.MoveApplication(h.db, id, req, claims.UserID)
+	if err != nil {
+		respondApplicationError(w, err, "Application not found", "Failed to move application")
+		return
+	}
+	app, _ := services.LoadApplication(h.db, id)
+	utils.RespondSuccess(w, "Application moved to "+stage.Name, app)
+}
+
+// GetStages lists the pipeline stages
+func (h *ApplicationHandler) GetStages(w http.ResponseWriter, r *http.Request) {
+	list, err := services.ListApplicationStages(h.db)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch stages")
+		return
+	}
+	utils.RespondSuccess(w, "Stages retrieved", list)
+}
+
+// CreateStage adds a pipeline stage
+func (h *ApplicationHandler) CreateStage(w http.ResponseWriter, r *http.Request) {
+	h.saveStage(w, r, 0)
+}
+
+// UpdateStage edits a stage's name, position and email template
+func (h *ApplicationHandler) UpdateStage(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid stage ID")
+	if !ok {
+		return
+	}
+	h.saveStage(w, r, id)
+}
+
+func (h *ApplicationHandler) saveStage(w http.ResponseWriter, r *http.Request, id int64) {
+	var req models.ApplicationStageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	savedID, err := services.SaveApplicationStage(h.db, id, req)
+	if err != nil {
+		respondApplicationError(w, err, "Stage not found", "Failed to save stage")
+		return
+	}
+	stage, _ := services.LoadApplicationStage(h.db, savedID)
+	if id == 0 {
+		utils.RespondCreated(w, "Stage created", stage)
+		return
+	}
+	utils.RespondSuccess(w, "Stage updated", stage)
+}
+
+// DeleteStage removes a stage no application has used
+func (h *ApplicationHandler) DeleteStage(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid stage ID")
+	if !ok {
+		return
+	}
+	if err := services.DeleteApplicationStage(h.db, id); err != nil {
+		respondApplicationError(w, err, "Stage not found", "Failed to delete stage")
+		return
+	}
+	utils.RespondSuccess(w, "Stage deleted", nil)
+}
+
+// GetDocumentTypes lists the documents applicants upload
+func (h *ApplicationHandler) GetDocumentTypes(w http.ResponseWriter, r *http.Request) {
+	list, err := services.ListApplicationDocumentTypes(h.db)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch document types")
+		return
+	}
+	utils.RespondSuccess(w, "Document types retrieved", list)
+}
+
+// CreateDocumentType adds a document type
+func (h *ApplicationHandler) CreateDocumentType(w http.ResponseWriter, r *http.Request) {
+	h.saveDocumentType(w, r, 0)
+}
+
+// UpdateDocumentType edits a document type
+func (h *ApplicationHandler) UpdateDocumentType(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid document type ID")
+	if !ok {
+		return
+	}
+	h.saveDocumentType(w, r, id)
+}
+
+func (h *ApplicationHandler) saveDocumentType(w http.ResponseWriter, r *http.Request, id int64) {
+	var req models.ApplicationDocumentTypeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if _, err := services.SaveApplicationDocumentType(h.db, id, req); err != nil {
+		respondApplicationError(w, err, "Document type not found", "Failed to save document type")
+		return
+	}
+	list, _ := services.ListApplicationDocumentTypes(h.db)
+	if id == 0 {
+		utils.RespondCreated(w, "Document type created", list)
+		return
+	}
+	utils.RespondSuccess(w, "Document type updated", list)
+}
+
+// DeleteDocumentType removes a document type and the files uploaded for it
+func (h *ApplicationHandler) DeleteDocumentType(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid document type ID")
+	if !ok {
+		return
+	}
+	paths, err := services.DeleteApplicationDocumentType(h.db, id)
+	if err != nil {
+		respondApplicationError(w, err, "Document type not found", "Failed to delete document type")
+		return
+	}
+	for _, p := range paths {
+		_ = utils.DeleteFile(p)
+	}
+	utils.RespondSuccess(w, "Document type deleted", nil)
+}
+
+// GetSlots lists interview slots. Applicants only see open ones; staff
+// may filter by ?interviewer_id=, ?application_id= and ?open=true.
+func (h *ApplicationHandler) GetSlots(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	q := r.URL.Query()
+	var f services.InterviewSlotFilter
+	switch normalizeRole(claims.Role) {
+	case models.RoleApplicant:
+		f.OpenOnly = true
+	case "admin", "pembimbing":
+		f.InterviewerID, _ = strconv.ParseInt(q.Get("interviewer_id"), 10, 64)
+		f.ApplicationID, _ = strconv.ParseInt(q.Get("application_id"), 10, 64)
+		f.OpenOnly = q.Get("open") == "true"
+	default:
+		utils.RespondForbidden(w, "Insufficient permissions")
+		return
+	}
+	list, err := services.ListInterviewSlots(h.db, f)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch interview slots")
+		return
+	}
+	utils.RespondSuccess(w, "Interview slots retrieved", list)
+}
+
+// CreateSlots offers interview times. Pembimbing offer their own; admins
+// may offer them for another interviewer.
+func (h *ApplicationHandler) CreateSlots(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	var req models.CreateInterviewSlotsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	interviewerID := claims.UserID
+	if req.InterviewerID > 0 && req.InterviewerID != claims.UserID {
+		if normalizeRole(claims.Role) != "admin" {
+			utils.RespondForbidden(w, "Only admins can offer slots for another interviewer")
+			return
+		}
+		interviewerID = req.InterviewerID
+	}
+	if _, err := services.CreateInterviewSlots(h.db, interviewerID, req, claims.UserID); err != nil {
+		respondApplicationError(w, err, "Interviewer not found", "Failed to create interview slots")
+		return
+	}
+	list, _ := services.ListInterviewSlots(h.db, services.InterviewSlotFilter{InterviewerID: interviewerID, OpenOnly: true})
+	utils.RespondCreated(w, "Interview slots created", list)
+}
+
+// Book books an interview slot. Applicants book for their own application
+// once it reaches the interview stage; staff pass application_id.
+func (h *ApplicationHandler) Book(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	slotID, ok := pathID(w, r, "id", "Invalid slot ID")
+	if !ok {
+		return
+	}
+	var req models.BookInterviewRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+
+	switch normalizeRole(claims.Role) {
+	case models.RoleApplicant:
+		id, err := services.LatestApplicationForUser(h.db, claims.UserID)
+		if err != nil {
+			respondApplicationError(w, err, "No application found", "Failed to fetch application")
+			return
+		}
+		app, err := services.LoadApplication(h.db, id)
+		if err != nil {
+			respondApplicationError(w, err, "No application found", "Failed to fetch application")
+			return
+		}
+		if app.StageCode != services.InterviewStageCode {
+			utils.RespondBadRequest(w, "Interviews can be booked once the application reaches the interview stage")
+			return
+		}
+		req.ApplicationID = id
+	case "admin", "pembimbing":
+		if req.ApplicationID <= 0 {
+			utils.RespondBadRequest(w, "application_id is required")
+			return
+		}
+	default:
+		utils.RespondForbidden(w, "Insufficient permissions")
+		return
+	}
+
+	if err := services.BookInterview(h.db, slotID, req.ApplicationID); err != nil {
+		respondApplicationError(w, err, "Interview slot not found", "Failed to book interview")
+		return
+	}
+	list, _ := services.ListInterviewSlots(h.db, services.InterviewSlotFilter{ApplicationID: req.ApplicationID})
+	utils.RespondSuccess(w, "Interview booked", list)
+}
+
+// canManageSlot allows admins and the slot's own interviewer
+func (h *ApplicationHandler) canManageSlot(w http.ResponseWriter, r *http.Request, slotID int64) bool {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return false
+	}
+	interviewerID, err := services.InterviewSlotOwner(h.db, slotID)
+	if err != nil {
+		respondApplicationError(w, err, "Interview slot not found", "Database error")
+		return false
+	}
+	if normalizeRole(claims.Role) != "admin" && interviewerID != claims.UserID {
+		utils.RespondForbidden(w, "This is another interviewer's slot")
+		return false
+	}
+	return true
+}
+
+// CancelBooking releases a booked slot and removes the interview from
+// both agendas
+func (h *ApplicationHandler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	slotID, ok := pathID(w, r, "id", "Invalid slot ID")
+	if !ok || !h.canManageSlot(w, r, slotID) {
+		return
+	}
+	if err := services.CancelInterview(h.db, slotID); err != nil {
+		respondApplicationError(w, err, "Interview slot not found", "Failed to cancel interview")
+		return
+	}
+	utils.RespondSuccess(w, "Interview cancelled", nil)
+}
+
+// DeleteSlot withdraws an unbooked slot
+func (h *ApplicationHandler) DeleteSlot(w http.ResponseWriter, r *http.Request) {
+	slotID, ok := pathID(w, r, "id", "Invalid slot ID")
+	if !ok || !h.canManageSlot(w, r, slotID) {
+		return
+	}
+	if err := services.DeleteInterviewSlot(h.db, slotID); err != nil {
+		respondApplicationError(w, err, "Interview slot not found", "Failed to delete interview slot")
+		return
+	}
+	utils.RespondSuccess(w, "Interview slot deleted", nil)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -11,6 +12,7 @@ import (
 
 	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/go-sql-driver/mysql"
@@ -33,6 +35,9 @@ type updateAssessmentPayload struct {
 	Aspect             *string `json:"aspect,omitempty"`
 	Notes              *string `json:"notes,omitempty"`
 	AssessmentDate     *string `json:"assessment_date,omitempty"` // YYYY-MM-DD
+
+	// Scores by criterion code; merged into the existing scores
+	Scores map[string]float64 `json:"scores,omitempty"`
 }
 
 func NewAssessmentHandler(db *sql.DB) *AssessmentHandler {
@@ -131,7 +136,8 @@ func (h *AssessmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	// We prioritize User from Supervisor relation (u_sup) over Direct User (u_direct)
 	// because standard flow uses Supervisor ID.
 	query := `
-		SELECT a.id, a.intern_id, a.task_id, a.assessed_by, a.score, a.category, a.aspect,
+		SELECT a.id, a.intern_id, a.task_id, a.rubric_version_id, a.assessed_by, a.score, a.rubric_score,
+		       COALESCE(a.category, ''), a.category_label, a.aspect,
 		       a.quality_score, a.speed_score, a.initiative_score, a.teamwork_score, a.communication_score,
 		       a.strengths, a.improvements, a.comments, a.notes, a.assessment_date, a.created_at, a.updated_at,
 		       iu.name, iu.avatar, 
@@ -150,11 +156,13 @@ func (h *AssessmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var assessments []map[string]interface{}
+	var ids []int64
 	for rows.Next() {
 		var a models.Assessment
 		var internName, internAvatar, assessorName, assessorAvatar, assessorRole, taskTitle sql.NullString
 		if err := rows.Scan(
-			&a.ID, &a.InternID, &a.TaskID, &a.AssessedBy, &a.Score, &a.Category, &a.Aspect,
+			&a.ID, &a.InternID, &a.TaskID, &a.RubricVersionID, &a.AssessedBy, &a.Score, &a.RubricScore,
+			&a.Category, &a.CategoryLabel, &a.Aspect,
 			&a.QualityScore, &a.SpeedScore, &a.InitiativeScore, &a.TeamworkScore, &a.CommunicationScore,
 			&a.Strengths, &a.Improvements, &a.Comments, &a.Notes, &a.AssessmentDate, &a.CreatedAt, &a.UpdatedAt,
 			&internName, &internAvatar, &assessorName, &assessorAvatar, &assessorRole, &taskTitle,
@@ -184,6 +192,13 @@ func (h *AssessmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 				assessmentMap["assessor_role"] = assessorRole.String
 			}
 			assessments = append(assessments, assessmentMap)
+			ids = append(ids, a.ID)
+		}
+	}
+
+	if scores, err := services.LoadAssessmentScores(h.db, ids); err == nil {
+		for i, id := range ids {
+			assessments[i]["scores"] = scoresOrEmpty(scores[id])
 		}
 	}
 
@@ -201,7 +216,8 @@ func (h *AssessmentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	id, _ := strconv.ParseInt(vars["id"], 10, 64)
 
 	query := `
-		SELECT a.id, a.intern_id, a.task_id, a.assessed_by, a.score, a.category, a.aspect,
+		SELECT a.id, a.intern_id, a.task_id, a.rubric_version_id, a.assessed_by, a.score, a.rubric_score,
+		       COALESCE(a.category, ''), a.category_label, a.aspect,
 		       a.quality_score, a.speed_score, a.initiative_score, a.teamwork_score, a.communication_score,
 		       a.strengths, a.improvements, a.comments, a.notes, a.assessment_date, a.created_at, a.updated_at,
 		       iu.name, iu.avatar, 
@@ -221,7 +237,8 @@ func (h *AssessmentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	var a models.Assessment
 	var internName, internAvatar, assessorName, assessorAvatar, assessorRole, taskTitle sql.NullString
 	err := h.db.QueryRow(query, id).Scan(
-		&a.ID, &a.InternID, &a.TaskID, &a.AssessedBy, &a.Score, &a.Category, &a.Aspect,
+		&a.ID, &a.InternID, &a.TaskID, &a.RubricVersionID, &a.AssessedBy, &a.Score, &a.RubricScore,
+		&a.Category, &a.CategoryLabel, &a.Aspect,
 		&a.QualityScore, &a.SpeedScore, &a.InitiativeScore, &a.TeamworkScore, &a.CommunicationScore,
 		&a.Strengths, &a.Improvements, &a.Comments, &a.Notes, &a.AssessmentDate, &a.CreatedAt, &a.UpdatedAt,
 		&internName, &internAvatar, &assessorName, &assessorAvatar, &assessorRole, &taskTitle,
@@ -263,6 +280,14 @@ func (h *AssessmentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	if assessorRole.Valid {
 		resp["assessor_role"] = assessorRole.String
 	}
+	if scores, err := services.LoadAssessmentScores(h.db, []int64{a.ID}); err == nil {
+		resp["scores"] = scoresOrEmpty(scores[a.ID])
+	}
+	if a.RubricVersionID.Valid {
+		if rubric, err := services.LoadRubricVersion(h.db, a.RubricVersionID.Int64); err == nil {
+			resp["rubric"] = rubric
+		}
+	}
 	utils.RespondSuccess(w, "Assessment retrieved", resp)
 }
 
@@ -335,25 +360,39 @@ func (h *AssessmentHandler) Create(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	// Basic score validation to avoid DB constraint errors
-	scores := []struct {
-		name string
-		val  int
-	}{
-		{"quality_score", req.QualityScore},
-		{"speed_score", req.SpeedScore},
-		{"initiative_score", req.InitiativeScore},
-		{"teamwork_score", req.TeamworkScore},
-		{"communication_score", req.CommunicationScore},
-	}
-	for _, s := range scores {
-		if s.val < 0 || s.val > 100 {
-			utils.RespondBadRequest(w, s.name+" must be between 0 and 100")
-			return
+	var rubric *models.RubricVersion
+	var err error
+	if req.RubricID != nil && *req.RubricID > 0 {
+		rubric, err = services.LoadCurrentRubricVersion(h.db, *req.RubricID)
+	} else {
+		rubric, err = services.ResolveRubricVersion(h.db, req.InternID)
+	}
+	if errors.Is(err, services.ErrRubricNotFound) {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load rubric")
+		return
+	}
+
+	criterionScores := req.Scores
+	if len(criterionScores) == 0 {
+		criterionScores = map[string]float64{
+			"quality":       float64(req.QualityScore),
+			"speed":         float64(req.SpeedScore),
+			"initiative":    float64(req.InitiativeScore),
+			"teamwork":      float64(req.TeamworkScore),
+			"communication": float64(req.CommunicationScore),
 		}
 	}
+	result, err := services.ScoreRubric(rubric, criterionScores)
+	if err != nil {
+		utils.RespondBadRequest(w, scoreErrorMessage(err))
+		return
+	}
+	legacy := services.LegacyAssessmentScores(rubric, result.Scores)
 
-	score := (req.QualityScore + req.SpeedScore + req.InitiativeScore + req.TeamworkScore + req.CommunicationScore) / 5
 	aspect := req.Aspect
 	if strings.TrimSpace(aspect) == "" {
 		aspect = "overall"
@@ -390,15 +429,22 @@ func (h *AssessmentHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var lastErr error
 	for _, assessorID := range assessorCandidates {
 		res, err := h.db.Exec(
-			`INSERT INTO assessments (intern_id, task_id, assessed_by, score, aspect, quality_score, speed_score, initiative_score,
-			                          teamwork_score, communication_score, strengths, improvements, comments, notes, assessment_date)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-			req.InternID, taskID, assessorID, score, aspect,
-			req.QualityScore, req.SpeedScore, req.InitiativeScore, req.TeamworkScore, req.CommunicationScore,
+			`INSERT INTO assessments (intern_id, task_id, rubric_version_id, assessed_by, score, rubric_score, category, category_label,
+			                          aspect, quality_score, speed_score, initiative_score, teamwork_score, communication_score,
+			                          strengths, improvements, comments, notes, assessment_date)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			req.InternID, taskID, rubric.ID, assessorID, result.Percent, result.RubricScore, result.Category.Category, result.Category.Label,
+			aspect, legacy["quality"], legacy["speed"], legacy["initiative"], legacy["teamwork"], legacy["communication"],
 			nullIfEmpty(req.Strengths), nullIfEmpty(req.Improvements), nullIfEmpty(req.Comments), nullIfEmpty(req.Notes), assessmentDate,
 		)
 		if err == nil {
 			id, _ := res.LastInsertId()
+			if err := services.SaveAssessmentScores(h.db, id, result.Scores); err != nil {
+				log.Printf("save assessment scores failed: %v", err)
+				_, _ = h.db.Exec("DELETE FROM assessments WHERE id = ?", id)
+				utils.RespondInternalError(w, "Failed to save assessment scores")
+				return
+			}
 			// Notify Intern
 			var internUserID int64
 			_ = h.db.QueryRow("SELECT user_id FROM interns WHERE id = ?", req.InternID).Scan(&internUserID)
@@ -449,18 +495,9 @@ func (h *AssessmentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Load existing scores
-	var existing struct {
-		Quality       sql.NullInt64
-		Speed         sql.NullInt64
-		Initiative    sql.NullInt64
-		Teamwork      sql.NullInt64
-		Communication sql.NullInt64
-	}
-	err := h.db.QueryRow(
-		`SELECT quality_score, speed_score, initiative_score, teamwork_score, communication_score
-		 FROM assessments WHERE id = ?`, id,
-	).Scan(&existing.Quality, &existing.Speed, &existing.Initiative, &existing.Teamwork, &existing.Communication)
+	var internID int64
+	var rubricVersionID sql.NullInt64
+	err := h.db.QueryRow("SELECT intern_id, rubric_version_id FROM assessments WHERE id = ?", id).Scan(&internID, &rubricVersionID)
 	if err == sql.ErrNoRows {
 		utils.RespondNotFound(w, "Assessment not found")
 		return
@@ -470,39 +507,67 @@ func (h *AssessmentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	quality := int64OrZero(existing.Quality)
-	speed := int64OrZero(existing.Speed)
-	initiative := int64OrZero(existing.Initiative)
-	teamwork := int64OrZero(existing.Teamwork)
-	communication := int64OrZero(existing.Communication)
-
 	updates := []string{}
 	args := []interface{}{}
 
-	if req.QualityScore != nil {
-		quality = int64(*req.QualityScore)
-		updates = append(updates, "quality_score = ?")
-		args = append(args, *req.QualityScore)
-	}
-	if req.SpeedScore != nil {
-		speed = int64(*req.SpeedScore)
-		updates = append(updates, "speed_score = ?")
-		args = append(args, *req.SpeedScore)
-	}
-	if req.InitiativeScore != nil {
-		initiative = int64(*req.InitiativeScore)
-		updates = append(updates, "initiative_score = ?")
-		args = append(args, *req.InitiativeScore)
-	}
-	if req.TeamworkScore != nil {
-		teamwork = int64(*req.TeamworkScore)
-		updates = append(updates, "teamwork_score = ?")
-		args = append(args, *req.TeamworkScore)
+	changed := map[string]float64{}
+	for code, v := range req.Scores {
+		changed[code] = v
+	}
+	for code, v := range map[string]*int{
+		"quality":       req.QualityScore,
+		"speed":         req.SpeedScore,
+		"initiative":    req.InitiativeScore,
+		"teamwork":      req.TeamworkScore,
+		"communication": req.CommunicationScore,
+	} {
+		if _, ok := changed[code]; !ok && v != nil {
+			changed[code] = float64(*v)
+		}
 	}
-	if req.CommunicationScore != nil {
-		communication = int64(*req.CommunicationScore)
-		updates = append(updates, "communication_score = ?")
-		args = append(args, *req.CommunicationScore)
+
+	var result services.RubricResult
+	if len(changed) > 0 {
+		// Rescore against the rubric version the assessment was made with
+		var rubric *models.RubricVersion
+		if rubricVersionID.Valid {
+			rubric, err = services.LoadRubricVersion(h.db, rubricVersionID.Int64)
+		} else {
+			rubric, err = services.ResolveRubricVersion(h.db, internID)
+		}
+		if errors.Is(err, services.ErrRubricNotFound) {
+			utils.RespondBadRequest(w, err.Error())
+			return
+		}
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to load rubric")
+			return
+		}
+
+		current := map[string]float64{}
+		if existing, err := services.LoadAssessmentScores(h.db, []int64{id}); err == nil {
+			for _, sc := range existing[id] {
+				current[sc.Code] = sc.Score
+			}
+		}
+		for code, v := range changed {
+			current[code] = v
+		}
+
+		result, err = services.ScoreRubric(rubric, current)
+		if err != nil {
+			utils.RespondBadRequest(w, scoreErrorMessage(err))
+			return
+		}
+		legacy := services.LegacyAssessmentScores(rubric, result.Scores)
+		updates = append(updates,
+			"rubric_version_id = ?", "score = ?", "rubric_score = ?", "category = ?", "category_label = ?",
+			"quality_score = ?", "speed_score = ?", "initiative_score = ?", "teamwork_score = ?", "communication_score = ?",
+		)
+		args = append(args,
+			rubric.ID, result.Percent, result.RubricScore, result.Category.Category, result.Category.Label,
+			legacy["quality"], legacy["speed"], legacy["initiative"], legacy["teamwork"], legacy["communication"],
+		)
 	}
 
 	if req.Strengths != nil {
@@ -532,18 +597,30 @@ func (h *AssessmentHandler) Update(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// recompute score if any criteria updated
-	score := int((quality + speed + initiative + teamwork + communication) / 5)
-	updates = append(updates, "score = ?")
-	args = append(args, score)
-
 	if len(updates) == 0 {
 		utils.RespondBadRequest(w, "No updates provided")
 		return
 	}
 
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	defer tx.Rollback()
+
 	args = append(args, id)
-	if _, err := h.db.Exec("UPDATE assessments SET "+strings.Join(updates, ", ")+" WHERE id = ?", args...); err != nil {
+	if _, err := tx.Exec("UPDATE assessments SET "+strings.Join(updates, ", ")+" WHERE id = ?", args...); err != nil {
+		utils.RespondInternalError(w, "Failed to update assessment")
+		return
+	}
+	if len(changed) > 0 {
+		if err := services.SaveAssessmentScores(tx, id, result.Scores); err != nil {
+			utils.RespondInternalError(w, "Failed to update assessment scores")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
 		utils.RespondInternalError(w, "Failed to update assessment")
 		return
 	}
@@ -571,7 +648,10 @@ func presentAssessment(a models.Assessment) map[string]interface{} {
 		"task_id":             nullIntToPtr(a.TaskID),
 		"assessed_by":         a.AssessedBy,
 		"score":               a.Score,
+		"rubric_version_id":   nullIntToPtr(a.RubricVersionID),
+		"rubric_score":        sqlNullFloatToPointer(a.RubricScore),
 		"category":            a.Category,
+		"category_label":      a.GetCategoryIndo(),
 		"aspect":              a.Aspect,
 		"quality_score":       nullIntToPtr(a.QualityScore),
 		"speed_score":         nullIntToPtr(a.SpeedScore),
@@ -593,6 +673,17 @@ func presentAssessment(a models.Assessment) map[string]interface{} {
 	}
 }
 
+func scoreErrorMessage(err error) string {
+	return strings.TrimPrefix(err.Error(), services.ErrInvalidScores.Error()+": ")
+}
+
+func scoresOrEmpty(scores []models.AssessmentScore) []models.AssessmentScore {
+	if scores == nil {
+		return []models.AssessmentScore{}
+	}
+	return scores
+}
+
 func nullIntToPtr(v sql.NullInt64) *int {
 	if !v.Valid {
 		return nil
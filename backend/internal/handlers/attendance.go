@@ -6,13 +6,12 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"dsi_interna_sys/internal/config"
-	"dsi_interna_sys/internal/holiday"
 	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/gorilla/mux"
@@ -22,59 +21,6 @@ type AttendanceHandler struct {
 	db *sql.DB
 }
 
-// local wrapper to keep existing calls
-
-var holidayCache = struct {
-	mu   sync.RWMutex
-	data map[int]map[string]bool
-}{
-	data: map[int]map[string]bool{},
-}
-
-type workCalendarConfig struct {
-	workdays      map[time.Weekday]bool
-	manualOffDate *time.Time
-}
-
-func defaultWorkdays() map[time.Weekday]bool {
-	return map[time.Weekday]bool{
-		time.Monday:    true,
-		time.Tuesday:   true,
-		time.Wednesday: true,
-		time.Thursday:  true,
-		time.Friday:    true,
-		time.Saturday:  true,
-	}
-}
-
-func parseWorkdays(val string) map[time.Weekday]bool {
-	parsed := map[time.Weekday]bool{}
-	if strings.TrimSpace(val) == "" {
-		return parsed
-	}
-
-	for _, part := range strings.Split(val, ",") {
-		p := strings.TrimSpace(strings.ToLower(part))
-		switch p {
-		case "0", "7", "sun", "sunday", "minggu":
-			parsed[time.Sunday] = true
-		case "1", "mon", "monday", "senin":
-			parsed[time.Monday] = true
-		case "2", "tue", "tuesday", "selasa":
-			parsed[time.Tuesday] = true
-		case "3", "wed", "wednesday", "rabu":
-			parsed[time.Wednesday] = true
-		case "4", "thu", "thursday", "kamis":
-			parsed[time.Thursday] = true
-		case "5", "fri", "friday", "jumat", "jum'at", "jum\u2019at":
-			parsed[time.Friday] = true
-		case "6", "sat", "saturday", "sabtu":
-			parsed[time.Saturday] = true
-		}
-	}
-	return parsed
-}
-
 func NewAttendanceHandler(db *sql.DB) *AttendanceHandler {
 	return &AttendanceHandler{db: db}
 }
@@ -169,79 +115,6 @@ func (h *AttendanceHandler) getRuntimeOfficeConfig() config.OfficeConfig {
 	return cfg
 }
 
-func (h *AttendanceHandler) getWorkCalendarConfig() workCalendarConfig {
-	cfg := workCalendarConfig{
-		workdays: defaultWorkdays(),
-	}
-
-	rows, err := h.db.Query("SELECT `key`, `value` FROM settings WHERE `key` IN ('workdays', 'manual_off_date')")
-	if err != nil {
-		return cfg
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var k, v string
-		if err := rows.Scan(&k, &v); err != nil {
-			continue
-		}
-		switch k {
-		case "workdays":
-			if parsed := parseWorkdays(v); len(parsed) > 0 {
-				cfg.workdays = parsed
-			}
-		case "manual_off_date":
-			if strings.TrimSpace(v) != "" {
-				if t, e := time.Parse("2006-01-02", v); e == nil {
-					cfg.manualOffDate = &t
-				}
-			}
-		}
-	}
-
-	return cfg
-}
-
-func isHolidayDate(t time.Time) bool {
-	year := t.Year()
-
-	holidayCache.mu.RLock()
-	if m, ok := holidayCache.data[year]; ok {
-		defer holidayCache.mu.RUnlock()
-		_, exists := m[t.Format("2006-01-02")]
-		return exists
-	}
-	holidayCache.mu.RUnlock()
-
-	hols, _ := holiday.GetHolidays(year)
-	m := make(map[string]bool, len(hols))
-	for _, h := range hols {
-		m[h.Date] = true
-	}
-	holidayCache.mu.Lock()
-	holidayCache.data[year] = m
-	holidayCache.mu.Unlock()
-	return m[t.Format("2006-01-02")]
-}
-
-func isOffDay(t time.Time, cal workCalendarConfig) bool {
-	dateStr := t.Format("2006-01-02")
-
-	if cal.manualOffDate != nil && dateStr == cal.manualOffDate.Format("2006-01-02") {
-		return true
-	}
-
-	if cal.workdays != nil {
-		if !cal.workdays[t.Weekday()] {
-			return true
-		}
-	} else if t.Weekday() == time.Sunday {
-		return true
-	}
-
-	return isHolidayDate(t)
-}
-
 // Helpers to convert SQL Nulls to JSON Pointers
 func sqlNullTimeToPointer(t sql.NullTime) *time.Time {
 	if t.Valid {
@@ -318,9 +191,9 @@ func (h *AttendanceHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	workCal := h.getWorkCalendarConfig()
+	workCal := services.LoadWorkCalendar(h.db)
 	now := time.Now()
-	if isOffDay(now, workCal) {
+	if workCal.IsOffDay(now) {
 		utils.RespondBadRequest(w, "Office closed today")
 		return
 	}
@@ -383,6 +256,7 @@ func (h *AttendanceHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	attendanceID, _ := result.LastInsertId()
+	services.QueueRollupRefresh(h.db, internID, now)
 
 	if status == "late" {
 		// Notify Supervisor
@@ -477,12 +351,12 @@ func (h *AttendanceHandler) GetToday(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	workCal := h.getWorkCalendarConfig()
+	workCal := services.LoadWorkCalendar(h.db)
 	now := time.Now()
 	today := now.Format("2006-01-02")
 
 	// If today is an off-day (Sunday/holiday), short-circuit with friendly message
-	if isOffDay(now, workCal) {
+	if workCal.IsOffDay(now) {
 		utils.RespondSuccess(w, "Office closed today", map[string]interface{}{
 			"checked_in": false,
 			"off_day":    true,
@@ -527,17 +401,19 @@ func (h *AttendanceHandler) GetToday(w http.ResponseWriter, r *http.Request) {
 
 		// If yesterday has no record, log it as absent (a full day passed) unless off-day
 		yesterday := now.AddDate(0, 0, -1)
-		if !isOffDay(yesterday, workCal) {
+		if !workCal.IsOffDay(yesterday) {
 			yesterdayStr := yesterday.Format("2006-01-02")
 			var yID int64
 			if yErr := h.db.QueryRow("SELECT id FROM attendances WHERE intern_id = ? AND date = ?", internID, yesterdayStr).Scan(&yID); yErr == sql.ErrNoRows {
-				_, _ = h.db.Exec(`INSERT INTO attendances (intern_id, date, status, created_at) VALUES (?, ?, 'absent', NOW())`,
-					internID, yesterdayStr)
+				if _, err := h.db.Exec(`INSERT INTO attendances (intern_id, date, status, created_at) VALUES (?, ?, 'absent', NOW())`,
+					internID, yesterdayStr); err == nil {
+					services.QueueRollupRefresh(h.db, internID, yesterday)
+				}
 			}
 		}
 
 		// If today is weekend/holiday, show off-day message
-		if isOffDay(now, workCal) {
+		if workCal.IsOffDay(now) {
 			utils.RespondSuccess(w, "Office closed today", map[string]interface{}{
 				"checked_in": false,
 				"off_day":    true,
@@ -627,8 +503,8 @@ func (h *AttendanceHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		where = append(where, "a.intern_id = ?")
 		args = append(args, internID)
 	} else if role == "pembimbing" {
-		where = append(where, "i.supervisor_id = ?")
-		args = append(args, claims.UserID)
+		where = append(where, services.SupervisedInternCond)
+		args = append(args, services.SupervisedInternArgs(claims.UserID)...)
 	} else if internFilter != "" {
 		if id, err := strconv.ParseInt(internFilter, 10, 64); err == nil {
 			where = append(where, "a.intern_id = ?")
@@ -952,6 +828,7 @@ func (h *AttendanceHandler) SubmitPermission(w http.ResponseWriter, r *http.Requ
 		utils.RespondInternalError(w, "Failed to create permission record")
 		return
 	}
+	services.QueueRollupRefresh(h.db, internID, time.Now())
 
 	utils.RespondCreated(w, "Permission submitted", nil)
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"image/png"
 	"net/http"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"dsi_interna_sys/internal/config"
 	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -107,8 +109,12 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate role
-	if role != "admin" && role != "pembimbing" && role != "intern" {
-		utils.RespondBadRequest(w, "Invalid role. Must be admin, pembimbing, or intern")
+	if role != "admin" && role != "pembimbing" && role != "intern" && role != models.RoleCampusSupervisor {
+		utils.RespondBadRequest(w, "Invalid role. Must be admin, pembimbing, intern, or campus_supervisor")
+		return
+	}
+	if role == models.RoleCampusSupervisor && req.InstitutionID == 0 {
+		utils.RespondBadRequest(w, "Institution is required for campus supervisors")
 		return
 	}
 
@@ -167,12 +173,22 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		if req.EndDate != "" {
 			endDate, _ = time.Parse("2006-01-02", req.EndDate)
 		}
+		if err := services.CheckInstitutionQuota(tx, req.InstitutionID); err != nil {
+			if errors.Is(err, services.ErrInvalidInstitution) {
+				utils.RespondBadRequest(w, err.Error())
+				return
+			}
+			utils.RespondInternalError(w, "Failed to check institution quota")
+			return
+		}
 
 		_, err = tx.Exec(
 			`INSERT INTO interns (user_id, institution_id, supervisor_id, full_name, student_id, start_date, end_date, status) 
 			 VALUES (?, ?, ?, ?, ?, ?, ?, 'active')`,
 			userID, req.InstitutionID, req.SupervisorID, req.FullName, req.StudentID, startDate, endDate,
 		)
+	case models.RoleCampusSupervisor:
+		_, err = tx.Exec("UPDATE users SET institution_id = ? WHERE id = ?", req.InstitutionID, userID)
 	}
 
 	if err != nil {
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// CampusHandler serves campus supervisors: read access to the interns of
+// their institution, plus academic assessments. Admins see every
+// institution, or one with ?institution_id=.
+type CampusHandler struct {
+	db *sql.DB
+}
+
+func NewCampusHandler(db *sql.DB) *CampusHandler {
+	return &CampusHandler{db: db}
+}
+
+// scope returns the institution the caller is limited to, 0 for all
+func (h *CampusHandler) scope(w http.ResponseWriter, r *http.Request) (*middleware.Claims, int64, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return nil, 0, false
+	}
+	if normalizeRole(claims.Role) == "admin" {
+		institutionID, _ := strconv.ParseInt(r.URL.Query().Get("institution_id"), 10, 64)
+		return claims, institutionID, true
+	}
+	institutionID, ok := services.CampusInstitution(h.db, claims.UserID)
+	if !ok {
+		utils.RespondForbidden(w, "Your account is not linked to an institution")
+		return nil, 0, false
+	}
+	return claims, institutionID, true
+}
+
+// loadIntern resolves the {id} intern within the caller's institution
+func (h *CampusHandler) loadIntern(w http.ResponseWriter, r *http.Request) (models.CampusIntern, *middleware.Claims, bool) {
+	var intern models.CampusIntern
+	claims, institutionID, ok := h.scope(w, r)
+	if !ok {
+		return intern, nil, false
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid intern ID")
+		return intern, nil, false
+	}
+	list, err := h.queryInterns("i.id = ?", id)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return intern, nil, false
+	}
+	// Interns of other institutions are reported as missing, not forbidden
+	if len(list) == 0 || (institutionID > 0 && list[0].InstitutionID != institutionID) {
+		utils.RespondNotFound(w, "Intern not found")
+		return intern, nil, false
+	}
+	return list[0], claims, true
+}
+
+func (h *CampusHandler) queryInterns(where string, args ...interface{}) ([]models.CampusIntern, error) {
+	rows, err := h.db.Query(
+		`SELECT i.id, i.full_name, u.email, i.student_id, i.department, i.institution_id, i.start_date, i.end_date,
+		        i.status, su.name
+		 FROM interns i
+		 JOIN users u ON i.user_id = u.id
+		 LEFT JOIN users su ON i.supervisor_id = su.id
+		 WHERE `+where+`
+		 ORDER BY i.full_name`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.CampusIntern{}
+	for rows.Next() {
+		var in models.CampusIntern
+		var studentID, department, supervisorName sql.NullString
+		if err := rows.Scan(&in.ID, &in.FullName, &in.Email, &studentID, &department, &in.InstitutionID,
+			&in.StartDate, &in.EndDate, &in.Status, &supervisorName); err != nil {
+			return nil, err
+		}
+		in.StudentID = ptrStringFromNull(studentID)
+		in.Department = ptrStringFromNull(department)
+		in.SupervisorName = ptrStringFromNull(supervisorName)
+		list = append(list, in)
+	}
+	return list, rows.Err()
+}
+
+// GetInterns lists the institution's interns, optionally by ?status=
+func (h *CampusHandler) GetInterns(w http.ResponseWriter, r *http.Request) {
+	_, institutionID, ok := h.scope(w, r)
+	if !ok {
+		return
+	}
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if institutionID > 0 {
+		where = append(where, "i.institution_id = ?")
+		args = append(args, institutionID)
+	}
+	if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
+		where = append(where, "i.status = ?")
+		args = append(args, status)
+	}
+	list, err := h.queryInterns(strings.Join(where, " AND "), args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch interns")
+		return
+	}
+	utils.RespondSuccess(w, "Interns retrieved", list)
+}
+
+// GetIntern returns one intern with their supervisors
+func (h *CampusHandler) GetIntern(w http.ResponseWriter, r *http.Request) {
+	intern, _, ok := h.loadIntern(w, r)
+	if !ok {
+		return
+	}
+	intern.Supervisors, _ = services.LoadInternSupervisors(h.db, intern.ID)
+	utils.RespondSuccess(w, "Intern retrieved", intern)
+}
+
+// GetAttendance returns an intern's attendance over ?from=&to= (default
+// the whole internship) with a count per status
+func (h *CampusHandler) GetAttendance(w http.ResponseWriter, r *http.Request) {
+	intern, _, ok := h.loadIntern(w, r)
+	if !ok {
+		return
+	}
+	from := intern.StartDate.Format("2006-01-02")
+	to := intern.EndDate.Format("2006-01-02")
+	if v := r.URL.Query().Get("from"); v != "" {
+		from = v
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to = v
+	}
+
+	rows, err := h.db.Query(
+		`SELECT id, intern_id, date, check_in_time, check_out_time, status, late_reason, notes, created_at, updated_at
+		 FROM attendances
+		 WHERE intern_id = ? AND date BETWEEN ? AND ?
+		 ORDER BY date DESC`,
+		intern.ID, from, to,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch attendance")
+		return
+	}
+	defer rows.Close()
+	records := []models.Attendance{}
+	summary := map[string]int{}
+	for rows.Next() {
+		var a models.Attendance
+		var checkIn, checkOut sql.NullTime
+		var lateReason, notes sql.NullString
+		if err := rows.Scan(&a.ID, &a.InternID, &a.Date, &checkIn, &checkOut, &a.Status, &lateReason, &notes,
+			&a.CreatedAt, &a.UpdatedAt); err != nil {
+			continue
+		}
+		a.CheckInTime = ptrTimeFromNull(checkIn)
+		a.CheckOutTime = ptrTimeFromNull(checkOut)
+		a.LateReason = ptrStringFromNull(lateReason)
+		a.Notes = ptrStringFromNull(notes)
+		a.InternName = intern.FullName
+		records = append(records, a)
+		summary[a.Status]++
+	}
+	utils.RespondSuccess(w, "Attendance retrieved", map[string]interface{}{
+		"intern":  intern,
+		"from":    from,
+		"to":      to,
+		"summary": summary,
+		"records": records,
+	})
+}
+
+// GetReports lists submitted reports of the institution's interns,
+// optionally by ?intern_id=. Drafts stay private to their authors.
+func (h *CampusHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	_, institutionID, ok := h.scope(w, r)
+	if !ok {
+		return
+	}
+	where := []string{"r.status != 'draft'"}
+	args := []interface{}{}
+	if institutionID > 0 {
+		where = append(where, "i.institution_id = ?")
+		args = append(args, institutionID)
+	}
+	if internID, err := strconv.ParseInt(r.URL.Query().Get("intern_id"), 10, 64); err == nil {
+		where = append(where, "r.intern_id = ?")
+		args = append(args, internID)
+	}
+
+	rows, err := h.db.Query(
+		`SELECT r.id, r.intern_id, r.created_by, r.title, r.content, r.type, r.period_start, r.period_end,
+		        r.status, r.feedback, r.created_at, r.updated_at, i.full_name, cu.name
+		 FROM reports r
+		 JOIN interns i ON r.intern_id = i.id
+		 LEFT JOIN users cu ON r.created_by = cu.id
+		 WHERE `+strings.Join(where, " AND ")+`
+		 ORDER BY r.period_end DESC, r.id DESC`, args...,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch reports")
+		return
+	}
+	defer rows.Close()
+	reports := []models.Report{}
+	for rows.Next() {
+		var rep models.Report
+		var feedback, createdByName sql.NullString
+		if err := rows.Scan(&rep.ID, &rep.InternID, &rep.CreatedBy, &rep.Title, &rep.Content, &rep.Type,
+			&rep.PeriodStart, &rep.PeriodEnd, &rep.Status, &feedback, &rep.CreatedAt, &rep.UpdatedAt,
+			&rep.InternName, &createdByName); err != nil {
+			continue
+		}
+		rep.Feedback = feedback.String
+		rep.CreatedByName = createdByName.String
+		reports = append(reports, rep)
+	}
+	utils.RespondSuccess(w, "Reports retrieved", reports)
+}
+
+// GetCertificates lists the certificates issued to the institution's
+// interns
+func (h *CampusHandler) GetCertificates(w http.ResponseWriter, r *http.Request) {
+	_, institutionID, ok := h.scope(w, r)
+	if !ok {
+		return
+	}
+	where := "c.status != ?"
+	args := []interface{}{models.CertificateDraft}
+	if institutionID > 0 {
+		where += " AND i.institution_id = ?"
+		args = append(args, institutionID)
+	}
+	certs, err := loadCertificates(h.db, where, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch certificates")
+		return
+	}
+	utils.RespondSuccess(w, "Certificates retrieved", certs)
+}
+
+// GetAssessments lists an intern's company and academic assessments
+func (h *CampusHandler) GetAssessments(w http.ResponseWriter, r *http.Request) {
+	intern, _, ok := h.loadIntern(w, r)
+	if !ok {
+		return
+	}
+	rows, err := h.db.Query(
+		`SELECT a.id, a.source, COALESCE(u.name, ''), a.score, a.category, a.category_label,
+		        a.strengths, a.improvements, a.comments, a.assessment_date
+		 FROM assessments a
+		 LEFT JOIN users u ON a.assessed_by = u.id
+		 WHERE a.intern_id = ?
+		 ORDER BY a.assessment_date DESC, a.id DESC`,
+		intern.ID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch assessments")
+		return
+	}
+	defer rows.Close()
+	list := []models.CampusAssessment{}
+	for rows.Next() {
+		var a models.CampusAssessment
+		var label, strengths, improvements, comments sql.NullString
+		if err := rows.Scan(&a.ID, &a.Source, &a.AssessorName, &a.Score, &a.Category, &label,
+			&strengths, &improvements, &comments, &a.AssessmentDate); err != nil {
+			continue
+		}
+		a.CategoryLabel = ptrStringFromNull(label)
+		a.Strengths = ptrStringFromNull(strengths)
+		a.Improvements = ptrStringFromNull(improvements)
+		a.Comments = ptrStringFromNull(comments)
+		list = append(list, a)
+	}
+	utils.RespondSuccess(w, "Assessments retrieved", list)
+}
+
+// CreateAssessment records an academic assessment against the intern's
+// rubric. Academic assessments are kept apart from the company grade.
+func (h *CampusHandler) CreateAssessment(w http.ResponseWriter, r *http.Request) {
+	intern, claims, ok := h.loadIntern(w, r)
+	if !ok {
+		return
+	}
+	if normalizeRole(claims.Role) != models.RoleCampusSupervisor {
+		utils.RespondForbidden(w, "Only campus supervisors can submit academic assessments")
+		return
+	}
+	var req models.CreateAssessmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	var rubric *models.RubricVersion
+	var err error
+	if req.RubricID != nil && *req.RubricID > 0 {
+		rubric, err = services.LoadCurrentRubricVersion(h.db, *req.RubricID)
+	} else {
+		rubric, err = services.ResolveRubricVersion(h.db, intern.ID)
+	}
+	if errors.Is(err, services.ErrRubricNotFound) {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load rubric")
+		return
+	}
+	criterionScores := req.Scores
+	if len(criterionScores) == 0 {
+		criterionScores = map[string]float64{
+			"quality":       float64(req.QualityScore),
+			"speed":         float64(req.SpeedScore),
+			"initiative":    float64(req.InitiativeScore),
+			"teamwork":      float64(req.TeamworkScore),
+			"communication": float64(req.CommunicationScore),
+		}
+	}
+	result, err := services.ScoreRubric(rubric, criterionScores)
+	if err != nil {
+		utils.RespondBadRequest(w, scoreErrorMessage(err))
+		return
+	}
+	legacy := services.LegacyAssessmentScores(rubric, result.Scores)
+
+	assessmentDate := time.Now()
+	if strings.TrimSpace(req.AssessmentDate) != "" {
+		parsed, err := time.Parse("2006-01-02", req.AssessmentDate)
+		if err != nil {
+			utils.RespondBadRequest(w, "assessment_date must be in YYYY-MM-DD format")
+			return
+		}
+		assessmentDate = parsed
+	}
+
+	res, err := h.db.Exec(
+		`INSERT INTO assessments (intern_id, rubric_version_id, assessed_by, source, score, rubric_score, category, category_label,
+		                          aspect, quality_score, speed_score, initiative_score, teamwork_score, communication_score,
+		                          strengths, improvements, comments, notes, assessment_date)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'overall', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		intern.ID, rubric.ID, claims.UserID, models.AssessmentSourceAcademic, result.Percent, result.RubricScore,
+		result.Category.Category, result.Category.Label,
+		legacy["quality"], legacy["speed"], legacy["initiative"], legacy["teamwork"], legacy["communication"],
+		nullIfEmpty(req.Strengths), nullIfEmpty(req.Improvements), nullIfEmpty(req.Comments), nullIfEmpty(req.Notes), assessmentDate,
+	)
+	if err != nil {
+		log.Printf("create academic assessment failed: %v", err)
+		utils.RespondInternalError(w, "Failed to create assessment")
+		return
+	}
+	id, _ := res.LastInsertId()
+	if err := services.SaveAssessmentScores(h.db, id, result.Scores); err != nil {
+		log.Printf("save assessment scores failed: %v", err)
+		_, _ = h.db.Exec("DELETE FROM assessments WHERE id = ?", id)
+		utils.RespondInternalError(w, "Failed to save assessment scores")
+		return
+	}
+
+	var internUserID int64
+	var supervisorID sql.NullInt64
+	_ = h.db.QueryRow("SELECT user_id, supervisor_id FROM interns WHERE id = ?", intern.ID).Scan(&internUserID, &supervisorID)
+	link := "/assessments/" + strconv.FormatInt(id, 10)
+	data := map[string]interface{}{"assessment_id": id}
+	_ = createNotification(h.db, internUserID, models.NotificationAssessmentCreated, "Penilaian Akademik Baru",
+		"Anda telah menerima penilaian dari pembimbing kampus.", link, data)
+	if supervisorID.Valid {
+		_ = createNotification(h.db, supervisorID.Int64, models.NotificationAssessmentCreated, "Penilaian Akademik Baru",
+			"Pembimbing kampus telah menilai "+intern.FullName+".", link, data)
+	}
+
+	utils.RespondCreated(w, "Assessment created", map[string]int64{"id": id})
+}
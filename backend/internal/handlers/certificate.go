@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type CertificateHandler struct {
+	db *sql.DB
+}
+
+func NewCertificateHandler(db *sql.DB) *CertificateHandler {
+	return &CertificateHandler{db: db}
+}
+
+// Verify is the public endpoint behind the certificate QR code. It only
+// discloses what is printed on the certificate itself.
+func (h *CertificateHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	number := strings.TrimSpace(mux.Vars(r)["number"])
+	if number == "" {
+		utils.RespondBadRequest(w, "Certificate number is required")
+		return
+	}
+
+	result, err := services.VerifyCertificate(h.db, number, r.URL.Query().Get("sig"))
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Certificate not found")
+		return
+	}
+	if errors.Is(err, services.ErrSigningKeyMissing) {
+		utils.RespondError(w, http.StatusServiceUnavailable, "Certificate verification is not available")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to verify certificate")
+		return
+	}
+
+	utils.RespondSuccess(w, "Certificate verification result", result)
+}
+
+// GetInternCertificates lists every certificate of an intern, including
+// revoked and superseded ones, with their lifecycle events
+func (h *CertificateHandler) GetInternCertificates(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	internID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	certs, err := loadCertificates(h.db, "c.intern_id = ?", internID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch certificates")
+		return
+	}
+	for i := range certs {
+		certs[i].Events, _ = loadCertificateEvents(h.db, certs[i].ID)
+	}
+
+	utils.RespondSuccess(w, "Certificates retrieved", certs)
+}
+
+// GetEligibility reports whether an intern passes the issuance rules
+func (h *CertificateHandler) GetEligibility(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	internID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	eligibility, err := services.CheckCertificateEligibility(h.db, internID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to check eligibility")
+		return
+	}
+
+	utils.RespondSuccess(w, "Certificate eligibility retrieved", eligibility)
+}
+
+// CreateDraft starts a certificate for an intern without numbering it
+func (h *CertificateHandler) CreateDraft(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		InternID int64  `json:"intern_id"`
+		Remarks  string `json:"remarks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.InternID <= 0 {
+		utils.RespondBadRequest(w, "intern_id is required")
+		return
+	}
+
+	certID, err := services.CreateCertificateDraft(h.db, req.InternID, claims.UserID, req.Remarks)
+	if !respondCertificateError(w, err) {
+		return
+	}
+
+	h.respondCertificate(w, certID, "Certificate draft created", true)
+}
+
+// Issue numbers, signs and issues a draft certificate
+func (h *CertificateHandler) Issue(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	certID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !respondCertificateError(w, services.IssueCertificate(h.db, certID, claims.UserID)) {
+		return
+	}
+
+	h.notifyHolder(certID, "Sertifikat Diterbitkan", "Sertifikat magang Anda telah diterbitkan dengan nomor %s")
+
+	h.respondCertificate(w, certID, "Certificate issued", false)
+}
+
+// Reissue supersedes an issued certificate with a newly numbered one
+func (h *CertificateHandler) Reissue(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	certID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+
+	newID, err := services.ReissueCertificate(h.db, certID, claims.UserID, reason)
+	if !respondCertificateError(w, err) {
+		return
+	}
+
+	h.notifyHolder(newID, "Sertifikat Diterbitkan Ulang", "Sertifikat magang Anda diterbitkan ulang dengan nomor %s: "+reason)
+
+	h.respondCertificate(w, newID, "Certificate reissued", true)
+}
+
+// Revoke marks an issued certificate as revoked
+func (h *CertificateHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	certID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	reason, ok := decodeReason(w, r)
+	if !ok {
+		return
+	}
+
+	if !respondCertificateError(w, services.RevokeCertificate(h.db, certID, claims.UserID, reason)) {
+		return
+	}
+
+	h.notifyHolder(certID, "Sertifikat Dicabut", "Sertifikat %s telah dicabut: "+reason)
+
+	h.respondCertificate(w, certID, "Certificate revoked", false)
+}
+
+// notifyHolder tells the intern about a certificate change; message gets
+// the certificate number substituted for %s
+func (h *CertificateHandler) notifyHolder(certID int64, title, message string) {
+	var internUserID int64
+	var number string
+	if err := h.db.QueryRow(
+		`SELECT i.user_id, c.certificate_number FROM certificates c JOIN interns i ON c.intern_id = i.id WHERE c.id = ?`, certID,
+	).Scan(&internUserID, &number); err != nil {
+		return
+	}
+	_ = createNotification(h.db, internUserID, models.NotificationCertificate, title,
+		strings.Replace(message, "%s", number, 1), "", nil)
+}
+
+func (h *CertificateHandler) respondCertificate(w http.ResponseWriter, certID int64, message string, created bool) {
+	certs, err := loadCertificates(h.db, "c.id = ?", certID)
+	if err != nil || len(certs) == 0 {
+		utils.RespondInternalError(w, "Failed to fetch certificate")
+		return
+	}
+	cert := certs[0]
+	cert.Events, _ = loadCertificateEvents(h.db, certID)
+	if created {
+		utils.RespondCreated(w, message, cert)
+		return
+	}
+	utils.RespondSuccess(w, message, cert)
+}
+
+// respondCertificateError maps lifecycle errors to responses and returns
+// true when err is nil.
+func respondCertificateError(w http.ResponseWriter, err error) bool {
+	var notEligible *services.NotEligibleError
+	switch {
+	case err == nil:
+		return true
+	case err == sql.ErrNoRows:
+		utils.RespondNotFound(w, "Certificate or intern not found")
+	case errors.Is(err, services.ErrCertificateExists), errors.Is(err, services.ErrCertificateTransition),
+		errors.Is(err, services.ErrCertificateRevoked):
+		utils.RespondBadRequest(w, err.Error())
+	case errors.Is(err, services.ErrCertificateNotIssued):
+		utils.RespondError(w, http.StatusUnprocessableEntity, "Certificate has not been issued yet")
+	case errors.Is(err, services.ErrSigningKeyMissing):
+		utils.RespondError(w, http.StatusServiceUnavailable, "Certificates cannot be signed: CERTIFICATE_SIGNING_KEY is not configured")
+	case errors.As(err, &notEligible):
+		utils.RespondJSON(w, http.StatusUnprocessableEntity, utils.Response{
+			Success: false,
+			Message: "Intern does not meet the certificate issuance rules",
+			Data:    notEligible.Eligibility,
+		})
+	default:
+		utils.RespondInternalError(w, "Failed to update certificate")
+	}
+	return false
+}
+
+func decodeReason(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return "", false
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		utils.RespondBadRequest(w, "Reason is required")
+		return "", false
+	}
+	return req.Reason, true
+}
+
+func loadCertificates(db *sql.DB, where string, args ...interface{}) ([]models.Certificate, error) {
+	rows, err := db.Query(
+		`SELECT c.id, c.intern_id, i.full_name, c.certificate_number, c.status, c.issue_date, c.final_score, c.remarks,
+		        c.issued_at, c.issued_by, c.revoked_at, c.revoked_reason, c.reissued_from, c.signature, c.created_at
+		 FROM certificates c
+		 JOIN interns i ON c.intern_id = i.id
+		 WHERE `+where+`
+		 ORDER BY c.id DESC`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	certs := []models.Certificate{}
+	for rows.Next() {
+		var c models.Certificate
+		var number, remarks, revokedReason, signature sql.NullString
+		var finalScore sql.NullFloat64
+		var issuedAt, revokedAt sql.NullTime
+		var issuedBy, reissuedFrom sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.InternID, &c.InternName, &number, &c.Status, &c.IssueDate, &finalScore, &remarks,
+			&issuedAt, &issuedBy, &revokedAt, &revokedReason, &reissuedFrom, &signature, &c.CreatedAt); err != nil {
+			continue
+		}
+		c.CertificateNumber = ptrStringFromNull(number)
+		if finalScore.Valid {
+			score := finalScore.Float64
+			c.FinalScore = &score
+		}
+		c.Remarks = ptrStringFromNull(remarks)
+		c.IssuedAt = ptrTimeFromNull(issuedAt)
+		c.IssuedBy = ptrInt64FromNull(issuedBy)
+		c.RevokedAt = ptrTimeFromNull(revokedAt)
+		c.RevokedReason = ptrStringFromNull(revokedReason)
+		c.ReissuedFrom = ptrInt64FromNull(reissuedFrom)
+		c.Signed = signature.Valid
+		if number.Valid && c.Status != models.CertificateDraft {
+			c.VerifyURL = services.CertificateVerifyURL(number.String, signature.String)
+		}
+		certs = append(certs, c)
+	}
+	return certs, nil
+}
+
+func loadCertificateEvents(db *sql.DB, certID int64) ([]models.CertificateEvent, error) {
+	rows, err := db.Query(
+		`SELECT e.id, e.action, e.from_status, e.to_status, e.reason, e.actor_id, u.name, e.created_at
+		 FROM certificate_events e
+		 LEFT JOIN users u ON e.actor_id = u.id
+		 WHERE e.certificate_id = ?
+		 ORDER BY e.created_at ASC, e.id ASC`, certID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.CertificateEvent{}
+	for rows.Next() {
+		var e models.CertificateEvent
+		var from, reason, actorName sql.NullString
+		var actorID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Action, &from, &e.ToStatus, &reason, &actorID, &actorName, &e.CreatedAt); err != nil {
+			continue
+		}
+		e.FromStatus = ptrStringFromNull(from)
+		e.Reason = ptrStringFromNull(reason)
+		e.ActorID = ptrInt64FromNull(actorID)
+		e.ActorName = actorName.String
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,539 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Authors can fix typos shortly after posting; older comments are history
+	commentEditWindow   = 15 * time.Minute
+	commentDeleteWindow = 15 * time.Minute
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+type CommentHandler struct {
+	db *sql.DB
+}
+
+func NewCommentHandler(db *sql.DB) *CommentHandler {
+	return &CommentHandler{db: db}
+}
+
+type createCommentRequest struct {
+	Body     string  `json:"body"`
+	ParentID *int64  `json:"parent_id"`
+	Mentions []int64 `json:"mentions"` // user ids picked from the mention autocomplete
+}
+
+type updateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// List returns the comment thread of a task, report or leave request
+func (h *CommentHandler) List(entityType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			utils.RespondUnauthorized(w, "Unauthorized")
+			return
+		}
+
+		vars := mux.Vars(r)
+		entityID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+		if _, status := h.participants(claims, entityType, entityID); status != http.StatusOK {
+			respondCommentAccess(w, status)
+			return
+		}
+
+		rows, err := h.db.Query(`
+			SELECT c.id, c.entity_type, c.entity_id, c.parent_id, c.author_id, c.body, c.attachment_path, c.attachment_name,
+			       c.edited_at, c.deleted_at, c.created_at, u.name, u.role, u.avatar
+			FROM comments c
+			LEFT JOIN users u ON c.author_id = u.id
+			WHERE c.entity_type = ? AND c.entity_id = ?
+			ORDER BY c.created_at ASC, c.id ASC
+		`, entityType, entityID)
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to fetch comments")
+			return
+		}
+		defer rows.Close()
+
+		comments := []models.Comment{}
+		for rows.Next() {
+			var c models.Comment
+			var parentID sql.NullInt64
+			var attachmentPath, attachmentName, authorName, authorRole, authorAvatar sql.NullString
+			var editedAt, deletedAt sql.NullTime
+			if err := rows.Scan(
+				&c.ID, &c.EntityType, &c.EntityID, &parentID, &c.AuthorID, &c.Body, &attachmentPath, &attachmentName,
+				&editedAt, &deletedAt, &c.CreatedAt, &authorName, &authorRole, &authorAvatar,
+			); err != nil {
+				continue
+			}
+			c.ParentID = ptrInt64FromNull(parentID)
+			c.AttachmentPath = ptrStringFromNull(attachmentPath)
+			c.AttachmentName = ptrStringFromNull(attachmentName)
+			c.EditedAt = ptrTimeFromNull(editedAt)
+			c.DeletedAt = ptrTimeFromNull(deletedAt)
+			c.AuthorName = authorName.String
+			c.AuthorRole = normalizeRole(authorRole.String)
+			c.AuthorAvatar = authorAvatar.String
+			c.Mentions = []int64{}
+			c.Replies = []models.Comment{}
+			if c.DeletedAt != nil {
+				c.Body = ""
+				c.AttachmentPath = nil
+				c.AttachmentName = nil
+			}
+			comments = append(comments, c)
+		}
+		rows.Close()
+
+		h.attachMentions(comments)
+
+		utils.RespondSuccess(w, "Comments retrieved", buildCommentTree(comments))
+	}
+}
+
+// Create posts a comment (optionally a reply and/or with an attachment)
+func (h *CommentHandler) Create(entityType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := middleware.GetUserFromContext(r.Context())
+		if !ok {
+			utils.RespondUnauthorized(w, "Unauthorized")
+			return
+		}
+
+		vars := mux.Vars(r)
+		entityID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+		participants, status := h.participants(claims, entityType, entityID)
+		if status != http.StatusOK {
+			respondCommentAccess(w, status)
+			return
+		}
+
+		var req createCommentRequest
+		var attachmentPath, attachmentName sql.NullString
+
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(10 << 20); err != nil {
+				utils.RespondBadRequest(w, "Failed to parse form data")
+				return
+			}
+			req.Body = r.FormValue("body")
+			if v := r.FormValue("parent_id"); v != "" {
+				parentID, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					utils.RespondBadRequest(w, "Invalid parent_id")
+					return
+				}
+				req.ParentID = &parentID
+			}
+			if v := r.FormValue("mentions"); v != "" {
+				for _, part := range strings.Split(strings.Trim(v, "[]"), ",") {
+					if id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64); err == nil {
+						req.Mentions = append(req.Mentions, id)
+					}
+				}
+			}
+
+			file, header, err := r.FormFile("file")
+			if err == nil {
+				defer file.Close()
+				path, err := utils.UploadFile(file, header, "comments")
+				if err != nil {
+					utils.RespondBadRequest(w, "Upload failed: "+err.Error())
+					return
+				}
+				attachmentPath = sql.NullString{String: path, Valid: true}
+				attachmentName = sql.NullString{String: header.Filename, Valid: true}
+			}
+		} else {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.RespondBadRequest(w, "Invalid request body")
+				return
+			}
+		}
+
+		req.Body = strings.TrimSpace(req.Body)
+		if req.Body == "" && !attachmentPath.Valid {
+			utils.RespondBadRequest(w, "Comment body or attachment is required")
+			return
+		}
+
+		if req.ParentID != nil {
+			var parentEntityType string
+			var parentEntityID int64
+			err := h.db.QueryRow("SELECT entity_type, entity_id FROM comments WHERE id = ?", *req.ParentID).
+				Scan(&parentEntityType, &parentEntityID)
+			if err != nil || parentEntityType != entityType || parentEntityID != entityID {
+				utils.RespondBadRequest(w, "Parent comment does not belong to this thread")
+				return
+			}
+		}
+
+		res, err := h.db.Exec(
+			`INSERT INTO comments (entity_type, entity_id, parent_id, author_id, body, attachment_path, attachment_name, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			entityType, entityID, req.ParentID, claims.UserID, req.Body, attachmentPath, attachmentName, time.Now(),
+		)
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to create comment")
+			return
+		}
+		commentID, _ := res.LastInsertId()
+
+		mentioned := h.resolveMentions(req.Body, req.Mentions, participants, claims.UserID)
+		for _, userID := range mentioned {
+			if _, err := h.db.Exec("INSERT IGNORE INTO comment_mentions (comment_id, user_id) VALUES (?, ?)", commentID, userID); err != nil {
+				log.Printf("Error recording mention of user %d on comment %d: %v", userID, commentID, err)
+			}
+		}
+
+		h.notify(entityType, entityID, claims.UserID, participants, mentioned)
+
+		utils.RespondCreated(w, "Comment created", map[string]interface{}{
+			"id":       commentID,
+			"mentions": mentioned,
+		})
+	}
+}
+
+// Update edits the body of a comment within the edit window
+func (h *CommentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var authorID int64
+	var createdAt time.Time
+	var deletedAt sql.NullTime
+	err := h.db.QueryRow("SELECT author_id, created_at, deleted_at FROM comments WHERE id = ?", id).
+		Scan(&authorID, &createdAt, &deletedAt)
+	if err == sql.ErrNoRows || deletedAt.Valid {
+		utils.RespondNotFound(w, "Comment not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if authorID != claims.UserID {
+		utils.RespondForbidden(w, "You can only edit your own comments")
+		return
+	}
+	if time.Since(createdAt) > commentEditWindow {
+		utils.RespondForbidden(w, "Comments can only be edited within "+strconv.Itoa(int(commentEditWindow.Minutes()))+" minutes")
+		return
+	}
+
+	var req updateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Body) == "" {
+		utils.RespondBadRequest(w, "Comment body is required")
+		return
+	}
+
+	if _, err := h.db.Exec("UPDATE comments SET body = ?, edited_at = ? WHERE id = ?", strings.TrimSpace(req.Body), time.Now(), id); err != nil {
+		utils.RespondInternalError(w, "Failed to update comment")
+		return
+	}
+
+	utils.RespondSuccess(w, "Comment updated", nil)
+}
+
+// Delete soft-deletes a comment so replies stay attached to the thread
+func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var authorID int64
+	var createdAt time.Time
+	var attachmentPath sql.NullString
+	var deletedAt sql.NullTime
+	err := h.db.QueryRow("SELECT author_id, created_at, attachment_path, deleted_at FROM comments WHERE id = ?", id).
+		Scan(&authorID, &createdAt, &attachmentPath, &deletedAt)
+	if err == sql.ErrNoRows || deletedAt.Valid {
+		utils.RespondNotFound(w, "Comment not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	if normalizeRole(claims.Role) != "admin" {
+		if authorID != claims.UserID {
+			utils.RespondForbidden(w, "You can only delete your own comments")
+			return
+		}
+		if time.Since(createdAt) > commentDeleteWindow {
+			utils.RespondForbidden(w, "Comments can only be deleted within "+strconv.Itoa(int(commentDeleteWindow.Minutes()))+" minutes")
+			return
+		}
+	}
+
+	if _, err := h.db.Exec(
+		"UPDATE comments SET body = '', attachment_path = NULL, attachment_name = NULL, deleted_at = ? WHERE id = ?",
+		time.Now(), id,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to delete comment")
+		return
+	}
+	if attachmentPath.Valid {
+		_ = utils.DeleteFile(attachmentPath.String)
+	}
+
+	utils.RespondSuccess(w, "Comment deleted", nil)
+}
+
+// participants resolves who takes part in the thread of an entity and
+// whether the caller may access it. Admins can access every thread.
+func (h *CommentHandler) participants(claims *middleware.Claims, entityType string, entityID int64) ([]int64, int) {
+	var query string
+	switch entityType {
+	case models.CommentEntityTask:
+		query = `SELECT i.user_id, i.supervisor_id, t.assigned_by, t.assigner_id
+		         FROM tasks t JOIN interns i ON t.intern_id = i.id WHERE t.id = ?`
+	case models.CommentEntityReport:
+		query = `SELECT i.user_id, i.supervisor_id, r.created_by, NULL
+		         FROM reports r JOIN interns i ON r.intern_id = i.id WHERE r.id = ?`
+	case models.CommentEntityLeave:
+		query = `SELECT i.user_id, i.supervisor_id, NULL, NULL
+		         FROM leave_requests l JOIN interns i ON l.intern_id = i.id WHERE l.id = ?`
+	default:
+		return nil, http.StatusNotFound
+	}
+
+	var internUserID int64
+	var supervisorID, ownerID, assignerID sql.NullInt64
+	err := h.db.QueryRow(query, entityID).Scan(&internUserID, &supervisorID, &ownerID, &assignerID)
+	if err == sql.ErrNoRows {
+		return nil, http.StatusNotFound
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+
+	ids := []int64{internUserID}
+	for _, v := range []sql.NullInt64{supervisorID, ownerID, assignerID} {
+		if v.Valid && !containsInt64(ids, v.Int64) {
+			ids = append(ids, v.Int64)
+		}
+	}
+
+	if normalizeRole(claims.Role) == "admin" || containsInt64(ids, claims.UserID) {
+		return ids, http.StatusOK
+	}
+	return ids, http.StatusForbidden
+}
+
+// resolveMentions merges explicit mention ids with @email mentions in the body.
+// Only thread participants and admins can be mentioned since nobody else can
+// open the thread.
+func (h *CommentHandler) resolveMentions(body string, explicit []int64, participants []int64, authorID int64) []int64 {
+	candidates := append([]int64{}, explicit...)
+	for _, email := range extractMentions(body) {
+		var userID int64
+		if err := h.db.QueryRow("SELECT id FROM users WHERE LOWER(email) = ?", email).Scan(&userID); err == nil {
+			candidates = append(candidates, userID)
+		}
+	}
+
+	mentioned := []int64{}
+	for _, userID := range candidates {
+		if userID == authorID || containsInt64(mentioned, userID) {
+			continue
+		}
+		if !containsInt64(participants, userID) {
+			var role string
+			if err := h.db.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role); err != nil || role != "admin" {
+				continue
+			}
+		}
+		mentioned = append(mentioned, userID)
+	}
+	return mentioned
+}
+
+func (h *CommentHandler) notify(entityType string, entityID, authorID int64, participants, mentioned []int64) {
+	var authorName sql.NullString
+	_ = h.db.QueryRow("SELECT name FROM users WHERE id = ?", authorID).Scan(&authorName)
+	name := "Seseorang"
+	if authorName.Valid && authorName.String != "" {
+		name = authorName.String
+	}
+
+	subject, link := commentSubject(entityType, entityID)
+	for _, userID := range mentioned {
+		_ = createNotification(h.db, userID, models.NotificationMention, "Anda Disebut",
+			name+" menyebut Anda dalam komentar pada "+subject+".", link, nil)
+	}
+	for _, userID := range participants {
+		if userID == authorID || containsInt64(mentioned, userID) {
+			continue
+		}
+		_ = createNotification(h.db, userID, models.NotificationComment, "Komentar Baru",
+			name+" menambahkan komentar pada "+subject+".", link, nil)
+	}
+}
+
+func (h *CommentHandler) attachMentions(comments []models.Comment) {
+	if len(comments) == 0 {
+		return
+	}
+	index := make(map[int64]int, len(comments))
+	placeholders := make([]string, 0, len(comments))
+	args := make([]interface{}, 0, len(comments))
+	for i, c := range comments {
+		index[c.ID] = i
+		placeholders = append(placeholders, "?")
+		args = append(args, c.ID)
+	}
+
+	rows, err := h.db.Query(
+		"SELECT comment_id, user_id FROM comment_mentions WHERE comment_id IN ("+strings.Join(placeholders, ",")+")", args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var commentID, userID int64
+		if err := rows.Scan(&commentID, &userID); err == nil {
+			if i, ok := index[commentID]; ok {
+				comments[i].Mentions = append(comments[i].Mentions, userID)
+			}
+		}
+	}
+}
+
+// recordComment appends a system-side comment to a thread, used to keep
+// review feedback in the history when the feedback column is overwritten.
+func recordComment(db *sql.DB, entityType string, entityID, authorID int64, body string) {
+	if strings.TrimSpace(body) == "" {
+		return
+	}
+	if _, err := db.Exec(
+		"INSERT INTO comments (entity_type, entity_id, author_id, body, created_at) VALUES (?, ?, ?, ?, ?)",
+		entityType, entityID, authorID, strings.TrimSpace(body), time.Now(),
+	); err != nil {
+		log.Printf("Error recording %s %d comment: %v", entityType, entityID, err)
+	}
+}
+
+// buildCommentTree nests replies under their parents. Input must be ordered
+// by creation so parents are always seen before their replies.
+func buildCommentTree(comments []models.Comment) []models.Comment {
+	children := make(map[int64][]int)
+	roots := []int{}
+	known := make(map[int64]bool, len(comments))
+	for i, c := range comments {
+		known[c.ID] = true
+		if c.ParentID != nil && known[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	var build func(i int) models.Comment
+	build = func(i int) models.Comment {
+		c := comments[i]
+		for _, child := range children[c.ID] {
+			c.Replies = append(c.Replies, build(child))
+		}
+		return c
+	}
+
+	tree := make([]models.Comment, 0, len(roots))
+	for _, i := range roots {
+		tree = append(tree, build(i))
+	}
+	return tree
+}
+
+// extractMentions returns the lower-cased e-mail addresses mentioned as
+// "@user@example.com" in a comment body, without duplicates.
+func extractMentions(body string) []string {
+	found := []string{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(strings.TrimRight(m[1], "."))
+		if !containsString(found, email) {
+			found = append(found, email)
+		}
+	}
+	return found
+}
+
+func commentSubject(entityType string, entityID int64) (string, string) {
+	id := strconv.FormatInt(entityID, 10)
+	switch entityType {
+	case models.CommentEntityTask:
+		return "tugas", "/tasks/" + id
+	case models.CommentEntityReport:
+		return "laporan", "/reports/" + id
+	default:
+		return "pengajuan izin", "/leaves"
+	}
+}
+
+func respondCommentAccess(w http.ResponseWriter, status int) {
+	switch status {
+	case http.StatusNotFound:
+		utils.RespondNotFound(w, "Not found")
+	case http.StatusForbidden:
+		utils.RespondForbidden(w, "You do not have access to this thread")
+	default:
+		utils.RespondInternalError(w, "Database error")
+	}
+}
+
+func containsInt64(values []int64, v int64) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -9,6 +9,8 @@ import (
 
 	"dsi_interna_sys/internal/config"
 	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 )
 
@@ -106,46 +108,51 @@ func (h *DashboardHandler) GetInternDashboard(w http.ResponseWriter, r *http.Req
 		}
 	}
 
-	// 2. Task Statistics
-	var totalTasks, pendingTasks, inProgressTasks, completedTasks int
-	err = h.db.QueryRow(`
-		SELECT 
-			COUNT(*) as total,
-			SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END) as pending,
-			SUM(CASE WHEN status = 'in_progress' THEN 1 ELSE 0 END) as in_progress,
-			SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END) as completed
-		FROM task_assignments WHERE intern_id = ?`, internID).Scan(
-		&totalTasks, &pendingTasks, &inProgressTasks, &completedTasks,
-	)
+	// 2. Task Statistics, from the intern's rollup
+	rollup, err := h.internRollup(internID)
+	if err != nil {
+		log.Printf("intern dashboard rollup query failed: %v", err)
+	}
 
 	taskStats := map[string]interface{}{
-		"total":       totalTasks,
-		"pending":     pendingTasks,
-		"in_progress": inProgressTasks,
-		"completed":   completedTasks,
+		"total":       rollup.total,
+		"pending":     rollup.pending,
+		"in_progress": rollup.inProgress,
+		"completed":   rollup.completed,
 		"percentage":  0,
 	}
-	if totalTasks > 0 {
-		taskStats["percentage"] = int(float64(completedTasks) / float64(totalTasks) * 100)
+	if rollup.total > 0 {
+		taskStats["percentage"] = int(float64(rollup.completed) / float64(rollup.total) * 100)
 	}
 
 	// 2.1 Task Breakdown for Pie Chart (including submitted and revision)
-	var submittedTasks, revisionTasks int
-	h.db.QueryRow(`
-		SELECT 
-			SUM(CASE WHEN status = 'submitted' THEN 1 ELSE 0 END) as submitted,
-			SUM(CASE WHEN status = 'revision' THEN 1 ELSE 0 END) as revision
-		FROM task_assignments WHERE intern_id = ?`, internID).Scan(&submittedTasks, &revisionTasks)
-
 	taskBreakdown := map[string]interface{}{
-		"pending":     pendingTasks,
-		"in_progress": inProgressTasks,
-		"submitted":   submittedTasks,
-		"completed":   completedTasks,
-		"revision":    revisionTasks,
+		"pending":     rollup.pending,
+		"in_progress": rollup.inProgress,
+		"submitted":   rollup.submitted,
+		"completed":   rollup.completed,
+		"revision":    rollup.revision,
 	}
 
 	// 2.2 Weekly Attendance Counts for Bar Chart (formatted for CSS charts)
+	weekStatuses := map[string]string{}
+	weekRows, err := h.db.Query(
+		"SELECT date, status FROM attendances WHERE intern_id = ? AND date BETWEEN ? AND ?",
+		internID, weekStart.Format("2006-01-02"), today,
+	)
+	if err != nil {
+		log.Printf("intern dashboard weekly attendance query failed: %v", err)
+	} else {
+		for weekRows.Next() {
+			var date time.Time
+			var status string
+			if weekRows.Scan(&date, &status) == nil {
+				weekStatuses[date.Format("2006-01-02")] = status
+			}
+		}
+		weekRows.Close()
+	}
+
 	weeklyLabels := []string{}
 	weeklyData := []int{}
 	weeklyColors := []string{}
@@ -153,14 +160,10 @@ func (h *DashboardHandler) GetInternDashboard(w http.ResponseWriter, r *http.Req
 	for i := 0; i < 7; i++ {
 		dayName := weekStart.AddDate(0, 0, i).Format("Mon")
 		dayDate := weekStart.AddDate(0, 0, i).Format("2006-01-02")
-		var attStatus sql.NullString
-
-		h.db.QueryRow(`
-			SELECT attendance.status FROM attendances WHERE intern_id = ? AND date = ?`, internID, dayDate).Scan(&attStatus)
 
-		status := "absent"
-		if attStatus.Valid {
-			status = attStatus.String
+		status, ok := weekStatuses[dayDate]
+		if !ok {
+			status = "absent"
 		}
 
 		weeklyLabels = append(weeklyLabels, dayName)
@@ -193,9 +196,9 @@ func (h *DashboardHandler) GetInternDashboard(w http.ResponseWriter, r *http.Req
 
 	// 3. Recent Tasks (5 latest)
 	tasksRows, err := h.db.Query(`
-		SELECT id, title, status, priority, deadline, deadline_time, submitted_at, grade, is_late
-		FROM task_assignments 
-		WHERE intern_id = ? 
+		SELECT id, title, status, priority, deadline, deadline_time, submitted_at, score, is_late
+		FROM tasks
+		WHERE intern_id = ?
 		ORDER BY created_at DESC LIMIT 5`, internID)
 	if err != nil {
 		utils.RespondInternalError(w, "Failed to fetch tasks")
@@ -247,14 +250,9 @@ func (h *DashboardHandler) GetInternDashboard(w http.ResponseWriter, r *http.Req
 
 	for i := 0; i < 7; i++ {
 		date := weekStart.AddDate(0, 0, i).Format("2006-01-02")
-		var attStatus sql.NullString
-
-		h.db.QueryRow(`
-			SELECT status FROM attendances WHERE intern_id = ? AND date = ?`, internID, date).Scan(&attStatus)
-
-		status := "absent"
-		if attStatus.Valid {
-			status = attStatus.String
+		status, ok := weekStatuses[date]
+		if !ok {
+			status = "absent"
 		}
 
 		dayName := weekStart.AddDate(0, 0, i).Format("Mon")
@@ -266,18 +264,9 @@ func (h *DashboardHandler) GetInternDashboard(w http.ResponseWriter, r *http.Req
 	}
 
 	// 5. Attendance Percentage (last 30 days)
-	var totalDays, presentDays int
-	h.db.QueryRow(`
-		SELECT 
-			COUNT(*) as total,
-			SUM(CASE WHEN status IN ('present', 'late') THEN 1 ELSE 0 END) as present
-		FROM attendances 
-		WHERE intern_id = ? AND date >= ?`,
-		internID, now.AddDate(0, 0, -30).Format("2006-01-02")).Scan(&totalDays, &presentDays)
-
 	attendancePercentage := 0
-	if totalDays > 0 {
-		attendancePercentage = int(float64(presentDays) / float64(totalDays) * 100)
+	if rollup.attendanceDays > 0 {
+		attendancePercentage = int(float64(rollup.presentDays) / float64(rollup.attendanceDays) * 100)
 	}
 
 	// 6. Recent Attendance History (last 5)
@@ -332,9 +321,45 @@ func (h *DashboardHandler) GetInternDashboard(w http.ResponseWriter, r *http.Req
 			"name": userName,
 			"role": claims.Role,
 		},
+		"freshness": map[string]interface{}{
+			"stats_refreshed_at": ptrTimeFromNull(rollup.refreshedAt),
+		},
 	})
 }
 
+// internDashboardRollup is an intern's row of intern_dashboard_rollups
+type internDashboardRollup struct {
+	total, pending, inProgress, submitted, revision, completed int
+	attendanceDays, presentDays                                int
+	refreshedAt                                                sql.NullTime
+}
+
+// internRollup reads an intern's dashboard rollup, building it first for
+// interns the refresh job has not reached yet
+func (h *DashboardHandler) internRollup(internID int64) (internDashboardRollup, error) {
+	var ro internDashboardRollup
+	query := `SELECT tasks_total, tasks_pending, tasks_in_progress, tasks_submitted, tasks_revision, tasks_completed,
+	                 attendance_days_30d, present_days_30d, refreshed_at
+	          FROM intern_dashboard_rollups WHERE intern_id = ?`
+	scan := func() error {
+		return h.db.QueryRow(query, internID).Scan(
+			&ro.total, &ro.pending, &ro.inProgress, &ro.submitted, &ro.revision, &ro.completed,
+			&ro.attendanceDays, &ro.presentDays, &ro.refreshedAt,
+		)
+	}
+	err := scan()
+	if err == sql.ErrNoRows {
+		if err := services.RefreshInternRollup(h.db, internID, time.Now()); err != nil {
+			return ro, err
+		}
+		err = scan()
+	}
+	if err == sql.ErrNoRows {
+		return ro, nil
+	}
+	return ro, err
+}
+
 // GetAdminDashboard returns dashboard data for admin/supervisor view
 func (h *DashboardHandler) GetAdminDashboard(w http.ResponseWriter, r *http.Request) {
 	claims, ok := middleware.GetUserFromContext(r.Context())
@@ -361,16 +386,30 @@ func (h *DashboardHandler) GetAdminDashboard(w http.ResponseWriter, r *http.Requ
 	}
 	h.db.QueryRow(intQuery, intArgs...).Scan(&totalInterns)
 
-	// 2. Total Tasks
-	var totalTasks int
-	taskCountQuery := "SELECT COUNT(*) FROM tasks t LEFT JOIN interns i ON t.intern_id = i.id"
-	taskCountArgs := []interface{}{}
+	// 2. Task counts, summed from the interns' rollups
+	var totalTasks, completedOnTime, completedLate, pendingTasks, overdueTasks, inProgressTasks int
+	var tasksRefreshedAt sql.NullTime
+	taskStatsQuery := `
+		SELECT
+			COALESCE(SUM(r.tasks_total), 0),
+			COALESCE(SUM(r.tasks_completed - r.tasks_completed_late), 0) as on_time,
+			COALESCE(SUM(r.tasks_completed_late), 0) as late,
+			COALESCE(SUM(r.tasks_pending + r.tasks_in_progress + r.tasks_submitted + r.tasks_revision), 0) as pending,
+			COALESCE(SUM(r.tasks_open_late), 0) as overdue,
+			COALESCE(SUM(r.tasks_total - r.tasks_completed - r.tasks_open_late), 0) as in_progress,
+			MIN(r.refreshed_at)
+		FROM intern_dashboard_rollups r
+		JOIN interns i ON r.intern_id = i.id
+	`
+	taskStatsArgs := []interface{}{}
 	if role == "pembimbing" {
-		taskCountQuery += " WHERE i.supervisor_id = ?"
-		taskCountArgs = append(taskCountArgs, claims.UserID)
+		taskStatsQuery += " WHERE i.supervisor_id = ?"
+		taskStatsArgs = append(taskStatsArgs, claims.UserID)
 	}
-	if err := h.db.QueryRow(taskCountQuery, taskCountArgs...).Scan(&totalTasks); err != nil {
-		log.Printf("admin dashboard total tasks query failed: %v", err)
+	if err := h.db.QueryRow(taskStatsQuery, taskStatsArgs...).Scan(
+		&totalTasks, &completedOnTime, &completedLate, &pendingTasks, &overdueTasks, &inProgressTasks, &tasksRefreshedAt,
+	); err != nil {
+		log.Printf("admin dashboard task stats query failed: %v", err)
 	}
 
 	// 2.1 Pending Registrations (Interns) - Admin Only
@@ -387,43 +426,47 @@ func (h *DashboardHandler) GetAdminDashboard(w http.ResponseWriter, r *http.Requ
 		h.db.QueryRow("SELECT COUNT(*) FROM supervisors WHERE status = 'pending'").Scan(&pendingSupervisors)
 	}
 
-	// 3. Task Completion Stats (include overdue & in-progress for dashboard pie chart)
-	var completedOnTime, completedLate, pendingTasks, overdueTasks, inProgressTasks int
-	taskStatsQuery := `
-		SELECT 
-			COALESCE(SUM(CASE WHEN t.status = 'completed' AND t.is_late = 0 THEN 1 ELSE 0 END), 0) as on_time,
-			COALESCE(SUM(CASE WHEN t.status = 'completed' AND t.is_late = 1 THEN 1 ELSE 0 END), 0) as late,
-			COALESCE(SUM(CASE WHEN t.status IN ('pending', 'in_progress', 'submitted', 'revision') THEN 1 ELSE 0 END), 0) as pending,
-			COALESCE(SUM(CASE WHEN t.status != 'completed' AND t.is_late = 1 THEN 1 ELSE 0 END), 0) as overdue,
-			COALESCE(SUM(CASE WHEN t.status != 'completed' AND t.is_late = 0 THEN 1 ELSE 0 END), 0) as in_progress
-		FROM tasks t
-		LEFT JOIN interns i ON t.intern_id = i.id
-	`
-	taskStatsArgs := []interface{}{}
+	// 3. Attendance of the last 7 days, from the daily rollups
+	today := now.Format("2006-01-02")
+	weekStart := now.AddDate(0, 0, -6)
+	type dayCounts struct{ present, absent, total int }
+	days := map[string]dayCounts{}
+	var attendanceRefreshedAt sql.NullTime
+	attQuery := `
+		SELECT date,
+			SUM(present + late),
+			SUM(total - present - late - sick - permission),
+			SUM(total),
+			MIN(refreshed_at)
+		FROM attendance_daily_rollups
+		WHERE date BETWEEN ? AND ?`
+	attArgs := []interface{}{weekStart.Format("2006-01-02"), today}
 	if role == "pembimbing" {
-		taskStatsQuery += " WHERE i.supervisor_id = ?"
-		taskStatsArgs = append(taskStatsArgs, claims.UserID)
+		attQuery += " AND supervisor_id = ?"
+		attArgs = append(attArgs, claims.UserID)
 	}
-	if err := h.db.QueryRow(taskStatsQuery, taskStatsArgs...).Scan(&completedOnTime, &completedLate, &pendingTasks, &overdueTasks, &inProgressTasks); err != nil {
-		log.Printf("admin dashboard task stats query failed: %v", err)
+	attQuery += " GROUP BY date"
+	attRows, err := h.db.Query(attQuery, attArgs...)
+	if err != nil {
+		log.Printf("admin dashboard attendance rollup query failed: %v", err)
+	} else {
+		for attRows.Next() {
+			var date time.Time
+			var c dayCounts
+			var refreshedAt sql.NullTime
+			if err := attRows.Scan(&date, &c.present, &c.absent, &c.total, &refreshedAt); err != nil {
+				continue
+			}
+			days[date.Format("2006-01-02")] = c
+			if refreshedAt.Valid && (!attendanceRefreshedAt.Valid || refreshedAt.Time.Before(attendanceRefreshedAt.Time)) {
+				attendanceRefreshedAt = refreshedAt
+			}
+		}
+		attRows.Close()
 	}
 
 	// 4. Today's Attendance
-	today := now.Format("2006-01-02")
-	var presentToday, totalToday int
-	attTodayQuery := `
-		SELECT 
-			COUNT(CASE WHEN a.status IN ('present', 'late') THEN 1 END) as present,
-			COUNT(*) as total
-		FROM attendances a
-		JOIN interns i ON a.intern_id = i.id
-		WHERE a.date = ?`
-	attTodayArgs := []interface{}{today}
-	if role == "pembimbing" {
-		attTodayQuery += " AND i.supervisor_id = ?"
-		attTodayArgs = append(attTodayArgs, claims.UserID)
-	}
-	h.db.QueryRow(attTodayQuery, attTodayArgs...).Scan(&presentToday, &totalToday)
+	presentToday, totalToday := days[today].present, days[today].total
 
 	// 5. Recent Tasks
 	var recentTasks []map[string]interface{}
@@ -513,34 +556,28 @@ func (h *DashboardHandler) GetAdminDashboard(w http.ResponseWriter, r *http.Requ
 
 	// 7. Weekly Attendance Trend
 	weeklyTrend := []map[string]interface{}{}
-	weekStart := now.AddDate(0, 0, -6)
-
 	for i := 0; i < 7; i++ {
 		date := weekStart.AddDate(0, 0, i).Format("2006-01-02")
-		var present, absent int
-		weekQuery := `
-			SELECT 
-				COUNT(CASE WHEN a.status IN ('present', 'late') THEN 1 END) as present,
-				COUNT(CASE WHEN a.status NOT IN ('present', 'late', 'sick', 'permission') THEN 1 END) as absent
-			FROM attendances a
-			JOIN interns i ON a.intern_id = i.id
-			WHERE a.date = ?`
-		weekArgs := []interface{}{date}
-		if role == "pembimbing" {
-			weekQuery += " AND i.supervisor_id = ?"
-			weekArgs = append(weekArgs, claims.UserID)
-		}
-		h.db.QueryRow(weekQuery, weekArgs...).Scan(&present, &absent)
-
 		dayName := weekStart.AddDate(0, 0, i).Format("Mon")
 		weeklyTrend = append(weeklyTrend, map[string]interface{}{
 			"date":    date,
 			"day":     dayName,
-			"present": present,
-			"absent":  absent,
+			"present": days[date].present,
+			"absent":  days[date].absent,
 		})
 	}
 
+	// 8. Interns at risk, from the nightly risk scoring
+	riskScope := int64(0)
+	if role == "pembimbing" {
+		riskScope = claims.UserID
+	}
+	atRisk, err := services.LoadRiskScores(h.db, riskScope, services.RiskThreshold(h.db)/2, 10)
+	if err != nil {
+		log.Printf("admin dashboard risk scores query failed: %v", err)
+		atRisk = []models.InternRisk{}
+	}
+
 	utils.RespondSuccess(w, "Admin dashboard data retrieved", map[string]interface{}{
 		"stats": map[string]interface{}{
 			"total_interns":         totalInterns,
@@ -558,5 +595,40 @@ func (h *DashboardHandler) GetAdminDashboard(w http.ResponseWriter, r *http.Requ
 		"recent_tasks":     recentTasks,
 		"today_attendance": todayAttendance,
 		"weekly_trend":     weeklyTrend,
+		"at_risk_interns":  atRisk,
+		"freshness": map[string]interface{}{
+			"tasks_refreshed_at":      ptrTimeFromNull(tasksRefreshedAt),
+			"attendance_refreshed_at": ptrTimeFromNull(attendanceRefreshedAt),
+		},
 	})
 }
+
+// GetAtRisk lists interns by risk score, highest first. Pembimbing only
+// see their own interns. ?min_score= defaults to the medium level.
+func (h *DashboardHandler) GetAtRisk(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	scope := int64(0)
+	if normalizeRole(claims.Role) == "pembimbing" {
+		scope = claims.UserID
+	}
+
+	minScore := services.RiskThreshold(h.db) / 2
+	if v, err := strconv.Atoi(r.URL.Query().Get("min_score")); err == nil && v >= 0 {
+		minScore = v
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	list, err := services.LoadRiskScores(h.db, scope, minScore, limit)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch risk scores")
+		return
+	}
+	utils.RespondSuccess(w, "At-risk interns retrieved", list)
+}
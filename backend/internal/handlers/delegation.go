@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// DelegationHandler manages supervisors' out-of-office delegations and
+// the trail of actions taken under them
+type DelegationHandler struct {
+	db *sql.DB
+}
+
+func NewDelegationHandler(db *sql.DB) *DelegationHandler {
+	return &DelegationHandler{db: db}
+}
+
+// coversSupervisor tells whether userID is the supervisor, or is covering
+// for the supervisor today
+func coversSupervisor(db *sql.DB, userID int64, supervisorID sql.NullInt64) bool {
+	if !supervisorID.Valid {
+		return false
+	}
+	if supervisorID.Int64 == userID {
+		return true
+	}
+	_, ok := services.CoveringDelegation(db, userID, supervisorID.Int64, time.Now())
+	return ok
+}
+
+// Create declares an absence period with a delegate. Pembimbing declare
+// their own; admins may pass supervisor_id.
+func (h *DelegationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	var req models.CreateDelegationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.DelegateID <= 0 {
+		utils.RespondBadRequest(w, "delegate_id is required")
+		return
+	}
+	supervisorID := claims.UserID
+	if normalizeRole(claims.Role) == "admin" && req.SupervisorID > 0 {
+		supervisorID = req.SupervisorID
+	}
+
+	id, err := services.CreateDelegation(h.db, supervisorID, req, claims.UserID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDelegation) {
+			utils.RespondBadRequest(w, err.Error())
+			return
+		}
+		utils.RespondInternalError(w, "Failed to create delegation")
+		return
+	}
+	d, err := services.LoadDelegation(h.db, id)
+	if err != nil {
+		utils.RespondCreated(w, "Delegation created", map[string]int64{"id": id})
+		return
+	}
+	utils.RespondCreated(w, "Delegation created", d)
+}
+
+// GetAll lists delegations. Pembimbing see those they declared or cover;
+// admins see all, or one user's with ?user_id=. Cancelled ones are
+// included with ?include_cancelled=true.
+func (h *DelegationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	userID := claims.UserID
+	if normalizeRole(claims.Role) == "admin" {
+		userID, _ = strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	}
+
+	list, err := services.ListDelegations(h.db, userID, r.URL.Query().Get("include_cancelled") == "true")
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch delegations")
+		return
+	}
+	utils.RespondSuccess(w, "Delegations retrieved", list)
+}
+
+// Cancel ends a delegation early. Only the absent supervisor, whoever
+// declared it, or an admin may cancel.
+func (h *DelegationHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	d, claims, ok := h.loadDelegation(w, r)
+	if !ok {
+		return
+	}
+	if normalizeRole(claims.Role) != "admin" && d.SupervisorID != claims.UserID && d.CreatedBy != claims.UserID {
+		utils.RespondForbidden(w, "You cannot cancel this delegation")
+		return
+	}
+	if err := services.CancelDelegation(h.db, d.ID); err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondBadRequest(w, "This delegation has already been cancelled")
+			return
+		}
+		utils.RespondInternalError(w, "Failed to cancel delegation")
+		return
+	}
+	_ = createNotification(h.db, d.DelegateID, "info", "Penugasan Pengganti Dibatalkan",
+		"Penugasan Anda sebagai pengganti "+d.SupervisorName+" telah dibatalkan.", "/delegations", nil)
+
+	d, _ = services.LoadDelegation(h.db, d.ID)
+	utils.RespondSuccess(w, "Delegation cancelled", d)
+}
+
+// GetActions returns the approvals and reviews made under a delegation
+func (h *DelegationHandler) GetActions(w http.ResponseWriter, r *http.Request) {
+	d, claims, ok := h.loadDelegation(w, r)
+	if !ok {
+		return
+	}
+	if normalizeRole(claims.Role) != "admin" && d.SupervisorID != claims.UserID && d.DelegateID != claims.UserID {
+		utils.RespondForbidden(w, "You do not have access to this delegation")
+		return
+	}
+	actions, err := services.ListDelegatedActions(h.db, d.ID, 0, 500)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch delegated actions")
+		return
+	}
+	utils.RespondSuccess(w, "Delegated actions retrieved", actions)
+}
+
+// GetAuditTrail lists actions taken on someone's behalf across all
+// delegations, newest first. Pembimbing see the actions they took or that
+// were taken for them.
+func (h *DelegationHandler) GetAuditTrail(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	userID := claims.UserID
+	if normalizeRole(claims.Role) == "admin" {
+		userID, _ = strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 500 {
+		limit = 100
+	}
+
+	actions, err := services.ListDelegatedActions(h.db, 0, userID, limit)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch delegated actions")
+		return
+	}
+	utils.RespondSuccess(w, "Delegated actions retrieved", actions)
+}
+
+func (h *DelegationHandler) loadDelegation(w http.ResponseWriter, r *http.Request) (models.SupervisorDelegation, *middleware.Claims, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return models.SupervisorDelegation{}, nil, false
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid delegation ID")
+		return models.SupervisorDelegation{}, nil, false
+	}
+	d, err := services.LoadDelegation(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Delegation not found")
+		return d, nil, false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return d, nil, false
+	}
+	return d, claims, true
+}
@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type DocumentJobHandler struct {
+	db *sql.DB
+}
+
+func NewDocumentJobHandler(db *sql.DB) *DocumentJobHandler {
+	return &DocumentJobHandler{db: db}
+}
+
+type createDocumentJobRequest struct {
+	Kind    string                    `json:"kind"` // certificates, reports, both
+	Filters models.DocumentJobFilters `json:"filters"`
+}
+
+// Create queues a batch job for every intern matching the filters.
+// Supervisors can only include their own interns.
+func (h *DocumentJobHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	var req createDocumentJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if !containsString([]string{models.DocumentJobCertificates, models.DocumentJobReports, models.DocumentJobBoth}, req.Kind) {
+		utils.RespondBadRequest(w, "kind must be certificates, reports or both")
+		return
+	}
+	if normalizeRole(claims.Role) == "pembimbing" {
+		req.Filters.SupervisorID = claims.UserID
+	}
+
+	internIDs, msg, err := h.matchInterns(req.Filters)
+	if msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to select interns")
+		return
+	}
+	if len(internIDs) == 0 {
+		utils.RespondBadRequest(w, "No interns match the filters")
+		return
+	}
+
+	filtersJSON, _ := json.Marshal(req.Filters)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"INSERT INTO document_jobs (kind, filters, total, requested_by) VALUES (?, ?, ?, ?)",
+		req.Kind, string(filtersJSON), len(internIDs), claims.UserID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create document job")
+		return
+	}
+	jobID, _ := res.LastInsertId()
+	for _, internID := range internIDs {
+		if _, err := tx.Exec(
+			"INSERT INTO document_job_items (job_id, intern_id) VALUES (?, ?)", jobID, internID,
+		); err != nil {
+			utils.RespondInternalError(w, "Failed to create document job")
+			return
+		}
+	}
+	if err := services.EnqueueDocumentJob(tx, jobID); err != nil {
+		utils.RespondInternalError(w, "Failed to queue document job")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to create document job")
+		return
+	}
+
+	job, _ := h.loadJob(jobID, false)
+	utils.RespondCreated(w, "Document job queued", job)
+}
+
+func (h *DocumentJobHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	query := "SELECT id FROM document_jobs"
+	args := []interface{}{}
+	if normalizeRole(claims.Role) != "admin" {
+		query += " WHERE requested_by = ?"
+		args = append(args, claims.UserID)
+	}
+	query += " ORDER BY id DESC LIMIT 50"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch document jobs")
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	jobs := []models.DocumentJob{}
+	for _, id := range ids {
+		if job, err := h.loadJob(id, false); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+
+	utils.RespondSuccess(w, "Document jobs retrieved", jobs)
+}
+
+func (h *DocumentJobHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.accessibleJob(w, r, true)
+	if !ok {
+		return
+	}
+	utils.RespondSuccess(w, "Document job retrieved", job)
+}
+
+// Cancel stops a job before its next intern; files already rendered are discarded
+func (h *DocumentJobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.accessibleJob(w, r, false)
+	if !ok {
+		return
+	}
+	if job.Status != models.DocumentJobQueued && job.Status != models.DocumentJobRunning {
+		utils.RespondBadRequest(w, "Only queued or running jobs can be cancelled")
+		return
+	}
+
+	if _, err := h.db.Exec(
+		"UPDATE document_jobs SET status = ?, finished_at = ? WHERE id = ? AND status IN ('queued', 'running')",
+		models.DocumentJobCancelled, time.Now(), job.ID,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to cancel document job")
+		return
+	}
+	_ = os.RemoveAll(services.DocumentJobDir(job.ID))
+
+	job, _ = h.loadJob(job.ID, false)
+	utils.RespondSuccess(w, "Document job cancelled", job)
+}
+
+// Download streams the ZIP of a completed job
+func (h *DocumentJobHandler) Download(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.accessibleJob(w, r, false)
+	if !ok {
+		return
+	}
+
+	var resultPath sql.NullString
+	_ = h.db.QueryRow("SELECT result_path FROM document_jobs WHERE id = ?", job.ID).Scan(&resultPath)
+	if job.Status != models.DocumentJobCompleted || !resultPath.Valid {
+		utils.RespondBadRequest(w, "Document job is not finished yet")
+		return
+	}
+
+	fullPath := filepath.Join(config.Loaded.Upload.Dir, resultPath.String)
+	if _, err := os.Stat(fullPath); err != nil {
+		utils.RespondNotFound(w, "Document job archive not found")
+		return
+	}
+
+	filename := fmt.Sprintf("Dokumen_%d_%s.zip", job.ID, job.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	http.ServeFile(w, r, fullPath)
+}
+
+// accessibleJob loads the job in the URL; non-admins only see their own jobs
+func (h *DocumentJobHandler) accessibleJob(w http.ResponseWriter, r *http.Request, withItems bool) (models.DocumentJob, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return models.DocumentJob{}, false
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	job, err := h.loadJob(id, withItems)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document job not found")
+		return job, false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return job, false
+	}
+	if normalizeRole(claims.Role) != "admin" && job.RequestedBy != claims.UserID {
+		utils.RespondForbidden(w, "You do not have access to this document job")
+		return job, false
+	}
+	return job, true
+}
+
+func (h *DocumentJobHandler) loadJob(id int64, withItems bool) (models.DocumentJob, error) {
+	var job models.DocumentJob
+	var filters, jobError, resultPath, requesterName sql.NullString
+	var startedAt, finishedAt sql.NullTime
+	err := h.db.QueryRow(
+		`SELECT dj.id, dj.kind, dj.filters, dj.status, dj.total, dj.processed, dj.succeeded, dj.failed, dj.error,
+		        dj.result_path, dj.requested_by, u.name, dj.created_at, dj.started_at, dj.finished_at
+		 FROM document_jobs dj
+		 LEFT JOIN users u ON dj.requested_by = u.id
+		 WHERE dj.id = ?`, id,
+	).Scan(&job.ID, &job.Kind, &filters, &job.Status, &job.Total, &job.Processed, &job.Succeeded, &job.Failed, &jobError,
+		&resultPath, &job.RequestedBy, &requesterName, &job.CreatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return job, err
+	}
+	if filters.Valid {
+		_ = json.Unmarshal([]byte(filters.String), &job.Filters)
+	}
+	job.Error = ptrStringFromNull(jobError)
+	job.RequesterName = requesterName.String
+	job.StartedAt = ptrTimeFromNull(startedAt)
+	job.FinishedAt = ptrTimeFromNull(finishedAt)
+	job.DownloadReady = job.Status == models.DocumentJobCompleted && resultPath.Valid
+	if job.Total > 0 {
+		job.Progress = job.Processed * 100 / job.Total
+	}
+
+	if !withItems {
+		return job, nil
+	}
+
+	rows, err := h.db.Query(
+		`SELECT dji.id, dji.intern_id, i.full_name, dji.status, dji.error, dji.processed_at
+		 FROM document_job_items dji
+		 JOIN interns i ON dji.intern_id = i.id
+		 WHERE dji.job_id = ?
+		 ORDER BY dji.id ASC`, id,
+	)
+	if err != nil {
+		return job, err
+	}
+	defer rows.Close()
+
+	job.Items = []models.DocumentJobItem{}
+	for rows.Next() {
+		var it models.DocumentJobItem
+		var itemError sql.NullString
+		var processedAt sql.NullTime
+		if err := rows.Scan(&it.ID, &it.InternID, &it.InternName, &it.Status, &itemError, &processedAt); err != nil {
+			continue
+		}
+		it.Error = ptrStringFromNull(itemError)
+		it.ProcessedAt = ptrTimeFromNull(processedAt)
+		job.Items = append(job.Items, it)
+	}
+	return job, nil
+}
+
+// matchInterns resolves job filters to intern ids. The string result is a
+// validation message for the client.
+func (h *DocumentJobHandler) matchInterns(f models.DocumentJobFilters) ([]int64, string, error) {
+	where := []string{}
+	args := []interface{}{}
+
+	status := f.Status
+	if status == "" {
+		status = "completed"
+	}
+	if status != "all" {
+		where = append(where, "status = ?")
+		args = append(args, status)
+	}
+	if f.EndFrom != "" {
+		if _, err := time.Parse("2006-01-02", f.EndFrom); err != nil {
+			return nil, "end_from must be YYYY-MM-DD", nil
+		}
+		where = append(where, "end_date >= ?")
+		args = append(args, f.EndFrom)
+	}
+	if f.EndTo != "" {
+		if _, err := time.Parse("2006-01-02", f.EndTo); err != nil {
+			return nil, "end_to must be YYYY-MM-DD", nil
+		}
+		where = append(where, "end_date <= ?")
+		args = append(args, f.EndTo)
+	}
+	if f.SupervisorID > 0 {
+		where = append(where, "supervisor_id = ?")
+		args = append(args, f.SupervisorID)
+	}
+	if school := strings.TrimSpace(f.School); school != "" {
+		where = append(where, "school LIKE ?")
+		args = append(args, "%"+school+"%")
+	}
+	if len(f.InternIDs) > 0 {
+		where = append(where, "id IN ("+placeholders(len(f.InternIDs))+")")
+		for _, id := range f.InternIDs {
+			args = append(args, id)
+		}
+	}
+
+	query := "SELECT id FROM interns"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY full_name ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, "", nil
+}
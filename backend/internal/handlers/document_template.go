@@ -0,0 +1,535 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type DocumentTemplateHandler struct {
+	db *sql.DB
+}
+
+func NewDocumentTemplateHandler(db *sql.DB) *DocumentTemplateHandler {
+	return &DocumentTemplateHandler{db: db}
+}
+
+type documentTemplateRequest struct {
+	Name           string                `json:"name"`
+	DocumentType   string                `json:"document_type"`
+	Orientation    string                `json:"orientation"`
+	PageSize       string                `json:"page_size"`
+	SignatoryName  string                `json:"signatory_name"`
+	SignatoryTitle string                `json:"signatory_title"`
+	Layout         models.DocumentLayout `json:"layout"`
+	IsDefault      bool                  `json:"is_default"`
+	IsActive       *bool                 `json:"is_active"`
+}
+
+// generateDocumentRequest carries values that only exist for one letter,
+// such as its number or a supervisor's recommendation text
+type generateDocumentRequest struct {
+	TemplateID   int64  `json:"template_id"`
+	LetterNumber string `json:"letter_number"`
+	Notes        string `json:"notes"`
+}
+
+var documentTypes = []string{
+	models.DocumentCertificate,
+	models.DocumentAcceptanceLetter,
+	models.DocumentCompletionLetter,
+	models.DocumentRecommendationLetter,
+}
+
+func (h *DocumentTemplateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM document_templates"
+	args := []interface{}{}
+	if docType := r.URL.Query().Get("type"); docType != "" {
+		query += " WHERE document_type = ?"
+		args = append(args, docType)
+	}
+	query += " ORDER BY document_type ASC, is_default DESC, name ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch document templates")
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	templates := []models.DocumentTemplate{}
+	for _, id := range ids {
+		if t, err := services.LoadDocumentTemplate(h.db, id); err == nil {
+			templates = append(templates, t)
+		}
+	}
+
+	utils.RespondSuccess(w, "Document templates retrieved", templates)
+}
+
+func (h *DocumentTemplateHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	t, err := services.LoadDocumentTemplate(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	utils.RespondSuccess(w, "Document template retrieved", t)
+}
+
+// GetBuiltIn returns the built-in layout of a document type, a starting
+// point for admins designing their own template
+func (h *DocumentTemplateHandler) GetBuiltIn(w http.ResponseWriter, r *http.Request) {
+	docType := mux.Vars(r)["type"]
+	if !containsString(documentTypes, docType) {
+		utils.RespondBadRequest(w, "Unknown document type")
+		return
+	}
+
+	utils.RespondSuccess(w, "Built-in document template retrieved", services.DefaultDocumentTemplate(docType))
+}
+
+func (h *DocumentTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	var req documentTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if msg := validateDocumentTemplate(&req); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+
+	isActive := req.IsActive == nil || *req.IsActive
+	layoutJSON, _ := json.Marshal(req.Layout)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault {
+		if _, err := tx.Exec("UPDATE document_templates SET is_default = 0 WHERE document_type = ?", req.DocumentType); err != nil {
+			utils.RespondInternalError(w, "Failed to create document template")
+			return
+		}
+	}
+	res, err := tx.Exec(
+		`INSERT INTO document_templates (name, document_type, orientation, page_size, signatory_name, signatory_title, layout, is_default, is_active, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		req.Name, req.DocumentType, req.Orientation, req.PageSize, nullIfEmpty(req.SignatoryName), nullIfEmpty(req.SignatoryTitle),
+		string(layoutJSON), req.IsDefault, isActive, claims.UserID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create document template")
+		return
+	}
+	id, _ := res.LastInsertId()
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to create document template")
+		return
+	}
+
+	t, _ := services.LoadDocumentTemplate(h.db, id)
+	utils.RespondCreated(w, "Document template created", t)
+}
+
+func (h *DocumentTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	existing, err := services.LoadDocumentTemplate(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	var req documentTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.DocumentType == "" {
+		req.DocumentType = existing.DocumentType
+	}
+	if msg := validateDocumentTemplate(&req); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+
+	isActive := existing.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	layoutJSON, _ := json.Marshal(req.Layout)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	if req.IsDefault {
+		if _, err := tx.Exec(
+			"UPDATE document_templates SET is_default = 0 WHERE document_type = ? AND id <> ?", req.DocumentType, id,
+		); err != nil {
+			utils.RespondInternalError(w, "Failed to update document template")
+			return
+		}
+	}
+	if _, err := tx.Exec(
+		`UPDATE document_templates
+		 SET name = ?, document_type = ?, orientation = ?, page_size = ?, signatory_name = ?, signatory_title = ?,
+		     layout = ?, is_default = ?, is_active = ?
+		 WHERE id = ?`,
+		req.Name, req.DocumentType, req.Orientation, req.PageSize, nullIfEmpty(req.SignatoryName), nullIfEmpty(req.SignatoryTitle),
+		string(layoutJSON), req.IsDefault, isActive, id,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to update document template")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to update document template")
+		return
+	}
+
+	t, _ := services.LoadDocumentTemplate(h.db, id)
+	utils.RespondSuccess(w, "Document template updated", t)
+}
+
+func (h *DocumentTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	existing, err := services.LoadDocumentTemplate(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM document_templates WHERE id = ?", id); err != nil {
+		utils.RespondInternalError(w, "Failed to delete document template")
+		return
+	}
+	for _, path := range []*string{existing.BackgroundPath, existing.LogoPath, existing.SignaturePath} {
+		if path != nil {
+			_ = utils.DeleteFile(*path)
+		}
+	}
+
+	utils.RespondSuccess(w, "Document template deleted", nil)
+}
+
+// UploadAsset stores the background, logo or signature image of a template
+func (h *DocumentTemplateHandler) UploadAsset(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	columns := map[string]string{
+		"background": "background_path",
+		"logo":       "logo_path",
+		"signature":  "signature_path",
+	}
+	column, ok := columns[vars["asset"]]
+	if !ok {
+		utils.RespondBadRequest(w, "Asset must be background, logo or signature")
+		return
+	}
+
+	existing, err := services.LoadDocumentTemplate(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		utils.RespondBadRequest(w, "Invalid multipart form")
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.RespondBadRequest(w, "file is required")
+		return
+	}
+	defer file.Close()
+
+	// gofpdf can only place PNG and JPEG images
+	if err := utils.ValidateFileType(header.Filename, []string{"png", "jpg", "jpeg"}); err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+
+	path, err := utils.UploadFile(file, header, "document_templates")
+	if err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+
+	if _, err := h.db.Exec("UPDATE document_templates SET "+column+" = ? WHERE id = ?", path, id); err != nil {
+		_ = utils.DeleteFile(path)
+		utils.RespondInternalError(w, "Failed to save asset")
+		return
+	}
+
+	var previous *string
+	switch column {
+	case "background_path":
+		previous = existing.BackgroundPath
+	case "logo_path":
+		previous = existing.LogoPath
+	case "signature_path":
+		previous = existing.SignaturePath
+	}
+	if previous != nil && *previous != path {
+		_ = utils.DeleteFile(*previous)
+	}
+
+	t, _ := services.LoadDocumentTemplate(h.db, id)
+	utils.RespondSuccess(w, "Document template asset uploaded", t)
+}
+
+// Preview renders a template, with an intern's data when intern_id is given
+// and with sample values otherwise
+func (h *DocumentTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	tpl, err := services.LoadDocumentTemplate(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	values := sampleDocumentValues()
+	if internID, _ := strconv.ParseInt(r.URL.Query().Get("intern_id"), 10, 64); internID > 0 {
+		values, err = services.DocumentValues(h.db, internID)
+		if err == sql.ErrNoRows {
+			utils.RespondNotFound(w, "Intern not found")
+			return
+		}
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to load intern data")
+			return
+		}
+	}
+
+	writeDocumentPDF(w, tpl, values, "Preview_"+sanitizeFilename(tpl.Name))
+}
+
+// Generate renders a certificate or letter for an intern. POST bodies may
+// carry a template_id, letter_number and notes; GET uses query parameters.
+func (h *DocumentTemplateHandler) Generate(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	internID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	docType := vars["type"]
+	if !containsString(documentTypes, docType) {
+		utils.RespondBadRequest(w, "Unknown document type")
+		return
+	}
+
+	var req generateDocumentRequest
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondBadRequest(w, "Invalid request body")
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		req.TemplateID, _ = strconv.ParseInt(q.Get("template_id"), 10, 64)
+		req.LetterNumber = q.Get("letter_number")
+		req.Notes = q.Get("notes")
+	}
+
+	values, err := services.DocumentValues(h.db, internID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load intern data")
+		return
+	}
+	if docType == models.DocumentCertificate && values["certificate_number"] == "" {
+		utils.RespondBadRequest(w, "Intern has no issued certificate")
+		return
+	}
+	values["letter_number"] = strings.TrimSpace(req.LetterNumber)
+	values["notes"] = strings.TrimSpace(req.Notes)
+	// Recommendation letters are signed by the supervisor unless the
+	// template names a signatory
+	if docType == models.DocumentRecommendationLetter {
+		values["signatory_name"] = values["supervisor_name"]
+		values["signatory_title"] = "Pembimbing Magang"
+	}
+
+	tpl, err := services.ResolveDocumentTemplate(h.db, docType, req.TemplateID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Document template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+
+	writeDocumentPDF(w, tpl, values, docType+"_"+sanitizeFilename(values["intern_name"]))
+}
+
+func writeDocumentPDF(w http.ResponseWriter, tpl models.DocumentTemplate, values map[string]string, name string) {
+	pdf, err := services.RenderDocument(tpl, values)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to render document: "+err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.pdf", name, time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	if err := pdf.Output(w); err != nil {
+		utils.RespondInternalError(w, "Failed to generate PDF")
+		return
+	}
+}
+
+func sampleDocumentValues() map[string]string {
+	today := services.FormatDateID(time.Now())
+	return map[string]string{
+		"intern_name":           "Nama Peserta",
+		"nis":                   "1234567890",
+		"school":                "Universitas Contoh",
+		"department":            "Teknik Informatika",
+		"email":                 "peserta@example.com",
+		"supervisor_name":       "Nama Pembimbing",
+		"start_date":            today,
+		"end_date":              today,
+		"period":                today + " - " + today,
+		"today":                 today,
+		"organization":          "Nama Instansi",
+		"certificate_number":    "MG-DSI/2026/0001",
+		"issue_date":            today,
+		"final_score":           "90.0",
+		"grade_letter":          "A",
+		"grade_predicate":       "Sangat Baik",
+		"verify_url":            "https://example.com/api/verify/MG-DSI/2026/0001",
+		"signature_fingerprint": "0123456789abcdef",
+		"letter_number":         "001/MG/2026",
+		"notes":                 "Catatan tambahan dari pembimbing.",
+		"signatory_name":        "Nama Penandatangan",
+		"signatory_title":       "Jabatan Penandatangan",
+	}
+}
+
+func validateDocumentTemplate(req *documentTemplateRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if !containsString(documentTypes, req.DocumentType) {
+		return "document_type must be one of " + strings.Join(documentTypes, ", ")
+	}
+	if req.Orientation == "" {
+		req.Orientation = "P"
+	}
+	if req.Orientation != "P" && req.Orientation != "L" {
+		return "orientation must be P or L"
+	}
+	if req.PageSize == "" {
+		req.PageSize = "A4"
+	}
+	if !containsString([]string{"A3", "A4", "A5", "Letter", "Legal"}, req.PageSize) {
+		return "page_size must be A3, A4, A5, Letter or Legal"
+	}
+	if len(req.Layout.Elements) == 0 {
+		return "layout must have at least one element"
+	}
+
+	for i, el := range req.Layout.Elements {
+		prefix := fmt.Sprintf("layout element %d: ", i+1)
+		switch el.Type {
+		case "text":
+			if strings.TrimSpace(el.Text) == "" {
+				return prefix + "text is required"
+			}
+		case "image":
+			if el.Source != "logo" && el.Source != "signature" {
+				return prefix + "image source must be logo or signature"
+			}
+			if el.W <= 0 && el.H <= 0 {
+				return prefix + "image needs a width or height"
+			}
+		case "qr", "line":
+		default:
+			return prefix + "type must be text, image, qr or line"
+		}
+		if el.X < 0 || el.Y < 0 || el.W < 0 || el.H < 0 || el.Size < 0 || el.LineHeight < 0 {
+			return prefix + "positions and sizes cannot be negative"
+		}
+		if el.Font != "" && !containsString([]string{"Helvetica", "Arial", "Times", "Courier"}, el.Font) {
+			return prefix + "font must be Helvetica, Arial, Times or Courier"
+		}
+		if strings.Trim(strings.ToUpper(el.Style), "BIU") != "" {
+			return prefix + "style may only contain B, I and U"
+		}
+		req.Layout.Elements[i].Style = strings.ToUpper(el.Style)
+		if el.Align != "" && !containsString([]string{"L", "C", "R", "J"}, el.Align) {
+			return prefix + "align must be L, C, R or J"
+		}
+		if el.Color != "" {
+			c := strings.TrimPrefix(el.Color, "#")
+			if _, err := strconv.ParseUint(c, 16, 32); err != nil || len(c) != 6 {
+				return prefix + "color must be #RRGGBB"
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,651 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// EvaluationHandler runs evaluation cycles: intern self-assessment,
+// supervisor assessment and sign-off
+type EvaluationHandler struct {
+	db *sql.DB
+}
+
+func NewEvaluationHandler(db *sql.DB) *EvaluationHandler {
+	return &EvaluationHandler{db: db}
+}
+
+// CreateCycle opens a cycle for the interns matching the cohort filter
+func (h *EvaluationHandler) CreateCycle(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	var req models.CreateEvaluationCycleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		utils.RespondBadRequest(w, "name is required")
+		return
+	}
+	if req.Type != "" && !containsString([]string{"mid_term", "final", "custom"}, req.Type) {
+		utils.RespondBadRequest(w, "type must be mid_term, final or custom")
+		return
+	}
+	selfDue, err := time.Parse("2006-01-02", req.SelfDueDate)
+	if err != nil {
+		utils.RespondBadRequest(w, "self_due_date must be YYYY-MM-DD")
+		return
+	}
+	supervisorDue, err := time.Parse("2006-01-02", req.SupervisorDueDate)
+	if err != nil {
+		utils.RespondBadRequest(w, "supervisor_due_date must be YYYY-MM-DD")
+		return
+	}
+	if supervisorDue.Before(selfDue) {
+		utils.RespondBadRequest(w, "supervisor_due_date cannot be before self_due_date")
+		return
+	}
+	for _, d := range []string{req.Cohort.StartDateFrom, req.Cohort.StartDateTo} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			utils.RespondBadRequest(w, "cohort start dates must be YYYY-MM-DD")
+			return
+		}
+	}
+
+	cycleID, count, err := services.OpenEvaluationCycle(h.db, req, selfDue, supervisorDue, claims.UserID)
+	if errors.Is(err, services.ErrEmptyCohort) || errors.Is(err, services.ErrRubricNotFound) {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to open evaluation cycle")
+		return
+	}
+
+	cycle, _ := h.loadCycle(cycleID)
+	utils.RespondCreated(w, strconv.Itoa(count)+" evaluations opened", cycle)
+}
+
+func (h *EvaluationHandler) loadCycle(id int64) (models.EvaluationCycle, error) {
+	var c models.EvaluationCycle
+	var description, cohort sql.NullString
+	var createdBy sql.NullInt64
+	err := h.db.QueryRow(
+		`SELECT id, name, type, description, self_due_date, supervisor_due_date, cohort, status, created_by, created_at, updated_at
+		 FROM evaluation_cycles WHERE id = ?`, id,
+	).Scan(&c.ID, &c.Name, &c.Type, &description, &c.SelfDueDate, &c.SupervisorDueDate, &cohort, &c.Status, &createdBy, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return c, err
+	}
+	c.Description = ptrStringFromNull(description)
+	c.CreatedBy = ptrInt64FromNull(createdBy)
+	if cohort.Valid {
+		var filter models.EvaluationCohort
+		if json.Unmarshal([]byte(cohort.String), &filter) == nil {
+			c.Cohort = &filter
+		}
+	}
+
+	c.Progress = map[string]int{}
+	rows, err := h.db.Query("SELECT status, COUNT(*) FROM evaluations WHERE cycle_id = ? GROUP BY status", id)
+	if err != nil {
+		return c, nil
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var count int
+		if rows.Scan(&status, &count) == nil {
+			c.Progress[status] = count
+		}
+	}
+	return c, nil
+}
+
+// GetCycles lists cycles, newest first, with per-status progress
+func (h *EvaluationHandler) GetCycles(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM evaluation_cycles"
+	args := []interface{}{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	rows, err := h.db.Query(query+" ORDER BY created_at DESC, id DESC", args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch evaluation cycles")
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	cycles := []models.EvaluationCycle{}
+	for _, id := range ids {
+		if c, err := h.loadCycle(id); err == nil {
+			cycles = append(cycles, c)
+		}
+	}
+	utils.RespondSuccess(w, "Evaluation cycles retrieved", cycles)
+}
+
+func (h *EvaluationHandler) GetCycle(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	c, err := h.loadCycle(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Evaluation cycle not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	utils.RespondSuccess(w, "Evaluation cycle retrieved", c)
+}
+
+// CloseCycle stops further submissions in a cycle
+func (h *EvaluationHandler) CloseCycle(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	res, err := h.db.Exec("UPDATE evaluation_cycles SET status = 'closed' WHERE id = ?", id)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to close evaluation cycle")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := h.loadCycle(id); err == sql.ErrNoRows {
+			utils.RespondNotFound(w, "Evaluation cycle not found")
+			return
+		}
+	}
+	c, _ := h.loadCycle(id)
+	utils.RespondSuccess(w, "Evaluation cycle closed", c)
+}
+
+const evaluationSelect = `
+	SELECT e.id, e.cycle_id, c.name, c.type, c.self_due_date, c.supervisor_due_date, c.status,
+	       e.intern_id, iu.name, su.name, i.user_id, i.supervisor_id, e.rubric_version_id, e.status,
+	       e.self_comments, e.self_submitted_at, e.supervisor_comments, e.strengths, e.improvements,
+	       e.supervisor_submitted_at, e.signed_off_at, ou.name, e.assessment_id, e.created_at
+	FROM evaluations e
+	JOIN evaluation_cycles c ON e.cycle_id = c.id
+	JOIN interns i ON e.intern_id = i.id
+	LEFT JOIN users iu ON i.user_id = iu.id
+	LEFT JOIN users su ON i.supervisor_id = su.id
+	LEFT JOIN users ou ON e.signed_off_by = ou.id
+`
+
+// evaluationRow carries the access data alongside the evaluation
+type evaluationRow struct {
+	models.Evaluation
+	cycleStatus  string
+	internUserID int64
+	supervisorID sql.NullInt64
+}
+
+func scanEvaluation(row interface{ Scan(...interface{}) error }) (evaluationRow, error) {
+	var e evaluationRow
+	var supervisorName, selfComments, supervisorComments, strengths, improvements, signedOffBy sql.NullString
+	var selfAt, supervisorAt, signedOffAt sql.NullTime
+	var assessmentID sql.NullInt64
+	err := row.Scan(
+		&e.ID, &e.CycleID, &e.CycleName, &e.CycleType, &e.SelfDueDate, &e.SupervisorDueDate, &e.cycleStatus,
+		&e.InternID, &e.InternName, &supervisorName, &e.internUserID, &e.supervisorID, &e.RubricVersionID, &e.Status,
+		&selfComments, &selfAt, &supervisorComments, &strengths, &improvements,
+		&supervisorAt, &signedOffAt, &signedOffBy, &assessmentID, &e.CreatedAt,
+	)
+	if err != nil {
+		return e, err
+	}
+	e.SupervisorName = supervisorName.String
+	e.SelfComments = ptrStringFromNull(selfComments)
+	e.SelfSubmittedAt = ptrTimeFromNull(selfAt)
+	e.SupervisorComments = ptrStringFromNull(supervisorComments)
+	e.Strengths = ptrStringFromNull(strengths)
+	e.Improvements = ptrStringFromNull(improvements)
+	e.SupervisorSubmittedAt = ptrTimeFromNull(supervisorAt)
+	e.SignedOffAt = ptrTimeFromNull(signedOffAt)
+	e.SignedOffByName = signedOffBy.String
+	e.AssessmentID = ptrInt64FromNull(assessmentID)
+
+	today := time.Now().Format("2006-01-02")
+	switch e.Status {
+	case models.EvaluationPendingSelf:
+		e.Overdue = e.SelfDueDate.Format("2006-01-02") < today
+	case models.EvaluationPendingSupervisor, models.EvaluationInReview:
+		e.Overdue = e.SupervisorDueDate.Format("2006-01-02") < today
+	}
+	return e, nil
+}
+
+// hideFromIntern drops the supervisor's input until the evaluation has
+// been signed off
+func (e *evaluationRow) hideFromIntern() {
+	if e.Status == models.EvaluationSignedOff {
+		return
+	}
+	e.SupervisorComments = nil
+	e.Strengths = nil
+	e.Improvements = nil
+	e.AssessmentID = nil
+}
+
+// GetAll lists evaluations: interns see their own, supervisors their
+// interns', admins everything. Filters: cycle_id, status, intern_id.
+func (h *EvaluationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	where := []string{}
+	args := []interface{}{}
+	role := normalizeRole(claims.Role)
+	switch role {
+	case "intern":
+		where = append(where, "i.user_id = ?")
+		args = append(args, claims.UserID)
+	case "pembimbing":
+		where = append(where, services.SupervisedInternCond)
+		args = append(args, services.SupervisedInternArgs(claims.UserID)...)
+	}
+	q := r.URL.Query()
+	if v, err := strconv.ParseInt(q.Get("cycle_id"), 10, 64); err == nil {
+		where = append(where, "e.cycle_id = ?")
+		args = append(args, v)
+	}
+	if v, err := strconv.ParseInt(q.Get("intern_id"), 10, 64); err == nil {
+		where = append(where, "e.intern_id = ?")
+		args = append(args, v)
+	}
+	if status := q.Get("status"); status != "" {
+		where = append(where, "e.status = ?")
+		args = append(args, status)
+	}
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	if err := h.db.QueryRow(
+		"SELECT COUNT(*) FROM evaluations e JOIN interns i ON e.intern_id = i.id"+whereClause, args...,
+	).Scan(&total); err != nil {
+		utils.RespondInternalError(w, "Failed to count evaluations")
+		return
+	}
+
+	args = append(args, limit, offset)
+	rows, err := h.db.Query(evaluationSelect+whereClause+" ORDER BY c.supervisor_due_date DESC, e.id DESC LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch evaluations")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Evaluation{}
+	for rows.Next() {
+		e, err := scanEvaluation(rows)
+		if err != nil {
+			continue
+		}
+		if role == "intern" {
+			e.hideFromIntern()
+		}
+		list = append(list, e.Evaluation)
+	}
+	utils.RespondPaginated(w, list, utils.CalculatePagination(page, limit, total))
+}
+
+// loadEvaluation loads the evaluation in the URL and checks read access,
+// which co-supervisors have too. The returned flag tells whether the caller
+// is on the reviewing side (supervisor or admin); scoring and sign-off also
+// need canReview.
+func (h *EvaluationHandler) loadEvaluation(w http.ResponseWriter, r *http.Request) (evaluationRow, *middleware.Claims, bool, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return evaluationRow{}, nil, false, false
+	}
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	e, err := scanEvaluation(h.db.QueryRow(evaluationSelect+" WHERE e.id = ?", id))
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Evaluation not found")
+		return e, nil, false, false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return e, nil, false, false
+	}
+
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		if e.internUserID != claims.UserID {
+			utils.RespondForbidden(w, "You do not have access to this evaluation")
+			return e, nil, false, false
+		}
+		return e, claims, false, true
+	case "pembimbing":
+		if !supervisesIntern(h.db, claims.UserID, e.InternID, e.supervisorID) {
+			utils.RespondForbidden(w, "You do not have access to this evaluation")
+			return e, nil, false, false
+		}
+	}
+	return e, claims, true, true
+}
+
+// canReview tells whether the caller may score or sign off an evaluation:
+// an admin, or the primary supervisor or their delegate
+func (h *EvaluationHandler) canReview(claims *middleware.Claims, e evaluationRow) bool {
+	return normalizeRole(claims.Role) != "pembimbing" || coversSupervisor(h.db, claims.UserID, e.supervisorID)
+}
+
+// GetByID returns an evaluation with the rubric and the self and
+// supervisor scores side by side. Interns see the supervisor's scores only
+// after sign-off.
+func (h *EvaluationHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	e, _, reviewer, ok := h.loadEvaluation(w, r)
+	if !ok {
+		return
+	}
+	if err := h.attachScores(&e, reviewer); err != nil {
+		utils.RespondInternalError(w, "Failed to load evaluation scores")
+		return
+	}
+	utils.RespondSuccess(w, "Evaluation retrieved", e.Evaluation)
+}
+
+func (h *EvaluationHandler) attachScores(e *evaluationRow, reviewer bool) error {
+	rubric, err := services.LoadRubricVersion(h.db, e.RubricVersionID)
+	if err != nil {
+		return err
+	}
+	scores, err := services.LoadEvaluationScores(h.db, e.ID)
+	if err != nil {
+		return err
+	}
+	showSupervisor := reviewer || e.Status == models.EvaluationSignedOff
+	if !reviewer {
+		e.hideFromIntern()
+	}
+
+	e.Rubric = rubric
+	e.Criteria = []models.EvaluationCriterion{}
+	for _, c := range rubric.Criteria {
+		item := models.EvaluationCriterion{CriterionID: c.ID, Code: c.Code, Name: c.Name, Weight: c.Weight}
+		if v, ok := scores[models.EvaluationSourceSelf][c.Code]; ok {
+			item.SelfScore = &v
+		}
+		if v, ok := scores[models.EvaluationSourceSupervisor][c.Code]; ok && showSupervisor {
+			item.SupervisorScore = &v
+			if item.SelfScore != nil {
+				diff := v - *item.SelfScore
+				item.Difference = &diff
+			}
+		}
+		e.Criteria = append(e.Criteria, item)
+	}
+
+	result := &models.EvaluationResult{}
+	if res, err := services.ScoreRubric(rubric, scores[models.EvaluationSourceSelf]); err == nil {
+		result.SelfScore = &res.RubricScore
+	}
+	if showSupervisor {
+		if res, err := services.ScoreRubric(rubric, scores[models.EvaluationSourceSupervisor]); err == nil {
+			result.SupervisorScore = &res.RubricScore
+			result.Percent = &res.Percent
+			result.Category = res.Category.Category
+			result.CategoryLabel = res.Category.Label
+		}
+	}
+	e.Result = result
+	return nil
+}
+
+func decodeEvaluationScores(w http.ResponseWriter, r *http.Request) (models.EvaluationScoresRequest, bool) {
+	var req models.EvaluationScoresRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return req, false
+	}
+	if len(req.Scores) == 0 {
+		utils.RespondBadRequest(w, "scores is required")
+		return req, false
+	}
+	return req, true
+}
+
+// SubmitSelf saves the intern's self-assessment. It can be revised until
+// the supervisor submits theirs.
+func (h *EvaluationHandler) SubmitSelf(w http.ResponseWriter, r *http.Request) {
+	e, _, reviewer, ok := h.loadEvaluation(w, r)
+	if !ok {
+		return
+	}
+	if reviewer {
+		utils.RespondForbidden(w, "Only the intern can submit a self-assessment")
+		return
+	}
+	if e.cycleStatus == "closed" {
+		utils.RespondBadRequest(w, services.ErrEvaluationClosed.Error())
+		return
+	}
+	if e.Status != models.EvaluationPendingSelf && e.Status != models.EvaluationPendingSupervisor {
+		utils.RespondBadRequest(w, "The self-assessment can no longer be changed")
+		return
+	}
+	req, ok := decodeEvaluationScores(w, r)
+	if !ok {
+		return
+	}
+
+	rubric, err := services.LoadRubricVersion(h.db, e.RubricVersionID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load rubric")
+		return
+	}
+	result, err := services.ScoreRubric(rubric, req.Scores)
+	if err != nil {
+		utils.RespondBadRequest(w, scoreErrorMessage(err))
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE evaluations SET status = ?, self_comments = ?, self_submitted_at = NOW()
+		 WHERE id = ? AND status IN (?, ?)`,
+		models.EvaluationPendingSupervisor, nullIfEmpty(req.Comments),
+		e.ID, models.EvaluationPendingSelf, models.EvaluationPendingSupervisor,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to save self-assessment")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		utils.RespondBadRequest(w, "The self-assessment can no longer be changed")
+		return
+	}
+	if err := services.SaveEvaluationScores(tx, e.ID, models.EvaluationSourceSelf, result.Scores); err != nil {
+		utils.RespondInternalError(w, "Failed to save self-assessment")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to save self-assessment")
+		return
+	}
+
+	if e.Status == models.EvaluationPendingSelf && e.supervisorID.Valid {
+		_ = createNotification(h.db, e.supervisorID.Int64, "info", "Penilaian Diri Masuk",
+			e.InternName+" telah mengisi penilaian diri untuk "+e.CycleName+".",
+			"/evaluations/"+strconv.FormatInt(e.ID, 10), nil)
+	}
+	h.respond(w, r, e.ID, false, "Self-assessment submitted")
+}
+
+// SubmitSupervisor saves the supervisor's scores. It is available once the
+// intern has submitted, or after the self-assessment deadline has passed.
+func (h *EvaluationHandler) SubmitSupervisor(w http.ResponseWriter, r *http.Request) {
+	e, claims, reviewer, ok := h.loadEvaluation(w, r)
+	if !ok {
+		return
+	}
+	if !reviewer || !h.canReview(claims, e) {
+		utils.RespondForbidden(w, "Only the supervisor can submit this assessment")
+		return
+	}
+	if e.cycleStatus == "closed" {
+		utils.RespondBadRequest(w, services.ErrEvaluationClosed.Error())
+		return
+	}
+	switch e.Status {
+	case models.EvaluationPendingSupervisor, models.EvaluationInReview:
+	case models.EvaluationPendingSelf:
+		if !e.Overdue {
+			utils.RespondBadRequest(w, "Waiting for the intern's self-assessment until "+services.FormatDateID(e.SelfDueDate))
+			return
+		}
+	default:
+		utils.RespondBadRequest(w, "This evaluation has already been signed off")
+		return
+	}
+	req, ok := decodeEvaluationScores(w, r)
+	if !ok {
+		return
+	}
+
+	rubric, err := services.LoadRubricVersion(h.db, e.RubricVersionID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load rubric")
+		return
+	}
+	result, err := services.ScoreRubric(rubric, req.Scores)
+	if err != nil {
+		utils.RespondBadRequest(w, scoreErrorMessage(err))
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE evaluations
+		 SET status = ?, supervisor_comments = ?, strengths = ?, improvements = ?,
+		     supervisor_submitted_at = NOW(), supervisor_submitted_by = ?
+		 WHERE id = ? AND status <> ?`,
+		models.EvaluationInReview, nullIfEmpty(req.Comments), nullIfEmpty(req.Strengths), nullIfEmpty(req.Improvements),
+		claims.UserID, e.ID, models.EvaluationSignedOff,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to save assessment")
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		utils.RespondBadRequest(w, "This evaluation has already been signed off")
+		return
+	}
+	if err := services.SaveEvaluationScores(tx, e.ID, models.EvaluationSourceSupervisor, result.Scores); err != nil {
+		utils.RespondInternalError(w, "Failed to save assessment")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to save assessment")
+		return
+	}
+	if e.supervisorID.Valid {
+		services.NoteDelegatedAction(h.db, claims.UserID, e.supervisorID.Int64, "evaluation.supervisor_assessment", "evaluation", e.ID)
+	}
+	h.respond(w, r, e.ID, true, "Supervisor assessment saved")
+}
+
+// SignOff locks the evaluation, records it as an assessment and releases
+// the result to the intern
+func (h *EvaluationHandler) SignOff(w http.ResponseWriter, r *http.Request) {
+	e, claims, reviewer, ok := h.loadEvaluation(w, r)
+	if !ok {
+		return
+	}
+	if !reviewer || !h.canReview(claims, e) {
+		utils.RespondForbidden(w, "Only the supervisor can sign off this evaluation")
+		return
+	}
+
+	if _, err := services.SignOffEvaluation(h.db, e.ID, claims.UserID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrEvaluationState):
+			utils.RespondBadRequest(w, "Only evaluations with a supervisor assessment can be signed off")
+		case errors.Is(err, services.ErrEvaluationClosed):
+			utils.RespondBadRequest(w, err.Error())
+		case errors.Is(err, services.ErrInvalidScores):
+			utils.RespondBadRequest(w, scoreErrorMessage(err))
+		default:
+			utils.RespondInternalError(w, "Failed to sign off evaluation")
+		}
+		return
+	}
+
+	if e.supervisorID.Valid {
+		services.NoteDelegatedAction(h.db, claims.UserID, e.supervisorID.Int64, "evaluation.sign_off", "evaluation", e.ID)
+	}
+	_ = createNotification(h.db, e.internUserID, models.NotificationAssessmentCreated, "Hasil Evaluasi Tersedia",
+		"Hasil evaluasi "+e.CycleName+" Anda telah ditandatangani pembimbing.",
+		"/evaluations/"+strconv.FormatInt(e.ID, 10), map[string]interface{}{"evaluation_id": e.ID})
+	h.respond(w, r, e.ID, true, "Evaluation signed off")
+}
+
+func (h *EvaluationHandler) respond(w http.ResponseWriter, r *http.Request, id int64, reviewer bool, message string) {
+	e, err := scanEvaluation(h.db.QueryRow(evaluationSelect+" WHERE e.id = ?", id))
+	if err != nil {
+		utils.RespondSuccess(w, message, nil)
+		return
+	}
+	if err := h.attachScores(&e, reviewer); err != nil {
+		utils.RespondSuccess(w, message, nil)
+		return
+	}
+	utils.RespondSuccess(w, message, e.Evaluation)
+}
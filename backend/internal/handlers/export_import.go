@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/xuri/excelize/v2"
@@ -58,7 +59,9 @@ func (h *ExportImportHandler) ExportInterns(w http.ResponseWriter, r *http.Reque
 	headers := []string{
 		"ID", "Nama", "Email", "NIS", "Sekolah", "Jurusan", "No. Telepon", "Alamat", "Pembimbing",
 		"Tanggal Mulai", "Tanggal Selesai", "Status", "Total Tugas", "Tugas Selesai", "Tingkat Kehadiran (%)", "Skor Rata-rata",
+		"Nilai Akhir", "Predikat",
 	}
+	policy := services.LoadGradingPolicy(h.db)
 
 	var data [][]string
 	for rows.Next() {
@@ -87,6 +90,12 @@ func (h *ExportImportHandler) ExportInterns(w http.ResponseWriter, r *http.Reque
 			attendanceRate = (float64(presentAtt) / float64(totalAttendance)) * 100
 		}
 
+		finalScore, predicate := "-", "-"
+		if grade, err := services.GradeIntern(h.db, policy, id); err == nil && grade.FinalScore != nil {
+			finalScore = fmt.Sprintf("%.2f", *grade.FinalScore)
+			predicate = grade.Letter + " - " + grade.Predicate
+		}
+
 		data = append(data, []string{
 			strconv.FormatInt(id, 10),
 			fullName,
@@ -104,6 +113,8 @@ func (h *ExportImportHandler) ExportInterns(w http.ResponseWriter, r *http.Reque
 			strconv.FormatInt(completedTasks, 10),
 			fmt.Sprintf("%.1f", attendanceRate),
 			formatFloat(avgScore),
+			finalScore,
+			predicate,
 		})
 	}
 
@@ -416,10 +427,35 @@ func (h *ExportImportHandler) ImportInterns(w http.ResponseWriter, r *http.Reque
 		}
 		userID, _ := res.LastInsertId()
 
+		var institutionID sql.NullInt64
+		if school := cell(row, 4); school != "" {
+			id, err := services.ResolveInstitution(tx, school)
+			if err != nil {
+				tx.Rollback()
+				errors = append(errors, fmt.Sprintf("Row %d: failed to resolve institution", i+1))
+				skipped++
+				continue
+			}
+			institutionID = sql.NullInt64{Int64: id, Valid: true}
+		}
+		if institutionID.Valid && status == "active" {
+			if err := services.CheckInstitutionQuota(tx, institutionID.Int64); err != nil {
+				tx.Rollback()
+				message := "failed to check institution quota"
+				if prefix := services.ErrInvalidInstitution.Error() + ": "; strings.HasPrefix(err.Error(), prefix) {
+					message = strings.TrimPrefix(err.Error(), prefix)
+				}
+				errors = append(errors, fmt.Sprintf("Row %d: %s", i+1, message))
+				skipped++
+				continue
+			}
+		}
+
 		_, err = tx.Exec(
-			`INSERT INTO interns (user_id, supervisor_id, full_name, nis, school, department, phone, address, start_date, end_date, status)
-			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			`INSERT INTO interns (user_id, institution_id, supervisor_id, full_name, nis, school, department, phone, address, start_date, end_date, status)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			userID,
+			institutionID,
 			nullInt64(resolvedSupervisorID),
 			name,
 			cell(row, 3),
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// GradeHandler exposes the grading policy and interns' final grades
+type GradeHandler struct {
+	db *sql.DB
+}
+
+func NewGradeHandler(db *sql.DB) *GradeHandler {
+	return &GradeHandler{db: db}
+}
+
+// GetPolicy returns the grading policy in effect
+func (h *GradeHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	utils.RespondSuccess(w, "Grading policy retrieved", services.LoadGradingPolicy(h.db))
+}
+
+// UpdatePolicy replaces the grading policy. Issued certificates keep the
+// score they were issued with.
+func (h *GradeHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	var policy models.GradingPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if err := services.SaveGradingPolicy(h.db, policy); err != nil {
+		if errors.Is(err, services.ErrInvalidGradingPolicy) {
+			utils.RespondBadRequest(w, err.Error())
+			return
+		}
+		utils.RespondInternalError(w, "Failed to save grading policy")
+		return
+	}
+	utils.RespondSuccess(w, "Grading policy updated", services.LoadGradingPolicy(h.db))
+}
+
+// GetBreakdown returns an intern's final grade with the score and weight
+// of each component. Interns can only see their own grade and supervisors
+// those of their interns.
+func (h *GradeHandler) GetBreakdown(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	internID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid intern ID")
+		return
+	}
+
+	var userID int64
+	var supervisorID sql.NullInt64
+	err = h.db.QueryRow("SELECT user_id, supervisor_id FROM interns WHERE id = ?", internID).Scan(&userID, &supervisorID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		if userID != claims.UserID {
+			utils.RespondForbidden(w, "You can only view your own grade")
+			return
+		}
+	case "pembimbing":
+		if !supervisesIntern(h.db, claims.UserID, internID, supervisorID) {
+			utils.RespondForbidden(w, "You can only view grades of your interns")
+			return
+		}
+	}
+
+	grade, err := services.ComputeGrade(h.db, internID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to compute grade")
+		return
+	}
+	utils.RespondSuccess(w, "Grade retrieved", grade)
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"testing"
 	"time"
+
+	"dsi_interna_sys/internal/models"
 )
 
 func TestNullIntToPtr(t *testing.T) {
@@ -50,3 +52,73 @@ func TestNullTimeToPtr(t *testing.T) {
 		t.Fatalf("nullTimeToPtr(invalid) = %v, want nil", got)
 	}
 }
+
+func TestExtractMentions(t *testing.T) {
+	got := extractMentions("Tolong cek @Budi@Example.com dan @siti@example.co.id. Sekali lagi @budi@example.com")
+	want := []string{"budi@example.com", "siti@example.co.id"}
+	if len(got) != len(want) {
+		t.Fatalf("extractMentions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("extractMentions() = %v, want %v", got, want)
+		}
+	}
+
+	if got := extractMentions("email me at budi@example.com"); len(got) != 0 {
+		t.Fatalf("extractMentions(plain email) = %v, want none", got)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := diffLines("a\nb\nc", "a\nc\nd")
+	want := []string{"equal:a", "removed:b", "equal:c", "added:d"}
+	if len(got) != len(want) {
+		t.Fatalf("diffLines() = %v, want %v", got, want)
+	}
+	for i, line := range got {
+		if line.Op+":"+line.Text != want[i] {
+			t.Fatalf("diffLines()[%d] = %s:%s, want %s", i, line.Op, line.Text, want[i])
+		}
+	}
+}
+
+func TestRollupProgress(t *testing.T) {
+	cases := []struct {
+		status      string
+		done, total int
+		subtasks    []int
+		want        int
+	}{
+		{"pending", 0, 0, nil, 0},
+		{"completed", 0, 4, nil, 100},
+		{"in_progress", 1, 4, nil, 25},
+		{"in_progress", 1, 2, []int{100, 0}, 50},
+		{"in_progress", 0, 0, []int{50, 100, 0}, 50},
+	}
+	for _, c := range cases {
+		if got := rollupProgress(c.status, c.done, c.total, c.subtasks); got != c.want {
+			t.Fatalf("rollupProgress(%q, %d, %d, %v) = %d, want %d", c.status, c.done, c.total, c.subtasks, got, c.want)
+		}
+	}
+}
+
+func TestImprovementTrend(t *testing.T) {
+	summary := func(days int, attendance, punctuality float64) models.MonthlySummary {
+		return models.MonthlySummary{TotalWorkingDays: days, AttendanceRate: attendance, PunctualityRate: punctuality}
+	}
+	cases := []struct {
+		current, previous models.MonthlySummary
+		want              string
+	}{
+		{summary(20, 95, 90), summary(20, 80, 85), "improving"},
+		{summary(20, 80, 70), summary(20, 90, 80), "declining"},
+		{summary(20, 90, 88), summary(20, 88, 86), "stable"},
+		{summary(20, 95, 90), summary(0, 0, 0), "stable"},
+	}
+	for _, c := range cases {
+		if got := improvementTrend(c.current, c.previous); got != c.want {
+			t.Fatalf("improvementTrend(%+v, %+v) = %q, want %q", c.current, c.previous, got, c.want)
+		}
+	}
+}
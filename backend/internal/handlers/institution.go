@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// InstitutionHandler manages partner institutions, their agreements and
+// contacts, and the clean-up of free-text school names
+type InstitutionHandler struct {
+	db *sql.DB
+}
+
+func NewInstitutionHandler(db *sql.DB) *InstitutionHandler {
+	return &InstitutionHandler{db: db}
+}
+
+// respondInstitutionError maps service errors to responses
+func respondInstitutionError(w http.ResponseWriter, err error, notFound, failed string) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		utils.RespondNotFound(w, notFound)
+	case errors.Is(err, services.ErrInvalidInstitution):
+		utils.RespondBadRequest(w, err.Error())
+	case errors.Is(err, services.ErrInstitutionInUse):
+		utils.RespondBadRequest(w, "Institution still has interns or campus accounts; merge it into another instead")
+	default:
+		utils.RespondInternalError(w, failed)
+	}
+}
+
+func pathID(w http.ResponseWriter, r *http.Request, key, message string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[key], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, message)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetAll lists institutions, optionally filtered by ?search=
+func (h *InstitutionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	list, err := services.ListInstitutions(h.db, r.URL.Query().Get("search"))
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch institutions")
+		return
+	}
+	utils.RespondSuccess(w, "Institutions retrieved", list)
+}
+
+// GetByID returns an institution with its agreements, contacts and aliases
+func (h *InstitutionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	inst, err := services.LoadInstitution(h.db, id)
+	if err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Failed to fetch institution")
+		return
+	}
+	utils.RespondSuccess(w, "Institution retrieved", inst)
+}
+
+// Create adds an institution
+func (h *InstitutionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	h.save(w, r, 0)
+}
+
+// Update edits an institution. Aliases are replaced when given.
+func (h *InstitutionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	h.save(w, r, id)
+}
+
+func (h *InstitutionHandler) save(w http.ResponseWriter, r *http.Request, id int64) {
+	var req models.InstitutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	savedID, err := services.SaveInstitution(h.db, id, req)
+	if err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Failed to save institution")
+		return
+	}
+	inst, _ := services.LoadInstitution(h.db, savedID)
+	if id == 0 {
+		utils.RespondCreated(w, "Institution created", inst)
+		return
+	}
+	utils.RespondSuccess(w, "Institution updated", inst)
+}
+
+// Delete removes an institution nobody refers to
+func (h *InstitutionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	if err := services.DeleteInstitution(h.db, id); err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Failed to delete institution")
+		return
+	}
+	utils.RespondSuccess(w, "Institution deleted", nil)
+}
+
+// CreateAgreement records a partnership agreement (MoU)
+func (h *InstitutionHandler) CreateAgreement(w http.ResponseWriter, r *http.Request) {
+	h.saveAgreement(w, r, 0)
+}
+
+// UpdateAgreement edits an agreement
+func (h *InstitutionHandler) UpdateAgreement(w http.ResponseWriter, r *http.Request) {
+	agreementID, ok := pathID(w, r, "agreementId", "Invalid agreement ID")
+	if !ok {
+		return
+	}
+	h.saveAgreement(w, r, agreementID)
+}
+
+func (h *InstitutionHandler) saveAgreement(w http.ResponseWriter, r *http.Request, agreementID int64) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	institutionID, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	if _, err := services.LoadInstitution(h.db, institutionID); err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Database error")
+		return
+	}
+	var req models.InstitutionAgreementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	savedID, err := services.SaveAgreement(h.db, institutionID, agreementID, req, claims.UserID)
+	if err != nil {
+		respondInstitutionError(w, err, "Agreement not found", "Failed to save agreement")
+		return
+	}
+	a, _ := services.LoadAgreement(h.db, savedID)
+	if agreementID == 0 {
+		utils.RespondCreated(w, "Agreement created", a)
+		return
+	}
+	utils.RespondSuccess(w, "Agreement updated", a)
+}
+
+// DeleteAgreement removes an agreement
+func (h *InstitutionHandler) DeleteAgreement(w http.ResponseWriter, r *http.Request) {
+	institutionID, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	agreementID, ok := pathID(w, r, "agreementId", "Invalid agreement ID")
+	if !ok {
+		return
+	}
+	if err := services.DeleteAgreement(h.db, institutionID, agreementID); err != nil {
+		respondInstitutionError(w, err, "Agreement not found", "Failed to delete agreement")
+		return
+	}
+	utils.RespondSuccess(w, "Agreement deleted", nil)
+}
+
+// CreateContact adds a contact person
+func (h *InstitutionHandler) CreateContact(w http.ResponseWriter, r *http.Request) {
+	h.saveContact(w, r, 0)
+}
+
+// UpdateContact edits a contact person
+func (h *InstitutionHandler) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	contactID, ok := pathID(w, r, "contactId", "Invalid contact ID")
+	if !ok {
+		return
+	}
+	h.saveContact(w, r, contactID)
+}
+
+func (h *InstitutionHandler) saveContact(w http.ResponseWriter, r *http.Request, contactID int64) {
+	institutionID, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	if _, err := services.LoadInstitution(h.db, institutionID); err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Database error")
+		return
+	}
+	var req models.InstitutionContactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if _, err := services.SaveContact(h.db, institutionID, contactID, req); err != nil {
+		respondInstitutionError(w, err, "Contact not found", "Failed to save contact")
+		return
+	}
+	contacts, _ := services.ListContacts(h.db, institutionID)
+	if contactID == 0 {
+		utils.RespondCreated(w, "Contact created", contacts)
+		return
+	}
+	utils.RespondSuccess(w, "Contact updated", contacts)
+}
+
+// DeleteContact removes a contact person
+func (h *InstitutionHandler) DeleteContact(w http.ResponseWriter, r *http.Request) {
+	institutionID, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	contactID, ok := pathID(w, r, "contactId", "Invalid contact ID")
+	if !ok {
+		return
+	}
+	if err := services.DeleteContact(h.db, institutionID, contactID); err != nil {
+		respondInstitutionError(w, err, "Contact not found", "Failed to delete contact")
+		return
+	}
+	utils.RespondSuccess(w, "Contact deleted", nil)
+}
+
+// GetSchoolNames lists the free-text school names on interns with the
+// institution each is linked to and likely matches
+func (h *InstitutionHandler) GetSchoolNames(w http.ResponseWriter, r *http.Request) {
+	list, err := services.ListSchoolNames(h.db)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch school names")
+		return
+	}
+	utils.RespondSuccess(w, "School names retrieved", list)
+}
+
+// AssignSchool links every intern with a school name to an institution
+func (h *InstitutionHandler) AssignSchool(w http.ResponseWriter, r *http.Request) {
+	var req models.AssignSchoolRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	moved, err := services.AssignSchool(h.db, req.School, req.InstitutionID)
+	if err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Failed to assign school")
+		return
+	}
+	utils.RespondSuccess(w, "School assigned", map[string]interface{}{"interns_updated": moved})
+}
+
+// GetDuplicates lists groups of institutions that look like the same one
+func (h *InstitutionHandler) GetDuplicates(w http.ResponseWriter, r *http.Request) {
+	groups, err := services.DuplicateInstitutionGroups(h.db)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to find duplicate institutions")
+		return
+	}
+	utils.RespondSuccess(w, "Duplicate institutions retrieved", groups)
+}
+
+// Merge folds source_ids into the institution in the path
+func (h *InstitutionHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	targetID, ok := pathID(w, r, "id", "Invalid institution ID")
+	if !ok {
+		return
+	}
+	var req models.MergeInstitutionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	moved, err := services.MergeInstitutions(h.db, targetID, req.SourceIDs, claims.UserID)
+	if err != nil {
+		respondInstitutionError(w, err, "Institution not found", "Failed to merge institutions")
+		return
+	}
+	inst, _ := services.LoadInstitution(h.db, targetID)
+	utils.RespondSuccess(w, "Institutions merged", map[string]interface{}{
+		"interns_moved": moved,
+		"institution":   inst,
+	})
+}
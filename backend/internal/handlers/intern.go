@@ -3,6 +3,7 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/gorilla/mux"
@@ -102,8 +104,8 @@ func (h *InternHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		args = append(args, status)
 	}
 	if role == "pembimbing" {
-		where = append(where, "i.supervisor_id = ?")
-		args = append(args, claims.UserID)
+		where = append(where, services.SupervisedInternCond)
+		args = append(args, services.SupervisedInternArgs(claims.UserID)...)
 	} else if supervisorFilter != "" {
 		if id, err := strconv.ParseInt(supervisorFilter, 10, 64); err == nil {
 			where = append(where, "i.supervisor_id = ?")
@@ -368,16 +370,18 @@ func (h *InternHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Auto-resolve Institution ID from School name if not provided
 	var institutionID *int64 = req.InstitutionID
 	if institutionID == nil && strings.TrimSpace(req.School) != "" {
-		var id int64
-		err := tx.QueryRow("SELECT id FROM institutions WHERE name = ?", req.School).Scan(&id)
-		if err == nil {
+		if id, err := services.ResolveInstitution(tx, req.School); err == nil {
 			institutionID = &id
-		} else if err == sql.ErrNoRows {
-			res, err := tx.Exec("INSERT INTO institutions (name) VALUES (?)", req.School)
-			if err == nil {
-				lid, _ := res.LastInsertId()
-				institutionID = &lid
+		}
+	}
+	if institutionID != nil && status == "active" {
+		if err := services.CheckInstitutionQuota(tx, *institutionID); err != nil {
+			if errors.Is(err, services.ErrInvalidInstitution) {
+				utils.RespondBadRequest(w, err.Error())
+				return
 			}
+			utils.RespondInternalError(w, "Failed to check institution quota")
+			return
 		}
 	}
 
@@ -432,7 +436,11 @@ func (h *InternHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Get user_id for this intern
 	var userID int64
-	err := h.db.QueryRow("SELECT user_id FROM interns WHERE id = ?", internID).Scan(&userID)
+	var currentStatus string
+	var currentInstitution sql.NullInt64
+	err := h.db.QueryRow(
+		"SELECT user_id, status, institution_id FROM interns WHERE id = ?", internID,
+	).Scan(&userID, &currentStatus, &currentInstitution)
 	if err == sql.ErrNoRows {
 		utils.RespondNotFound(w, "Intern not found")
 		return
@@ -531,6 +539,27 @@ func (h *InternHandler) Update(w http.ResponseWriter, r *http.Request) {
 		internArgs = append(internArgs, nullableInt(req.SupervisorID))
 	}
 
+	// Activating an intern, or moving an active one to another institution,
+	// takes a place in that institution's quota
+	newStatus, newInstitution := currentStatus, currentInstitution
+	if req.Status != nil {
+		newStatus = *req.Status
+	}
+	if req.InstitutionID != nil {
+		newInstitution = nullableInt(req.InstitutionID)
+	}
+	if strings.EqualFold(newStatus, "active") && newInstitution.Valid &&
+		(!strings.EqualFold(currentStatus, "active") || newInstitution != currentInstitution) {
+		if err := services.CheckInstitutionQuota(tx, newInstitution.Int64); err != nil {
+			if errors.Is(err, services.ErrInvalidInstitution) {
+				utils.RespondBadRequest(w, err.Error())
+				return
+			}
+			utils.RespondInternalError(w, "Failed to check institution quota")
+			return
+		}
+	}
+
 	if len(internUpdates) > 0 {
 		internArgs = append(internArgs, internID)
 		if _, err := tx.Exec("UPDATE interns SET "+strings.Join(internUpdates, ", ")+" WHERE id = ?", internArgs...); err != nil {
@@ -3,6 +3,7 @@ package handlers
 import (
 	"database/sql"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -83,16 +84,9 @@ func (h *InternHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var institutionID sql.NullInt64
 	var err error
 	if schoolName != "" {
-		var instID int64
-		err = h.db.QueryRow("SELECT id FROM institutions WHERE name = ? LIMIT 1", schoolName).Scan(&instID)
-		if err == sql.ErrNoRows {
-			resInst, errInst := h.db.Exec("INSERT INTO institutions (name, created_at, updated_at) VALUES (?, NOW(), NOW())", schoolName)
-			if errInst != nil {
-				http.Error(w, `{"message": "Gagal membuat institusi"}`, http.StatusInternalServerError)
-				return
-			}
-			instID, _ = resInst.LastInsertId()
-		} else if err != nil {
+		// Variants such as "Univ. Diponegoro" resolve to the same institution
+		instID, errInst := services.ResolveInstitution(h.db, schoolName)
+		if errInst != nil {
 			http.Error(w, `{"message": "Gagal mencari institusi"}`, http.StatusInternalServerError)
 			return
 		}
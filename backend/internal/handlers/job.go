@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dsi_interna_sys/internal/jobs"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// JobHandler lets admins inspect the background job queue
+type JobHandler struct {
+	db *sql.DB
+}
+
+func NewJobHandler(db *sql.DB) *JobHandler {
+	return &JobHandler{db: db}
+}
+
+// GetAll lists jobs, newest first, filtered by status and/or type
+func (h *JobHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	where := []string{}
+	args := []interface{}{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		where = append(where, "status = ?")
+		args = append(args, status)
+	}
+	if jobType := r.URL.Query().Get("type"); jobType != "" {
+		where = append(where, "type = ?")
+		args = append(args, jobType)
+	}
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM jobs"+whereClause, args...).Scan(&total); err != nil {
+		utils.RespondInternalError(w, "Failed to count jobs")
+		return
+	}
+
+	args = append(args, limit, offset)
+	rows, err := h.db.Query(
+		"SELECT "+jobs.JobColumns+" FROM jobs"+whereClause+" ORDER BY id DESC LIMIT ? OFFSET ?", args...,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch jobs")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Job{}
+	for rows.Next() {
+		job, err := jobs.ScanJob(rows)
+		if err != nil {
+			continue
+		}
+		list = append(list, job)
+	}
+
+	utils.RespondPaginated(w, list, utils.CalculatePagination(page, limit, total))
+}
+
+// GetStats counts jobs per type and status
+func (h *JobHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query("SELECT type, status, COUNT(*) FROM jobs GROUP BY type, status ORDER BY type, status")
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch job stats")
+		return
+	}
+	defer rows.Close()
+
+	stats := map[string]map[string]int{}
+	for rows.Next() {
+		var jobType, status string
+		var count int
+		if err := rows.Scan(&jobType, &status, &count); err != nil {
+			continue
+		}
+		if stats[jobType] == nil {
+			stats[jobType] = map[string]int{}
+		}
+		stats[jobType][status] = count
+	}
+
+	utils.RespondSuccess(w, "Job stats retrieved", stats)
+}
+
+func (h *JobHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	job, err := jobs.LoadJob(h.db, id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Job not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	utils.RespondSuccess(w, "Job retrieved", job)
+}
+
+// Retry re-queues a failed or cancelled job with a fresh attempt budget
+func (h *JobHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, jobs.Retry, "Job queued for retry")
+}
+
+// Cancel drops a job that hasn't started yet
+func (h *JobHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, jobs.Cancel, "Job cancelled")
+}
+
+func (h *JobHandler) transition(w http.ResponseWriter, r *http.Request, apply func(*sql.DB, int64) error, message string) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if _, err := jobs.LoadJob(h.db, id); err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Job not found")
+		return
+	} else if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	if err := apply(h.db, id); err != nil {
+		if err == jobs.ErrNotRetryable || err == jobs.ErrNotCancelable {
+			utils.RespondBadRequest(w, err.Error())
+			return
+		}
+		utils.RespondInternalError(w, "Failed to update job")
+		return
+	}
+
+	job, _ := jobs.LoadJob(h.db, id)
+	utils.RespondSuccess(w, message, job)
+}
+
+// RetryFailed re-queues every failed job, or only those of the given type
+func (h *JobHandler) RetryFailed(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Type string `json:"type"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondBadRequest(w, "Invalid request body")
+			return
+		}
+	}
+
+	count, err := jobs.RetryFailed(h.db, strings.TrimSpace(req.Type))
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to retry jobs")
+		return
+	}
+	utils.RespondSuccess(w, "Failed jobs queued for retry", map[string]int64{"retried": count})
+}
+
+// GetSchedules lists the recurring jobs and when they fire next
+func (h *JobHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(
+		"SELECT name, spec, job_type, payload, next_run_at, last_run_at FROM job_schedules ORDER BY name",
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch job schedules")
+		return
+	}
+	defer rows.Close()
+
+	schedules := []models.JobSchedule{}
+	for rows.Next() {
+		var s models.JobSchedule
+		var payload sql.NullString
+		var lastRunAt sql.NullTime
+		if err := rows.Scan(&s.Name, &s.Spec, &s.JobType, &payload, &s.NextRunAt, &lastRunAt); err != nil {
+			continue
+		}
+		if payload.Valid {
+			s.Payload = json.RawMessage(payload.String)
+		}
+		s.LastRunAt = ptrTimeFromNull(lastRunAt)
+		schedules = append(schedules, s)
+	}
+
+	utils.RespondSuccess(w, "Job schedules retrieved", schedules)
+}
@@ -10,6 +10,7 @@ import (
 
 	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/gorilla/mux"
@@ -197,16 +198,53 @@ func (h *LeaveHandler) updateStatus(w http.ResponseWriter, r *http.Request, stat
 	vars := mux.Vars(r)
 	id, _ := strconv.ParseInt(vars["id"], 10, 64)
 
+	// Pembimbing decide for their own interns, or for those of a supervisor
+	// they are covering for
+	var supervisorID sql.NullInt64
+	err := h.db.QueryRow(
+		`SELECT i.supervisor_id FROM leave_requests l
+		 JOIN interns i ON l.intern_id = i.id
+		 WHERE l.id = ?`, id,
+	).Scan(&supervisorID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Leave request not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if normalizeRole(claims.Role) == "pembimbing" && !coversSupervisor(h.db, claims.UserID, supervisorID) {
+		utils.RespondForbidden(w, "You can only decide on leave requests of your interns")
+		return
+	}
+
 	query := `
 		UPDATE leave_requests 
 		SET status = ?, approved_by = ?, approved_at = NOW(), updated_at = NOW() 
 		WHERE id = ?`
 
-	_, err := h.db.Exec(query, status, claims.UserID, id) // Note: approved_by stores UserID of supervisor
+	_, err = h.db.Exec(query, status, claims.UserID, id) // Note: approved_by stores UserID of supervisor
 	if err != nil {
 		utils.RespondInternalError(w, "Failed to update status")
 		return
 	}
+	if supervisorID.Valid {
+		services.NoteDelegatedAction(h.db, claims.UserID, supervisorID.Int64, "leave."+status, "leave", id)
+	}
+
+	// An approved leave covers attendance days the dashboards count
+	if status == "approved" {
+		var internID int64
+		var start, end time.Time
+		if h.db.QueryRow("SELECT intern_id, start_date, end_date FROM leave_requests WHERE id = ?", id).Scan(&internID, &start, &end) == nil {
+			var dates []time.Time
+			for d := start; !d.After(end) && !d.After(time.Now()); d = d.AddDate(0, 0, 1) {
+				dates = append(dates, d)
+			}
+			services.QueueRollupRefresh(h.db, internID, dates...)
+		}
+	}
 
 	// Notify Intern
 	var internUserID int64
@@ -0,0 +1,649 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// LogbookHandler serves the interns' daily logbook (jurnal harian)
+type LogbookHandler struct {
+	db *sql.DB
+}
+
+func NewLogbookHandler(db *sql.DB) *LogbookHandler {
+	return &LogbookHandler{db: db}
+}
+
+const logbookSelect = `
+	SELECT l.id, l.intern_id, l.date, l.attendance_id, l.activities, l.hours,
+	       l.attachment_name, l.attachment_path, l.status, l.acknowledged_by, l.acknowledged_at,
+	       l.supervisor_note, l.created_at, l.updated_at,
+	       iu.name, a.status, a.check_in_time, a.check_out_time, au.name
+	FROM logbook_entries l
+	JOIN interns i ON l.intern_id = i.id
+	LEFT JOIN users iu ON i.user_id = iu.id
+	LEFT JOIN attendances a ON l.attendance_id = a.id
+	LEFT JOIN users au ON l.acknowledged_by = au.id
+`
+
+func scanLogbookEntry(row interface{ Scan(...interface{}) error }) (models.LogbookEntry, error) {
+	var e models.LogbookEntry
+	var attendanceID, ackBy sql.NullInt64
+	var attachmentName, attachmentPath, note, internName, attendanceStatus, ackName sql.NullString
+	var ackAt, checkIn, checkOut sql.NullTime
+	err := row.Scan(
+		&e.ID, &e.InternID, &e.Date, &attendanceID, &e.Activities, &e.Hours,
+		&attachmentName, &attachmentPath, &e.Status, &ackBy, &ackAt,
+		&note, &e.CreatedAt, &e.UpdatedAt,
+		&internName, &attendanceStatus, &checkIn, &checkOut, &ackName,
+	)
+	if err != nil {
+		return e, err
+	}
+	e.AttendanceID = ptrInt64FromNull(attendanceID)
+	e.AttachmentName = ptrStringFromNull(attachmentName)
+	if attachmentPath.Valid {
+		path := prependUpload(attachmentPath.String)
+		e.AttachmentPath = &path
+	}
+	e.AcknowledgedBy = ptrInt64FromNull(ackBy)
+	e.AcknowledgedAt = ptrTimeFromNull(ackAt)
+	e.SupervisorNote = ptrStringFromNull(note)
+	e.InternName = internName.String
+	e.AttendanceStatus = ptrStringFromNull(attendanceStatus)
+	e.CheckInTime = ptrTimeFromNull(checkIn)
+	e.CheckOutTime = ptrTimeFromNull(checkOut)
+	e.AcknowledgedByName = ackName.String
+	e.Tasks = []models.LogbookTask{}
+	return e, nil
+}
+
+func (h *LogbookHandler) loadEntry(id int64) (models.LogbookEntry, error) {
+	e, err := scanLogbookEntry(h.db.QueryRow(logbookSelect+" WHERE l.id = ?", id))
+	if err != nil {
+		return e, err
+	}
+	tasks, err := services.LoadLogbookTasks(h.db, []int64{e.ID})
+	if err == nil && tasks[e.ID] != nil {
+		e.Tasks = tasks[e.ID]
+	}
+	return e, nil
+}
+
+// accessibleEntry loads the entry in the URL: interns see their own,
+// supervisors their interns', admins everything
+func (h *LogbookHandler) accessibleEntry(w http.ResponseWriter, r *http.Request) (models.LogbookEntry, *middleware.Claims, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return models.LogbookEntry{}, nil, false
+	}
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	entry, err := h.loadEntry(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Logbook entry not found")
+		return entry, nil, false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return entry, nil, false
+	}
+
+	if !h.canView(claims, entry.InternID) {
+		utils.RespondForbidden(w, "You do not have access to this logbook entry")
+		return entry, nil, false
+	}
+	return entry, claims, true
+}
+
+func (h *LogbookHandler) canView(claims *middleware.Claims, internID int64) bool {
+	var userID int64
+	var supervisorID sql.NullInt64
+	if err := h.db.QueryRow("SELECT user_id, supervisor_id FROM interns WHERE id = ?", internID).Scan(&userID, &supervisorID); err != nil {
+		return false
+	}
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		return userID == claims.UserID
+	case "pembimbing":
+		return supervisesIntern(h.db, claims.UserID, internID, supervisorID)
+	default:
+		return true
+	}
+}
+
+// GetAll lists logbook entries, newest day first. Filters: intern_id,
+// status, month (YYYY-MM) or from/to dates.
+func (h *LogbookHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 31
+	}
+	offset := (page - 1) * limit
+
+	where := []string{}
+	args := []interface{}{}
+
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		where = append(where, "i.user_id = ?")
+		args = append(args, claims.UserID)
+	case "pembimbing":
+		where = append(where, services.SupervisedInternCond)
+		args = append(args, services.SupervisedInternArgs(claims.UserID)...)
+	}
+
+	q := r.URL.Query()
+	if internFilter := strings.TrimSpace(q.Get("intern_id")); internFilter != "" {
+		if id, err := strconv.ParseInt(internFilter, 10, 64); err == nil {
+			where = append(where, "l.intern_id = ?")
+			args = append(args, id)
+		}
+	}
+	if status := strings.TrimSpace(q.Get("status")); status != "" {
+		where = append(where, "l.status = ?")
+		args = append(args, status)
+	}
+	if month := strings.TrimSpace(q.Get("month")); month != "" {
+		if parsed, err := time.Parse("2006-01", month); err == nil {
+			where = append(where, "l.date BETWEEN ? AND ?")
+			args = append(args, parsed.Format("2006-01-02"), parsed.AddDate(0, 1, -1).Format("2006-01-02"))
+		}
+	}
+	if from := strings.TrimSpace(q.Get("from")); from != "" {
+		if _, err := time.Parse("2006-01-02", from); err == nil {
+			where = append(where, "l.date >= ?")
+			args = append(args, from)
+		}
+	}
+	if to := strings.TrimSpace(q.Get("to")); to != "" {
+		if _, err := time.Parse("2006-01-02", to); err == nil {
+			where = append(where, "l.date <= ?")
+			args = append(args, to)
+		}
+	}
+
+	whereClause := ""
+	if len(where) > 0 {
+		whereClause = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int64
+	if err := h.db.QueryRow(
+		"SELECT COUNT(*) FROM logbook_entries l JOIN interns i ON l.intern_id = i.id"+whereClause, args...,
+	).Scan(&total); err != nil {
+		utils.RespondInternalError(w, "Failed to count logbook entries")
+		return
+	}
+
+	args = append(args, limit, offset)
+	rows, err := h.db.Query(logbookSelect+whereClause+" ORDER BY l.date DESC, l.id DESC LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch logbook entries")
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.LogbookEntry{}
+	var ids []int64
+	for rows.Next() {
+		e, err := scanLogbookEntry(rows)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, e)
+		ids = append(ids, e.ID)
+	}
+
+	if tasks, err := services.LoadLogbookTasks(h.db, ids); err == nil {
+		for i := range entries {
+			if t := tasks[entries[i].ID]; t != nil {
+				entries[i].Tasks = t
+			}
+		}
+	}
+
+	utils.RespondPaginated(w, entries, utils.CalculatePagination(page, limit, total))
+}
+
+func (h *LogbookHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	entry, _, ok := h.accessibleEntry(w, r)
+	if !ok {
+		return
+	}
+	utils.RespondSuccess(w, "Logbook entry retrieved", entry)
+}
+
+type logbookRequest struct {
+	Date       string  `json:"date"`
+	Activities string  `json:"activities"`
+	Hours      float64 `json:"hours"`
+	TaskIDs    []int64 `json:"task_ids"`
+}
+
+// decodeLogbookRequest reads JSON, or a multipart form with an optional
+// "file" attachment and comma separated task_ids
+func decodeLogbookRequest(r *http.Request) (logbookRequest, *models.LogbookEntry, error) {
+	var req logbookRequest
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, nil, fmt.Errorf("Invalid request body")
+		}
+		return req, nil, nil
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		return req, nil, fmt.Errorf("Failed to parse form data")
+	}
+	req.Date = r.FormValue("date")
+	req.Activities = r.FormValue("activities")
+	if v := strings.TrimSpace(r.FormValue("hours")); v != "" {
+		hours, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return req, nil, fmt.Errorf("hours must be a number")
+		}
+		req.Hours = hours
+	}
+	for _, part := range strings.Split(r.FormValue("task_ids"), ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return req, nil, fmt.Errorf("Invalid task_ids")
+		}
+		req.TaskIDs = append(req.TaskIDs, id)
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return req, nil, nil
+	}
+	defer file.Close()
+	path, err := utils.UploadFile(file, header, "logbooks")
+	if err != nil {
+		return req, nil, fmt.Errorf("Upload failed: %v", err)
+	}
+	name := header.Filename
+	return req, &models.LogbookEntry{AttachmentName: &name, AttachmentPath: &path}, nil
+}
+
+// Save writes the intern's entry for a day (today unless date is given).
+// Saving again for the same day replaces the entry until the supervisor
+// has acknowledged it.
+func (h *LogbookHandler) Save(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	if normalizeRole(claims.Role) != "intern" {
+		utils.RespondForbidden(w, "Only interns can write logbook entries")
+		return
+	}
+
+	req, attachment, err := decodeLogbookRequest(r)
+	if err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+
+	var internID int64
+	var startDate, endDate time.Time
+	if err := h.db.QueryRow(
+		"SELECT id, start_date, end_date FROM interns WHERE user_id = ?", claims.UserID,
+	).Scan(&internID, &startDate, &endDate); err != nil {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	date := today
+	if strings.TrimSpace(req.Date) != "" {
+		date, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(req.Date), time.Local)
+		if err != nil {
+			utils.RespondBadRequest(w, "date must be YYYY-MM-DD")
+			return
+		}
+	}
+	if date.After(today) {
+		utils.RespondBadRequest(w, "Logbook entries cannot be written for future days")
+		return
+	}
+	if day := date.Format("2006-01-02"); day < startDate.Format("2006-01-02") || day > endDate.Format("2006-01-02") {
+		utils.RespondBadRequest(w, "Date is outside your internship period")
+		return
+	}
+
+	h.save(w, internID, date, req, attachment)
+}
+
+// Update edits an entry that hasn't been acknowledged yet
+func (h *LogbookHandler) Update(w http.ResponseWriter, r *http.Request) {
+	entry, claims, ok := h.accessibleEntry(w, r)
+	if !ok {
+		return
+	}
+	if normalizeRole(claims.Role) != "intern" {
+		utils.RespondForbidden(w, "Only the intern can edit a logbook entry")
+		return
+	}
+
+	req, attachment, err := decodeLogbookRequest(r)
+	if err != nil {
+		utils.RespondBadRequest(w, err.Error())
+		return
+	}
+	h.save(w, entry.InternID, entry.Date, req, attachment)
+}
+
+func (h *LogbookHandler) save(w http.ResponseWriter, internID int64, date time.Time, req logbookRequest, attachment *models.LogbookEntry) {
+	req.Activities = strings.TrimSpace(req.Activities)
+	if req.Activities == "" {
+		utils.RespondBadRequest(w, "activities is required")
+		return
+	}
+	if req.Hours < 0 || req.Hours > 24 {
+		utils.RespondBadRequest(w, "hours must be between 0 and 24")
+		return
+	}
+
+	taskIDs := []int64{}
+	seen := map[int64]bool{}
+	for _, id := range req.TaskIDs {
+		if !seen[id] {
+			seen[id] = true
+			taskIDs = append(taskIDs, id)
+		}
+	}
+	if len(taskIDs) > 0 {
+		args := []interface{}{internID}
+		for _, id := range taskIDs {
+			args = append(args, id)
+		}
+		var count int
+		if err := h.db.QueryRow(
+			"SELECT COUNT(*) FROM tasks WHERE intern_id = ? AND id IN ("+placeholders(len(taskIDs))+")", args...,
+		).Scan(&count); err != nil {
+			utils.RespondInternalError(w, "Database error")
+			return
+		}
+		if count != len(taskIDs) {
+			utils.RespondBadRequest(w, "Only your own tasks can be linked")
+			return
+		}
+	}
+
+	day := date.Format("2006-01-02")
+	var attendanceID sql.NullInt64
+	_ = h.db.QueryRow("SELECT id FROM attendances WHERE intern_id = ? AND date = ?", internID, day).Scan(&attendanceID)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	defer tx.Rollback()
+
+	var entryID int64
+	var status string
+	err = tx.QueryRow(
+		"SELECT id, status FROM logbook_entries WHERE intern_id = ? AND date = ? FOR UPDATE", internID, day,
+	).Scan(&entryID, &status)
+	created := err == sql.ErrNoRows
+	if err != nil && !created {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if status == models.LogbookAcknowledged {
+		utils.RespondBadRequest(w, "This entry has been acknowledged by your supervisor and can no longer be changed")
+		return
+	}
+
+	var attachmentName, attachmentPath interface{}
+	if attachment != nil {
+		attachmentName, attachmentPath = *attachment.AttachmentName, *attachment.AttachmentPath
+	}
+
+	if created {
+		res, err := tx.Exec(
+			`INSERT INTO logbook_entries (intern_id, date, attendance_id, activities, hours, attachment_name, attachment_path, status)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			internID, day, attendanceID, req.Activities, req.Hours, attachmentName, attachmentPath, models.LogbookSubmitted,
+		)
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to save logbook entry")
+			return
+		}
+		entryID, _ = res.LastInsertId()
+	} else {
+		if _, err := tx.Exec(
+			`UPDATE logbook_entries
+			 SET attendance_id = ?, activities = ?, hours = ?,
+			     attachment_name = COALESCE(?, attachment_name), attachment_path = COALESCE(?, attachment_path)
+			 WHERE id = ?`,
+			attendanceID, req.Activities, req.Hours, attachmentName, attachmentPath, entryID,
+		); err != nil {
+			utils.RespondInternalError(w, "Failed to save logbook entry")
+			return
+		}
+		if _, err := tx.Exec("DELETE FROM logbook_entry_tasks WHERE entry_id = ?", entryID); err != nil {
+			utils.RespondInternalError(w, "Failed to save logbook entry")
+			return
+		}
+	}
+
+	for _, taskID := range taskIDs {
+		if _, err := tx.Exec("INSERT INTO logbook_entry_tasks (entry_id, task_id) VALUES (?, ?)", entryID, taskID); err != nil {
+			utils.RespondInternalError(w, "Failed to link tasks")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to save logbook entry")
+		return
+	}
+
+	entry, err := h.loadEntry(entryID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load logbook entry")
+		return
+	}
+	if created {
+		utils.RespondCreated(w, "Logbook entry saved", entry)
+		return
+	}
+	utils.RespondSuccess(w, "Logbook entry updated", entry)
+}
+
+func (h *LogbookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	entry, claims, ok := h.accessibleEntry(w, r)
+	if !ok {
+		return
+	}
+	if normalizeRole(claims.Role) != "intern" {
+		utils.RespondForbidden(w, "Only the intern can delete a logbook entry")
+		return
+	}
+	if entry.Status == models.LogbookAcknowledged {
+		utils.RespondBadRequest(w, "Acknowledged entries cannot be deleted")
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM logbook_entries WHERE id = ? AND status <> ?", entry.ID, models.LogbookAcknowledged); err != nil {
+		utils.RespondInternalError(w, "Failed to delete logbook entry")
+		return
+	}
+	utils.RespondSuccess(w, "Logbook entry deleted", nil)
+}
+
+// Acknowledge marks several submitted entries as read by the supervisor,
+// optionally with a note. Supervisors can only acknowledge their own
+// interns' entries; others in the list are ignored.
+func (h *LogbookHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	role := normalizeRole(claims.Role)
+	if role == "intern" {
+		utils.RespondForbidden(w, "Only admin or pembimbing can acknowledge logbook entries")
+		return
+	}
+
+	var req models.LogbookAcknowledgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if len(req.IDs) == 0 {
+		utils.RespondBadRequest(w, "ids is required")
+		return
+	}
+
+	args := []interface{}{models.LogbookSubmitted}
+	for _, id := range req.IDs {
+		args = append(args, id)
+	}
+	query := `SELECT l.id, i.user_id, i.supervisor_id FROM logbook_entries l
+		JOIN interns i ON l.intern_id = i.id
+		WHERE l.status = ? AND l.id IN (` + placeholders(len(req.IDs)) + `)`
+	if role == "pembimbing" {
+		// Their own interns and those of supervisors they cover for
+		supervisors := append([]int64{claims.UserID}, services.CoveredSupervisors(h.db, claims.UserID, time.Now())...)
+		query += " AND i.supervisor_id IN (" + placeholders(len(supervisors)) + ")"
+		for _, id := range supervisors {
+			args = append(args, id)
+		}
+	}
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	var ids []interface{}
+	perUser := map[int64]int{}
+	owners := map[int64]int64{}
+	for rows.Next() {
+		var id, userID int64
+		var supervisorID sql.NullInt64
+		if rows.Scan(&id, &userID, &supervisorID) == nil {
+			ids = append(ids, id)
+			perUser[userID]++
+			if supervisorID.Valid {
+				owners[id] = supervisorID.Int64
+			}
+		}
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		utils.RespondSuccess(w, "No entries to acknowledge", map[string]int{"acknowledged": 0})
+		return
+	}
+
+	updateArgs := append([]interface{}{models.LogbookAcknowledged, claims.UserID, nullIfEmpty(req.Note)}, ids...)
+	if _, err := h.db.Exec(
+		`UPDATE logbook_entries
+		 SET status = ?, acknowledged_by = ?, acknowledged_at = NOW(), supervisor_note = COALESCE(?, supervisor_note)
+		 WHERE id IN (`+placeholders(len(ids))+`)`, updateArgs...,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to acknowledge logbook entries")
+		return
+	}
+
+	for id, supervisorID := range owners {
+		services.NoteDelegatedAction(h.db, claims.UserID, supervisorID, "logbook.acknowledge", "logbook", id)
+	}
+	for userID, count := range perUser {
+		_ = createNotification(h.db, userID, "info", "Jurnal Harian Diketahui",
+			fmt.Sprintf("%d entri jurnal harian Anda telah diketahui pembimbing.", count), "/logbook", nil)
+	}
+
+	utils.RespondSuccess(w, "Logbook entries acknowledged", map[string]int{"acknowledged": len(ids)})
+}
+
+// DownloadPDF prints the monthly logbook for school submission.
+// Query: intern_id (ignored for interns) and month (YYYY-MM, default this
+// month).
+func (h *LogbookHandler) DownloadPDF(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	var internID int64
+	if normalizeRole(claims.Role) == "intern" {
+		if err := h.db.QueryRow("SELECT id FROM interns WHERE user_id = ?", claims.UserID).Scan(&internID); err != nil {
+			utils.RespondNotFound(w, "Intern not found")
+			return
+		}
+	} else {
+		id, err := strconv.ParseInt(r.URL.Query().Get("intern_id"), 10, 64)
+		if err != nil {
+			utils.RespondBadRequest(w, "intern_id is required")
+			return
+		}
+		internID = id
+	}
+	if !h.canView(claims, internID) {
+		utils.RespondForbidden(w, "You do not have access to this intern")
+		return
+	}
+
+	month := time.Now()
+	if v := strings.TrimSpace(r.URL.Query().Get("month")); v != "" {
+		parsed, err := time.Parse("2006-01", v)
+		if err != nil {
+			utils.RespondBadRequest(w, "month must be YYYY-MM")
+			return
+		}
+		month = parsed
+	}
+
+	pdf, err := services.RenderLogbookPDF(h.db, internID, month)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to render logbook")
+		return
+	}
+
+	var internName string
+	_ = h.db.QueryRow("SELECT full_name FROM interns WHERE id = ?", internID).Scan(&internName)
+	filename := fmt.Sprintf("Jurnal_Harian_%s_%s.pdf", sanitizeFilename(internName), month.Format("2006-01"))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	if err := pdf.Output(w); err != nil {
+		utils.RespondInternalError(w, "Failed to render logbook")
+	}
+}
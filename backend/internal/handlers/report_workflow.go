@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// reportRef is what the workflow endpoints need to know about a report
+type reportRef struct {
+	ID           int64
+	InternID     int64
+	InternUserID int64
+	SupervisorID sql.NullInt64
+	CreatedBy    int64
+	Type         string
+	Status       string
+}
+
+func (h *ReportHandler) loadReportRef(id int64) (reportRef, error) {
+	var ref reportRef
+	err := h.db.QueryRow(
+		`SELECT r.id, r.intern_id, i.user_id, i.supervisor_id, r.created_by, r.type, r.status
+		 FROM reports r
+		 JOIN interns i ON r.intern_id = i.id
+		 WHERE r.id = ?`, id,
+	).Scan(&ref.ID, &ref.InternID, &ref.InternUserID, &ref.SupervisorID, &ref.CreatedBy, &ref.Type, &ref.Status)
+	return ref, err
+}
+
+// workflowReport loads the report in the URL and checks that the caller may
+// act on it: the intern (or whoever wrote the report) for submit, the
+// assigned supervisor or an admin for reviews.
+func (h *ReportHandler) workflowReport(w http.ResponseWriter, r *http.Request, reviewer bool) (reportRef, int64, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return reportRef{}, 0, false
+	}
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	ref, err := h.loadReportRef(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Report not found")
+		return ref, 0, false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return ref, 0, false
+	}
+
+	role := normalizeRole(claims.Role)
+	var allowed bool
+	if reviewer {
+		allowed = role == "admin" || (role == "pembimbing" && coversSupervisor(h.db, claims.UserID, ref.SupervisorID))
+	} else {
+		allowed = claims.UserID == ref.InternUserID || claims.UserID == ref.CreatedBy
+	}
+	if !allowed {
+		utils.RespondForbidden(w, "You do not have access to this report")
+		return ref, 0, false
+	}
+	return ref, claims.UserID, true
+}
+
+type reportWorkflowRequest struct {
+	Note string `json:"note"`
+}
+
+func decodeWorkflowNote(r *http.Request) (string, error) {
+	var req reportWorkflowRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(req.Note), nil
+}
+
+// Submit sends a draft or revised report to the supervisor
+func (h *ReportHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	ref, actorID, ok := h.workflowReport(w, r, false)
+	if !ok {
+		return
+	}
+	h.transition(w, ref, models.ReportActionSubmit, actorID, "", "Report submitted")
+}
+
+// RequestRevision sends a submitted report back to the intern
+func (h *ReportHandler) RequestRevision(w http.ResponseWriter, r *http.Request) {
+	ref, actorID, ok := h.workflowReport(w, r, true)
+	if !ok {
+		return
+	}
+	note, err := decodeWorkflowNote(r)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if note == "" {
+		utils.RespondBadRequest(w, "note is required when requesting a revision")
+		return
+	}
+	h.transition(w, ref, models.ReportActionRequestRevision, actorID, note, "Revision requested")
+}
+
+// Approve accepts a submitted report; it is locked from then on
+func (h *ReportHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	ref, actorID, ok := h.workflowReport(w, r, true)
+	if !ok {
+		return
+	}
+	note, err := decodeWorkflowNote(r)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	h.transition(w, ref, models.ReportActionApprove, actorID, note, "Report approved")
+}
+
+// Countersign is the admin sign-off of an approved report
+func (h *ReportHandler) Countersign(w http.ResponseWriter, r *http.Request) {
+	ref, actorID, ok := h.workflowReport(w, r, true)
+	if !ok {
+		return
+	}
+	note, err := decodeWorkflowNote(r)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	h.transition(w, ref, models.ReportActionCountersign, actorID, note, "Report countersigned")
+}
+
+func (h *ReportHandler) transition(w http.ResponseWriter, ref reportRef, action string, actorID int64, note, message string) {
+	if _, err := services.TransitionReport(h.db, ref.ID, action, actorID, note); err != nil {
+		if errors.Is(err, services.ErrReportTransition) {
+			utils.RespondBadRequest(w, fmt.Sprintf("A %s report cannot be changed this way", strings.ReplaceAll(ref.Status, "_", " ")))
+			return
+		}
+		if errors.Is(err, services.ErrSigningKeyMissing) {
+			utils.RespondError(w, http.StatusServiceUnavailable, "Reports cannot be signed: CERTIFICATE_SIGNING_KEY is not configured")
+			return
+		}
+		utils.RespondInternalError(w, "Failed to update report")
+		return
+	}
+
+	if action == models.ReportActionRequestRevision || action == models.ReportActionApprove {
+		recordComment(h.db, models.CommentEntityReport, ref.ID, actorID, note)
+	}
+	if ref.SupervisorID.Valid {
+		services.NoteDelegatedAction(h.db, actorID, ref.SupervisorID.Int64, "report."+action, "report", ref.ID)
+	}
+	h.notifyTransition(ref, action)
+
+	rep, err := h.loadReport(ref.ID)
+	if err != nil {
+		utils.RespondSuccess(w, message, nil)
+		return
+	}
+	utils.RespondSuccess(w, message, rep)
+}
+
+func (h *ReportHandler) notifyTransition(ref reportRef, action string) {
+	link := "/reports/" + strconv.FormatInt(ref.ID, 10)
+	switch action {
+	case models.ReportActionSubmit:
+		if ref.SupervisorID.Valid {
+			_ = createNotification(h.db, ref.SupervisorID.Int64, "info", "Laporan Baru",
+				"Seorang intern telah mengirim laporan "+ref.Type+".", link, nil)
+		}
+	case models.ReportActionRequestRevision:
+		_ = createNotification(h.db, ref.InternUserID, "info", "Laporan Perlu Revisi",
+			"Pembimbing meminta revisi pada laporan Anda.", link, nil)
+	case models.ReportActionApprove:
+		_ = createNotification(h.db, ref.InternUserID, "info", "Laporan Disetujui",
+			"Laporan Anda telah disetujui pembimbing.", link, nil)
+		rows, err := h.db.Query("SELECT id FROM users WHERE role = 'admin'")
+		if err != nil {
+			return
+		}
+		var admins []int64
+		for rows.Next() {
+			var id int64
+			if rows.Scan(&id) == nil {
+				admins = append(admins, id)
+			}
+		}
+		rows.Close()
+		for _, id := range admins {
+			_ = createNotification(h.db, id, "info", "Laporan Menunggu Pengesahan",
+				"Laporan "+ref.Type+" telah disetujui pembimbing dan menunggu pengesahan.", link, nil)
+		}
+	case models.ReportActionCountersign:
+		_ = createNotification(h.db, ref.InternUserID, "info", "Laporan Disahkan",
+			"Laporan Anda telah disahkan dan dapat diunduh.", link, nil)
+		if ref.SupervisorID.Valid {
+			_ = createNotification(h.db, ref.SupervisorID.Int64, "info", "Laporan Disahkan",
+				"Laporan "+ref.Type+" yang Anda setujui telah disahkan.", link, nil)
+		}
+	}
+}
+
+// GetHistory lists the workflow steps of a report
+func (h *ReportHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.accessibleReport(w, r); !ok {
+		return
+	}
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	events, err := loadReportEvents(h.db, id)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch report history")
+		return
+	}
+	utils.RespondSuccess(w, "Report history retrieved", events)
+}
+
+// DownloadSigned exports an approved report as a signed PDF
+func (h *ReportHandler) DownloadSigned(w http.ResponseWriter, r *http.Request) {
+	ref, ok := h.accessibleReport(w, r)
+	if !ok {
+		return
+	}
+	if !services.ReportSigned(ref.Status) {
+		utils.RespondBadRequest(w, "Only approved reports can be exported")
+		return
+	}
+
+	pdf, err := services.RenderSignedReport(h.db, ref.ID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to render report")
+		return
+	}
+
+	var internName string
+	_ = h.db.QueryRow("SELECT full_name FROM interns WHERE id = ?", ref.InternID).Scan(&internName)
+	filename := fmt.Sprintf("Laporan_%s_%d.pdf", sanitizeFilename(internName), ref.ID)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	if err := pdf.Output(w); err != nil {
+		utils.RespondInternalError(w, "Failed to render report")
+	}
+}
+
+// Verify is the public check behind the QR code on signed report PDFs
+func (h *ReportHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	result, err := services.VerifyReport(h.db, id, r.URL.Query().Get("sig"))
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Report not found")
+		return
+	}
+	if errors.Is(err, services.ErrSigningKeyMissing) {
+		utils.RespondError(w, http.StatusServiceUnavailable, "Report verification is not available")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to verify report")
+		return
+	}
+	utils.RespondSuccess(w, result.Message, result)
+}
+
+// accessibleReport loads the report in the URL for reading: interns see
+// their own, supervisors their interns', admins everything
+func (h *ReportHandler) accessibleReport(w http.ResponseWriter, r *http.Request) (reportRef, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return reportRef{}, false
+	}
+
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	ref, err := h.loadReportRef(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Report not found")
+		return ref, false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return ref, false
+	}
+
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		ok = ref.InternUserID == claims.UserID
+	case "pembimbing":
+		ok = supervisesIntern(h.db, claims.UserID, ref.InternID, ref.SupervisorID) || ref.CreatedBy == claims.UserID
+	default:
+		ok = true
+	}
+	if !ok {
+		utils.RespondForbidden(w, "You do not have access to this report")
+	}
+	return ref, ok
+}
+
+func loadReportEvents(db *sql.DB, reportID int64) ([]models.ReportEvent, error) {
+	rows, err := db.Query(
+		`SELECT e.id, e.action, e.from_status, e.to_status, e.note, e.actor_id, u.name, e.created_at
+		 FROM report_events e
+		 LEFT JOIN users u ON e.actor_id = u.id
+		 WHERE e.report_id = ?
+		 ORDER BY e.created_at ASC, e.id ASC`, reportID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.ReportEvent{}
+	for rows.Next() {
+		var e models.ReportEvent
+		var from, note, actorName sql.NullString
+		var actorID sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.Action, &from, &e.ToStatus, &note, &actorID, &actorName, &e.CreatedAt); err != nil {
+			continue
+		}
+		e.FromStatus = ptrStringFromNull(from)
+		e.Note = ptrStringFromNull(note)
+		e.ActorID = ptrInt64FromNull(actorID)
+		e.ActorName = actorName.String
+		events = append(events, e)
+	}
+	return events, nil
+}
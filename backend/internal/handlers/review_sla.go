@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// ReviewSLAHandler reports task review turnaround against the review SLA
+// and lets admins reassign reviewers
+type ReviewSLAHandler struct {
+	db *sql.DB
+}
+
+func NewReviewSLAHandler(db *sql.DB) *ReviewSLAHandler {
+	return &ReviewSLAHandler{db: db}
+}
+
+// GetPending lists submitted tasks waiting for review, oldest first.
+// Pembimbing only see their own queue; admins can filter by ?reviewer_id=
+// and ?breached=true.
+func (h *ReviewSLAHandler) GetPending(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	reviewerID := int64(0)
+	if normalizeRole(claims.Role) == "pembimbing" {
+		reviewerID = claims.UserID
+	} else if v, err := strconv.ParseInt(r.URL.Query().Get("reviewer_id"), 10, 64); err == nil {
+		reviewerID = v
+	}
+
+	sla := services.LoadReviewSLA(h.db)
+	pending, err := services.PendingReviews(h.db, sla, reviewerID, time.Now())
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch pending reviews")
+		return
+	}
+	if r.URL.Query().Get("breached") == "true" {
+		breached := []models.PendingReview{}
+		for _, p := range pending {
+			if p.Breached {
+				breached = append(breached, p)
+			}
+		}
+		pending = breached
+	}
+	utils.RespondSuccess(w, "Pending reviews retrieved", map[string]interface{}{
+		"policy":  sla,
+		"reviews": pending,
+	})
+}
+
+// GetStats measures time-to-review per reviewer over ?from=&to= (default
+// the last 30 days) with their current queue. Pembimbing only see their
+// own figures.
+func (h *ReviewSLAHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	reviewerID, from, to, ok := aggregateScope(w, r)
+	if !ok {
+		return
+	}
+	sla := services.LoadReviewSLA(h.db)
+	stats, err := services.ReviewerSLAStats(h.db, sla, reviewerID, from, to, time.Now())
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to compute review SLA stats")
+		return
+	}
+	utils.RespondSuccess(w, "Review SLA stats retrieved", models.ReviewSLAReport{
+		Range:     models.AnalyticsRange{From: from, To: to},
+		Policy:    sla,
+		Reviewers: stats,
+	})
+}
+
+// ReassignTask makes another admin or pembimbing the reviewer of a task
+func (h *ReviewSLAHandler) ReassignTask(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	taskID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid task ID")
+		return
+	}
+	var req models.ReassignReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ReviewerID <= 0 {
+		utils.RespondBadRequest(w, "reviewer_id is required")
+		return
+	}
+
+	if err := services.ReassignReviewer(h.db, taskID, req.ReviewerID, claims.UserID, req.Reason); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			utils.RespondNotFound(w, "Task not found")
+		case errors.Is(err, services.ErrInvalidReviewer):
+			utils.RespondBadRequest(w, err.Error())
+		default:
+			utils.RespondInternalError(w, "Failed to reassign reviewer")
+		}
+		return
+	}
+	utils.RespondSuccess(w, "Reviewer reassigned", map[string]interface{}{"task_id": taskID, "reviewer_id": req.ReviewerID})
+}
+
+// ReassignAll moves every open task of from_reviewer_id to reviewer_id,
+// for a reviewer who is away or has left
+func (h *ReviewSLAHandler) ReassignAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	var req models.ReassignReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.FromReviewerID <= 0 || req.ReviewerID <= 0 {
+		utils.RespondBadRequest(w, "from_reviewer_id and reviewer_id are required")
+		return
+	}
+	if req.FromReviewerID == req.ReviewerID {
+		utils.RespondBadRequest(w, "from_reviewer_id and reviewer_id must differ")
+		return
+	}
+
+	moved, err := services.ReassignReviews(h.db, req.FromReviewerID, req.ReviewerID, claims.UserID, req.Reason)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReviewer) {
+			utils.RespondBadRequest(w, err.Error())
+			return
+		}
+		utils.RespondInternalError(w, "Failed to reassign reviews")
+		return
+	}
+	utils.RespondSuccess(w, "Reviews reassigned", map[string]interface{}{"reassigned": moved})
+}
+
+// GetReviewerChanges returns the reviewer reassignment history of a task
+func (h *ReviewSLAHandler) GetReviewerChanges(w http.ResponseWriter, r *http.Request) {
+	taskID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid task ID")
+		return
+	}
+	changes, err := services.LoadReviewerChanges(h.db, taskID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch reviewer history")
+		return
+	}
+	utils.RespondSuccess(w, "Reviewer history retrieved", changes)
+}
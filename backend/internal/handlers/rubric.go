@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// RubricHandler manages the assessment rubrics admins assign per institution
+// or department
+type RubricHandler struct {
+	db *sql.DB
+}
+
+func NewRubricHandler(db *sql.DB) *RubricHandler {
+	return &RubricHandler{db: db}
+}
+
+func (h *RubricHandler) loadRubric(id int64) (models.Rubric, error) {
+	var rb models.Rubric
+	var description sql.NullString
+	var createdBy sql.NullInt64
+	err := h.db.QueryRow(
+		`SELECT id, name, description, is_default, is_active, current_version, created_by, created_at, updated_at
+		 FROM rubrics WHERE id = ?`, id,
+	).Scan(&rb.ID, &rb.Name, &description, &rb.IsDefault, &rb.IsActive, &rb.CurrentVersion, &createdBy, &rb.CreatedAt, &rb.UpdatedAt)
+	if err != nil {
+		return rb, err
+	}
+	rb.Description = ptrStringFromNull(description)
+	rb.CreatedBy = ptrInt64FromNull(createdBy)
+	rb.Assignments, err = h.loadAssignments(id)
+	return rb, err
+}
+
+func (h *RubricHandler) loadAssignments(rubricID int64) ([]models.RubricAssignment, error) {
+	rows, err := h.db.Query(
+		`SELECT ra.id, ra.institution_id, inst.name, ra.department, ra.legacy_school
+		 FROM rubric_assignments ra
+		 LEFT JOIN institutions inst ON ra.institution_id = inst.id
+		 WHERE ra.rubric_id = ? ORDER BY ra.id`, rubricID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.RubricAssignment{}
+	for rows.Next() {
+		var a models.RubricAssignment
+		var institutionID sql.NullInt64
+		var institutionName, department, legacySchool sql.NullString
+		if err := rows.Scan(&a.ID, &institutionID, &institutionName, &department, &legacySchool); err != nil {
+			continue
+		}
+		a.LegacySchool = ptrStringFromNull(legacySchool)
+		a.InstitutionID = ptrInt64FromNull(institutionID)
+		a.InstitutionName = ptrStringFromNull(institutionName)
+		a.Department = ptrStringFromNull(department)
+		list = append(list, a)
+	}
+	return list, nil
+}
+
+// GetAll lists rubrics with their assignments. ?active=true hides retired
+// rubrics.
+func (h *RubricHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM rubrics"
+	if r.URL.Query().Get("active") == "true" {
+		query += " WHERE is_active = TRUE"
+	}
+	rows, err := h.db.Query(query + " ORDER BY is_default DESC, name")
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch rubrics")
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	rubrics := []models.Rubric{}
+	for _, id := range ids {
+		rb, err := h.loadRubric(id)
+		if err != nil {
+			continue
+		}
+		rubrics = append(rubrics, rb)
+	}
+	utils.RespondSuccess(w, "Rubrics retrieved", rubrics)
+}
+
+// GetByID returns a rubric with its current version, or the version given
+// by ?version=
+func (h *RubricHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	rb, err := h.loadRubric(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Rubric not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	version := rb.CurrentVersion
+	if v, err := strconv.Atoi(r.URL.Query().Get("version")); err == nil && v > 0 {
+		version = v
+	}
+	var versionID int64
+	if err := h.db.QueryRow(
+		"SELECT id FROM rubric_versions WHERE rubric_id = ? AND version = ?", id, version,
+	).Scan(&versionID); err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Rubric version not found")
+		return
+	} else if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	rb.Version, err = services.LoadRubricVersion(h.db, versionID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to load rubric version")
+		return
+	}
+	utils.RespondSuccess(w, "Rubric retrieved", rb)
+}
+
+// GetVersions lists the versions of a rubric and how many assessments
+// used each
+func (h *RubricHandler) GetVersions(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	rows, err := h.db.Query(
+		`SELECT v.id, v.version, v.created_at, u.name,
+		        (SELECT COUNT(*) FROM assessments a WHERE a.rubric_version_id = v.id)
+		 FROM rubric_versions v
+		 LEFT JOIN users u ON v.created_by = u.id
+		 WHERE v.rubric_id = ?
+		 ORDER BY v.version DESC`, id,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch rubric versions")
+		return
+	}
+	defer rows.Close()
+
+	versions := []map[string]interface{}{}
+	for rows.Next() {
+		var versionID int64
+		var version, assessments int
+		var createdAt sql.NullTime
+		var createdBy sql.NullString
+		if err := rows.Scan(&versionID, &version, &createdAt, &createdBy, &assessments); err != nil {
+			continue
+		}
+		versions = append(versions, map[string]interface{}{
+			"id":              versionID,
+			"version":         version,
+			"created_at":      ptrTimeFromNull(createdAt),
+			"created_by_name": createdBy.String,
+			"assessments":     assessments,
+		})
+	}
+	utils.RespondSuccess(w, "Rubric versions retrieved", versions)
+}
+
+// Resolve returns the rubric version that applies to ?intern_id=
+func (h *RubricHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	internID, err := strconv.ParseInt(r.URL.Query().Get("intern_id"), 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "intern_id is required")
+		return
+	}
+	version, err := services.ResolveRubricVersion(h.db, internID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if errors.Is(err, services.ErrRubricNotFound) {
+		utils.RespondNotFound(w, err.Error())
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to resolve rubric")
+		return
+	}
+	utils.RespondSuccess(w, "Rubric resolved", version)
+}
+
+func (h *RubricHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	var req models.RubricRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		utils.RespondBadRequest(w, "name is required")
+		return
+	}
+
+	version := models.RubricVersion{
+		ScaleMin:   0,
+		ScaleMax:   100,
+		Criteria:   req.Criteria,
+		Thresholds: req.Thresholds,
+	}
+	if req.ScaleMin != nil {
+		version.ScaleMin = *req.ScaleMin
+	}
+	if req.ScaleMax != nil {
+		version.ScaleMax = *req.ScaleMax
+	}
+	if err := services.ValidateRubricVersion(version); err != nil {
+		utils.RespondBadRequest(w, rubricErrorMessage(err))
+		return
+	}
+
+	isDefault := req.IsDefault != nil && *req.IsDefault
+	isActive := req.IsActive == nil || *req.IsActive
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	defer tx.Rollback()
+
+	if isDefault {
+		if _, err := tx.Exec("UPDATE rubrics SET is_default = FALSE WHERE is_default = TRUE"); err != nil {
+			utils.RespondInternalError(w, "Failed to create rubric")
+			return
+		}
+	}
+	res, err := tx.Exec(
+		`INSERT INTO rubrics (name, description, is_default, is_active, current_version, created_by)
+		 VALUES (?, ?, ?, ?, 1, ?)`,
+		req.Name, nullIfEmpty(req.Description), isDefault, isActive, claims.UserID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create rubric")
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	if _, err := services.SaveRubricVersion(tx, id, 1, version, claims.UserID); err != nil {
+		utils.RespondInternalError(w, "Failed to save rubric criteria")
+		return
+	}
+	if err := replaceRubricAssignments(tx, id, req.Assignments); err != nil {
+		if errors.Is(err, services.ErrInvalidRubric) {
+			utils.RespondBadRequest(w, rubricErrorMessage(err))
+			return
+		}
+		utils.RespondInternalError(w, "Failed to save rubric assignments")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to create rubric")
+		return
+	}
+
+	rb, _ := h.loadRubric(id)
+	rb.Version, _ = services.LoadCurrentRubricVersion(h.db, id)
+	utils.RespondCreated(w, "Rubric created", rb)
+}
+
+// Update changes a rubric. Name, status and assignments are edited in
+// place; changing the scale, criteria or thresholds creates a new version
+// so existing assessments keep the version they were scored with.
+func (h *RubricHandler) Update(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req models.RubricRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	rb, err := h.loadRubric(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Rubric not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	newVersion := req.ScaleMin != nil || req.ScaleMax != nil || req.Criteria != nil || req.Thresholds != nil
+	var version models.RubricVersion
+	if newVersion {
+		var versionID int64
+		if err := h.db.QueryRow(
+			"SELECT id FROM rubric_versions WHERE rubric_id = ? AND version = ?", id, rb.CurrentVersion,
+		).Scan(&versionID); err != nil {
+			utils.RespondInternalError(w, "Failed to load rubric version")
+			return
+		}
+		current, err := services.LoadRubricVersion(h.db, versionID)
+		if err != nil {
+			utils.RespondInternalError(w, "Failed to load rubric version")
+			return
+		}
+		version = *current
+		if req.ScaleMin != nil {
+			version.ScaleMin = *req.ScaleMin
+		}
+		if req.ScaleMax != nil {
+			version.ScaleMax = *req.ScaleMax
+		}
+		if req.Criteria != nil {
+			version.Criteria = req.Criteria
+		}
+		if req.Thresholds != nil {
+			version.Thresholds = req.Thresholds
+		}
+		if err := services.ValidateRubricVersion(version); err != nil {
+			utils.RespondBadRequest(w, rubricErrorMessage(err))
+			return
+		}
+	}
+
+	if rb.IsDefault && req.IsActive != nil && !*req.IsActive {
+		utils.RespondBadRequest(w, "The default rubric cannot be deactivated; choose another default first")
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	defer tx.Rollback()
+
+	updates := []string{}
+	args := []interface{}{}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		updates = append(updates, "name = ?")
+		args = append(args, name)
+	}
+	if req.Description != "" {
+		updates = append(updates, "description = ?")
+		args = append(args, nullIfEmpty(req.Description))
+	}
+	if req.IsActive != nil {
+		updates = append(updates, "is_active = ?")
+		args = append(args, *req.IsActive)
+	}
+	if req.IsDefault != nil && *req.IsDefault && !rb.IsDefault {
+		if _, err := tx.Exec("UPDATE rubrics SET is_default = FALSE WHERE is_default = TRUE"); err != nil {
+			utils.RespondInternalError(w, "Failed to update rubric")
+			return
+		}
+		updates = append(updates, "is_default = TRUE", "is_active = TRUE")
+	}
+	if newVersion {
+		if _, err := services.SaveRubricVersion(tx, id, rb.CurrentVersion+1, version, claims.UserID); err != nil {
+			utils.RespondInternalError(w, "Failed to save rubric criteria")
+			return
+		}
+		updates = append(updates, "current_version = ?")
+		args = append(args, rb.CurrentVersion+1)
+	}
+	if len(updates) > 0 {
+		args = append(args, id)
+		if _, err := tx.Exec("UPDATE rubrics SET "+strings.Join(updates, ", ")+" WHERE id = ?", args...); err != nil {
+			utils.RespondInternalError(w, "Failed to update rubric")
+			return
+		}
+	}
+	if req.Assignments != nil {
+		if err := replaceRubricAssignments(tx, id, req.Assignments); err != nil {
+			if errors.Is(err, services.ErrInvalidRubric) {
+				utils.RespondBadRequest(w, rubricErrorMessage(err))
+				return
+			}
+			utils.RespondInternalError(w, "Failed to save rubric assignments")
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to update rubric")
+		return
+	}
+
+	rb, _ = h.loadRubric(id)
+	rb.Version, _ = services.LoadCurrentRubricVersion(h.db, id)
+	utils.RespondSuccess(w, "Rubric updated", rb)
+}
+
+// Delete removes an unused rubric. Rubrics that assessments were scored
+// with are deactivated instead so their history stays readable.
+func (h *RubricHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	rb, err := h.loadRubric(id)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Rubric not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if rb.IsDefault {
+		utils.RespondBadRequest(w, "The default rubric cannot be deleted; choose another default first")
+		return
+	}
+
+	var used int
+	if err := h.db.QueryRow(
+		`SELECT COUNT(*) FROM assessments a
+		 JOIN rubric_versions v ON a.rubric_version_id = v.id
+		 WHERE v.rubric_id = ?`, id,
+	).Scan(&used); err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if used > 0 {
+		if _, err := h.db.Exec("UPDATE rubrics SET is_active = FALSE WHERE id = ?", id); err != nil {
+			utils.RespondInternalError(w, "Failed to deactivate rubric")
+			return
+		}
+		utils.RespondSuccess(w, "Rubric is used by existing assessments and has been deactivated", nil)
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM rubrics WHERE id = ?", id); err != nil {
+		utils.RespondInternalError(w, "Failed to delete rubric")
+		return
+	}
+	utils.RespondSuccess(w, "Rubric deleted", nil)
+}
+
+func replaceRubricAssignments(tx *sql.Tx, rubricID int64, assignments []models.RubricAssignment) error {
+	if _, err := tx.Exec("DELETE FROM rubric_assignments WHERE rubric_id = ?", rubricID); err != nil {
+		return err
+	}
+	for _, a := range assignments {
+		var institutionID interface{}
+		var department, legacySchool string
+		if a.InstitutionID != nil && *a.InstitutionID > 0 {
+			var exists bool
+			if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM institutions WHERE id = ?)", *a.InstitutionID).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%w: institution %d not found", services.ErrInvalidRubric, *a.InstitutionID)
+			}
+			institutionID = *a.InstitutionID
+		}
+		if a.Department != nil {
+			department = strings.TrimSpace(*a.Department)
+		}
+		// An unmapped legacy assignment is kept as is until it gets an
+		// institution
+		if institutionID == nil && a.LegacySchool != nil {
+			legacySchool = strings.TrimSpace(*a.LegacySchool)
+		}
+		if institutionID == nil && department == "" && legacySchool == "" {
+			continue
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO rubric_assignments (rubric_id, institution_id, department, legacy_school) VALUES (?, ?, ?, ?)",
+			rubricID, institutionID, nullIfEmpty(department), nullIfEmpty(legacySchool),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rubricErrorMessage(err error) string {
+	return strings.TrimPrefix(err.Error(), services.ErrInvalidRubric.Error()+": ")
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// SupervisionHandler manages an intern's primary, secondary and academic
+// supervisors
+type SupervisionHandler struct {
+	db *sql.DB
+}
+
+func NewSupervisionHandler(db *sql.DB) *SupervisionHandler {
+	return &SupervisionHandler{db: db}
+}
+
+// supervisesIntern tells whether userID may view an intern's records: the
+// primary supervisor or their delegate, or a co-supervisor. Approvals stay
+// with coversSupervisor.
+func supervisesIntern(db *sql.DB, userID, internID int64, supervisorID sql.NullInt64) bool {
+	return coversSupervisor(db, userID, supervisorID) || services.IsCoSupervisor(db, userID, internID)
+}
+
+// GetSupervisors lists an intern's supervisors
+func (h *SupervisionHandler) GetSupervisors(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	internID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid intern ID")
+		return
+	}
+	var userID int64
+	var supervisorID sql.NullInt64
+	err = h.db.QueryRow("SELECT user_id, supervisor_id FROM interns WHERE id = ?", internID).Scan(&userID, &supervisorID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Intern not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	switch normalizeRole(claims.Role) {
+	case "intern":
+		if userID != claims.UserID {
+			utils.RespondForbidden(w, "You can only view your own supervisors")
+			return
+		}
+	case "pembimbing":
+		if !supervisesIntern(h.db, claims.UserID, internID, supervisorID) {
+			utils.RespondForbidden(w, "You can only view supervisors of your interns")
+			return
+		}
+	}
+
+	list, err := services.LoadInternSupervisors(h.db, internID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch supervisors")
+		return
+	}
+	utils.RespondSuccess(w, "Supervisors retrieved", list)
+}
+
+// SetSupervisors replaces an intern's supervisors
+func (h *SupervisionHandler) SetSupervisors(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+	internID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.RespondBadRequest(w, "Invalid intern ID")
+		return
+	}
+	var req models.SetInternSupervisorsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	if err := services.SetInternSupervisors(h.db, internID, req, claims.UserID); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			utils.RespondNotFound(w, "Intern not found")
+		case errors.Is(err, services.ErrInvalidSupervisor):
+			utils.RespondBadRequest(w, err.Error())
+		default:
+			utils.RespondInternalError(w, "Failed to update supervisors")
+		}
+		return
+	}
+	list, _ := services.LoadInternSupervisors(h.db, internID)
+	utils.RespondSuccess(w, "Supervisors updated", list)
+}
@@ -10,6 +10,7 @@ import (
 
 	"dsi_interna_sys/internal/middleware"
 	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
 	"dsi_interna_sys/internal/utils"
 
 	"github.com/gorilla/mux"
@@ -104,8 +105,9 @@ func (h *TaskHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Supervisors/pembimbing only see tasks for their own interns OR tasks they assigned
 		if claims.Role == "pembimbing" || claims.Role == "supervisor" {
-			where = append(where, "(i.supervisor_id = ? OR t.assigned_by = ? OR t.assigner_id = ?)")
-			args = append(args, claims.UserID, claims.UserID, claims.UserID)
+			where = append(where, "("+services.SupervisedInternCond+" OR t.assigned_by = ? OR t.assigner_id = ?)")
+			args = append(args, services.SupervisedInternArgs(claims.UserID)...)
+			args = append(args, claims.UserID, claims.UserID)
 		}
 		if internFilter != "" {
 			if id, err := strconv.ParseInt(internFilter, 10, 64); err == nil {
@@ -218,6 +220,8 @@ func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	h.loadTaskStructure(&t)
+
 	utils.RespondSuccess(w, "Task retrieved", t)
 }
 
@@ -411,6 +415,7 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		}
 
 		taskID, _ := taskRes.LastInsertId()
+		services.QueueRollupRefresh(tx, it.ID)
 
 		// For intern reporting, send notification to the assigner
 		if isIntern && req.AssignerID > 0 {
@@ -604,6 +609,10 @@ func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
 		utils.RespondInternalError(w, "Failed to update task")
 		return
 	}
+	services.QueueRollupRefresh(h.db, current.InternID)
+	if req.Status != nil && *req.Status == "completed" {
+		services.ReleaseDependents(h.db, taskID)
+	}
 
 	utils.RespondSuccess(w, "Task updated", nil)
 }
@@ -676,6 +685,7 @@ func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		utils.RespondInternalError(w, "Failed to delete task")
 		return
 	}
+	services.QueueRollupRefresh(h.db, current.InternID)
 
 	utils.RespondSuccess(w, "Task deleted", nil)
 }
@@ -744,6 +754,8 @@ func (h *TaskHandler) MarkComplete(w http.ResponseWriter, r *http.Request) {
 		utils.RespondInternalError(w, "Failed to update task")
 		return
 	}
+	services.QueueTaskRollupRefresh(h.db, taskID)
+	services.ReleaseDependents(h.db, taskID)
 
 	utils.RespondSuccess(w, "Task marked complete", nil)
 }
@@ -768,6 +780,9 @@ func (h *TaskHandler) Submit(w http.ResponseWriter, r *http.Request) {
 		utils.RespondNotFound(w, "Intern not found")
 		return
 	}
+	if !h.checkTaskWorkable(w, taskID, internID) {
+		return
+	}
 
 	var req submitTaskRequest
 	var submissionFilePath sql.NullString
@@ -857,7 +872,14 @@ func (h *TaskHandler) Submit(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	isLate := h.isLate(deadline, deadlineTime, now)
 
-	_, err = h.db.Exec(
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
 		`UPDATE tasks SET status = 'submitted', submitted_at = ?, submission_notes = ?, submission_links = ?, submission_file = ?, is_late = ?,
 		        started_at = COALESCE(started_at, ?)
 		 WHERE id = ?`,
@@ -868,16 +890,37 @@ func (h *TaskHandler) Submit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Notify Supervisor
+	// Every attempt is kept as its own version so revisions can be compared
+	var version int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(version), 0) + 1 FROM task_submissions WHERE task_id = ? FOR UPDATE", taskID).Scan(&version); err != nil {
+		utils.RespondInternalError(w, "Failed to record submission")
+		return
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO task_submissions (task_id, version, submitted_by, submission_notes, submission_links, submission_file, is_late, submitted_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		taskID, version, claims.UserID, nullIfEmpty(req.SubmissionNotes), string(linksJSON), submissionFilePath, isLate, now,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to record submission")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to submit task")
+		return
+	}
+	services.QueueRollupRefresh(h.db, internID)
+
+	// Notify the reviewer
 	var supervisorID int64
-	err = h.db.QueryRow("SELECT assigned_by FROM tasks WHERE id = ?", taskID).Scan(&supervisorID)
+	err = h.db.QueryRow("SELECT "+services.ReviewerExpr+" FROM tasks t WHERE t.id = ?", taskID).Scan(&supervisorID)
 	if err == nil {
 		_ = createNotification(h.db, supervisorID, models.NotificationTaskSubmitted, "Tugas Dikumpulkan",
 			"Seorang intern telah mengumpulkan tugas. Silakan periksa.", "/tasks/"+strconv.FormatInt(taskID, 10),
 			map[string]interface{}{"task_id": taskID})
 	}
 
-	utils.RespondSuccess(w, "Task submitted", map[string]interface{}{"is_late": isLate})
+	utils.RespondSuccess(w, "Task submitted", map[string]interface{}{"is_late": isLate, "version": version})
 }
 
 // Review task by admin/pembimbing
@@ -910,12 +953,12 @@ func (h *TaskHandler) Review(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var internUserID int64
-	var assignerID sql.NullInt64
+	var reviewerID sql.NullInt64
 	err := h.db.QueryRow(
-		`SELECT i.user_id, t.assigner_id FROM tasks t
+		`SELECT i.user_id, `+services.ReviewerExpr+` FROM tasks t
 		 LEFT JOIN interns i ON t.intern_id = i.id
 		 WHERE t.id = ?`, taskID,
-	).Scan(&internUserID, &assignerID)
+	).Scan(&internUserID, &reviewerID)
 	if err == sql.ErrNoRows {
 		utils.RespondNotFound(w, "Task not found")
 		return
@@ -927,15 +970,22 @@ func (h *TaskHandler) Review(w http.ResponseWriter, r *http.Request) {
 
 	rawRole := strings.ToLower(claims.Role)
 	isAdmin := rawRole == "admin" || rawRole == "super_admin"
-	isAssigner := assignerID.Valid && assignerID.Int64 == claims.UserID
+	isReviewer := coversSupervisor(h.db, claims.UserID, reviewerID)
+
+	if !isAdmin && !isReviewer {
+		utils.RespondForbidden(w, "Only the task's reviewer or an administrator can review this task")
+		return
+	}
 
-	if !isAdmin && !isAssigner {
-		utils.RespondForbidden(w, "Only the assigned admin or a super-administrator can review this task")
+	tx, err := h.db.Begin()
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to start transaction")
 		return
 	}
+	defer tx.Rollback()
 
 	if req.Action == "approve" {
-		_, err = h.db.Exec(
+		_, err = tx.Exec(
 			`UPDATE tasks SET status = 'completed', completed_at = ?, approved_at = ?, score = ?, admin_feedback = ?
 			 WHERE id = ?`,
 			time.Now(), time.Now(), *req.Score, nullIfEmpty(req.Feedback), taskID,
@@ -944,11 +994,8 @@ func (h *TaskHandler) Review(w http.ResponseWriter, r *http.Request) {
 			utils.RespondInternalError(w, "Failed to approve task")
 			return
 		}
-		_ = createNotification(h.db, internUserID, models.NotificationTaskApproved, "Tugas Disetujui",
-			"Tugas Anda telah disetujui. Nilai: "+strconv.Itoa(*req.Score), "/tasks/"+strconv.FormatInt(taskID, 10),
-			map[string]interface{}{"task_id": taskID, "score": *req.Score})
 	} else {
-		_, err = h.db.Exec(
+		_, err = tx.Exec(
 			`UPDATE tasks SET status = 'revision', admin_feedback = ?, score = NULL, approved_at = ?
 			 WHERE id = ?`,
 			nullIfEmpty(req.Feedback), time.Now(), taskID,
@@ -957,11 +1004,50 @@ func (h *TaskHandler) Review(w http.ResponseWriter, r *http.Request) {
 			utils.RespondInternalError(w, "Failed to request revision")
 			return
 		}
+	}
+
+	// Each review is its own record, tied to the submission version it judged
+	var submissionID sql.NullInt64
+	_ = tx.QueryRow("SELECT id FROM task_submissions WHERE task_id = ? ORDER BY version DESC LIMIT 1", taskID).Scan(&submissionID)
+	var score sql.NullInt64
+	if req.Action == "approve" {
+		score = sql.NullInt64{Int64: int64(*req.Score), Valid: true}
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO task_reviews (task_id, submission_id, reviewer_id, action, score, feedback, reviewed_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		taskID, submissionID, claims.UserID, req.Action, score, nullIfEmpty(req.Feedback), time.Now(),
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to record review")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondInternalError(w, "Failed to process review")
+		return
+	}
+	if reviewerID.Valid {
+		services.NoteDelegatedAction(h.db, claims.UserID, reviewerID.Int64, "task."+req.Action, "task", taskID)
+	}
+	var taskInternID sql.NullInt64
+	if h.db.QueryRow("SELECT intern_id FROM tasks WHERE id = ?", taskID).Scan(&taskInternID) == nil && taskInternID.Valid {
+		services.QueueRollupRefresh(h.db, taskInternID.Int64)
+	}
+
+	if req.Action == "approve" {
+		services.ReleaseDependents(h.db, taskID)
+		_ = createNotification(h.db, internUserID, models.NotificationTaskApproved, "Tugas Disetujui",
+			"Tugas Anda telah disetujui. Nilai: "+strconv.Itoa(*req.Score), "/tasks/"+strconv.FormatInt(taskID, 10),
+			map[string]interface{}{"task_id": taskID, "score": *req.Score})
+	} else {
 		_ = createNotification(h.db, internUserID, models.NotificationTaskRevision, "Perlu Revisi",
 			"Tugas Anda memerlukan revisi. Silakan cek feedback pembimbing.", "/tasks/"+strconv.FormatInt(taskID, 10),
 			map[string]interface{}{"task_id": taskID})
 	}
 
+	// admin_feedback only holds the latest round; keep every round in the thread
+	recordComment(h.db, models.CommentEntityTask, taskID, claims.UserID, req.Feedback)
+
 	utils.RespondSuccess(w, "Review processed", nil)
 }
 
@@ -995,6 +1081,9 @@ func (h *TaskHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		utils.RespondNotFound(w, "Intern not found")
 		return
 	}
+	if !h.checkTaskWorkable(w, taskID, internID) {
+		return
+	}
 
 	query := "UPDATE tasks SET status = ?"
 	args := []interface{}{req.Status}
@@ -1009,6 +1098,7 @@ func (h *TaskHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		utils.RespondInternalError(w, "Failed to update status")
 		return
 	}
+	services.QueueRollupRefresh(h.db, internID)
 
 	utils.RespondSuccess(w, "Status updated", nil)
 }
@@ -1353,6 +1443,28 @@ func (h *TaskHandler) assignmentStats(assignmentID int64) map[string]interface{}
 		progress = (float64(completed.Int64) / float64(total.Int64)) * 100
 	}
 
+	// Checklist items of the assignment's tasks and their subtasks
+	var checklistTotal, checklistDone, blocked sql.NullInt64
+	_ = h.db.QueryRow(`
+		SELECT COUNT(c.id), SUM(CASE WHEN c.is_done = 1 THEN 1 ELSE 0 END)
+		FROM task_checklist_items c
+		JOIN tasks t ON c.task_id = t.id
+		LEFT JOIN tasks p ON t.parent_task_id = p.id
+		WHERE t.task_assignment_id = ? OR p.task_assignment_id = ?
+	`, assignmentID, assignmentID).Scan(&checklistTotal, &checklistDone)
+	_ = h.db.QueryRow(`
+		SELECT COUNT(DISTINCT t.id)
+		FROM tasks t
+		JOIN task_dependencies d ON d.task_id = t.id
+		JOIN tasks pre ON d.depends_on_task_id = pre.id
+		WHERE t.task_assignment_id = ? AND t.status = 'scheduled' AND pre.status != 'completed'
+	`, assignmentID).Scan(&blocked)
+
+	checklistProgress := 0.0
+	if int64OrZero(checklistTotal) > 0 {
+		checklistProgress = float64(int64OrZero(checklistDone)) / float64(checklistTotal.Int64) * 100
+	}
+
 	stats := map[string]interface{}{
 		"total":               int64OrZero(total),
 		"completed":           int64OrZero(completed),
@@ -1365,6 +1477,10 @@ func (h *TaskHandler) assignmentStats(assignmentID int64) map[string]interface{}
 		"scheduled":           int64OrZero(scheduled),
 		"progress_percentage": int(progress + 0.5),
 		"average_score":       floatOrZero(avgScore),
+		"blocked":             int64OrZero(blocked),
+		"checklist_total":     int64OrZero(checklistTotal),
+		"checklist_done":      int64OrZero(checklistDone),
+		"checklist_progress":  int(checklistProgress + 0.5),
 	}
 	return stats
 }
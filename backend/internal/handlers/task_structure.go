@@ -0,0 +1,671 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// Subtasks nest at most this deep; it also bounds progress roll-up
+const maxSubtaskDepth = 5
+
+type checklistItemRequest struct {
+	Title    *string `json:"title"`
+	IsDone   *bool   `json:"is_done"`
+	Position *int    `json:"position"`
+}
+
+type createSubtaskRequest struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Priority     string `json:"priority"`
+	StartDate    string `json:"start_date"`    // YYYY-MM-DD
+	Deadline     string `json:"deadline"`      // YYYY-MM-DD
+	DeadlineTime string `json:"deadline_time"` // HH:MM
+}
+
+type addDependencyRequest struct {
+	DependsOnTaskID int64 `json:"depends_on_task_id"`
+}
+
+// taskAccess describes what the caller may do with a task
+type taskAccess struct {
+	InternID  int64
+	CanView   bool
+	CanManage bool // edit structure: subtasks, dependencies, checklist items
+}
+
+func (h *TaskHandler) resolveTaskAccess(claims *middleware.Claims, taskID int64) (taskAccess, error) {
+	var access taskAccess
+	var supervisorID, assignerID sql.NullInt64
+	var assignedBy int64
+	var isUnscheduled bool
+	err := h.db.QueryRow(
+		`SELECT t.intern_id, t.assigned_by, t.assigner_id, t.is_unscheduled, i.supervisor_id
+		 FROM tasks t LEFT JOIN interns i ON t.intern_id = i.id
+		 WHERE t.id = ?`, taskID,
+	).Scan(&access.InternID, &assignedBy, &assignerID, &isUnscheduled, &supervisorID)
+	if err != nil {
+		return access, err
+	}
+
+	switch normalizeRole(claims.Role) {
+	case "admin":
+		access.CanView = true
+		access.CanManage = true
+	case "pembimbing":
+		access.CanView = true
+		access.CanManage = assignedBy == claims.UserID ||
+			(assignerID.Valid && assignerID.Int64 == claims.UserID) ||
+			(supervisorID.Valid && supervisorID.Int64 == claims.UserID)
+	case "intern":
+		ownInternID, err := h.getInternIDForUser(claims.UserID)
+		access.CanView = err == nil && ownInternID == access.InternID
+		access.CanManage = access.CanView && isUnscheduled
+	}
+	return access, nil
+}
+
+// checkTaskWorkable rejects work on an intern's task that has not been
+// released yet or still waits on prerequisites. It writes the response
+// and returns false when the task cannot be worked on.
+func (h *TaskHandler) checkTaskWorkable(w http.ResponseWriter, taskID, internID int64) bool {
+	var status string
+	err := h.db.QueryRow("SELECT status FROM tasks WHERE id = ? AND intern_id = ?", taskID, internID).Scan(&status)
+	if err == sql.ErrNoRows {
+		utils.RespondForbidden(w, "You do not have access to this task")
+		return false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return false
+	}
+	if status == "scheduled" {
+		utils.RespondBadRequest(w, "This task has not been released yet")
+		return false
+	}
+	blocked, err := services.HasOpenPrerequisites(h.db, taskID)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return false
+	}
+	if blocked {
+		utils.RespondBadRequest(w, "This task is blocked by tasks that are not completed yet")
+		return false
+	}
+	return true
+}
+
+// loadTaskStructure fills parent, checklist, subtasks, dependencies and progress of a task
+func (h *TaskHandler) loadTaskStructure(t *models.Task) {
+	var parentID sql.NullInt64
+	_ = h.db.QueryRow("SELECT parent_task_id FROM tasks WHERE id = ?", t.ID).Scan(&parentID)
+	t.ParentTaskID = ptrInt64FromNull(parentID)
+
+	t.Checklist = h.loadChecklist(t.ID)
+	t.Subtasks = h.loadTaskSummaries("SELECT id, title, status FROM tasks WHERE parent_task_id = ? ORDER BY start_date, id", t.ID)
+	t.BlockedBy = h.loadTaskSummaries(
+		`SELECT p.id, p.title, p.status FROM task_dependencies d
+		 JOIN tasks p ON d.depends_on_task_id = p.id
+		 WHERE d.task_id = ? ORDER BY p.id`, t.ID)
+
+	progress := h.taskProgress(t.ID, t.Status, 0)
+	for _, dep := range t.BlockedBy {
+		if dep.Status != "completed" {
+			progress.IsBlocked = true
+			break
+		}
+	}
+	t.Progress = &progress
+}
+
+func (h *TaskHandler) loadChecklist(taskID int64) []models.TaskChecklistItem {
+	rows, err := h.db.Query(
+		`SELECT id, task_id, title, is_done, done_at, done_by, position, created_at
+		 FROM task_checklist_items WHERE task_id = ? ORDER BY position, id`, taskID,
+	)
+	if err != nil {
+		return []models.TaskChecklistItem{}
+	}
+	defer rows.Close()
+
+	items := []models.TaskChecklistItem{}
+	for rows.Next() {
+		var item models.TaskChecklistItem
+		var doneAt sql.NullTime
+		var doneBy sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.TaskID, &item.Title, &item.IsDone, &doneAt, &doneBy, &item.Position, &item.CreatedAt); err != nil {
+			continue
+		}
+		item.DoneAt = ptrTimeFromNull(doneAt)
+		item.DoneBy = ptrInt64FromNull(doneBy)
+		items = append(items, item)
+	}
+	return items
+}
+
+func (h *TaskHandler) loadTaskSummaries(query string, taskID int64) []models.TaskSummary {
+	rows, err := h.db.Query(query, taskID)
+	if err != nil {
+		return []models.TaskSummary{}
+	}
+
+	summaries := []models.TaskSummary{}
+	for rows.Next() {
+		var s models.TaskSummary
+		if err := rows.Scan(&s.ID, &s.Title, &s.Status); err == nil {
+			summaries = append(summaries, s)
+		}
+	}
+	rows.Close()
+
+	for i := range summaries {
+		summaries[i].Progress = h.taskProgress(summaries[i].ID, summaries[i].Status, 1).Percentage
+	}
+	return summaries
+}
+
+// taskProgress rolls checklist items and (recursively) subtasks up into a percentage
+func (h *TaskHandler) taskProgress(taskID int64, status string, depth int) models.TaskProgress {
+	var p models.TaskProgress
+	var total, done sql.NullInt64
+	_ = h.db.QueryRow(
+		"SELECT COUNT(*), SUM(CASE WHEN is_done = 1 THEN 1 ELSE 0 END) FROM task_checklist_items WHERE task_id = ?", taskID,
+	).Scan(&total, &done)
+	p.ChecklistTotal = int(int64OrZero(total))
+	p.ChecklistDone = int(int64OrZero(done))
+
+	subtaskPercents := []int{}
+	if depth < maxSubtaskDepth {
+		rows, err := h.db.Query("SELECT id, status FROM tasks WHERE parent_task_id = ?", taskID)
+		if err == nil {
+			type child struct {
+				ID     int64
+				Status string
+			}
+			var children []child
+			for rows.Next() {
+				var c child
+				if err := rows.Scan(&c.ID, &c.Status); err == nil {
+					children = append(children, c)
+				}
+			}
+			rows.Close()
+			for _, c := range children {
+				p.SubtasksTotal++
+				if c.Status == "completed" {
+					p.SubtasksCompleted++
+				}
+				subtaskPercents = append(subtaskPercents, h.taskProgress(c.ID, c.Status, depth+1).Percentage)
+			}
+		}
+	}
+
+	p.Percentage = rollupProgress(status, p.ChecklistDone, p.ChecklistTotal, subtaskPercents)
+	return p
+}
+
+// rollupProgress weighs every checklist item and every subtask equally.
+// A completed task is always 100%; a task without structure is 0% until completed.
+func rollupProgress(status string, checklistDone, checklistTotal int, subtaskPercents []int) int {
+	if status == "completed" {
+		return 100
+	}
+	parts := checklistTotal + len(subtaskPercents)
+	if parts == 0 {
+		return 0
+	}
+	sum := checklistDone * 100
+	for _, pct := range subtaskPercents {
+		sum += pct
+	}
+	return int(float64(sum)/float64(parts) + 0.5)
+}
+
+func (h *TaskHandler) AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	access, err := h.resolveTaskAccess(claims, taskID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !access.CanManage {
+		utils.RespondForbidden(w, "You cannot edit the checklist of this task")
+		return
+	}
+
+	var req checklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.Title == nil || strings.TrimSpace(*req.Title) == "" {
+		utils.RespondBadRequest(w, "Title is required")
+		return
+	}
+
+	position := 0
+	if req.Position != nil {
+		position = *req.Position
+	} else {
+		_ = h.db.QueryRow("SELECT COALESCE(MAX(position), -1) + 1 FROM task_checklist_items WHERE task_id = ?", taskID).Scan(&position)
+	}
+
+	res, err := h.db.Exec(
+		"INSERT INTO task_checklist_items (task_id, title, position) VALUES (?, ?, ?)",
+		taskID, strings.TrimSpace(*req.Title), position,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to add checklist item")
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	utils.RespondCreated(w, "Checklist item added", map[string]int64{"id": id})
+}
+
+// UpdateChecklistItem renames/reorders an item (managers) or ticks it (anyone who can view the task)
+func (h *TaskHandler) UpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	itemID, _ := strconv.ParseInt(vars["itemId"], 10, 64)
+
+	access, err := h.resolveTaskAccess(claims, taskID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !access.CanView {
+		utils.RespondForbidden(w, "You do not have access to this task")
+		return
+	}
+
+	var req checklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if (req.Title != nil || req.Position != nil) && !access.CanManage {
+		utils.RespondForbidden(w, "You can only tick checklist items of this task")
+		return
+	}
+
+	updates := []string{}
+	args := []interface{}{}
+	if req.Title != nil {
+		if strings.TrimSpace(*req.Title) == "" {
+			utils.RespondBadRequest(w, "Title cannot be empty")
+			return
+		}
+		updates = append(updates, "title = ?")
+		args = append(args, strings.TrimSpace(*req.Title))
+	}
+	if req.Position != nil {
+		updates = append(updates, "position = ?")
+		args = append(args, *req.Position)
+	}
+	if req.IsDone != nil {
+		if *req.IsDone {
+			updates = append(updates, "is_done = 1", "done_at = ?", "done_by = ?")
+			args = append(args, time.Now(), claims.UserID)
+		} else {
+			updates = append(updates, "is_done = 0", "done_at = NULL", "done_by = NULL")
+		}
+	}
+	if len(updates) == 0 {
+		utils.RespondBadRequest(w, "No updates provided")
+		return
+	}
+
+	args = append(args, itemID, taskID)
+	res, err := h.db.Exec("UPDATE task_checklist_items SET "+strings.Join(updates, ", ")+" WHERE id = ? AND task_id = ?", args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to update checklist item")
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		var exists int
+		if err := h.db.QueryRow("SELECT 1 FROM task_checklist_items WHERE id = ? AND task_id = ?", itemID, taskID).Scan(&exists); err != nil {
+			utils.RespondNotFound(w, "Checklist item not found")
+			return
+		}
+	}
+
+	utils.RespondSuccess(w, "Checklist item updated", nil)
+}
+
+func (h *TaskHandler) DeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	itemID, _ := strconv.ParseInt(vars["itemId"], 10, 64)
+
+	access, err := h.resolveTaskAccess(claims, taskID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !access.CanManage {
+		utils.RespondForbidden(w, "You cannot edit the checklist of this task")
+		return
+	}
+
+	res, err := h.db.Exec("DELETE FROM task_checklist_items WHERE id = ? AND task_id = ?", itemID, taskID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to delete checklist item")
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		utils.RespondNotFound(w, "Checklist item not found")
+		return
+	}
+
+	utils.RespondSuccess(w, "Checklist item deleted", nil)
+}
+
+// CreateSubtask creates a child task for the same intern as the parent
+func (h *TaskHandler) CreateSubtask(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	parentID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	access, err := h.resolveTaskAccess(claims, parentID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !access.CanManage {
+		utils.RespondForbidden(w, "You cannot add subtasks to this task")
+		return
+	}
+
+	depth := 0
+	for cursor := parentID; depth <= maxSubtaskDepth; depth++ {
+		var next sql.NullInt64
+		if err := h.db.QueryRow("SELECT parent_task_id FROM tasks WHERE id = ?", cursor).Scan(&next); err != nil || !next.Valid {
+			break
+		}
+		cursor = next.Int64
+	}
+	if depth >= maxSubtaskDepth {
+		utils.RespondBadRequest(w, "Subtasks cannot be nested deeper than "+strconv.Itoa(maxSubtaskDepth)+" levels")
+		return
+	}
+
+	var req createSubtaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		utils.RespondBadRequest(w, "Title is required")
+		return
+	}
+
+	var parent struct {
+		SubmissionMethod sql.NullString
+		Priority         string
+		StartDate        time.Time
+		Deadline         sql.NullTime
+		DeadlineTime     sql.NullString
+		IsUnscheduled    bool
+		AssignerID       sql.NullInt64
+	}
+	if err := h.db.QueryRow(
+		`SELECT submission_method, priority, start_date, deadline, deadline_time, is_unscheduled, assigner_id FROM tasks WHERE id = ?`, parentID,
+	).Scan(&parent.SubmissionMethod, &parent.Priority, &parent.StartDate, &parent.Deadline, &parent.DeadlineTime, &parent.IsUnscheduled, &parent.AssignerID); err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	priority := parent.Priority
+	if req.Priority != "" {
+		priority = req.Priority
+	}
+	startDate := parent.StartDate
+	if req.StartDate != "" {
+		if parsed, err := time.Parse("2006-01-02", req.StartDate); err == nil {
+			startDate = parsed
+		}
+	}
+	deadline := parent.Deadline
+	if req.Deadline != "" {
+		if parsed, err := time.Parse("2006-01-02", req.Deadline); err == nil {
+			deadline = sql.NullTime{Time: parsed, Valid: true}
+		}
+	}
+	deadlineTime := parent.DeadlineTime
+	if req.DeadlineTime != "" {
+		deadlineTime = sql.NullString{String: req.DeadlineTime + ":00", Valid: true}
+	}
+
+	status := "pending"
+	if startDate.After(time.Now()) {
+		status = "scheduled"
+	}
+
+	assignerID := parent.AssignerID
+	if normalizeRole(claims.Role) != "intern" {
+		assignerID = sql.NullInt64{Int64: claims.UserID, Valid: true}
+	}
+
+	res, err := h.db.Exec(
+		`INSERT INTO tasks (parent_task_id, title, description, submission_method, intern_id, assigned_by, priority, status, start_date, deadline, deadline_time, is_unscheduled, assigner_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		parentID, req.Title, nullIfEmpty(req.Description), parent.SubmissionMethod, access.InternID, claims.UserID, priority, status,
+		startDate, deadline, deadlineTime, parent.IsUnscheduled, assignerID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create subtask")
+		return
+	}
+	id, _ := res.LastInsertId()
+	services.QueueRollupRefresh(h.db, access.InternID)
+
+	utils.RespondCreated(w, "Subtask created", map[string]interface{}{"id": id, "status": status})
+}
+
+// AddDependency marks a task as blocked by another one. A pending or
+// in-progress task is moved back to 'scheduled' until the prerequisite
+// completes.
+func (h *TaskHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	access, err := h.resolveTaskAccess(claims, taskID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !access.CanManage {
+		utils.RespondForbidden(w, "You cannot change dependencies of this task")
+		return
+	}
+
+	var req addDependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.DependsOnTaskID == 0 || req.DependsOnTaskID == taskID {
+		utils.RespondBadRequest(w, "Invalid depends_on_task_id")
+		return
+	}
+
+	// The prerequisite must be a task the caller manages or one of the same
+	// intern's tasks; anything else is reported as missing
+	prerequisite, err := h.resolveTaskAccess(claims, req.DependsOnTaskID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if err == sql.ErrNoRows || !prerequisite.CanView || (!prerequisite.CanManage && prerequisite.InternID != access.InternID) {
+		utils.RespondNotFound(w, "Prerequisite task not found")
+		return
+	}
+	var prerequisiteStatus string
+	if err := h.db.QueryRow("SELECT status FROM tasks WHERE id = ?", req.DependsOnTaskID).Scan(&prerequisiteStatus); err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	createsCycle, err := h.dependsOn(req.DependsOnTaskID, taskID)
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if createsCycle {
+		utils.RespondBadRequest(w, "This dependency would create a cycle")
+		return
+	}
+
+	if _, err := h.db.Exec(
+		"INSERT IGNORE INTO task_dependencies (task_id, depends_on_task_id) VALUES (?, ?)", taskID, req.DependsOnTaskID,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to add dependency")
+		return
+	}
+
+	if prerequisiteStatus != "completed" {
+		// Work already started is put on hold until the prerequisite is done
+		res, err := h.db.Exec("UPDATE tasks SET status = 'scheduled' WHERE id = ? AND status IN ('pending', 'in_progress')", taskID)
+		if err == nil {
+			if n, _ := res.RowsAffected(); n > 0 {
+				services.QueueRollupRefresh(h.db, access.InternID)
+			}
+		}
+	}
+
+	utils.RespondCreated(w, "Dependency added", nil)
+}
+
+func (h *TaskHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	dependsOnID, _ := strconv.ParseInt(vars["dependsOnId"], 10, 64)
+
+	access, err := h.resolveTaskAccess(claims, taskID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+	if !access.CanManage {
+		utils.RespondForbidden(w, "You cannot change dependencies of this task")
+		return
+	}
+
+	res, err := h.db.Exec("DELETE FROM task_dependencies WHERE task_id = ? AND depends_on_task_id = ?", taskID, dependsOnID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to remove dependency")
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		utils.RespondNotFound(w, "Dependency not found")
+		return
+	}
+
+	// The task may have been waiting only on this prerequisite
+	services.ReleaseTask(h.db, taskID)
+
+	utils.RespondSuccess(w, "Dependency removed", nil)
+}
+
+// dependsOn reports whether task from (transitively) depends on task target
+func (h *TaskHandler) dependsOn(from, target int64) (bool, error) {
+	visited := map[int64]bool{}
+	queue := []int64{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == target {
+			return true, nil
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		rows, err := h.db.Query("SELECT depends_on_task_id FROM task_dependencies WHERE task_id = ?", current)
+		if err != nil {
+			return false, err
+		}
+		for rows.Next() {
+			var next int64
+			if err := rows.Scan(&next); err == nil {
+				queue = append(queue, next)
+			}
+		}
+		rows.Close()
+	}
+	return false, nil
+}
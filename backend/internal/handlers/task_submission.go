@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// GetSubmissions lists every submission version of a task with its reviews
+func (h *TaskHandler) GetSubmissions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !h.canViewTask(w, claims, taskID) {
+		return
+	}
+
+	submissions, err := h.loadSubmissions(taskID, 0)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch submissions")
+		return
+	}
+
+	reviews, err := h.loadReviews(taskID)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch reviews")
+		return
+	}
+
+	// Reviews without a submission (e.g. legacy data) are returned separately
+	unlinked := []models.TaskReview{}
+	index := make(map[int64]int, len(submissions))
+	for i, s := range submissions {
+		index[s.ID] = i
+	}
+	for _, rv := range reviews {
+		if rv.SubmissionID != nil {
+			if i, ok := index[*rv.SubmissionID]; ok {
+				submissions[i].Reviews = append(submissions[i].Reviews, rv)
+				continue
+			}
+		}
+		unlinked = append(unlinked, rv)
+	}
+
+	utils.RespondSuccess(w, "Submissions retrieved", map[string]interface{}{
+		"submissions":      submissions,
+		"unlinked_reviews": unlinked,
+	})
+}
+
+// GetSubmission returns one submission version
+func (h *TaskHandler) GetSubmission(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+	version, _ := strconv.Atoi(vars["version"])
+
+	if !h.canViewTask(w, claims, taskID) {
+		return
+	}
+
+	submissions, err := h.loadSubmissions(taskID, version)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch submission")
+		return
+	}
+	if len(submissions) == 0 {
+		utils.RespondNotFound(w, "Submission not found")
+		return
+	}
+
+	s := submissions[0]
+	reviews, err := h.loadReviews(taskID)
+	if err == nil {
+		for _, rv := range reviews {
+			if rv.SubmissionID != nil && *rv.SubmissionID == s.ID {
+				s.Reviews = append(s.Reviews, rv)
+			}
+		}
+	}
+
+	utils.RespondSuccess(w, "Submission retrieved", s)
+}
+
+// DiffSubmissions compares links and notes of two submission versions.
+// Defaults to the latest version against the one before it.
+func (h *TaskHandler) DiffSubmissions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	taskID, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !h.canViewTask(w, claims, taskID) {
+		return
+	}
+
+	submissions, err := h.loadSubmissions(taskID, 0)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch submissions")
+		return
+	}
+	if len(submissions) == 0 {
+		utils.RespondNotFound(w, "Task has no submissions")
+		return
+	}
+
+	toVersion := submissions[len(submissions)-1].Version
+	if v := r.URL.Query().Get("to"); v != "" {
+		toVersion, _ = strconv.Atoi(v)
+	}
+	fromVersion := toVersion - 1
+	if v := r.URL.Query().Get("from"); v != "" {
+		fromVersion, _ = strconv.Atoi(v)
+	}
+
+	var from, to *models.TaskSubmission
+	for i := range submissions {
+		if submissions[i].Version == fromVersion {
+			from = &submissions[i]
+		}
+		if submissions[i].Version == toVersion {
+			to = &submissions[i]
+		}
+	}
+	if to == nil {
+		utils.RespondNotFound(w, "Version "+strconv.Itoa(toVersion)+" not found")
+		return
+	}
+	// Diffing the first version is a diff against an empty submission
+	empty := models.TaskSubmission{Version: 0}
+	if from == nil {
+		if fromVersion != 0 {
+			utils.RespondNotFound(w, "Version "+strconv.Itoa(fromVersion)+" not found")
+			return
+		}
+		from = &empty
+	}
+
+	diff := models.SubmissionDiff{
+		TaskID:      taskID,
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Notes:       diffLines(derefString(from.SubmissionNotes), derefString(to.SubmissionNotes)),
+		Links:       diffLinks(from.SubmissionLinks, to.SubmissionLinks),
+		FileChanged: derefString(from.SubmissionFile) != derefString(to.SubmissionFile),
+		FromFile:    from.SubmissionFile,
+		ToFile:      to.SubmissionFile,
+	}
+
+	utils.RespondSuccess(w, "Submission diff retrieved", diff)
+}
+
+// canViewTask writes an error response and returns false when the caller
+// may not see the task. Interns can only see their own tasks.
+func (h *TaskHandler) canViewTask(w http.ResponseWriter, claims *middleware.Claims, taskID int64) bool {
+	var internID int64
+	err := h.db.QueryRow("SELECT intern_id FROM tasks WHERE id = ?", taskID).Scan(&internID)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task not found")
+		return false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return false
+	}
+
+	if normalizeRole(claims.Role) == "intern" {
+		myInternID, err := h.getInternIDForUser(claims.UserID)
+		if err != nil || myInternID != internID {
+			utils.RespondForbidden(w, "You do not have access to this task")
+			return false
+		}
+	}
+	return true
+}
+
+// loadSubmissions returns the versions of a task in ascending order, or a
+// single version when version > 0.
+func (h *TaskHandler) loadSubmissions(taskID int64, version int) ([]models.TaskSubmission, error) {
+	query := `SELECT id, task_id, version, submitted_by, submission_notes, submission_links, submission_file, is_late, submitted_at
+	          FROM task_submissions WHERE task_id = ?`
+	args := []interface{}{taskID}
+	if version > 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	query += " ORDER BY version ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	submissions := []models.TaskSubmission{}
+	for rows.Next() {
+		var s models.TaskSubmission
+		var notes, links, file sql.NullString
+		if err := rows.Scan(&s.ID, &s.TaskID, &s.Version, &s.SubmittedBy, &notes, &links, &file, &s.IsLate, &s.SubmittedAt); err != nil {
+			continue
+		}
+		s.SubmissionNotes = ptrStringFromNull(notes)
+		s.SubmissionFile = ptrStringFromNull(file)
+		s.SubmissionLinks = []models.SubmissionLink{}
+		if links.Valid {
+			_ = json.Unmarshal([]byte(links.String), &s.SubmissionLinks)
+		}
+		s.Reviews = []models.TaskReview{}
+		submissions = append(submissions, s)
+	}
+	return submissions, nil
+}
+
+func (h *TaskHandler) loadReviews(taskID int64) ([]models.TaskReview, error) {
+	rows, err := h.db.Query(
+		`SELECT tr.id, tr.task_id, tr.submission_id, tr.reviewer_id, tr.action, tr.score, tr.feedback, tr.reviewed_at, u.name
+		 FROM task_reviews tr
+		 LEFT JOIN users u ON tr.reviewer_id = u.id
+		 WHERE tr.task_id = ?
+		 ORDER BY tr.reviewed_at ASC, tr.id ASC`, taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []models.TaskReview{}
+	for rows.Next() {
+		var rv models.TaskReview
+		var submissionID, score sql.NullInt64
+		var feedback, reviewerName sql.NullString
+		if err := rows.Scan(&rv.ID, &rv.TaskID, &submissionID, &rv.ReviewerID, &rv.Action, &score, &feedback, &rv.ReviewedAt, &reviewerName); err != nil {
+			continue
+		}
+		rv.SubmissionID = ptrInt64FromNull(submissionID)
+		rv.Score = ptrIntFromNull(score)
+		rv.Feedback = ptrStringFromNull(feedback)
+		rv.ReviewerName = reviewerName.String
+		reviews = append(reviews, rv)
+	}
+	return reviews, nil
+}
+
+// diffLines produces a line based diff of two texts using the longest
+// common subsequence of their lines.
+func diffLines(from, to string) []models.DiffLine {
+	a := splitLines(from)
+	b := splitLines(to)
+
+	// lcs[i][j] = length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	result := []models.DiffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, models.DiffLine{Op: "equal", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, models.DiffLine{Op: "removed", Text: a[i]})
+			i++
+		default:
+			result = append(result, models.DiffLine{Op: "added", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, models.DiffLine{Op: "removed", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, models.DiffLine{Op: "added", Text: b[j]})
+	}
+	return result
+}
+
+// diffLinks matches links by URL first, then by label, so a relabelled or
+// re-pointed link shows up as changed instead of removed + added.
+func diffLinks(from, to []models.SubmissionLink) []models.LinkChange {
+	changes := []models.LinkChange{}
+	matched := make([]bool, len(to))
+
+	findMatch := func(same func(a, b models.SubmissionLink) bool, link models.SubmissionLink) int {
+		for j, candidate := range to {
+			if !matched[j] && same(link, candidate) {
+				return j
+			}
+		}
+		return -1
+	}
+	sameURL := func(a, b models.SubmissionLink) bool { return strings.TrimSpace(a.URL) == strings.TrimSpace(b.URL) }
+	sameLabel := func(a, b models.SubmissionLink) bool {
+		return strings.EqualFold(strings.TrimSpace(a.Label), strings.TrimSpace(b.Label))
+	}
+
+	for i := range from {
+		old := from[i]
+		j := findMatch(sameURL, old)
+		if j < 0 {
+			j = findMatch(sameLabel, old)
+		}
+		if j < 0 {
+			changes = append(changes, models.LinkChange{Op: "removed", From: &old})
+			continue
+		}
+		matched[j] = true
+		updated := to[j]
+		op := "unchanged"
+		if old.URL != updated.URL || old.Label != updated.Label {
+			op = "changed"
+		}
+		changes = append(changes, models.LinkChange{Op: op, From: &old, To: &updated})
+	}
+	for j := range to {
+		if !matched[j] {
+			added := to[j]
+			changes = append(changes, models.LinkChange{Op: "added", To: &added})
+		}
+	}
+	return changes
+}
+
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,607 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/services"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+type TaskTemplateHandler struct {
+	db *sql.DB
+}
+
+func NewTaskTemplateHandler(db *sql.DB) *TaskTemplateHandler {
+	return &TaskTemplateHandler{db: db}
+}
+
+type taskTemplateRequest struct {
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	SubmissionMethod   string   `json:"submission_method"` // links, files, both
+	Priority           string   `json:"priority"`          // low, medium, high
+	StartOffsetDays    int      `json:"start_offset_days"`
+	DeadlineOffsetDays *int     `json:"deadline_offset_days"`
+	DeadlineTime       string   `json:"deadline_time"` // HH:MM
+	Checklist          []string `json:"checklist"`
+}
+
+type instantiateTemplateRequest struct {
+	AssignTo  string  `json:"assign_to"` // all, selected
+	InternIDs []int64 `json:"intern_ids"`
+	StartDate string  `json:"start_date"` // YYYY-MM-DD, defaults to today
+}
+
+type taskRecurrenceRequest struct {
+	TemplateID int64   `json:"template_id"`
+	Frequency  string  `json:"frequency"` // daily, weekly, monthly
+	Weekday    *int    `json:"weekday"`
+	DayOfMonth *int    `json:"day_of_month"`
+	AssignTo   string  `json:"assign_to"`
+	InternIDs  []int64 `json:"intern_ids"`
+	StartsOn   string  `json:"starts_on"` // YYYY-MM-DD
+	EndsOn     string  `json:"ends_on"`   // YYYY-MM-DD
+	IsActive   *bool   `json:"is_active"`
+}
+
+const taskTemplateSelect = `
+	SELECT tt.id, tt.title, tt.description, tt.submission_method, tt.priority, tt.start_offset_days, tt.deadline_offset_days,
+	       tt.deadline_time, tt.checklist, tt.created_by, tt.created_at, tt.updated_at, u.name
+	FROM task_templates tt
+	LEFT JOIN users u ON tt.created_by = u.id
+`
+
+func (h *TaskTemplateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireManager(w, r); !ok {
+		return
+	}
+
+	query := taskTemplateSelect
+	args := []interface{}{}
+	if search := strings.TrimSpace(r.URL.Query().Get("search")); search != "" {
+		query += " WHERE tt.title LIKE ?"
+		args = append(args, "%"+search+"%")
+	}
+	query += " ORDER BY tt.title ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch task templates")
+		return
+	}
+	defer rows.Close()
+
+	templates := []models.TaskTemplate{}
+	for rows.Next() {
+		t, err := scanTaskTemplate(rows)
+		if err != nil {
+			continue
+		}
+		templates = append(templates, t)
+	}
+
+	utils.RespondSuccess(w, "Task templates retrieved", templates)
+}
+
+func (h *TaskTemplateHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.requireManager(w, r); !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	t, err := scanTaskTemplate(h.db.QueryRow(taskTemplateSelect+" WHERE tt.id = ?", id))
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task template not found")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return
+	}
+
+	utils.RespondSuccess(w, "Task template retrieved", t)
+}
+
+func (h *TaskTemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req taskTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if msg := validateTaskTemplate(&req); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+
+	checklistJSON, _ := json.Marshal(req.Checklist)
+	res, err := h.db.Exec(
+		`INSERT INTO task_templates (title, description, submission_method, priority, start_offset_days, deadline_offset_days, deadline_time, checklist, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		req.Title, nullIfEmpty(req.Description), req.SubmissionMethod, req.Priority, req.StartOffsetDays,
+		req.DeadlineOffsetDays, templateDeadlineTime(req.DeadlineTime), string(checklistJSON), claims.UserID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create task template")
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	utils.RespondCreated(w, "Task template created", map[string]int64{"id": id})
+}
+
+func (h *TaskTemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !h.canEdit(w, claims, "task_templates", id) {
+		return
+	}
+
+	var req taskTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if msg := validateTaskTemplate(&req); msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+
+	checklistJSON, _ := json.Marshal(req.Checklist)
+	if _, err := h.db.Exec(
+		`UPDATE task_templates SET title = ?, description = ?, submission_method = ?, priority = ?, start_offset_days = ?,
+		        deadline_offset_days = ?, deadline_time = ?, checklist = ?
+		 WHERE id = ?`,
+		req.Title, nullIfEmpty(req.Description), req.SubmissionMethod, req.Priority, req.StartOffsetDays,
+		req.DeadlineOffsetDays, templateDeadlineTime(req.DeadlineTime), string(checklistJSON), id,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to update task template")
+		return
+	}
+
+	utils.RespondSuccess(w, "Task template updated", nil)
+}
+
+func (h *TaskTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !h.canEdit(w, claims, "task_templates", id) {
+		return
+	}
+
+	// Recurrences are removed with the template; generated tasks are kept
+	if _, err := h.db.Exec("DELETE FROM task_templates WHERE id = ?", id); err != nil {
+		utils.RespondInternalError(w, "Failed to delete task template")
+		return
+	}
+
+	utils.RespondSuccess(w, "Task template deleted", nil)
+}
+
+// Instantiate creates a task assignment from a template for the selected interns
+func (h *TaskTemplateHandler) Instantiate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	var req instantiateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+	if req.AssignTo == "" {
+		req.AssignTo = "selected"
+	}
+	if req.AssignTo != "all" && req.AssignTo != "selected" {
+		utils.RespondBadRequest(w, "Invalid assign_to value")
+		return
+	}
+	if req.AssignTo == "selected" && len(req.InternIDs) == 0 {
+		utils.RespondBadRequest(w, "intern_ids is required when assign_to is selected")
+		return
+	}
+
+	baseDate := time.Now()
+	if req.StartDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+		if err != nil {
+			utils.RespondBadRequest(w, "Invalid start_date")
+			return
+		}
+		baseDate = parsed
+	}
+
+	assignmentID, count, err := services.InstantiateTemplate(h.db, services.TemplateInstance{
+		TemplateID:  id,
+		AssignedBy:  claims.UserID,
+		AssignToAll: req.AssignTo == "all",
+		InternIDs:   req.InternIDs,
+		BaseDate:    baseDate,
+	})
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Task template not found")
+		return
+	}
+	if err == services.ErrNoInterns {
+		utils.RespondBadRequest(w, "No interns found for assignment")
+		return
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create tasks from template")
+		return
+	}
+
+	utils.RespondCreated(w, "Tasks created from template", map[string]interface{}{
+		"assignment_id": assignmentID,
+		"count":         count,
+	})
+}
+
+func (h *TaskTemplateHandler) GetRecurrences(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	query := `
+		SELECT tr.id, tr.template_id, tr.frequency, tr.weekday, tr.day_of_month, tr.assign_to, tr.intern_ids,
+		       tr.starts_on, tr.ends_on, tr.next_run_on, tr.last_run_on, tr.is_active, tr.created_by, tr.created_at, tr.updated_at, tt.title
+		FROM task_recurrences tr
+		JOIN task_templates tt ON tr.template_id = tt.id
+	`
+	args := []interface{}{}
+	if normalizeRole(claims.Role) == "pembimbing" {
+		query += " WHERE tr.created_by = ?"
+		args = append(args, claims.UserID)
+	}
+	query += " ORDER BY tr.next_run_on ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to fetch recurrences")
+		return
+	}
+	defer rows.Close()
+
+	recurrences := []models.TaskRecurrence{}
+	for rows.Next() {
+		var rc models.TaskRecurrence
+		var weekday, dayOfMonth sql.NullInt64
+		var internIDs sql.NullString
+		var endsOn, lastRunOn sql.NullTime
+		if err := rows.Scan(&rc.ID, &rc.TemplateID, &rc.Frequency, &weekday, &dayOfMonth, &rc.AssignTo, &internIDs,
+			&rc.StartsOn, &endsOn, &rc.NextRunOn, &lastRunOn, &rc.IsActive, &rc.CreatedBy, &rc.CreatedAt, &rc.UpdatedAt,
+			&rc.TemplateTitle); err != nil {
+			continue
+		}
+		rc.Weekday = ptrIntFromNull(weekday)
+		rc.DayOfMonth = ptrIntFromNull(dayOfMonth)
+		rc.EndsOn = ptrTimeFromNull(endsOn)
+		rc.LastRunOn = ptrTimeFromNull(lastRunOn)
+		rc.InternIDs = []int64{}
+		if internIDs.Valid {
+			_ = json.Unmarshal([]byte(internIDs.String), &rc.InternIDs)
+		}
+		recurrences = append(recurrences, rc)
+	}
+
+	utils.RespondSuccess(w, "Recurrences retrieved", recurrences)
+}
+
+func (h *TaskTemplateHandler) CreateRecurrence(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req taskRecurrenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	var exists int
+	if err := h.db.QueryRow("SELECT 1 FROM task_templates WHERE id = ?", req.TemplateID).Scan(&exists); err != nil {
+		utils.RespondBadRequest(w, "Task template not found")
+		return
+	}
+
+	startsOn, endsOn, msg := validateRecurrence(&req)
+	if msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+	nextRunOn := services.NextOccurrence(req.Frequency, req.Weekday, req.DayOfMonth, startsOn.AddDate(0, 0, -1))
+
+	internIDsJSON, _ := json.Marshal(req.InternIDs)
+	res, err := h.db.Exec(
+		`INSERT INTO task_recurrences (template_id, frequency, weekday, day_of_month, assign_to, intern_ids, starts_on, ends_on, next_run_on, is_active, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?)`,
+		req.TemplateID, req.Frequency, req.Weekday, req.DayOfMonth, req.AssignTo, string(internIDsJSON),
+		startsOn, endsOn, nextRunOn, claims.UserID,
+	)
+	if err != nil {
+		utils.RespondInternalError(w, "Failed to create recurrence")
+		return
+	}
+	id, _ := res.LastInsertId()
+
+	utils.RespondCreated(w, "Recurrence created", map[string]interface{}{
+		"id":          id,
+		"next_run_on": nextRunOn.Format("2006-01-02"),
+	})
+}
+
+func (h *TaskTemplateHandler) UpdateRecurrence(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !h.canEdit(w, claims, "task_recurrences", id) {
+		return
+	}
+
+	var req taskRecurrenceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondBadRequest(w, "Invalid request body")
+		return
+	}
+
+	// Only toggling is_active keeps the existing rule as is
+	if req.Frequency == "" && req.IsActive != nil {
+		if _, err := h.db.Exec("UPDATE task_recurrences SET is_active = ? WHERE id = ?", *req.IsActive, id); err != nil {
+			utils.RespondInternalError(w, "Failed to update recurrence")
+			return
+		}
+		utils.RespondSuccess(w, "Recurrence updated", nil)
+		return
+	}
+
+	startsOn, endsOn, msg := validateRecurrence(&req)
+	if msg != "" {
+		utils.RespondBadRequest(w, msg)
+		return
+	}
+
+	// Never re-run a date that has already been expanded
+	from := startsOn.AddDate(0, 0, -1)
+	today := time.Now()
+	if yesterday := time.Date(today.Year(), today.Month(), today.Day()-1, 0, 0, 0, 0, time.Local); yesterday.After(from) {
+		from = yesterday
+	}
+	var lastRunOn sql.NullTime
+	_ = h.db.QueryRow("SELECT last_run_on FROM task_recurrences WHERE id = ?", id).Scan(&lastRunOn)
+	if lastRunOn.Valid && !lastRunOn.Time.Before(from) {
+		from = lastRunOn.Time
+	}
+	nextRunOn := services.NextOccurrence(req.Frequency, req.Weekday, req.DayOfMonth, from)
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	internIDsJSON, _ := json.Marshal(req.InternIDs)
+	if _, err := h.db.Exec(
+		`UPDATE task_recurrences SET frequency = ?, weekday = ?, day_of_month = ?, assign_to = ?, intern_ids = ?,
+		        starts_on = ?, ends_on = ?, next_run_on = ?, is_active = ?
+		 WHERE id = ?`,
+		req.Frequency, req.Weekday, req.DayOfMonth, req.AssignTo, string(internIDsJSON),
+		startsOn, endsOn, nextRunOn, isActive, id,
+	); err != nil {
+		utils.RespondInternalError(w, "Failed to update recurrence")
+		return
+	}
+
+	utils.RespondSuccess(w, "Recurrence updated", map[string]string{"next_run_on": nextRunOn.Format("2006-01-02")})
+}
+
+func (h *TaskTemplateHandler) DeleteRecurrence(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.requireManager(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+
+	if !h.canEdit(w, claims, "task_recurrences", id) {
+		return
+	}
+
+	if _, err := h.db.Exec("DELETE FROM task_recurrences WHERE id = ?", id); err != nil {
+		utils.RespondInternalError(w, "Failed to delete recurrence")
+		return
+	}
+
+	utils.RespondSuccess(w, "Recurrence deleted", nil)
+}
+
+func (h *TaskTemplateHandler) requireManager(w http.ResponseWriter, r *http.Request) (*middleware.Claims, bool) {
+	claims, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.RespondUnauthorized(w, "Unauthorized")
+		return nil, false
+	}
+	role := normalizeRole(claims.Role)
+	if role != "admin" && role != "pembimbing" {
+		utils.RespondForbidden(w, "Only admin or pembimbing can manage task templates")
+		return nil, false
+	}
+	return claims, true
+}
+
+// canEdit allows admins and the creator of a template/recurrence to change it
+func (h *TaskTemplateHandler) canEdit(w http.ResponseWriter, claims *middleware.Claims, table string, id int64) bool {
+	var createdBy int64
+	err := h.db.QueryRow("SELECT created_by FROM "+table+" WHERE id = ?", id).Scan(&createdBy)
+	if err == sql.ErrNoRows {
+		utils.RespondNotFound(w, "Not found")
+		return false
+	}
+	if err != nil {
+		utils.RespondInternalError(w, "Database error")
+		return false
+	}
+	if normalizeRole(claims.Role) != "admin" && createdBy != claims.UserID {
+		utils.RespondForbidden(w, "You can only change what you created")
+		return false
+	}
+	return true
+}
+
+func validateTaskTemplate(req *taskTemplateRequest) string {
+	if strings.TrimSpace(req.Title) == "" {
+		return "Title is required"
+	}
+	if req.Priority == "" {
+		req.Priority = "medium"
+	}
+	if req.Priority != "low" && req.Priority != "medium" && req.Priority != "high" {
+		return "Invalid priority"
+	}
+	if req.SubmissionMethod == "" {
+		req.SubmissionMethod = "both"
+	}
+	if req.SubmissionMethod != "links" && req.SubmissionMethod != "files" && req.SubmissionMethod != "both" {
+		return "Invalid submission_method"
+	}
+	if req.StartOffsetDays < 0 {
+		return "start_offset_days cannot be negative"
+	}
+	if req.DeadlineOffsetDays != nil && *req.DeadlineOffsetDays < req.StartOffsetDays {
+		return "deadline_offset_days must not be before start_offset_days"
+	}
+	if req.DeadlineTime != "" {
+		if _, err := time.Parse("15:04", req.DeadlineTime); err != nil {
+			return "Invalid deadline_time, expected HH:MM"
+		}
+	}
+
+	items := []string{}
+	for _, item := range req.Checklist {
+		if strings.TrimSpace(item) != "" {
+			items = append(items, strings.TrimSpace(item))
+		}
+	}
+	req.Checklist = items
+	return ""
+}
+
+func validateRecurrence(req *taskRecurrenceRequest) (time.Time, sql.NullTime, string) {
+	var endsOn sql.NullTime
+
+	switch req.Frequency {
+	case models.RecurrenceDaily:
+		req.Weekday, req.DayOfMonth = nil, nil
+	case models.RecurrenceWeekly:
+		if req.Weekday == nil || *req.Weekday < 0 || *req.Weekday > 6 {
+			return time.Time{}, endsOn, "weekday (0-6) is required for weekly recurrences"
+		}
+		req.DayOfMonth = nil
+	case models.RecurrenceMonthly:
+		// Capped at 28 so every month has the day
+		if req.DayOfMonth == nil || *req.DayOfMonth < 1 || *req.DayOfMonth > 28 {
+			return time.Time{}, endsOn, "day_of_month (1-28) is required for monthly recurrences"
+		}
+		req.Weekday = nil
+	default:
+		return time.Time{}, endsOn, "Invalid frequency"
+	}
+
+	if req.AssignTo == "" {
+		req.AssignTo = "all"
+	}
+	if req.AssignTo != "all" && req.AssignTo != "selected" {
+		return time.Time{}, endsOn, "Invalid assign_to value"
+	}
+	if req.AssignTo == "selected" && len(req.InternIDs) == 0 {
+		return time.Time{}, endsOn, "intern_ids is required when assign_to is selected"
+	}
+	if req.AssignTo == "all" {
+		req.InternIDs = []int64{}
+	}
+
+	now := time.Now()
+	startsOn := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if req.StartsOn != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.StartsOn, time.Local)
+		if err != nil {
+			return time.Time{}, endsOn, "Invalid starts_on"
+		}
+		startsOn = parsed
+	}
+	if req.EndsOn != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.EndsOn, time.Local)
+		if err != nil {
+			return time.Time{}, endsOn, "Invalid ends_on"
+		}
+		if parsed.Before(startsOn) {
+			return time.Time{}, endsOn, "ends_on must not be before starts_on"
+		}
+		endsOn = sql.NullTime{Time: parsed, Valid: true}
+	}
+
+	return startsOn, endsOn, ""
+}
+
+func templateDeadlineTime(value string) sql.NullString {
+	if value == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: value + ":00", Valid: true}
+}
+
+func scanTaskTemplate(scanner sqlScanner) (models.TaskTemplate, error) {
+	var t models.TaskTemplate
+	var description, deadlineTime, checklist, createdByName sql.NullString
+	var deadlineOffset sql.NullInt64
+	if err := scanner.Scan(&t.ID, &t.Title, &description, &t.SubmissionMethod, &t.Priority, &t.StartOffsetDays, &deadlineOffset,
+		&deadlineTime, &checklist, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt, &createdByName); err != nil {
+		return t, err
+	}
+	t.Description = ptrStringFromNull(description)
+	t.DeadlineOffsetDays = ptrIntFromNull(deadlineOffset)
+	t.DeadlineTime = ptrStringFromNull(deadlineTime)
+	t.Checklist = []string{}
+	if checklist.Valid {
+		_ = json.Unmarshal([]byte(checklist.String), &t.Checklist)
+	}
+	t.CreatedByName = createdByName.String
+	return t, nil
+}
@@ -0,0 +1,283 @@
+// Package jobs is a persistent background job queue backed by the jobs
+// table. Any number of server replicas can run a Queue against the same
+// database: due rows are claimed with SELECT ... FOR UPDATE SKIP LOCKED so
+// each job is handed to exactly one worker.
+package jobs
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+// DefaultMaxAttempts is used when a job is enqueued without MaxAttempts
+const DefaultMaxAttempts = 5
+
+var (
+	ErrNotRetryable  = errors.New("only failed or cancelled jobs can be retried")
+	ErrNotCancelable = errors.New("only pending jobs can be cancelled")
+)
+
+// Execer is satisfied by *sql.DB and *sql.Tx, so a job can be enqueued in
+// the same transaction as the rows it works on.
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type enqueueOptions struct {
+	runAt       time.Time
+	maxAttempts int
+	dedupeKey   string
+}
+
+// EnqueueOption customises a single Enqueue call
+type EnqueueOption func(*enqueueOptions)
+
+// RunAt delays the job until t
+func RunAt(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) { o.runAt = t }
+}
+
+// Delay delays the job by d from now
+func Delay(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) { o.runAt = time.Now().Add(d) }
+}
+
+// MaxAttempts overrides how many times a failing job is tried
+func MaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+// DedupeKey skips the enqueue while a pending job with the same key is
+// still waiting, so bursts of identical work collapse into one run. The
+// check is best effort: concurrent enqueues may both get through.
+func DedupeKey(key string) EnqueueOption {
+	return func(o *enqueueOptions) { o.dedupeKey = key }
+}
+
+// Enqueue stores a job for the workers. The payload is marshalled to JSON.
+// It returns 0 when a DedupeKey matched a pending job.
+func Enqueue(db Execer, jobType string, payload interface{}, opts ...EnqueueOption) (int64, error) {
+	o := enqueueOptions{runAt: time.Now(), maxAttempts: DefaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	raw, err := marshalPayload(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	if o.dedupeKey == "" {
+		res, err := db.Exec(
+			"INSERT INTO jobs (type, payload, max_attempts, run_at) VALUES (?, ?, ?, ?)",
+			jobType, raw, o.maxAttempts, o.runAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO jobs (type, payload, max_attempts, run_at, dedupe_key)
+		 SELECT ?, ?, ?, ?, ? FROM DUAL
+		 WHERE NOT EXISTS (SELECT 1 FROM jobs WHERE dedupe_key = ? AND status = 'pending')`,
+		jobType, raw, o.maxAttempts, o.runAt, o.dedupeKey, o.dedupeKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return 0, nil
+	}
+	return res.LastInsertId()
+}
+
+// Retry puts a failed or cancelled job back in the queue with a fresh
+// attempt budget
+func Retry(db *sql.DB, id int64) error {
+	res, err := db.Exec(
+		`UPDATE jobs SET status = 'pending', attempts = 0, run_at = ?, finished_at = NULL,
+		        locked_by = NULL, locked_at = NULL
+		 WHERE id = ? AND status IN ('failed', 'cancelled')`,
+		time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrNotRetryable
+	}
+	return nil
+}
+
+// RetryFailed re-queues every failed job, optionally of one type only
+func RetryFailed(db *sql.DB, jobType string) (int64, error) {
+	query := `UPDATE jobs SET status = 'pending', attempts = 0, run_at = ?, finished_at = NULL,
+	                 locked_by = NULL, locked_at = NULL
+	          WHERE status = 'failed'`
+	args := []interface{}{time.Now()}
+	if jobType != "" {
+		query += " AND type = ?"
+		args = append(args, jobType)
+	}
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Cancel removes a pending job from the queue. Running jobs can't be
+// interrupted from here; their handlers decide what cancellation means.
+func Cancel(db *sql.DB, id int64) error {
+	res, err := db.Exec(
+		"UPDATE jobs SET status = 'cancelled', finished_at = ? WHERE id = ? AND status = 'pending'",
+		time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrNotCancelable
+	}
+	return nil
+}
+
+// pruneBatch bounds how many rows one DELETE of Prune removes, keeping the
+// locks short while workers keep claiming jobs
+const pruneBatch = 1000
+
+// Prune deletes completed and cancelled jobs that finished before cutoff.
+// Failed jobs are kept until someone retries or inspects them.
+func Prune(db *sql.DB, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		res, err := db.Exec(
+			"DELETE FROM jobs WHERE status IN ('completed', 'cancelled') AND finished_at < ? LIMIT ?",
+			cutoff, pruneBatch,
+		)
+		if err != nil {
+			return total, err
+		}
+		affected, _ := res.RowsAffected()
+		total += affected
+		if affected < pruneBatch {
+			return total, nil
+		}
+	}
+}
+
+// Backoff is the delay before retry number attempt (1-based): 30s doubling
+// per attempt, capped at one hour
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := 30 * time.Second
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
+
+// withJitter spreads retries of jobs that failed together by up to 10%
+func withJitter(d time.Duration) time.Duration {
+	return d + time.Duration(rand.Int63n(int64(d)/10+1))
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying; the job fails
+// immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
+
+func marshalPayload(payload interface{}) (interface{}, error) {
+	switch p := payload.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		return string(p), nil
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encode job payload: %w", err)
+	}
+	return string(raw), nil
+}
+
+// LoadJob reads a single job row
+func LoadJob(db *sql.DB, id int64) (models.Job, error) {
+	return ScanJob(db.QueryRow("SELECT "+JobColumns+" FROM jobs WHERE id = ?", id))
+}
+
+// JobColumns lists the columns ScanJob expects, in order
+const JobColumns = `id, type, payload, status, attempts, max_attempts, run_at, locked_by, locked_at,
+	last_error, schedule_name, created_at, updated_at, finished_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanJob reads a job from a row selected with JobColumns
+func ScanJob(row rowScanner) (models.Job, error) {
+	var job models.Job
+	var payload, lockedBy, lastError, scheduleName sql.NullString
+	var lockedAt, finishedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.RunAt,
+		&lockedBy, &lockedAt, &lastError, &scheduleName, &job.CreatedAt, &job.UpdatedAt, &finishedAt)
+	if err != nil {
+		return job, err
+	}
+	if payload.Valid {
+		job.Payload = json.RawMessage(payload.String)
+	}
+	job.LockedBy = nullStringPtr(lockedBy)
+	job.LockedAt = nullTimePtr(lockedAt)
+	job.LastError = nullStringPtr(lastError)
+	job.ScheduleName = nullStringPtr(scheduleName)
+	job.FinishedAt = nullTimePtr(finishedAt)
+	return job, nil
+}
+
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
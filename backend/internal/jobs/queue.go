@@ -0,0 +1,477 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+const (
+	pollInterval      = 2 * time.Second
+	heartbeatInterval = time.Minute
+	// A running job whose heartbeat is older than this belonged to a
+	// replica that died; it is handed to another worker
+	staleAfter = 5 * time.Minute
+)
+
+// HandlerFunc processes one job. Returning an error retries the job with
+// backoff until its attempts run out; wrap it with Permanent to fail at once.
+// ctx is cancelled when a shutdown outlasts its drain timeout.
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+type handler struct {
+	fn            HandlerFunc
+	maxConcurrent int
+}
+
+// HandlerOption customises a registered handler
+type HandlerOption func(*handler)
+
+// MaxConcurrent limits how many jobs of this type one replica runs at once
+func MaxConcurrent(n int) HandlerOption {
+	return func(h *handler) { h.maxConcurrent = n }
+}
+
+type scheduleDef struct {
+	name     string
+	spec     string
+	schedule Schedule
+	jobType  string
+	payload  interface{}
+}
+
+// Queue is the worker pool of one server process
+type Queue struct {
+	db       *sql.DB
+	workerID string
+
+	handlers  map[string]*handler
+	schedules []scheduleDef
+
+	mu      sync.Mutex
+	running map[string]int
+	slots   chan struct{}
+	wake    chan struct{}
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// New creates a queue that runs at most workers jobs concurrently
+func New(db *sql.DB, workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		db:       db,
+		workerID: newWorkerID(),
+		handlers: map[string]*handler{},
+		running:  map[string]int{},
+		slots:    make(chan struct{}, workers),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a job type. Only registered types are
+// claimed, so replicas running different versions leave unknown types alone.
+func (q *Queue) Register(jobType string, fn HandlerFunc, opts ...HandlerOption) {
+	h := &handler{fn: fn}
+	for _, opt := range opts {
+		opt(h)
+	}
+	q.handlers[jobType] = h
+}
+
+// Handle registers a handler that receives the job payload decoded into T.
+// A payload that doesn't decode fails the job without retries.
+func Handle[T any](q *Queue, jobType string, fn func(ctx context.Context, payload T, job *models.Job) error, opts ...HandlerOption) {
+	q.Register(jobType, func(ctx context.Context, job *models.Job) error {
+		var payload T
+		if len(job.Payload) > 0 {
+			if err := json.Unmarshal(job.Payload, &payload); err != nil {
+				return Permanent(fmt.Errorf("decode payload: %w", err))
+			}
+		}
+		return fn(ctx, payload, job)
+	}, opts...)
+}
+
+// Schedule enqueues jobType every time spec fires (see ParseSchedule).
+// Schedules are stored in job_schedules when the queue starts.
+func (q *Queue) Schedule(name, spec, jobType string, payload interface{}) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", name, err)
+	}
+	q.schedules = append(q.schedules, scheduleDef{name: name, spec: spec, schedule: schedule, jobType: jobType, payload: payload})
+	return nil
+}
+
+// Start syncs the declared schedules and begins polling for jobs
+func (q *Queue) Start() error {
+	if err := q.syncSchedules(); err != nil {
+		return err
+	}
+
+	q.wg.Add(1)
+	go q.loop()
+	log.Printf("Job queue started (worker %s, %d slots)", q.workerID, cap(q.slots))
+	return nil
+}
+
+// Shutdown stops claiming new jobs and waits for running ones to finish.
+// When ctx expires first, handlers are cancelled and their jobs are put
+// back in the queue without using up an attempt.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) loop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.recoverStale()
+		q.enqueueDueSchedules()
+		q.dispatch()
+
+		select {
+		case <-q.stop:
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// dispatch claims jobs while there are free slots
+func (q *Queue) dispatch() {
+	for {
+		select {
+		case <-q.stop:
+			return
+		case q.slots <- struct{}{}:
+		default:
+			return
+		}
+
+		job, err := q.claim()
+		if err != nil {
+			log.Printf("Error claiming job: %v", err)
+		}
+		if job == nil {
+			<-q.slots
+			return
+		}
+
+		q.wg.Add(1)
+		go q.run(job)
+	}
+}
+
+// claim locks the next due job this process can handle and marks it running
+func (q *Queue) claim() (*models.Job, error) {
+	q.mu.Lock()
+	var types []interface{}
+	for t, h := range q.handlers {
+		if h.maxConcurrent > 0 && q.running[t] >= h.maxConcurrent {
+			continue
+		}
+		types = append(types, t)
+	}
+	q.mu.Unlock()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	args := append([]interface{}{now}, types...)
+	var id int64
+	err = tx.QueryRow(
+		`SELECT id FROM jobs
+		 WHERE status = 'pending' AND run_at <= ? AND type IN (`+strings.TrimSuffix(strings.Repeat("?,", len(types)), ",")+`)
+		 ORDER BY run_at ASC, id ASC
+		 LIMIT 1
+		 FOR UPDATE SKIP LOCKED`, args...,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_by = ?, locked_at = ? WHERE id = ?",
+		q.workerID, now, id,
+	); err != nil {
+		return nil, err
+	}
+	job, err := ScanJob(tx.QueryRow("SELECT "+JobColumns+" FROM jobs WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	q.running[job.Type]++
+	q.mu.Unlock()
+	return &job, nil
+}
+
+func (q *Queue) run(job *models.Job) {
+	defer q.wg.Done()
+	defer func() {
+		q.mu.Lock()
+		q.running[job.Type]--
+		q.mu.Unlock()
+		<-q.slots
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(q.ctx)
+	defer cancel()
+	go q.heartbeat(ctx, job.ID)
+
+	err := q.call(ctx, job)
+	q.finish(job, err)
+}
+
+// call runs the handler, turning a panic into a permanent failure
+func (q *Queue) call(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %d (%s) panicked: %v\n%s", job.ID, job.Type, r, debug.Stack())
+			err = Permanent(fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return q.handlers[job.Type].fn(ctx, job)
+}
+
+// heartbeat keeps locked_at fresh so other replicas don't treat a long job
+// as abandoned
+func (q *Queue) heartbeat(ctx context.Context, id int64) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = q.db.Exec("UPDATE jobs SET locked_at = ? WHERE id = ? AND locked_by = ?", time.Now(), id, q.workerID)
+		}
+	}
+}
+
+func (q *Queue) finish(job *models.Job, err error) {
+	now := time.Now()
+	var query string
+	var args []interface{}
+
+	switch {
+	case err == nil:
+		query = "UPDATE jobs SET status = 'completed', finished_at = ?, locked_by = NULL, locked_at = NULL"
+		args = []interface{}{now}
+	case q.ctx.Err() != nil:
+		// Interrupted by shutdown: the attempt doesn't count
+		log.Printf("Job %d (%s) interrupted by shutdown, re-queued", job.ID, job.Type)
+		query = "UPDATE jobs SET status = 'pending', attempts = GREATEST(attempts - 1, 0), run_at = ?, locked_by = NULL, locked_at = NULL"
+		args = []interface{}{now}
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %d (%s) failed: %v", job.ID, job.Type, err)
+		query = "UPDATE jobs SET status = 'failed', last_error = ?, finished_at = ?, locked_by = NULL, locked_at = NULL"
+		args = []interface{}{err.Error(), now}
+	default:
+		retryAt := now.Add(withJitter(Backoff(job.Attempts)))
+		log.Printf("Job %d (%s) attempt %d/%d failed, retrying at %s: %v",
+			job.ID, job.Type, job.Attempts, job.MaxAttempts, retryAt.Format(time.RFC3339), err)
+		query = "UPDATE jobs SET status = 'pending', last_error = ?, run_at = ?, locked_by = NULL, locked_at = NULL"
+		args = []interface{}{err.Error(), retryAt}
+	}
+
+	// Only touch the row while we still own it
+	query += " WHERE id = ? AND status = 'running' AND locked_by = ?"
+	args = append(args, job.ID, q.workerID)
+	if _, err := q.db.Exec(query, args...); err != nil {
+		log.Printf("Error updating job %d: %v", job.ID, err)
+	}
+}
+
+// recoverStale re-queues running jobs whose worker stopped sending
+// heartbeats
+func (q *Queue) recoverStale() {
+	if _, err := q.db.Exec(
+		`UPDATE jobs
+		 SET status = IF(attempts >= max_attempts, 'failed', 'pending'),
+		     finished_at = IF(attempts >= max_attempts, ?, NULL),
+		     last_error = 'worker stopped responding', locked_by = NULL, locked_at = NULL
+		 WHERE status = 'running' AND locked_at < ?`,
+		time.Now(), time.Now().Add(-staleAfter),
+	); err != nil {
+		log.Printf("Error recovering stale jobs: %v", err)
+	}
+}
+
+// syncSchedules upserts the declared schedules. next_run_at is kept unless
+// the spec changed, so restarts don't skip or repeat a run.
+func (q *Queue) syncSchedules() error {
+	names := []interface{}{}
+	for _, s := range q.schedules {
+		payload, err := marshalPayload(s.payload)
+		if err != nil {
+			return fmt.Errorf("schedule %s: %w", s.name, err)
+		}
+		if _, err := q.db.Exec(
+			`INSERT INTO job_schedules (name, spec, job_type, payload, next_run_at) VALUES (?, ?, ?, ?, ?)
+			 ON DUPLICATE KEY UPDATE
+			     next_run_at = IF(spec <> VALUES(spec), VALUES(next_run_at), next_run_at),
+			     spec = VALUES(spec), job_type = VALUES(job_type), payload = VALUES(payload)`,
+			s.name, s.spec, s.jobType, payload, s.schedule.Next(time.Now()),
+		); err != nil {
+			return fmt.Errorf("schedule %s: %w", s.name, err)
+		}
+		names = append(names, s.name)
+	}
+
+	// Schedules removed from the code stop firing
+	query := "DELETE FROM job_schedules"
+	if len(names) > 0 {
+		query += " WHERE name NOT IN (" + strings.TrimSuffix(strings.Repeat("?,", len(names)), ",") + ")"
+	}
+	_, err := q.db.Exec(query, names...)
+	return err
+}
+
+// enqueueDueSchedules turns due schedules into jobs. The rows are locked so
+// only one replica fires each run; a run is skipped while the previous one
+// is still queued or running.
+func (q *Queue) enqueueDueSchedules() {
+	tx, err := q.db.Begin()
+	if err != nil {
+		log.Printf("Error checking job schedules: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.Query(
+		"SELECT name, spec, job_type, payload FROM job_schedules WHERE next_run_at <= ? FOR UPDATE SKIP LOCKED", now,
+	)
+	if err != nil {
+		log.Printf("Error checking job schedules: %v", err)
+		return
+	}
+	type due struct {
+		name, spec, jobType string
+		payload             sql.NullString
+	}
+	var dues []due
+	for rows.Next() {
+		var d due
+		if err := rows.Scan(&d.name, &d.spec, &d.jobType, &d.payload); err == nil {
+			dues = append(dues, d)
+		}
+	}
+	rows.Close()
+	if len(dues) == 0 {
+		return
+	}
+
+	for _, d := range dues {
+		schedule, err := ParseSchedule(d.spec)
+		if err != nil {
+			log.Printf("Skipping job schedule %s: %v", d.name, err)
+			continue
+		}
+
+		var active int
+		if err := tx.QueryRow(
+			"SELECT COUNT(*) FROM jobs WHERE schedule_name = ? AND status IN ('pending', 'running')", d.name,
+		).Scan(&active); err != nil {
+			log.Printf("Error checking job schedule %s: %v", d.name, err)
+			return
+		}
+		if active == 0 {
+			var payload interface{}
+			if d.payload.Valid {
+				payload = d.payload.String
+			}
+			if _, err := tx.Exec(
+				"INSERT INTO jobs (type, payload, max_attempts, run_at, schedule_name) VALUES (?, ?, 1, ?, ?)",
+				d.jobType, payload, now, d.name,
+			); err != nil {
+				log.Printf("Error enqueueing job schedule %s: %v", d.name, err)
+				return
+			}
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE job_schedules SET next_run_at = ?, last_run_at = ? WHERE name = ?",
+			schedule.Next(now), now, d.name,
+		); err != nil {
+			log.Printf("Error advancing job schedule %s: %v", d.name, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing job schedules: %v", err)
+	}
+}
+
+func newWorkerID() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "worker"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -121,3 +121,27 @@ func NormalizeRole(role string) string {
 	}
 	return role
 }
+
+// ConfineRole keeps users of a role to the given path prefixes, for
+// limited roles that must not reach routes other roles share
+func ConfineRole(role string, prefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r.Context())
+			if ok && NormalizeRole(claims.Role) == role {
+				allowed := false
+				for _, prefix := range prefixes {
+					if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+						allowed = true
+						break
+					}
+				}
+				if !allowed {
+					utils.RespondForbidden(w, "Insufficient permissions")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -28,6 +28,8 @@ type DailyAttendanceStats struct {
 
 // WeeklySummary contains aggregated weekly statistics
 type WeeklySummary struct {
+	WeekStart         *time.Time `json:"week_start,omitempty"` // set in monthly and range views
+	WeekEnd           *time.Time `json:"week_end,omitempty"`
 	TotalDays         int     `json:"total_days"`
 	PresentDays       int     `json:"present_days"`
 	LateDays          int     `json:"late_days"`
@@ -48,6 +50,7 @@ type MonthlyTrends struct {
 	Month         time.Time              `json:"month"` // First day of month
 	WeeklyData    []WeeklySummary        `json:"weekly_data"`
 	MonthlySummary MonthlySummary        `json:"monthly_summary"`
+	PreviousMonth  *MonthlySummary       `json:"previous_month,omitempty"`
 }
 
 // MonthlySummary contains aggregated monthly statistics
@@ -63,6 +66,20 @@ type MonthlySummary struct {
 	ImprovementTrend   string  `json:"improvement_trend"` // "improving", "declining", "stable"
 }
 
+// RangeTrends is attendance analysis over an arbitrary date range, compared
+// with the preceding range of the same length
+type RangeTrends struct {
+	InternID        int64           `json:"intern_id"`
+	InternName      string          `json:"intern_name"`
+	From            time.Time       `json:"from"`
+	To              time.Time       `json:"to"`
+	WeeklyData      []WeeklySummary `json:"weekly_data"`
+	Summary         MonthlySummary  `json:"summary"`
+	PreviousFrom    time.Time       `json:"previous_from"`
+	PreviousTo      time.Time       `json:"previous_to"`
+	PreviousSummary MonthlySummary  `json:"previous_summary"`
+}
+
 // CheckInPattern represents hourly distribution of check-ins
 type CheckInPattern struct {
 	Hour      int     `json:"hour"`       // 0-23
@@ -78,5 +95,56 @@ type PerformanceInsights struct {
 	Strengths    []string `json:"strengths"`
 	Concerns     []string `json:"concerns"`
 	Suggestions  []string `json:"suggestions"`
-	OverallScore int      `json:"overall_score"` // 0-100
+	OverallScore int      `json:"overall_score"` // 0-100, the final grade
+
+	Grade *GradeBreakdown `json:"grade,omitempty"`
+}
+
+// AnalyticsRange is the period an aggregate covers
+type AnalyticsRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// AttendanceGroupStats is attendance and punctuality aggregated over the
+// interns in one group (school, department, supervisor or intake cohort)
+type AttendanceGroupStats struct {
+	Key             string  `json:"key"`
+	Label           string  `json:"label"`
+	Interns         int     `json:"interns"`
+	Records         int     `json:"records"`
+	PresentDays     int     `json:"present_days"`
+	LateDays        int     `json:"late_days"`
+	AbsentDays      int     `json:"absent_days"`
+	OnLeaveDays     int     `json:"on_leave_days"`
+	AttendanceRate  float64 `json:"attendance_rate"`
+	PunctualityRate float64 `json:"punctuality_rate"`
+}
+
+// SupervisorStats is task throughput and review turnaround of a supervisor
+type SupervisorStats struct {
+	SupervisorID           int64    `json:"supervisor_id"`
+	SupervisorName         string   `json:"supervisor_name"`
+	Interns                int      `json:"interns"`
+	TasksAssigned          int      `json:"tasks_assigned"`
+	TasksCompleted         int      `json:"tasks_completed"`
+	TasksCompletedLate     int      `json:"tasks_completed_late"`
+	Reviews                int      `json:"reviews"`
+	Revisions              int      `json:"revisions"`
+	AvgReviewTurnaroundHrs *float64 `json:"avg_review_turnaround_hours"`
+}
+
+// AssignmentRevisionStats is how often the tasks of an assignment were
+// sent back for revision
+type AssignmentRevisionStats struct {
+	AssignmentID    int64      `json:"assignment_id"`
+	Title           string     `json:"title"`
+	Deadline        *time.Time `json:"deadline,omitempty"`
+	Tasks           int        `json:"tasks"`
+	CompletedTasks  int        `json:"completed_tasks"`
+	Reviews         int        `json:"reviews"`
+	Revisions       int        `json:"revisions"`
+	RevisedTasks    int        `json:"revised_tasks"`
+	RevisionRate    float64    `json:"revision_rate"`     // revisions per review, percent
+	RevisedTaskRate float64    `json:"revised_task_rate"` // tasks revised at least once, percent
 }
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Announcement target types
+const (
+	AnnouncementTargetAll        = "all"
+	AnnouncementTargetSupervisor = "supervisor"
+	AnnouncementTargetSchool     = "school"
+	AnnouncementTargetDepartment = "department"
+)
+
+type Announcement struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	TargetType  string     `json:"target_type"`            // all, supervisor, school, department
+	TargetValue *string    `json:"target_value,omitempty"` // supervisor user_id, school or department name
+	CreatedBy   int64      `json:"created_by"`             // user_id (admin/pembimbing)
+	IsPinned    bool       `json:"is_pinned"`
+	RequiresAck bool       `json:"requires_ack"`
+	PublishAt   time.Time  `json:"publish_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"` // set once recipients have been notified
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Related data
+	CreatedByName string `json:"created_by_name,omitempty"`
+
+	// Recipient view
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+
+	// Author view
+	RecipientCount int64 `json:"recipient_count"`
+	ReadCount      int64 `json:"read_count"`
+	AckCount       int64 `json:"ack_count"`
+}
+
+type AnnouncementReceipt struct {
+	UserID         int64      `json:"user_id"`
+	InternID       *int64     `json:"intern_id,omitempty"`
+	Name           string     `json:"name"`
+	DeliveredAt    time.Time  `json:"delivered_at"`
+	ReadAt         *time.Time `json:"read_at,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+}
@@ -0,0 +1,166 @@
+package models
+
+import "time"
+
+// RoleApplicant is the limited role of someone applying for an internship
+const RoleApplicant = "applicant"
+
+// Application stage outcomes. Entering a stage with an outcome decides the
+// application.
+const (
+	ApplicationAccepted = "accepted"
+	ApplicationRejected = "rejected"
+)
+
+// ApplicationStage is a step of the recruitment pipeline
+type ApplicationStage struct {
+	ID           int64     `json:"id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	Position     int       `json:"position"`
+	Outcome      *string   `json:"outcome,omitempty"` // accepted, rejected
+	EmailSubject *string   `json:"email_subject,omitempty"`
+	EmailBody    *string   `json:"email_body,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ApplicationStageRequest creates or updates a stage. Outcome is fixed
+// once a stage exists.
+type ApplicationStageRequest struct {
+	Code         string `json:"code"`
+	Name         string `json:"name"`
+	Position     int    `json:"position"`
+	Outcome      string `json:"outcome,omitempty"`
+	EmailSubject string `json:"email_subject"`
+	EmailBody    string `json:"email_body"`
+}
+
+// ApplicationDocumentType is a document applicants upload
+type ApplicationDocumentType struct {
+	ID         int64     `json:"id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	IsRequired bool      `json:"is_required"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ApplicationDocumentTypeRequest creates or updates a document type
+type ApplicationDocumentTypeRequest struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	IsRequired bool   `json:"is_required"`
+}
+
+// Application is an internship application
+type Application struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	StageID        int64      `json:"stage_id"`
+	StageCode      string     `json:"stage_code"`
+	StageName      string     `json:"stage_name"`
+	Outcome        *string    `json:"outcome,omitempty"`
+	FullName       string     `json:"full_name"`
+	Email          string     `json:"email"`
+	Phone          *string    `json:"phone,omitempty"`
+	School         string     `json:"school"`
+	Department     string     `json:"department"`
+	StudentID      *string    `json:"student_id,omitempty"`
+	InstitutionID  *int64     `json:"institution_id,omitempty"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        time.Time  `json:"end_date"`
+	Motivation     *string    `json:"motivation,omitempty"`
+	SupervisorID   *int64     `json:"supervisor_id,omitempty"`
+	SupervisorName *string    `json:"supervisor_name,omitempty"`
+	InternID       *int64     `json:"intern_id,omitempty"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	DocumentsComplete bool                  `json:"documents_complete"`
+	Documents         []ApplicationDocument `json:"documents,omitempty"`
+	History           []ApplicationHistory  `json:"history,omitempty"`
+	Interviews        []InterviewSlot       `json:"interviews,omitempty"`
+}
+
+// CreateApplicationRequest is the public application form
+type CreateApplicationRequest struct {
+	FullName        string `json:"full_name"`
+	Email           string `json:"email"`
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+	Phone           string `json:"phone"`
+	School          string `json:"school"`
+	Department      string `json:"department"`
+	StudentID       string `json:"student_id"`
+	StartDate       string `json:"start_date"` // YYYY-MM-DD
+	EndDate         string `json:"end_date"`   // YYYY-MM-DD
+	Motivation      string `json:"motivation"`
+}
+
+// UpdateApplicationRequest lets admins settle the terms before an offer
+type UpdateApplicationRequest struct {
+	StartDate     string `json:"start_date,omitempty"`
+	EndDate       string `json:"end_date,omitempty"`
+	SupervisorID  *int64 `json:"supervisor_id,omitempty"`
+	InstitutionID *int64 `json:"institution_id,omitempty"`
+}
+
+// MoveApplicationRequest moves an application to another stage, by ID or
+// code. Note is shown to the applicant as {{note}} in the email.
+type MoveApplicationRequest struct {
+	StageID   int64  `json:"stage_id,omitempty"`
+	StageCode string `json:"stage_code,omitempty"`
+	Note      string `json:"note"`
+}
+
+// ApplicationDocument is an uploaded application document
+type ApplicationDocument struct {
+	ID             int64     `json:"id"`
+	DocumentTypeID int64     `json:"document_type_id"`
+	TypeCode       string    `json:"type_code"`
+	TypeName       string    `json:"type_name"`
+	FilePath       string    `json:"file_path"`
+	OriginalName   string    `json:"original_name"`
+	UploadedAt     time.Time `json:"uploaded_at"`
+}
+
+// ApplicationHistory is one stage change
+type ApplicationHistory struct {
+	ID            int64     `json:"id"`
+	FromStageName *string   `json:"from_stage_name,omitempty"`
+	ToStageName   string    `json:"to_stage_name"`
+	Note          *string   `json:"note,omitempty"`
+	EmailQueued   bool      `json:"email_queued"`
+	ChangedBy     *string   `json:"changed_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// InterviewSlot is a time an interviewer is available, booked by at most
+// one application
+type InterviewSlot struct {
+	ID              int64      `json:"id"`
+	InterviewerID   int64      `json:"interviewer_id"`
+	InterviewerName string     `json:"interviewer_name"`
+	StartsAt        time.Time  `json:"starts_at"`
+	DurationMinutes int        `json:"duration_minutes"`
+	Location        *string    `json:"location,omitempty"`
+	ApplicationID   *int64     `json:"application_id,omitempty"`
+	ApplicantName   *string    `json:"applicant_name,omitempty"`
+	BookedAt        *time.Time `json:"booked_at,omitempty"`
+}
+
+// CreateInterviewSlotsRequest offers one or more slots. Admins may offer
+// them on another interviewer's behalf.
+type CreateInterviewSlotsRequest struct {
+	InterviewerID   int64    `json:"interviewer_id,omitempty"`
+	StartsAt        []string `json:"starts_at"` // YYYY-MM-DDTHH:MM, local time
+	DurationMinutes int      `json:"duration_minutes"`
+	Location        string   `json:"location"`
+}
+
+// BookInterviewRequest books a slot. Applicants book for their own
+// application; staff pass application_id.
+type BookInterviewRequest struct {
+	ApplicationID int64 `json:"application_id,omitempty"`
+}
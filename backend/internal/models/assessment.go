@@ -6,25 +6,28 @@ import (
 )
 
 type Assessment struct {
-	ID                 int64          `json:"id"`
-	InternID           int64          `json:"intern_id"`
-	TaskID             sql.NullInt64  `json:"task_id,omitempty"`
-	AssessedBy         int64          `json:"assessed_by"` // user_id (admin/pembimbing)
-	Score              int            `json:"score"`       // 0-100 (average of criteria)
-	Category           string         `json:"category"`    // auto-calculated: very_good, good, not_good, very_bad
-	Aspect             string         `json:"aspect"`      // legacy: discipline, work_quality, attitude
-	QualityScore       sql.NullInt64  `json:"quality_score,omitempty"`
-	SpeedScore         sql.NullInt64  `json:"speed_score,omitempty"`
-	InitiativeScore    sql.NullInt64  `json:"initiative_score,omitempty"`
-	TeamworkScore      sql.NullInt64  `json:"teamwork_score,omitempty"`
-	CommunicationScore sql.NullInt64  `json:"communication_score,omitempty"`
-	Strengths          sql.NullString `json:"strengths,omitempty"`
-	Improvements       sql.NullString `json:"improvements,omitempty"`
-	Comments           sql.NullString `json:"comments,omitempty"`
-	Notes              sql.NullString `json:"notes,omitempty"`
-	AssessmentDate     time.Time      `json:"assessment_date"`
-	CreatedAt          time.Time      `json:"created_at"`
-	UpdatedAt          time.Time      `json:"updated_at"`
+	ID                 int64           `json:"id"`
+	InternID           int64           `json:"intern_id"`
+	TaskID             sql.NullInt64   `json:"task_id,omitempty"`
+	RubricVersionID    sql.NullInt64   `json:"rubric_version_id,omitempty"`
+	AssessedBy         int64           `json:"assessed_by"`            // user_id (admin/pembimbing)
+	Score              int             `json:"score"`                  // 0-100 (weighted criteria as a percentage)
+	RubricScore        sql.NullFloat64 `json:"rubric_score,omitempty"` // weighted score on the rubric's scale
+	Category           string          `json:"category"`               // from the rubric's thresholds
+	CategoryLabel      sql.NullString  `json:"category_label,omitempty"`
+	Aspect             string          `json:"aspect"` // legacy: discipline, work_quality, attitude
+	QualityScore       sql.NullInt64   `json:"quality_score,omitempty"`
+	SpeedScore         sql.NullInt64   `json:"speed_score,omitempty"`
+	InitiativeScore    sql.NullInt64   `json:"initiative_score,omitempty"`
+	TeamworkScore      sql.NullInt64   `json:"teamwork_score,omitempty"`
+	CommunicationScore sql.NullInt64   `json:"communication_score,omitempty"`
+	Strengths          sql.NullString  `json:"strengths,omitempty"`
+	Improvements       sql.NullString  `json:"improvements,omitempty"`
+	Comments           sql.NullString  `json:"comments,omitempty"`
+	Notes              sql.NullString  `json:"notes,omitempty"`
+	AssessmentDate     time.Time       `json:"assessment_date"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
 
 	// Related data
 	InternName     string `json:"intern_name,omitempty"`
@@ -32,22 +35,29 @@ type Assessment struct {
 	AssessorName   string `json:"assessor_name,omitempty"`
 	AssessorAvatar string `json:"assessor_avatar,omitempty"`
 	TaskTitle      string `json:"task_title,omitempty"`
+
+	Scores []AssessmentScore `json:"scores,omitempty"`
 }
 
 type CreateAssessmentRequest struct {
-	InternID           int64  `json:"intern_id" validate:"required"`
-	TaskID             *int64 `json:"task_id,omitempty"`
-	QualityScore       int    `json:"quality_score" validate:"required,min=0,max=100"`
-	SpeedScore         int    `json:"speed_score" validate:"required,min=0,max=100"`
-	InitiativeScore    int    `json:"initiative_score" validate:"required,min=0,max=100"`
-	TeamworkScore      int    `json:"teamwork_score" validate:"required,min=0,max=100"`
-	CommunicationScore int    `json:"communication_score" validate:"required,min=0,max=100"`
-	Strengths          string `json:"strengths,omitempty"`
-	Improvements       string `json:"improvements,omitempty"`
-	Comments           string `json:"comments,omitempty"`
-	Aspect             string `json:"aspect,omitempty"`
-	Notes              string `json:"notes,omitempty"`
-	AssessmentDate     string `json:"assessment_date"` // accepts YYYY-MM-DD; parsed server-side
+	InternID int64  `json:"intern_id" validate:"required"`
+	TaskID   *int64 `json:"task_id,omitempty"`
+	// RubricID overrides the rubric assigned to the intern's school or
+	// department. Scores are keyed by criterion code; the five legacy
+	// fields are used when Scores is empty.
+	RubricID           *int64             `json:"rubric_id,omitempty"`
+	Scores             map[string]float64 `json:"scores,omitempty"`
+	QualityScore       int                `json:"quality_score" validate:"required,min=0,max=100"`
+	SpeedScore         int                `json:"speed_score" validate:"required,min=0,max=100"`
+	InitiativeScore    int                `json:"initiative_score" validate:"required,min=0,max=100"`
+	TeamworkScore      int                `json:"teamwork_score" validate:"required,min=0,max=100"`
+	CommunicationScore int                `json:"communication_score" validate:"required,min=0,max=100"`
+	Strengths          string             `json:"strengths,omitempty"`
+	Improvements       string             `json:"improvements,omitempty"`
+	Comments           string             `json:"comments,omitempty"`
+	Aspect             string             `json:"aspect,omitempty"`
+	Notes              string             `json:"notes,omitempty"`
+	AssessmentDate     string             `json:"assessment_date"` // accepts YYYY-MM-DD; parsed server-side
 }
 
 type UpdateAssessmentRequest struct {
@@ -64,20 +74,46 @@ type UpdateAssessmentRequest struct {
 	AssessmentDate     time.Time `json:"assessment_date,omitempty"`
 }
 
-// GetCategory returns the category based on score
-func (a *Assessment) GetCategory() string {
-	if a.Score >= 85 {
-		return "very_good"
-	} else if a.Score >= 70 {
-		return "good"
-	} else if a.Score >= 50 {
-		return "not_good"
+// DefaultRubricThresholds are the cut-offs of the standard 0-100 rubric
+var DefaultRubricThresholds = []RubricThreshold{
+	{Category: "very_good", Label: "Sangat Baik", MinScore: 85},
+	{Category: "good", Label: "Baik", MinScore: 70},
+	{Category: "not_good", Label: "Tidak Baik", MinScore: 50},
+	{Category: "very_bad", Label: "Sangat Tidak Baik", MinScore: 0},
+}
+
+// CategoryFor returns the threshold a score falls in: the one with the
+// highest MinScore not above it, or the lowest threshold otherwise
+func CategoryFor(score float64, thresholds []RubricThreshold) RubricThreshold {
+	var best, lowest *RubricThreshold
+	for i := range thresholds {
+		t := &thresholds[i]
+		if score >= t.MinScore && (best == nil || t.MinScore > best.MinScore) {
+			best = t
+		}
+		if lowest == nil || t.MinScore < lowest.MinScore {
+			lowest = t
+		}
+	}
+	if best != nil {
+		return *best
 	}
-	return "very_bad"
+	if lowest != nil {
+		return *lowest
+	}
+	return RubricThreshold{}
+}
+
+// GetCategory returns the category of the score under the standard rubric
+func (a *Assessment) GetCategory() string {
+	return CategoryFor(float64(a.Score), DefaultRubricThresholds).Category
 }
 
 // GetCategoryIndo returns Indonesian translation
 func (a *Assessment) GetCategoryIndo() string {
+	if a.CategoryLabel.Valid && a.CategoryLabel.String != "" {
+		return a.CategoryLabel.String
+	}
 	switch a.Category {
 	case "very_good":
 		return "Sangat Baik"
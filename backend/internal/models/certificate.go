@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Certificate statuses. A reissued certificate has been superseded by a
+// newer one that points back to it through ReissuedFrom.
+const (
+	CertificateDraft    = "draft"
+	CertificateIssued   = "issued"
+	CertificateRevoked  = "revoked"
+	CertificateReissued = "reissued"
+)
+
+type Certificate struct {
+	ID                int64              `json:"id"`
+	InternID          int64              `json:"intern_id"`
+	InternName        string             `json:"intern_name,omitempty"`
+	CertificateNumber *string            `json:"certificate_number"`
+	Status            string             `json:"status"`
+	IssueDate         time.Time          `json:"issue_date"`
+	FinalScore        *float64           `json:"final_score"`
+	Remarks           *string            `json:"remarks,omitempty"`
+	IssuedAt          *time.Time         `json:"issued_at,omitempty"`
+	IssuedBy          *int64             `json:"issued_by,omitempty"`
+	RevokedAt         *time.Time         `json:"revoked_at,omitempty"`
+	RevokedReason     *string            `json:"revoked_reason,omitempty"`
+	ReissuedFrom      *int64             `json:"reissued_from,omitempty"`
+	Signed            bool               `json:"signed"`
+	VerifyURL         string             `json:"verify_url,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+	Events            []CertificateEvent `json:"events,omitempty"`
+}
+
+// CertificateEvent records one lifecycle transition
+type CertificateEvent struct {
+	ID         int64     `json:"id"`
+	Action     string    `json:"action"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     *string   `json:"reason,omitempty"`
+	ActorID    *int64    `json:"actor_id"`
+	ActorName  string    `json:"actor_name,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CertificateEligibility is the outcome of the issuance rules for an intern
+type CertificateEligibility struct {
+	InternID          int64    `json:"intern_id"`
+	Eligible          bool     `json:"eligible"`
+	InternStatus      string   `json:"intern_status"`
+	AttendanceRate    float64  `json:"attendance_rate"`
+	MinAttendanceRate float64  `json:"min_attendance_rate"`
+	FinalScore        *float64 `json:"final_score"`
+	MinFinalScore     float64  `json:"min_final_score"`
+	Reasons           []string `json:"reasons"`
+}
+
+// CertificateVerification is what the public verify endpoint discloses
+type CertificateVerification struct {
+	CertificateNumber string     `json:"certificate_number"`
+	HolderName        string     `json:"holder_name"`
+	School            string     `json:"school,omitempty"`
+	PeriodStart       time.Time  `json:"period_start"`
+	PeriodEnd         time.Time  `json:"period_end"`
+	IssueDate         time.Time  `json:"issue_date"`
+	Status            string     `json:"status"` // valid, revoked, reissued, invalid, unsigned
+	SignatureValid    bool       `json:"signature_valid"`
+	Message           string     `json:"message"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason     *string    `json:"revoked_reason,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Comment entity types
+const (
+	CommentEntityTask   = "task"
+	CommentEntityReport = "report"
+	CommentEntityLeave  = "leave"
+)
+
+type Comment struct {
+	ID             int64      `json:"id"`
+	EntityType     string     `json:"entity_type"` // task, report, leave
+	EntityID       int64      `json:"entity_id"`
+	ParentID       *int64     `json:"parent_id,omitempty"`
+	AuthorID       int64      `json:"author_id"`
+	Body           string     `json:"body"`
+	AttachmentPath *string    `json:"attachment_path,omitempty"`
+	AttachmentName *string    `json:"attachment_name,omitempty"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"` // body is cleared, the node stays so replies keep their place
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Related data
+	AuthorName   string    `json:"author_name,omitempty"`
+	AuthorRole   string    `json:"author_role,omitempty"`
+	AuthorAvatar string    `json:"author_avatar,omitempty"`
+	Mentions     []int64   `json:"mentions"`
+	Replies      []Comment `json:"replies"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Delegation statuses
+const (
+	DelegationActive    = "active"
+	DelegationCancelled = "cancelled"
+)
+
+// SupervisorDelegation is a period in which a delegate covers for an
+// absent supervisor
+type SupervisorDelegation struct {
+	ID             int64      `json:"id"`
+	SupervisorID   int64      `json:"supervisor_id"`
+	SupervisorName string     `json:"supervisor_name"`
+	DelegateID     int64      `json:"delegate_id"`
+	DelegateName   string     `json:"delegate_name"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        time.Time  `json:"end_date"`
+	Reason         *string    `json:"reason,omitempty"`
+	Status         string     `json:"status"`
+	InEffect       bool       `json:"in_effect"` // active and covering today
+	CreatedBy      int64      `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// CreateDelegationRequest declares an absence. Admins may declare one for
+// any supervisor; supervisors only for themselves.
+type CreateDelegationRequest struct {
+	SupervisorID int64  `json:"supervisor_id,omitempty"`
+	DelegateID   int64  `json:"delegate_id"`
+	StartDate    string `json:"start_date"` // YYYY-MM-DD
+	EndDate      string `json:"end_date"`   // YYYY-MM-DD
+	Reason       string `json:"reason"`
+}
+
+// DelegatedAction is one approval or review a delegate made on a
+// supervisor's behalf
+type DelegatedAction struct {
+	ID             int64     `json:"id"`
+	DelegationID   int64     `json:"delegation_id"`
+	ActorID        int64     `json:"actor_id"`
+	ActorName      string    `json:"actor_name"`
+	OnBehalfOf     int64     `json:"on_behalf_of"`
+	OnBehalfOfName string    `json:"on_behalf_of_name"`
+	Action         string    `json:"action"`      // e.g. task.review, leave.approved, report.approve
+	EntityType     string    `json:"entity_type"` // task, leave, report, evaluation, logbook
+	EntityID       int64     `json:"entity_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
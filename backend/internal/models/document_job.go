@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Document job kinds and statuses
+const (
+	DocumentJobCertificates = "certificates"
+	DocumentJobReports      = "reports"
+	DocumentJobBoth         = "both"
+
+	DocumentJobQueued    = "queued"
+	DocumentJobRunning   = "running"
+	DocumentJobCompleted = "completed"
+	DocumentJobFailed    = "failed"
+	DocumentJobCancelled = "cancelled"
+)
+
+// DocumentJobFilters selects the interns a batch job renders documents for
+type DocumentJobFilters struct {
+	Status       string  `json:"status,omitempty"`   // intern status, defaults to completed
+	EndFrom      string  `json:"end_from,omitempty"` // YYYY-MM-DD, internship end date
+	EndTo        string  `json:"end_to,omitempty"`   // YYYY-MM-DD
+	SupervisorID int64   `json:"supervisor_id,omitempty"`
+	School       string  `json:"school,omitempty"`
+	InternIDs    []int64 `json:"intern_ids,omitempty"`
+}
+
+// DocumentJob is a batch generation of certificates and/or reports
+type DocumentJob struct {
+	ID            int64              `json:"id"`
+	Kind          string             `json:"kind"`
+	Filters       DocumentJobFilters `json:"filters"`
+	Status        string             `json:"status"`
+	Total         int                `json:"total"`
+	Processed     int                `json:"processed"`
+	Succeeded     int                `json:"succeeded"`
+	Failed        int                `json:"failed"`
+	Progress      int                `json:"progress"` // percent
+	Error         *string            `json:"error,omitempty"`
+	DownloadReady bool               `json:"download_ready"`
+	RequestedBy   int64              `json:"requested_by"`
+	RequesterName string             `json:"requester_name,omitempty"`
+	CreatedAt     time.Time          `json:"created_at"`
+	StartedAt     *time.Time         `json:"started_at,omitempty"`
+	FinishedAt    *time.Time         `json:"finished_at,omitempty"`
+	Items         []DocumentJobItem  `json:"items,omitempty"`
+}
+
+// DocumentJobItem is the outcome for one intern of a batch job
+type DocumentJobItem struct {
+	ID          int64      `json:"id"`
+	InternID    int64      `json:"intern_id"`
+	InternName  string     `json:"intern_name"`
+	Status      string     `json:"status"` // pending, done, failed
+	Error       *string    `json:"error,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
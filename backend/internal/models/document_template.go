@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Document types that can be rendered from a template
+const (
+	DocumentCertificate          = "certificate"
+	DocumentAcceptanceLetter     = "acceptance_letter"
+	DocumentCompletionLetter     = "completion_letter"
+	DocumentRecommendationLetter = "recommendation_letter"
+)
+
+// DocumentTemplate is an admin-managed PDF layout. Uploaded images are
+// stored as paths relative to the upload directory.
+type DocumentTemplate struct {
+	ID             int64          `json:"id"`
+	Name           string         `json:"name"`
+	DocumentType   string         `json:"document_type"`
+	Orientation    string         `json:"orientation"` // P or L
+	PageSize       string         `json:"page_size"`
+	BackgroundPath *string        `json:"background_path"`
+	LogoPath       *string        `json:"logo_path"`
+	SignaturePath  *string        `json:"signature_path"`
+	SignatoryName  *string        `json:"signatory_name"`
+	SignatoryTitle *string        `json:"signatory_title"`
+	Layout         DocumentLayout `json:"layout"`
+	IsDefault      bool           `json:"is_default"`
+	IsActive       bool           `json:"is_active"`
+	BuiltIn        bool           `json:"built_in,omitempty"` // fallback layout, not stored
+	CreatedBy      *int64         `json:"created_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// DocumentLayout places elements on the page. Coordinates are in mm from
+// the top left corner.
+type DocumentLayout struct {
+	Elements []DocumentElement `json:"elements"`
+}
+
+// DocumentElement is one item drawn on the page:
+//   - text:  Text with {{placeholders}}, wrapped when W and LineHeight are set
+//   - image: Source is "logo" or "signature"
+//   - qr:    QR code of the certificate verification link
+//   - line:  horizontal rule of width W
+type DocumentElement struct {
+	Type       string  `json:"type"`
+	Text       string  `json:"text,omitempty"`
+	Source     string  `json:"source,omitempty"`
+	X          float64 `json:"x"`
+	Y          float64 `json:"y"`
+	W          float64 `json:"w,omitempty"`
+	H          float64 `json:"h,omitempty"`
+	Font       string  `json:"font,omitempty"`  // Helvetica, Times or Courier
+	Style      string  `json:"style,omitempty"` // combination of B, I, U
+	Size       float64 `json:"size,omitempty"`
+	Align      string  `json:"align,omitempty"` // L, C, R or J
+	Color      string  `json:"color,omitempty"` // #RRGGBB
+	LineHeight float64 `json:"line_height,omitempty"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+)
+
+// Evaluation statuses
+const (
+	EvaluationPendingSelf       = "pending_self"
+	EvaluationPendingSupervisor = "pending_supervisor"
+	EvaluationInReview          = "in_review"
+	EvaluationSignedOff         = "signed_off"
+)
+
+// Evaluation score sources
+const (
+	EvaluationSourceSelf       = "self"
+	EvaluationSourceSupervisor = "supervisor"
+)
+
+// EvaluationCycle is a scheduled round of evaluations, such as mid-term or
+// final, for a cohort of interns
+type EvaluationCycle struct {
+	ID                int64             `json:"id"`
+	Name              string            `json:"name"`
+	Type              string            `json:"type"` // mid_term, final, custom
+	Description       *string           `json:"description,omitempty"`
+	SelfDueDate       time.Time         `json:"self_due_date"`
+	SupervisorDueDate time.Time         `json:"supervisor_due_date"`
+	Cohort            *EvaluationCohort `json:"cohort,omitempty"`
+	Status            string            `json:"status"` // open, closed
+	CreatedBy         *int64            `json:"created_by,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+
+	// Number of evaluations per status
+	Progress map[string]int `json:"progress,omitempty"`
+}
+
+// EvaluationCohort selects the active interns a cycle is opened for. Empty
+// fields don't filter.
+type EvaluationCohort struct {
+	School        string  `json:"school,omitempty"`
+	Department    string  `json:"department,omitempty"`
+	SupervisorID  int64   `json:"supervisor_id,omitempty"`
+	StartDateFrom string  `json:"start_date_from,omitempty"`
+	StartDateTo   string  `json:"start_date_to,omitempty"`
+	InternIDs     []int64 `json:"intern_ids,omitempty"`
+}
+
+type CreateEvaluationCycleRequest struct {
+	Name              string           `json:"name"`
+	Type              string           `json:"type"`
+	Description       string           `json:"description"`
+	SelfDueDate       string           `json:"self_due_date"`       // YYYY-MM-DD
+	SupervisorDueDate string           `json:"supervisor_due_date"` // YYYY-MM-DD
+	Cohort            EvaluationCohort `json:"cohort"`
+}
+
+// Evaluation is one intern's evaluation in a cycle
+type Evaluation struct {
+	ID                    int64      `json:"id"`
+	CycleID               int64      `json:"cycle_id"`
+	CycleName             string     `json:"cycle_name"`
+	CycleType             string     `json:"cycle_type"`
+	SelfDueDate           time.Time  `json:"self_due_date"`
+	SupervisorDueDate     time.Time  `json:"supervisor_due_date"`
+	InternID              int64      `json:"intern_id"`
+	InternName            string     `json:"intern_name"`
+	SupervisorName        string     `json:"supervisor_name,omitempty"`
+	RubricVersionID       int64      `json:"rubric_version_id"`
+	Status                string     `json:"status"`
+	Overdue               bool       `json:"overdue"`
+	SelfComments          *string    `json:"self_comments,omitempty"`
+	SelfSubmittedAt       *time.Time `json:"self_submitted_at,omitempty"`
+	SupervisorComments    *string    `json:"supervisor_comments,omitempty"`
+	Strengths             *string    `json:"strengths,omitempty"`
+	Improvements          *string    `json:"improvements,omitempty"`
+	SupervisorSubmittedAt *time.Time `json:"supervisor_submitted_at,omitempty"`
+	SignedOffAt           *time.Time `json:"signed_off_at,omitempty"`
+	SignedOffByName       string     `json:"signed_off_by_name,omitempty"`
+	AssessmentID          *int64     `json:"assessment_id,omitempty"`
+	CreatedAt             time.Time  `json:"created_at"`
+
+	// Filled on detail requests. Supervisor scores and the result are
+	// hidden from the intern until sign-off.
+	Rubric   *RubricVersion        `json:"rubric,omitempty"`
+	Criteria []EvaluationCriterion `json:"criteria,omitempty"`
+	Result   *EvaluationResult     `json:"result,omitempty"`
+}
+
+// EvaluationCriterion shows the self and supervisor score side by side
+type EvaluationCriterion struct {
+	CriterionID     int64    `json:"criterion_id"`
+	Code            string   `json:"code"`
+	Name            string   `json:"name"`
+	Weight          float64  `json:"weight"`
+	SelfScore       *float64 `json:"self_score"`
+	SupervisorScore *float64 `json:"supervisor_score,omitempty"`
+	Difference      *float64 `json:"difference,omitempty"` // supervisor - self
+}
+
+// EvaluationResult is the weighted outcome of a set of scores
+type EvaluationResult struct {
+	SelfScore       *float64 `json:"self_score,omitempty"`
+	SupervisorScore *float64 `json:"supervisor_score,omitempty"`
+	Percent         *int     `json:"percent,omitempty"`
+	Category        string   `json:"category,omitempty"`
+	CategoryLabel   string   `json:"category_label,omitempty"`
+}
+
+// EvaluationScoresRequest is a self or supervisor submission
+type EvaluationScoresRequest struct {
+	Scores       map[string]float64 `json:"scores"`
+	Comments     string             `json:"comments"`
+	Strengths    string             `json:"strengths,omitempty"`
+	Improvements string             `json:"improvements,omitempty"`
+}
@@ -0,0 +1,91 @@
+package models
+
+// Grade components
+const (
+	GradeComponentTasks       = "tasks"
+	GradeComponentAttendance  = "attendance"
+	GradeComponentAssessments = "assessments"
+)
+
+// GradingPolicy decides how an intern's final grade is computed. Weights are
+// relative; components without data are left out and the rest reweighted.
+type GradingPolicy struct {
+	Weights GradeWeights `json:"weights"`
+	// Share (0-100) of the attendance component that comes from
+	// punctuality; the rest is the attendance rate
+	PunctualityShare float64 `json:"punctuality_share"`
+	// Points deducted from the score of a task submitted late
+	LatePenalty float64     `json:"late_penalty"`
+	Scale       []GradeBand `json:"scale"`
+}
+
+type GradeWeights struct {
+	Tasks       float64 `json:"tasks"`
+	Attendance  float64 `json:"attendance"`
+	Assessments float64 `json:"assessments"`
+}
+
+// GradeBand maps final scores from MinScore up to a letter and predicate
+type GradeBand struct {
+	MinScore  float64 `json:"min_score"`
+	Letter    string  `json:"letter"`
+	Predicate string  `json:"predicate"`
+}
+
+// DefaultGradingPolicy is used until an admin saves a policy
+var DefaultGradingPolicy = GradingPolicy{
+	Weights:          GradeWeights{Tasks: 40, Attendance: 20, Assessments: 40},
+	PunctualityShare: 30,
+	LatePenalty:      10,
+	Scale: []GradeBand{
+		{MinScore: 85, Letter: "A", Predicate: "Sangat Baik"},
+		{MinScore: 75, Letter: "B", Predicate: "Baik"},
+		{MinScore: 60, Letter: "C", Predicate: "Cukup"},
+		{MinScore: 0, Letter: "D", Predicate: "Kurang"},
+	},
+}
+
+// BandFor returns the band a score falls in: the one with the highest
+// MinScore not above it, or the lowest band otherwise
+func (p GradingPolicy) BandFor(score float64) GradeBand {
+	var best, lowest *GradeBand
+	for i := range p.Scale {
+		b := &p.Scale[i]
+		if lowest == nil || b.MinScore < lowest.MinScore {
+			lowest = b
+		}
+		if score >= b.MinScore && (best == nil || b.MinScore > best.MinScore) {
+			best = b
+		}
+	}
+	if best != nil {
+		return *best
+	}
+	if lowest != nil {
+		return *lowest
+	}
+	return GradeBand{}
+}
+
+// GradeBreakdown is an intern's final grade with the score of each component
+type GradeBreakdown struct {
+	InternID   int64            `json:"intern_id"`
+	InternName string           `json:"intern_name"`
+	FinalScore *float64         `json:"final_score"`
+	Letter     string           `json:"letter,omitempty"`
+	Predicate  string           `json:"predicate,omitempty"`
+	Components []GradeComponent `json:"components"`
+	Policy     GradingPolicy    `json:"policy"`
+}
+
+// GradeComponent is one part of the final grade. Score is nil when the
+// intern has no data for it yet; EffectiveWeight is the share (0-100) it
+// ended up with after reweighting.
+type GradeComponent struct {
+	Key             string                 `json:"key"`
+	Label           string                 `json:"label"`
+	Weight          float64                `json:"weight"`
+	EffectiveWeight float64                `json:"effective_weight"`
+	Score           *float64               `json:"score"`
+	Details         map[string]interface{} `json:"details"`
+}
@@ -0,0 +1,122 @@
+package models
+
+import "time"
+
+// Institution is a school or university interns come from
+type Institution struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ShortName *string   `json:"short_name,omitempty"`
+	Address   *string   `json:"address,omitempty"`
+	Phone     *string   `json:"phone,omitempty"`
+	Email     *string   `json:"email,omitempty"`
+	Website   *string   `json:"website,omitempty"`
+	Quota     *int      `json:"quota,omitempty"` // interns active at once; nil is unlimited
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ActiveInterns   int                    `json:"active_interns"`
+	TotalInterns    int                    `json:"total_interns"`
+	QuotaRemaining  *int                   `json:"quota_remaining,omitempty"`
+	ActiveAgreement *InstitutionAgreement  `json:"active_agreement,omitempty"`
+	Agreements      []InstitutionAgreement `json:"agreements,omitempty"`
+	Contacts        []InstitutionContact   `json:"contacts,omitempty"`
+	Aliases         []InstitutionAlias     `json:"aliases,omitempty"`
+}
+
+// InstitutionRequest creates or updates an institution
+type InstitutionRequest struct {
+	Name      string   `json:"name"`
+	ShortName string   `json:"short_name"`
+	Address   string   `json:"address"`
+	Phone     string   `json:"phone"`
+	Email     string   `json:"email"`
+	Website   string   `json:"website"`
+	Quota     *int     `json:"quota"`
+	Aliases   []string `json:"aliases,omitempty"`
+}
+
+// InstitutionAgreement is a partnership agreement (MoU)
+type InstitutionAgreement struct {
+	ID            int64     `json:"id"`
+	InstitutionID int64     `json:"institution_id"`
+	MouNumber     string    `json:"mou_number"`
+	Title         *string   `json:"title,omitempty"`
+	StartDate     time.Time `json:"start_date"`
+	EndDate       time.Time `json:"end_date"`
+	Notes         *string   `json:"notes,omitempty"`
+	Status        string    `json:"status"` // upcoming, active, expired
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Agreement statuses, derived from the validity dates
+const (
+	AgreementUpcoming = "upcoming"
+	AgreementActive   = "active"
+	AgreementExpired  = "expired"
+)
+
+// InstitutionAgreementRequest creates or updates an agreement
+type InstitutionAgreementRequest struct {
+	MouNumber string `json:"mou_number"`
+	Title     string `json:"title"`
+	StartDate string `json:"start_date"` // YYYY-MM-DD
+	EndDate   string `json:"end_date"`   // YYYY-MM-DD
+	Notes     string `json:"notes"`
+}
+
+// InstitutionContact is a contact person at an institution
+type InstitutionContact struct {
+	ID            int64     `json:"id"`
+	InstitutionID int64     `json:"institution_id"`
+	Name          string    `json:"name"`
+	Position      *string   `json:"position,omitempty"`
+	Email         *string   `json:"email,omitempty"`
+	Phone         *string   `json:"phone,omitempty"`
+	IsPrimary     bool      `json:"is_primary"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// InstitutionContactRequest creates or updates a contact person
+type InstitutionContactRequest struct {
+	Name      string `json:"name"`
+	Position  string `json:"position"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+// InstitutionAlias is another name an institution is known by
+type InstitutionAlias struct {
+	ID    int64  `json:"id"`
+	Alias string `json:"alias"`
+}
+
+// SchoolName is a free-text school name as entered on interns, with the
+// institution it resolves to
+type SchoolName struct {
+	School          string  `json:"school"`
+	Interns         int     `json:"interns"`
+	InstitutionID   *int64  `json:"institution_id,omitempty"`
+	InstitutionName *string `json:"institution_name,omitempty"`
+	Suggested       []int64 `json:"suggested_institution_ids,omitempty"`
+}
+
+// DuplicateInstitutions is a group of institutions whose names look alike
+type DuplicateInstitutions struct {
+	Key          string        `json:"key"`
+	Institutions []Institution `json:"institutions"`
+}
+
+// MergeInstitutionsRequest folds source institutions into the target
+type MergeInstitutionsRequest struct {
+	SourceIDs []int64 `json:"source_ids"`
+}
+
+// AssignSchoolRequest links every intern with a free-text school name to
+// an institution, and remembers the name as an alias
+type AssignSchoolRequest struct {
+	School        string `json:"school"`
+	InstitutionID int64  `json:"institution_id"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Background job statuses
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// Job is one unit of work in the persistent background queue
+type Job struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	MaxAttempts  int             `json:"max_attempts"`
+	RunAt        time.Time       `json:"run_at"`
+	LockedBy     *string         `json:"locked_by,omitempty"`
+	LockedAt     *time.Time      `json:"locked_at,omitempty"`
+	LastError    *string         `json:"last_error,omitempty"`
+	ScheduleName *string         `json:"schedule_name,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
+}
+
+// JobSchedule is a recurring job declared with a cron expression
+type JobSchedule struct {
+	Name      string          `json:"name"`
+	Spec      string          `json:"spec"`
+	JobType   string          `json:"job_type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	NextRunAt time.Time       `json:"next_run_at"`
+	LastRunAt *time.Time      `json:"last_run_at,omitempty"`
+}
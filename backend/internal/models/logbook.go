@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+)
+
+// Logbook entry statuses
+const (
+	LogbookSubmitted    = "submitted"
+	LogbookAcknowledged = "acknowledged"
+)
+
+// LogbookEntry is an intern's daily journal (jurnal harian) for one workday
+type LogbookEntry struct {
+	ID             int64      `json:"id"`
+	InternID       int64      `json:"intern_id"`
+	Date           time.Time  `json:"date"`
+	AttendanceID   *int64     `json:"attendance_id"`
+	Activities     string     `json:"activities"`
+	Hours          float64    `json:"hours"`
+	AttachmentName *string    `json:"attachment_name,omitempty"`
+	AttachmentPath *string    `json:"attachment_path,omitempty"`
+	Status         string     `json:"status"` // submitted, acknowledged
+	AcknowledgedBy *int64     `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	SupervisorNote *string    `json:"supervisor_note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	Tasks []LogbookTask `json:"tasks"`
+
+	// Related data
+	InternName         string     `json:"intern_name,omitempty"`
+	AttendanceStatus   *string    `json:"attendance_status,omitempty"`
+	CheckInTime        *time.Time `json:"check_in_time,omitempty"`
+	CheckOutTime       *time.Time `json:"check_out_time,omitempty"`
+	AcknowledgedByName string     `json:"acknowledged_by_name,omitempty"`
+}
+
+// LogbookTask is a task the intern worked on that day
+type LogbookTask struct {
+	ID     int64  `json:"id"`
+	Title  string `json:"title"`
+	Status string `json:"status"`
+}
+
+// LogbookAcknowledgeRequest acknowledges several entries at once
+type LogbookAcknowledgeRequest struct {
+	IDs  []int64 `json:"ids"`
+	Note string  `json:"note"`
+}
@@ -15,6 +15,11 @@ const (
 	NotificationNewSupervisor     = "new_supervisor_registration"
 	NotificationAssessmentCreated = "assessment_created"
 	NotificationAttendanceLate    = "attendance_late"
+	NotificationAnnouncement      = "announcement"
+	NotificationComment           = "comment"
+	NotificationMention           = "mention"
+	NotificationCertificate       = "certificate"
+	NotificationDocumentJob       = "document_job"
 )
 
 type Notification struct {
@@ -4,6 +4,21 @@ import (
 	"time"
 )
 
+// Report workflow statuses and actions
+const (
+	ReportDraft             = "draft"
+	ReportSubmitted         = "submitted"
+	ReportRevisionRequested = "revision_requested"
+	ReportApproved          = "approved"
+	ReportCountersigned     = "countersigned"
+
+	ReportActionCreate          = "create"
+	ReportActionSubmit          = "submit"
+	ReportActionRequestRevision = "request_revision"
+	ReportActionApprove         = "approve"
+	ReportActionCountersign     = "countersign"
+)
+
 type Report struct {
 	ID          int64     `json:"id"`
 	InternID    int64     `json:"intern_id"`
@@ -13,14 +28,110 @@ type Report struct {
 	Type        string    `json:"type"` // weekly, monthly, final
 	PeriodStart time.Time `json:"period_start"`
 	PeriodEnd   time.Time `json:"period_end"`
-	Status      string    `json:"status"` // draft, submitted, reviewed
+	Status      string    `json:"status"` // draft, submitted, revision_requested, approved, countersigned
 	Feedback    string    `json:"feedback,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
+	// Set when the draft was generated from activity data
+	AutoGenerated bool            `json:"auto_generated"`
+	Activity      *ReportActivity `json:"activity,omitempty"`
+
+	// Workflow
+	Locked            bool          `json:"locked"`
+	SubmittedAt       *time.Time    `json:"submitted_at,omitempty"`
+	SubmittedByName   string        `json:"submitted_by_name,omitempty"`
+	ReviewedAt        *time.Time    `json:"reviewed_at,omitempty"`
+	ReviewedByName    string        `json:"reviewed_by_name,omitempty"`
+	CountersignedAt   *time.Time    `json:"countersigned_at,omitempty"`
+	CountersignedName string        `json:"countersigned_by_name,omitempty"`
+	Events            []ReportEvent `json:"events,omitempty"`
+
 	// Related data
 	InternName      string `json:"intern_name,omitempty"`
 	InternAvatar    string `json:"intern_avatar,omitempty"`
 	CreatedByName   string `json:"created_by_name,omitempty"`
 	CreatedByAvatar string `json:"created_by_avatar,omitempty"`
 }
+
+// ReportActivity summarizes what an intern did in a report period. Generated
+// drafts are composed from it and keep a copy for reviewers.
+type ReportActivity struct {
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+
+	Attendance  ReportAttendanceSummary `json:"attendance"`
+	Tasks       ReportTaskSummary       `json:"tasks"`
+	Assessments ReportAssessmentSummary `json:"assessments"`
+}
+
+type ReportAttendanceSummary struct {
+	Present     int     `json:"present"`
+	Late        int     `json:"late"`
+	Absent      int     `json:"absent"`
+	Excused     int     `json:"excused"` // sick, permission, leave
+	Hours       float64 `json:"hours"`
+	LateMinutes int     `json:"late_minutes"`
+	Rate        float64 `json:"rate"`
+}
+
+type ReportTaskSummary struct {
+	Completed     int              `json:"completed"`
+	CompletedLate int              `json:"completed_late"`
+	InProgress    int              `json:"in_progress"`
+	Revisions     int              `json:"revisions"`
+	AverageScore  *float64         `json:"average_score,omitempty"`
+	Items         []ReportTaskItem `json:"items"`
+}
+
+type ReportTaskItem struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	CompletedAt time.Time `json:"completed_at"`
+	Score       *int      `json:"score,omitempty"`
+	IsLate      bool      `json:"is_late"`
+	Revisions   int       `json:"revisions"`
+}
+
+type ReportAssessmentSummary struct {
+	Count        int                    `json:"count"`
+	AverageScore *float64               `json:"average_score,omitempty"`
+	Items        []ReportAssessmentItem `json:"items"`
+}
+
+type ReportAssessmentItem struct {
+	Aspect string    `json:"aspect"`
+	Score  int       `json:"score"`
+	Date   time.Time `json:"date"`
+	Notes  string    `json:"notes,omitempty"`
+}
+
+// ReportEvent is one step of the approval workflow
+type ReportEvent struct {
+	ID         int64     `json:"id"`
+	Action     string    `json:"action"`
+	FromStatus *string   `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Note       *string   `json:"note,omitempty"`
+	ActorID    *int64    `json:"actor_id"`
+	ActorName  string    `json:"actor_name,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReportVerification is the public result of checking a signed report
+type ReportVerification struct {
+	Status          string     `json:"status"` // valid, modified, unsigned
+	Message         string     `json:"message"`
+	SignatureValid  bool       `json:"signature_valid"`
+	ReportID        int64      `json:"report_id"`
+	Title           string     `json:"title"`
+	Type            string     `json:"type"`
+	InternName      string     `json:"intern_name"`
+	PeriodStart     time.Time  `json:"period_start"`
+	PeriodEnd       time.Time  `json:"period_end"`
+	ReportStatus    string     `json:"report_status"`
+	ApprovedBy      string     `json:"approved_by,omitempty"`
+	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
+	CountersignedBy string     `json:"countersigned_by,omitempty"`
+	CountersignedAt *time.Time `json:"countersigned_at,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// ReviewSLA is the review turnaround policy, in hours after submission
+type ReviewSLA struct {
+	SLAHours      int `json:"sla_hours"`
+	ReminderHours int `json:"reminder_hours"`
+}
+
+// PendingReview is a submitted task waiting for its reviewer
+type PendingReview struct {
+	TaskID       int64      `json:"task_id"`
+	Title        string     `json:"title"`
+	InternID     int64      `json:"intern_id"`
+	InternName   string     `json:"intern_name"`
+	ReviewerID   *int64     `json:"reviewer_id,omitempty"`
+	ReviewerName string     `json:"reviewer_name,omitempty"`
+	Version      int        `json:"version"`
+	SubmittedAt  time.Time  `json:"submitted_at"`
+	DueAt        time.Time  `json:"due_at"`
+	WaitingHours float64    `json:"waiting_hours"`
+	Breached     bool       `json:"breached"`
+	RemindedAt   *time.Time `json:"reminded_at,omitempty"`
+	EscalatedAt  *time.Time `json:"escalated_at,omitempty"`
+}
+
+// ReviewerSLAStats measures one reviewer's time-to-review over a period
+// and their current queue
+type ReviewerSLAStats struct {
+	ReviewerID       int64    `json:"reviewer_id"`
+	ReviewerName     string   `json:"reviewer_name"`
+	Reviews          int      `json:"reviews"`
+	WithinSLA        int      `json:"within_sla"`
+	Breached         int      `json:"breached"`
+	AvgHours         *float64 `json:"avg_hours"`
+	MaxHours         *float64 `json:"max_hours"`
+	ComplianceRate   *float64 `json:"compliance_rate"` // percent of reviews within the SLA
+	Pending          int      `json:"pending"`
+	PendingBreached  int      `json:"pending_breached"`
+	OldestPendingHrs *float64 `json:"oldest_pending_hours"`
+}
+
+// ReviewSLAReport is the SLA summary for a period
+type ReviewSLAReport struct {
+	Range     AnalyticsRange     `json:"range"`
+	Policy    ReviewSLA          `json:"policy"`
+	Reviewers []ReviewerSLAStats `json:"reviewers"`
+}
+
+// TaskReviewerChange is one reassignment of a task's reviewer
+type TaskReviewerChange struct {
+	ID             int64     `json:"id"`
+	TaskID         int64     `json:"task_id"`
+	FromReviewerID *int64    `json:"from_reviewer_id,omitempty"`
+	ToReviewerID   int64     `json:"to_reviewer_id"`
+	ChangedBy      int64     `json:"changed_by"`
+	Reason         *string   `json:"reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ReassignReviewerRequest moves review of one task (PUT /tasks/{id}/reviewer)
+// or of every open task of a reviewer (from_reviewer_id) to another user
+type ReassignReviewerRequest struct {
+	FromReviewerID int64  `json:"from_reviewer_id,omitempty"`
+	ReviewerID     int64  `json:"reviewer_id"`
+	Reason         string `json:"reason"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Risk levels
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// InternRisk is an intern's early-warning score (0-100) with the signals
+// that contributed to it
+type InternRisk struct {
+	InternID       int64        `json:"intern_id"`
+	InternName     string       `json:"intern_name"`
+	SupervisorID   *int64       `json:"supervisor_id,omitempty"`
+	SupervisorName string       `json:"supervisor_name,omitempty"`
+	Score          int          `json:"score"`
+	Level          string       `json:"level"`
+	Reasons        []RiskReason `json:"reasons"`
+	ComputedAt     time.Time    `json:"computed_at"`
+}
+
+// RiskReason is one signal and the points it added
+type RiskReason struct {
+	Code    string `json:"code"` // consecutive_absences, lateness_trend, overdue_tasks, revision_heavy_tasks, falling_assessments, missed_reports
+	Message string `json:"message"`
+	Points  int    `json:"points"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// Rubric is an assessment form admins configure per institution or department
+type Rubric struct {
+	ID             int64              `json:"id"`
+	Name           string             `json:"name"`
+	Description    *string            `json:"description,omitempty"`
+	IsDefault      bool               `json:"is_default"`
+	IsActive       bool               `json:"is_active"`
+	CurrentVersion int                `json:"current_version"`
+	CreatedBy      *int64             `json:"created_by,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Assignments    []RubricAssignment `json:"assignments"`
+	Version        *RubricVersion     `json:"version,omitempty"`
+}
+
+// RubricVersion is an immutable snapshot of a rubric's criteria, scale and
+// thresholds. Assessments point at the version they were scored with.
+type RubricVersion struct {
+	ID         int64             `json:"id"`
+	RubricID   int64             `json:"rubric_id"`
+	RubricName string            `json:"rubric_name,omitempty"`
+	Version    int               `json:"version"`
+	ScaleMin   float64           `json:"scale_min"`
+	ScaleMax   float64           `json:"scale_max"`
+	Thresholds []RubricThreshold `json:"thresholds"`
+	Criteria   []RubricCriterion `json:"criteria"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type RubricCriterion struct {
+	ID          int64   `json:"id,omitempty"`
+	Code        string  `json:"code"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Weight      float64 `json:"weight"`
+	SortOrder   int     `json:"sort_order"`
+}
+
+// RubricThreshold assigns Category to weighted scores of at least MinScore,
+// on the rubric's scale
+type RubricThreshold struct {
+	Category string  `json:"category"`
+	Label    string  `json:"label"`
+	MinScore float64 `json:"min_score"`
+}
+
+// RubricAssignment targets an institution, a department, or both.
+// LegacySchool is a school name from before assignments were per
+// institution that matched no institution; such an assignment is ignored
+// until an admin picks the institution.
+type RubricAssignment struct {
+	ID              int64   `json:"id,omitempty"`
+	InstitutionID   *int64  `json:"institution_id,omitempty"`
+	InstitutionName *string `json:"institution_name,omitempty"`
+	Department      *string `json:"department,omitempty"`
+	LegacySchool    *string `json:"legacy_school,omitempty"`
+}
+
+type RubricRequest struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	IsDefault   *bool              `json:"is_default,omitempty"`
+	IsActive    *bool              `json:"is_active,omitempty"`
+	ScaleMin    *float64           `json:"scale_min,omitempty"`
+	ScaleMax    *float64           `json:"scale_max,omitempty"`
+	Criteria    []RubricCriterion  `json:"criteria,omitempty"`
+	Thresholds  []RubricThreshold  `json:"thresholds,omitempty"`
+	Assignments []RubricAssignment `json:"assignments,omitempty"`
+}
+
+// AssessmentScore is one criterion score of an assessment
+type AssessmentScore struct {
+	CriterionID int64   `json:"criterion_id"`
+	Code        string  `json:"code"`
+	Name        string  `json:"name"`
+	Weight      float64 `json:"weight"`
+	Score       float64 `json:"score"`
+}
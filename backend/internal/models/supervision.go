@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Supervisor roles on an intern. The primary supervisor is the intern's
+// supervisor_id; the others are kept in intern_supervisors.
+const (
+	SupervisorRolePrimary   = "primary"
+	SupervisorRoleSecondary = "secondary"
+	SupervisorRoleAcademic  = "academic"
+)
+
+// RoleCampusSupervisor is a university-side account scoped to the interns
+// of its institution
+const RoleCampusSupervisor = "campus_supervisor"
+
+// Assessment sources
+const (
+	AssessmentSourceCompany  = "company"
+	AssessmentSourceAcademic = "academic"
+)
+
+// InternSupervisor is one of an intern's supervisors
+type InternSupervisor struct {
+	UserID     int64      `json:"user_id"`
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	UserRole   string     `json:"user_role"`
+	Role       string     `json:"role"` // primary, secondary, academic
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+}
+
+// SetInternSupervisorsRequest replaces an intern's supervisors. At most
+// one entry may be primary; without one the intern has no primary
+// supervisor.
+type SetInternSupervisorsRequest struct {
+	Supervisors []struct {
+		UserID int64  `json:"user_id"`
+		Role   string `json:"role"`
+	} `json:"supervisors"`
+}
+
+// CampusIntern is an intern as shown to their institution
+type CampusIntern struct {
+	ID             int64              `json:"id"`
+	FullName       string             `json:"full_name"`
+	Email          string             `json:"email"`
+	StudentID      *string            `json:"student_id,omitempty"`
+	Department     *string            `json:"department,omitempty"`
+	InstitutionID  int64              `json:"institution_id"`
+	StartDate      time.Time          `json:"start_date"`
+	EndDate        time.Time          `json:"end_date"`
+	Status         string             `json:"status"`
+	SupervisorName *string            `json:"supervisor_name,omitempty"`
+	Supervisors    []InternSupervisor `json:"supervisors,omitempty"`
+}
+
+// CampusAssessment is a company or academic assessment of an intern
+type CampusAssessment struct {
+	ID             int64     `json:"id"`
+	Source         string    `json:"source"` // company, academic
+	AssessorName   string    `json:"assessor_name"`
+	Score          int       `json:"score"`
+	Category       string    `json:"category"`
+	CategoryLabel  *string   `json:"category_label,omitempty"`
+	Strengths      *string   `json:"strengths,omitempty"`
+	Improvements   *string   `json:"improvements,omitempty"`
+	Comments       *string   `json:"comments,omitempty"`
+	AssessmentDate time.Time `json:"assessment_date"`
+}
@@ -41,6 +41,13 @@ type Task struct {
 	AssignedByName string `json:"assigned_by_name,omitempty"`
 	AssignerName   string `json:"assigner_name,omitempty"`
 	AssignerRole   string `json:"assigner_role,omitempty"`
+
+	// Structure (loaded on detail views)
+	ParentTaskID *int64              `json:"parent_task_id,omitempty"`
+	Checklist    []TaskChecklistItem `json:"checklist,omitempty"`
+	Subtasks     []TaskSummary       `json:"subtasks,omitempty"`
+	BlockedBy    []TaskSummary       `json:"blocked_by,omitempty"`
+	Progress     *TaskProgress       `json:"progress,omitempty"`
 }
 
 type TaskAssignment struct {
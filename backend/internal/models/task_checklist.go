@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type TaskChecklistItem struct {
+	ID        int64      `json:"id"`
+	TaskID    int64      `json:"task_id"`
+	Title     string     `json:"title"`
+	IsDone    bool       `json:"is_done"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+	DoneBy    *int64     `json:"done_by,omitempty"`
+	Position  int        `json:"position"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TaskSummary is the short form used for subtasks and dependencies
+type TaskSummary struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	Progress int    `json:"progress"`
+}
+
+// TaskProgress rolls checklist items and subtasks up into one percentage
+type TaskProgress struct {
+	ChecklistTotal    int  `json:"checklist_total"`
+	ChecklistDone     int  `json:"checklist_done"`
+	SubtasksTotal     int  `json:"subtasks_total"`
+	SubtasksCompleted int  `json:"subtasks_completed"`
+	Percentage        int  `json:"percentage"`
+	IsBlocked         bool `json:"is_blocked"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// TaskSubmission is an immutable snapshot of one submission attempt
+type TaskSubmission struct {
+	ID              int64            `json:"id"`
+	TaskID          int64            `json:"task_id"`
+	Version         int              `json:"version"`
+	SubmittedBy     int64            `json:"submitted_by"`
+	SubmissionNotes *string          `json:"submission_notes,omitempty"`
+	SubmissionLinks []SubmissionLink `json:"submission_links"`
+	SubmissionFile  *string          `json:"submission_file,omitempty"`
+	IsLate          bool             `json:"is_late"`
+	SubmittedAt     time.Time        `json:"submitted_at"`
+
+	Reviews []TaskReview `json:"reviews"`
+}
+
+type TaskReview struct {
+	ID           int64     `json:"id"`
+	TaskID       int64     `json:"task_id"`
+	SubmissionID *int64    `json:"submission_id,omitempty"`
+	ReviewerID   int64     `json:"reviewer_id"`
+	Action       string    `json:"action"` // approve, revision
+	Score        *int      `json:"score,omitempty"`
+	Feedback     *string   `json:"feedback,omitempty"`
+	ReviewedAt   time.Time `json:"reviewed_at"`
+
+	ReviewerName string `json:"reviewer_name,omitempty"`
+}
+
+// DiffLine is one line of a notes diff
+type DiffLine struct {
+	Op   string `json:"op"` // equal, added, removed
+	Text string `json:"text"`
+}
+
+// LinkChange describes how a submission link changed between versions
+type LinkChange struct {
+	Op   string          `json:"op"` // added, removed, changed, unchanged
+	From *SubmissionLink `json:"from,omitempty"`
+	To   *SubmissionLink `json:"to,omitempty"`
+}
+
+type SubmissionDiff struct {
+	TaskID      int64        `json:"task_id"`
+	FromVersion int          `json:"from_version"`
+	ToVersion   int          `json:"to_version"`
+	Notes       []DiffLine   `json:"notes"`
+	Links       []LinkChange `json:"links"`
+	FileChanged bool         `json:"file_changed"`
+	FromFile    *string      `json:"from_file,omitempty"`
+	ToFile      *string      `json:"to_file,omitempty"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type TaskTemplate struct {
+	ID                 int64     `json:"id"`
+	Title              string    `json:"title"`
+	Description        *string   `json:"description,omitempty"`
+	SubmissionMethod   string    `json:"submission_method"` // links, files, both
+	Priority           string    `json:"priority"`          // low, medium, high
+	StartOffsetDays    int       `json:"start_offset_days"` // days after the instantiation date
+	DeadlineOffsetDays *int      `json:"deadline_offset_days,omitempty"`
+	DeadlineTime       *string   `json:"deadline_time,omitempty"` // HH:MM:SS
+	Checklist          []string  `json:"checklist"`
+	CreatedBy          int64     `json:"created_by"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	CreatedByName string `json:"created_by_name,omitempty"`
+}
+
+// Recurrence frequencies
+const (
+	RecurrenceDaily   = "daily"
+	RecurrenceWeekly  = "weekly"
+	RecurrenceMonthly = "monthly"
+)
+
+// TaskRecurrence expands a template into a task assignment on a schedule
+type TaskRecurrence struct {
+	ID         int64      `json:"id"`
+	TemplateID int64      `json:"template_id"`
+	Frequency  string     `json:"frequency"`              // daily, weekly, monthly
+	Weekday    *int       `json:"weekday,omitempty"`      // 0 = Sunday ... 6 = Saturday (weekly)
+	DayOfMonth *int       `json:"day_of_month,omitempty"` // 1-28 (monthly)
+	AssignTo   string     `json:"assign_to"`              // all, selected
+	InternIDs  []int64    `json:"intern_ids"`
+	StartsOn   time.Time  `json:"starts_on"`
+	EndsOn     *time.Time `json:"ends_on,omitempty"`
+	NextRunOn  time.Time  `json:"next_run_on"`
+	LastRunOn  *time.Time `json:"last_run_on,omitempty"`
+	IsActive   bool       `json:"is_active"`
+	CreatedBy  int64      `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	TemplateTitle string `json:"template_title,omitempty"`
+}
@@ -42,13 +42,3 @@ type Supervisor struct {
 	UpdatedAt    time.Time      `json:"updated_at"`
 	Avatar       sql.NullString `json:"avatar"`
 }
-
-type Institution struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Address   string    `json:"address"`
-	Phone     string    `json:"phone"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
@@ -8,6 +8,7 @@ import (
 
 	"dsi_interna_sys/internal/handlers"
 	"dsi_interna_sys/internal/middleware"
+	"dsi_interna_sys/internal/models"
 
 	"github.com/gorilla/mux"
 )
@@ -29,6 +30,23 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	passwordResetHandler := handlers.NewPasswordResetHandler(db)
 	dashboardHandler := handlers.NewDashboardHandler(db)
 	agendaHandler := handlers.NewAgendaHandler(db)
+	announcementHandler := handlers.NewAnnouncementHandler(db)
+	commentHandler := handlers.NewCommentHandler(db)
+	taskTemplateHandler := handlers.NewTaskTemplateHandler(db)
+	certificateHandler := handlers.NewCertificateHandler(db)
+	documentTemplateHandler := handlers.NewDocumentTemplateHandler(db)
+	documentJobHandler := handlers.NewDocumentJobHandler(db)
+	jobHandler := handlers.NewJobHandler(db)
+	logbookHandler := handlers.NewLogbookHandler(db)
+	rubricHandler := handlers.NewRubricHandler(db)
+	evaluationHandler := handlers.NewEvaluationHandler(db)
+	gradeHandler := handlers.NewGradeHandler(db)
+	reviewSLAHandler := handlers.NewReviewSLAHandler(db)
+	delegationHandler := handlers.NewDelegationHandler(db)
+	supervisionHandler := handlers.NewSupervisionHandler(db)
+	campusHandler := handlers.NewCampusHandler(db)
+	institutionHandler := handlers.NewInstitutionHandler(db)
+	applicationHandler := handlers.NewApplicationHandler(db)
 
 	api := router.PathPrefix("/api").Subrouter()
 
@@ -47,10 +65,22 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	api.HandleFunc("/supervisor/register", supervisorHandler.Register).Methods("POST")
 	api.HandleFunc("/supervisors", supervisorHandler.GetAllPublic).Methods("GET")
 	api.HandleFunc("/admins", supervisorHandler.GetAdminsPublic).Methods("GET")
+	api.HandleFunc("/applications", applicationHandler.Apply).Methods("POST")
+
+	// Certificate verification (linked from the QR code on certificates)
+	api.HandleFunc("/verify-report/{id}", reportHandler.Verify).Methods("GET")
+	api.HandleFunc("/verify/{number:.+}", certificateHandler.Verify).Methods("GET")
 
 	// Protected
 	protected := api.PathPrefix("").Subrouter()
 	protected.Use(middleware.AuthMiddleware)
+	// Campus supervisors only reach their own section and account routes
+	protected.Use(middleware.ConfineRole(models.RoleCampusSupervisor,
+		"/api/campus", "/api/auth", "/api/profile", "/api/notifications", "/api/holidays"))
+	// Applicants only follow their own application until they are accepted
+	protected.Use(middleware.ConfineRole(models.RoleApplicant,
+		"/api/applications/me", "/api/interview-slots", "/api/application-stages", "/api/application-document-types",
+		"/api/auth", "/api/profile", "/api/notifications", "/api/agendas", "/api/holidays"))
 
 	// Holidays
 	protected.HandleFunc("/holidays", handlers.GetHolidays).Methods("GET")
@@ -58,6 +88,75 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	admin := protected.PathPrefix("").Subrouter()
 	admin.Use(middleware.RequireRole("admin"))
 	admin.HandleFunc("/auth/register", authHandler.Register).Methods("POST")
+	admin.HandleFunc("/certificates", certificateHandler.CreateDraft).Methods("POST")
+	admin.HandleFunc("/certificates/{id}/issue", certificateHandler.Issue).Methods("POST")
+	admin.HandleFunc("/certificates/{id}/reissue", certificateHandler.Reissue).Methods("POST")
+	admin.HandleFunc("/certificates/{id}/revoke", certificateHandler.Revoke).Methods("POST")
+	admin.HandleFunc("/reports/{id}/countersign", reportHandler.Countersign).Methods("POST")
+
+	// Assessment rubrics
+	admin.HandleFunc("/rubrics", rubricHandler.Create).Methods("POST")
+	admin.HandleFunc("/rubrics/{id}", rubricHandler.Update).Methods("PUT")
+	admin.HandleFunc("/rubrics/{id}", rubricHandler.Delete).Methods("DELETE")
+
+	// Evaluation cycles
+	admin.HandleFunc("/evaluation-cycles", evaluationHandler.CreateCycle).Methods("POST")
+	admin.HandleFunc("/evaluation-cycles/{id}/close", evaluationHandler.CloseCycle).Methods("POST")
+
+	// Grading policy
+	admin.HandleFunc("/grading-policy", gradeHandler.UpdatePolicy).Methods("PUT")
+
+	// Review SLA: reviewer reassignment
+	admin.HandleFunc("/tasks/{id}/reviewer", reviewSLAHandler.ReassignTask).Methods("PUT")
+	admin.HandleFunc("/review-sla/reassign", reviewSLAHandler.ReassignAll).Methods("POST")
+
+	// Co-supervisors
+	admin.HandleFunc("/interns/{id}/supervisors", supervisionHandler.SetSupervisors).Methods("PUT")
+
+	// Institutions: partnerships and school name clean-up
+	admin.HandleFunc("/institutions/school-names", institutionHandler.GetSchoolNames).Methods("GET")
+	admin.HandleFunc("/institutions/school-names/assign", institutionHandler.AssignSchool).Methods("POST")
+	admin.HandleFunc("/institutions/duplicates", institutionHandler.GetDuplicates).Methods("GET")
+	admin.HandleFunc("/institutions", institutionHandler.Create).Methods("POST")
+	admin.HandleFunc("/institutions/{id}", institutionHandler.Update).Methods("PUT")
+	admin.HandleFunc("/institutions/{id}", institutionHandler.Delete).Methods("DELETE")
+	admin.HandleFunc("/institutions/{id}/merge", institutionHandler.Merge).Methods("POST")
+	admin.HandleFunc("/institutions/{id}/agreements", institutionHandler.CreateAgreement).Methods("POST")
+	admin.HandleFunc("/institutions/{id}/agreements/{agreementId}", institutionHandler.UpdateAgreement).Methods("PUT")
+	admin.HandleFunc("/institutions/{id}/agreements/{agreementId}", institutionHandler.DeleteAgreement).Methods("DELETE")
+	admin.HandleFunc("/institutions/{id}/contacts", institutionHandler.CreateContact).Methods("POST")
+	admin.HandleFunc("/institutions/{id}/contacts/{contactId}", institutionHandler.UpdateContact).Methods("PUT")
+	admin.HandleFunc("/institutions/{id}/contacts/{contactId}", institutionHandler.DeleteContact).Methods("DELETE")
+
+	// Recruitment pipeline: stages, required documents and decisions
+	admin.HandleFunc("/application-stages", applicationHandler.CreateStage).Methods("POST")
+	admin.HandleFunc("/application-stages/{id}", applicationHandler.UpdateStage).Methods("PUT")
+	admin.HandleFunc("/application-stages/{id}", applicationHandler.DeleteStage).Methods("DELETE")
+	admin.HandleFunc("/application-document-types", applicationHandler.CreateDocumentType).Methods("POST")
+	admin.HandleFunc("/application-document-types/{id}", applicationHandler.UpdateDocumentType).Methods("PUT")
+	admin.HandleFunc("/application-document-types/{id}", applicationHandler.DeleteDocumentType).Methods("DELETE")
+	admin.HandleFunc("/applications/{id:[0-9]+}", applicationHandler.Update).Methods("PUT")
+	admin.HandleFunc("/applications/{id:[0-9]+}/stage", applicationHandler.Move).Methods("POST")
+
+	// Document templates (certificates and official letters)
+	admin.HandleFunc("/document-templates", documentTemplateHandler.GetAll).Methods("GET")
+	admin.HandleFunc("/document-templates", documentTemplateHandler.Create).Methods("POST")
+	admin.HandleFunc("/document-templates/built-in/{type}", documentTemplateHandler.GetBuiltIn).Methods("GET")
+	admin.HandleFunc("/document-templates/{id}", documentTemplateHandler.GetByID).Methods("GET")
+	admin.HandleFunc("/document-templates/{id}", documentTemplateHandler.Update).Methods("PUT")
+	admin.HandleFunc("/document-templates/{id}", documentTemplateHandler.Delete).Methods("DELETE")
+	admin.HandleFunc("/document-templates/{id}/assets/{asset}", documentTemplateHandler.UploadAsset).Methods("POST")
+	admin.HandleFunc("/document-templates/{id}/preview", documentTemplateHandler.Preview).Methods("GET")
+
+	// Background job queue
+	admin.HandleFunc("/admin/jobs", jobHandler.GetAll).Methods("GET")
+	admin.HandleFunc("/admin/jobs/stats", jobHandler.GetStats).Methods("GET")
+	admin.HandleFunc("/admin/jobs/schedules", jobHandler.GetSchedules).Methods("GET")
+	admin.HandleFunc("/admin/jobs/retry-failed", jobHandler.RetryFailed).Methods("POST")
+	admin.HandleFunc("/admin/jobs/{id}", jobHandler.GetByID).Methods("GET")
+	admin.HandleFunc("/admin/jobs/{id}/retry", jobHandler.Retry).Methods("POST")
+	admin.HandleFunc("/admin/jobs/{id}/cancel", jobHandler.Cancel).Methods("POST")
+
 	// Admin supervisor aliases (avoid clash with public supervisors)
 	admin.HandleFunc("/admin/supervisors", supervisorHandler.GetAll).Methods("GET")
 	admin.HandleFunc("/admin/supervisors", supervisorHandler.Create).Methods("POST")
@@ -85,6 +184,9 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	protected.HandleFunc("/interns/{id}", internHandler.GetByID).Methods("GET")
 	protected.HandleFunc("/interns/{id}", internHandler.Update).Methods("PUT")
 	protected.HandleFunc("/interns/{id}", internHandler.Delete).Methods("DELETE")
+	protected.HandleFunc("/interns/{id}/grade", gradeHandler.GetBreakdown).Methods("GET")
+	protected.HandleFunc("/interns/{id}/supervisors", supervisionHandler.GetSupervisors).Methods("GET")
+	protected.HandleFunc("/grading-policy", gradeHandler.GetPolicy).Methods("GET")
 
 	// Tasks
 	protected.HandleFunc("/tasks", taskHandler.GetAll).Methods("GET")
@@ -96,10 +198,33 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	protected.HandleFunc("/tasks/{id}/status", taskHandler.UpdateStatus).Methods("POST")
 	protected.HandleFunc("/tasks/{id}/submit", taskHandler.Submit).Methods("POST")
 	protected.HandleFunc("/tasks/{id}/review", taskHandler.Review).Methods("POST")
+	protected.HandleFunc("/tasks/{id}/comments", commentHandler.List(models.CommentEntityTask)).Methods("GET")
+	protected.HandleFunc("/tasks/{id}/comments", commentHandler.Create(models.CommentEntityTask)).Methods("POST")
+	protected.HandleFunc("/tasks/{id}/submissions", taskHandler.GetSubmissions).Methods("GET")
+	protected.HandleFunc("/tasks/{id}/submissions/diff", taskHandler.DiffSubmissions).Methods("GET")
+	protected.HandleFunc("/tasks/{id}/submissions/{version}", taskHandler.GetSubmission).Methods("GET")
+	protected.HandleFunc("/tasks/{id}/checklist", taskHandler.AddChecklistItem).Methods("POST")
+	protected.HandleFunc("/tasks/{id}/checklist/{itemId}", taskHandler.UpdateChecklistItem).Methods("PUT")
+	protected.HandleFunc("/tasks/{id}/checklist/{itemId}", taskHandler.DeleteChecklistItem).Methods("DELETE")
+	protected.HandleFunc("/tasks/{id}/subtasks", taskHandler.CreateSubtask).Methods("POST")
+	protected.HandleFunc("/tasks/{id}/dependencies", taskHandler.AddDependency).Methods("POST")
+	protected.HandleFunc("/tasks/{id}/dependencies/{dependsOnId}", taskHandler.RemoveDependency).Methods("DELETE")
 	protected.HandleFunc("/tasks/{id}", taskHandler.GetByID).Methods("GET")
 	protected.HandleFunc("/tasks/{id}", taskHandler.Update).Methods("PUT")
 	protected.HandleFunc("/tasks/{id}", taskHandler.Delete).Methods("DELETE")
 
+	// Task templates & recurring tasks
+	protected.HandleFunc("/task-templates", taskTemplateHandler.GetAll).Methods("GET")
+	protected.HandleFunc("/task-templates", taskTemplateHandler.Create).Methods("POST")
+	protected.HandleFunc("/task-templates/recurrences", taskTemplateHandler.GetRecurrences).Methods("GET")
+	protected.HandleFunc("/task-templates/recurrences", taskTemplateHandler.CreateRecurrence).Methods("POST")
+	protected.HandleFunc("/task-templates/recurrences/{id}", taskTemplateHandler.UpdateRecurrence).Methods("PUT")
+	protected.HandleFunc("/task-templates/recurrences/{id}", taskTemplateHandler.DeleteRecurrence).Methods("DELETE")
+	protected.HandleFunc("/task-templates/{id}/instantiate", taskTemplateHandler.Instantiate).Methods("POST")
+	protected.HandleFunc("/task-templates/{id}", taskTemplateHandler.GetByID).Methods("GET")
+	protected.HandleFunc("/task-templates/{id}", taskTemplateHandler.Update).Methods("PUT")
+	protected.HandleFunc("/task-templates/{id}", taskTemplateHandler.Delete).Methods("DELETE")
+
 	// Task Assignments (grouped)
 	// protected.HandleFunc("/task-assignments", taskHandler.GetAssignments).Methods("GET")
 	// protected.HandleFunc("/task-assignments/{id}", taskHandler.GetAssignmentByID).Methods("GET")
@@ -121,6 +246,8 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	protected.HandleFunc("/leaves/{id}/approve", leaveHandler.Approve).Methods("POST")
 	protected.HandleFunc("/leaves/{id}/reject", leaveHandler.Reject).Methods("POST")
 	protected.HandleFunc("/leaves/{id}/attachment", leaveHandler.UploadAttachment).Methods("POST")
+	protected.HandleFunc("/leaves/{id}/comments", commentHandler.List(models.CommentEntityLeave)).Methods("GET")
+	protected.HandleFunc("/leaves/{id}/comments", commentHandler.Create(models.CommentEntityLeave)).Methods("POST")
 	protected.HandleFunc("/leaves/{id}", leaveHandler.GetByID).Methods("GET")
 	protected.HandleFunc("/leaves/{id}", leaveHandler.Update).Methods("PUT")
 
@@ -131,14 +258,44 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	protected.HandleFunc("/assessments/{id}", assessmentHandler.GetByID).Methods("GET")
 	protected.HandleFunc("/assessments/{id}", assessmentHandler.Update).Methods("PUT")
 	protected.HandleFunc("/assessments/{id}", assessmentHandler.Delete).Methods("DELETE")
+	protected.HandleFunc("/rubrics", rubricHandler.GetAll).Methods("GET")
+	protected.HandleFunc("/rubrics/resolve", rubricHandler.Resolve).Methods("GET")
+	protected.HandleFunc("/rubrics/{id}", rubricHandler.GetByID).Methods("GET")
+	protected.HandleFunc("/rubrics/{id}/versions", rubricHandler.GetVersions).Methods("GET")
+
+	// Evaluations
+	protected.HandleFunc("/evaluation-cycles", evaluationHandler.GetCycles).Methods("GET")
+	protected.HandleFunc("/evaluation-cycles/{id}", evaluationHandler.GetCycle).Methods("GET")
+	protected.HandleFunc("/evaluations", evaluationHandler.GetAll).Methods("GET")
+	protected.HandleFunc("/evaluations/{id}", evaluationHandler.GetByID).Methods("GET")
+	protected.HandleFunc("/evaluations/{id}/self", evaluationHandler.SubmitSelf).Methods("POST")
+	protected.HandleFunc("/evaluations/{id}/supervisor", evaluationHandler.SubmitSupervisor).Methods("POST")
+	protected.HandleFunc("/evaluations/{id}/sign-off", evaluationHandler.SignOff).Methods("POST")
+
+	// Daily logbook
+	protected.HandleFunc("/logbook", logbookHandler.GetAll).Methods("GET")
+	protected.HandleFunc("/logbook", logbookHandler.Save).Methods("POST")
+	protected.HandleFunc("/logbook/pdf", logbookHandler.DownloadPDF).Methods("GET")
+	protected.HandleFunc("/logbook/acknowledge", logbookHandler.Acknowledge).Methods("POST")
+	protected.HandleFunc("/logbook/{id}", logbookHandler.GetByID).Methods("GET")
+	protected.HandleFunc("/logbook/{id}", logbookHandler.Update).Methods("PUT")
+	protected.HandleFunc("/logbook/{id}", logbookHandler.Delete).Methods("DELETE")
 
 	// Reports
 	protected.HandleFunc("/reports", reportHandler.GetAll).Methods("GET")
 	protected.HandleFunc("/reports", reportHandler.Create).Methods("POST")
+	protected.HandleFunc("/reports/generate", reportHandler.Generate).Methods("POST")
 	protected.HandleFunc("/reports/{id}", reportHandler.GetByID).Methods("GET")
 	protected.HandleFunc("/reports/{id}", reportHandler.Update).Methods("PUT")
 	protected.HandleFunc("/reports/{id}", reportHandler.Delete).Methods("DELETE")
 	protected.HandleFunc("/reports/{id}/feedback", reportHandler.AddFeedback).Methods("POST")
+	protected.HandleFunc("/reports/{id}/submit", reportHandler.Submit).Methods("POST")
+	protected.HandleFunc("/reports/{id}/request-revision", reportHandler.RequestRevision).Methods("POST")
+	protected.HandleFunc("/reports/{id}/approve", reportHandler.Approve).Methods("POST")
+	protected.HandleFunc("/reports/{id}/history", reportHandler.GetHistory).Methods("GET")
+	protected.HandleFunc("/reports/{id}/pdf", reportHandler.DownloadSigned).Methods("GET")
+	protected.HandleFunc("/reports/{id}/comments", commentHandler.List(models.CommentEntityReport)).Methods("GET")
+	protected.HandleFunc("/reports/{id}/comments", commentHandler.Create(models.CommentEntityReport)).Methods("POST")
 	protected.HandleFunc("/reports/intern/{id}", reportHandler.GetInternReport).Methods("GET")
 	protected.HandleFunc("/reports/attendance/{id}", reportHandler.GetAttendanceReport).Methods("GET")
 	protected.HandleFunc("/reports/assessments/{id}", reportHandler.GetAssessmentReport).Methods("GET")
@@ -146,11 +303,27 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	protected.HandleFunc("/reports/certificate/{id}/generate", reportHandler.GenerateCertificate).Methods("POST")
 
 	// Analytics
+	// Campus supervisors: their institution's interns
+	campus := protected.PathPrefix("/campus").Subrouter()
+	campus.Use(middleware.RequireRole(models.RoleCampusSupervisor, "admin"))
+	campus.HandleFunc("/interns", campusHandler.GetInterns).Methods("GET")
+	campus.HandleFunc("/interns/{id}", campusHandler.GetIntern).Methods("GET")
+	campus.HandleFunc("/interns/{id}/attendance", campusHandler.GetAttendance).Methods("GET")
+	campus.HandleFunc("/interns/{id}/assessments", campusHandler.GetAssessments).Methods("GET")
+	campus.HandleFunc("/interns/{id}/assessments", campusHandler.CreateAssessment).Methods("POST")
+	campus.HandleFunc("/reports", campusHandler.GetReports).Methods("GET")
+	campus.HandleFunc("/certificates", campusHandler.GetCertificates).Methods("GET")
+
 	analytics := protected.PathPrefix("/analytics").Subrouter()
 	analytics.Use(middleware.RequireRole("admin", "pembimbing", "supervisor", "intern"))
 	analytics.HandleFunc("/trends/weekly/{id:[0-9]+}", analyticsHandler.GetWeeklyTrends).Methods("GET")
+	analytics.HandleFunc("/trends/monthly/{id:[0-9]+}", analyticsHandler.GetMonthlyTrends).Methods("GET")
+	analytics.HandleFunc("/trends/range/{id:[0-9]+}", analyticsHandler.GetRangeTrends).Methods("GET")
 	analytics.HandleFunc("/patterns/checkin/{id:[0-9]+}", analyticsHandler.GetCheckInPatterns).Methods("GET")
 	analytics.HandleFunc("/insights/{id:[0-9]+}", analyticsHandler.GetPerformanceInsights).Methods("GET")
+	analytics.HandleFunc("/aggregate/attendance", analyticsHandler.GetAttendanceByGroup).Methods("GET")
+	analytics.HandleFunc("/aggregate/supervisors", analyticsHandler.GetSupervisorStats).Methods("GET")
+	analytics.HandleFunc("/aggregate/assignments", analyticsHandler.GetAssignmentRevisions).Methods("GET")
 
 	// Dashboard (all authenticated users)
 	dashboard := protected.PathPrefix("/dashboard").Subrouter()
@@ -166,12 +339,37 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	// Office Info (all authenticated users can read)
 	protected.HandleFunc("/office-info", settingHandler.GetOfficeInfo).Methods("GET")
 
+	// Recruitment: applicants follow their application; interview slots are
+	// booked by applicants and staff alike
+	applicant := protected.PathPrefix("/applications/me").Subrouter()
+	applicant.Use(middleware.RequireRole(models.RoleApplicant))
+	applicant.HandleFunc("", applicationHandler.GetMine).Methods("GET")
+	applicant.HandleFunc("/documents/{type}", applicationHandler.UploadDocument).Methods("POST")
+	protected.HandleFunc("/application-stages", applicationHandler.GetStages).Methods("GET")
+	protected.HandleFunc("/application-document-types", applicationHandler.GetDocumentTypes).Methods("GET")
+	protected.HandleFunc("/interview-slots", applicationHandler.GetSlots).Methods("GET")
+	protected.HandleFunc("/interview-slots/{id}/book", applicationHandler.Book).Methods("POST")
+
 	// Agendas
 	protected.HandleFunc("/agendas", agendaHandler.GetAll).Methods("GET")
 	protected.HandleFunc("/agendas", agendaHandler.Create).Methods("POST")
 	protected.HandleFunc("/agendas/{id}", agendaHandler.Update).Methods("PUT")
 	protected.HandleFunc("/agendas/{id}", agendaHandler.Delete).Methods("DELETE")
 
+	// Comments (threads are listed/created under their task, report or leave)
+	protected.HandleFunc("/comments/{id}", commentHandler.Update).Methods("PUT")
+	protected.HandleFunc("/comments/{id}", commentHandler.Delete).Methods("DELETE")
+
+	// Announcements
+	protected.HandleFunc("/announcements", announcementHandler.GetAll).Methods("GET")
+	protected.HandleFunc("/announcements", announcementHandler.Create).Methods("POST")
+	protected.HandleFunc("/announcements/{id}/read", announcementHandler.MarkRead).Methods("POST")
+	protected.HandleFunc("/announcements/{id}/acknowledge", announcementHandler.Acknowledge).Methods("POST")
+	protected.HandleFunc("/announcements/{id}/receipts", announcementHandler.GetReceipts).Methods("GET")
+	protected.HandleFunc("/announcements/{id}", announcementHandler.GetByID).Methods("GET")
+	protected.HandleFunc("/announcements/{id}", announcementHandler.Update).Methods("PUT")
+	protected.HandleFunc("/announcements/{id}", announcementHandler.Delete).Methods("DELETE")
+
 	// Settings
 	protected.HandleFunc("/settings", settingHandler.GetAll).Methods("GET")
 
@@ -203,6 +401,26 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	// Export/Import (admin & pembimbing)
 	manager := protected.PathPrefix("").Subrouter()
 	manager.Use(middleware.RequireRole("admin", "pembimbing"))
+	manager.HandleFunc("/risk-scores", dashboardHandler.GetAtRisk).Methods("GET")
+	manager.HandleFunc("/review-sla/pending", reviewSLAHandler.GetPending).Methods("GET")
+	manager.HandleFunc("/review-sla/stats", reviewSLAHandler.GetStats).Methods("GET")
+	manager.HandleFunc("/tasks/{id}/reviewer-changes", reviewSLAHandler.GetReviewerChanges).Methods("GET")
+
+	manager.HandleFunc("/institutions", institutionHandler.GetAll).Methods("GET")
+	manager.HandleFunc("/institutions/{id}", institutionHandler.GetByID).Methods("GET")
+
+	manager.HandleFunc("/applications", applicationHandler.GetAll).Methods("GET")
+	manager.HandleFunc("/applications/{id:[0-9]+}", applicationHandler.GetByID).Methods("GET")
+	manager.HandleFunc("/interview-slots", applicationHandler.CreateSlots).Methods("POST")
+	manager.HandleFunc("/interview-slots/{id}/cancel", applicationHandler.CancelBooking).Methods("POST")
+	manager.HandleFunc("/interview-slots/{id}", applicationHandler.DeleteSlot).Methods("DELETE")
+
+	// Out-of-office delegations
+	manager.HandleFunc("/delegations", delegationHandler.GetAll).Methods("GET")
+	manager.HandleFunc("/delegations", delegationHandler.Create).Methods("POST")
+	manager.HandleFunc("/delegations/{id}/cancel", delegationHandler.Cancel).Methods("POST")
+	manager.HandleFunc("/delegations/{id}/actions", delegationHandler.GetActions).Methods("GET")
+	manager.HandleFunc("/delegated-actions", delegationHandler.GetAuditTrail).Methods("GET")
 	manager.HandleFunc("/export/interns", exportImportHandler.ExportInterns).Methods("GET")
 	manager.HandleFunc("/export/attendances", exportImportHandler.ExportAttendances).Methods("GET")
 	manager.HandleFunc("/export/tasks", exportImportHandler.ExportTasks).Methods("GET")
@@ -210,6 +428,16 @@ func SetupRoutes(router *mux.Router, db *sql.DB) {
 	manager.HandleFunc("/import/template", exportImportHandler.DownloadTemplate).Methods("GET")
 	manager.HandleFunc("/interns/{id}/download-report", reportHandler.DownloadInternReport).Methods("GET")
 	manager.HandleFunc("/interns/{id}/certificate", reportHandler.DownloadCertificate).Methods("GET")
+	manager.HandleFunc("/interns/{id}/certificate/eligibility", certificateHandler.GetEligibility).Methods("GET")
+	manager.HandleFunc("/interns/{id}/certificates", certificateHandler.GetInternCertificates).Methods("GET")
+	manager.HandleFunc("/interns/{id}/documents/{type}", documentTemplateHandler.Generate).Methods("GET", "POST")
+
+	// Batch certificate / report generation
+	manager.HandleFunc("/document-jobs", documentJobHandler.GetAll).Methods("GET")
+	manager.HandleFunc("/document-jobs", documentJobHandler.Create).Methods("POST")
+	manager.HandleFunc("/document-jobs/{id}", documentJobHandler.GetByID).Methods("GET")
+	manager.HandleFunc("/document-jobs/{id}/cancel", documentJobHandler.Cancel).Methods("POST")
+	manager.HandleFunc("/document-jobs/{id}/download", documentJobHandler.Download).Methods("GET")
 
 	router.PathPrefix("/uploads/").Handler(
 		http.StripPrefix("/uploads/",
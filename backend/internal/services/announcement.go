@@ -0,0 +1,130 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+)
+
+func publishDueAnnouncements(db *sql.DB) {
+	rows, err := db.Query(
+		`SELECT id FROM announcements
+		 WHERE published_at IS NULL AND publish_at <= ?
+		 AND (expires_at IS NULL OR expires_at > ?)`,
+		time.Now(), time.Now(),
+	)
+	if err != nil {
+		log.Printf("Error checking announcements: %v", err)
+		return
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if _, err := PublishAnnouncement(db, id); err != nil {
+			log.Printf("Error publishing announcement %d: %v", id, err)
+		}
+	}
+}
+
+// PublishAnnouncement resolves the audience of an announcement, records a
+// receipt row per recipient and delivers it through the notifications table.
+// It is a no-op for announcements that were already published, so the
+// scheduler and the handler can both call it safely.
+func PublishAnnouncement(db *sql.DB, announcementID int64) (int, error) {
+	var a struct {
+		Title       string
+		TargetType  string
+		TargetValue sql.NullString
+		CreatedBy   int64
+		AuthorRole  string
+		RequiresAck bool
+	}
+	err := db.QueryRow(
+		`SELECT a.title, a.target_type, a.target_value, a.created_by, u.role, a.requires_ack
+		 FROM announcements a
+		 JOIN users u ON a.created_by = u.id
+		 WHERE a.id = ?`, announcementID,
+	).Scan(&a.Title, &a.TargetType, &a.TargetValue, &a.CreatedBy, &a.AuthorRole, &a.RequiresAck)
+	if err != nil {
+		return 0, err
+	}
+
+	// Claim the announcement first so concurrent publishers don't deliver twice
+	now := time.Now()
+	res, err := db.Exec("UPDATE announcements SET published_at = ? WHERE id = ? AND published_at IS NULL", now, announcementID)
+	if err != nil {
+		return 0, err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return 0, nil
+	}
+
+	query := "SELECT i.id, i.user_id FROM interns i WHERE i.status = 'active'"
+	args := []interface{}{}
+	switch a.TargetType {
+	case "supervisor":
+		query += " AND i.supervisor_id = ?"
+		args = append(args, a.TargetValue.String)
+	case "school":
+		query += " AND i.school = ?"
+		args = append(args, a.TargetValue.String)
+	case "department":
+		query += " AND i.department = ?"
+		args = append(args, a.TargetValue.String)
+	}
+	// Pembimbing can only broadcast to their own interns
+	if a.AuthorRole == "pembimbing" || a.AuthorRole == "supervisor" {
+		query += " AND i.supervisor_id = ?"
+		args = append(args, a.CreatedBy)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	type recipient struct {
+		InternID int64
+		UserID   int64
+	}
+	var recipients []recipient
+	for rows.Next() {
+		var rc recipient
+		if err := rows.Scan(&rc.InternID, &rc.UserID); err == nil {
+			recipients = append(recipients, rc)
+		}
+	}
+	rows.Close()
+
+	message := "Ada pengumuman baru untuk Anda."
+	if a.RequiresAck {
+		message = "Ada pengumuman baru yang memerlukan konfirmasi Anda."
+	}
+	link := "/announcements/" + strconv.FormatInt(announcementID, 10)
+
+	delivered := 0
+	for _, rc := range recipients {
+		if _, err := db.Exec(
+			`INSERT IGNORE INTO announcement_recipients (announcement_id, user_id, intern_id, delivered_at)
+			 VALUES (?, ?, ?, ?)`,
+			announcementID, rc.UserID, rc.InternID, now,
+		); err != nil {
+			log.Printf("Error recording announcement recipient %d: %v", rc.UserID, err)
+			continue
+		}
+		if err := SendNotification(db, rc.UserID, "announcement", "Pengumuman: "+a.Title, message, link); err != nil {
+			log.Printf("Error notifying user %d about announcement %d: %v", rc.UserID, announcementID, err)
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
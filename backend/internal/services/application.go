@@ -0,0 +1,1124 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/jobs"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidApplication = errors.New("invalid application")
+
+// InterviewStageCode is the stage in which applicants may book their own
+// interview slot
+const InterviewStageCode = "interview"
+
+var stageCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// applicationEmailPayload is a rendered decision email
+type applicationEmailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func handleApplicationEmail(ctx context.Context, p applicationEmailPayload, job *models.Job) error {
+	return utils.SendMail(p.To, p.Subject, p.Body)
+}
+
+// --- Stages ---
+
+const stageSelect = `SELECT id, code, name, position, outcome, email_subject, email_body, created_at, updated_at
+	                 FROM application_stages`
+
+func scanStage(row interface{ Scan(...interface{}) error }) (models.ApplicationStage, error) {
+	var s models.ApplicationStage
+	var outcome, subject, body sql.NullString
+	if err := row.Scan(&s.ID, &s.Code, &s.Name, &s.Position, &outcome, &subject, &body, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return s, err
+	}
+	s.Outcome = nullStringPtr(outcome)
+	s.EmailSubject = nullStringPtr(subject)
+	s.EmailBody = nullStringPtr(body)
+	return s, nil
+}
+
+// ListApplicationStages returns the pipeline in order
+func ListApplicationStages(db *sql.DB) ([]models.ApplicationStage, error) {
+	rows, err := db.Query(stageSelect + " ORDER BY position, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.ApplicationStage{}
+	for rows.Next() {
+		s, err := scanStage(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// LoadApplicationStage returns one stage
+func LoadApplicationStage(q Querier, id int64) (models.ApplicationStage, error) {
+	return scanStage(q.QueryRow(stageSelect+" WHERE id = ?", id))
+}
+
+// SaveApplicationStage creates a stage when id is 0, otherwise updates it
+func SaveApplicationStage(db *sql.DB, id int64, req models.ApplicationStageRequest) (int64, error) {
+	code := strings.TrimSpace(req.Code)
+	if !stageCodePattern.MatchString(code) {
+		return 0, fmt.Errorf("%w: code must be lowercase letters, digits or underscores", ErrInvalidApplication)
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return 0, fmt.Errorf("%w: name is required", ErrInvalidApplication)
+	}
+	if (strings.TrimSpace(req.EmailSubject) == "") != (strings.TrimSpace(req.EmailBody) == "") {
+		return 0, fmt.Errorf("%w: an email template needs both a subject and a body", ErrInvalidApplication)
+	}
+
+	now := time.Now()
+	if id == 0 {
+		var outcome sql.NullString
+		switch req.Outcome {
+		case "":
+		case models.ApplicationAccepted, models.ApplicationRejected:
+			outcome = sql.NullString{String: req.Outcome, Valid: true}
+		default:
+			return 0, fmt.Errorf("%w: outcome must be accepted or rejected", ErrInvalidApplication)
+		}
+		res, err := db.Exec(
+			`INSERT INTO application_stages (code, name, position, outcome, email_subject, email_body, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			code, strings.TrimSpace(req.Name), req.Position, outcome, nullString(req.EmailSubject), nullString(req.EmailBody), now, now,
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	res, err := db.Exec(
+		`UPDATE application_stages SET code = ?, name = ?, position = ?, email_subject = ?, email_body = ?, updated_at = ?
+		 WHERE id = ?`,
+		code, strings.TrimSpace(req.Name), req.Position, nullString(req.EmailSubject), nullString(req.EmailBody), now, id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+// DeleteApplicationStage removes a stage no application has been through.
+// The last stage of each outcome stays.
+func DeleteApplicationStage(db *sql.DB, id int64) error {
+	s, err := LoadApplicationStage(db, id)
+	if err != nil {
+		return err
+	}
+	if s.Outcome != nil {
+		var same int
+		if err := db.QueryRow("SELECT COUNT(*) FROM application_stages WHERE outcome = ?", *s.Outcome).Scan(&same); err != nil {
+			return err
+		}
+		if same <= 1 {
+			return fmt.Errorf("%w: the pipeline needs a stage that ends %s", ErrInvalidApplication, *s.Outcome)
+		}
+	}
+	var used int
+	if err := db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM applications WHERE stage_id = ?) +
+		        (SELECT COUNT(*) FROM application_stage_history WHERE to_stage_id = ?)`, id, id,
+	).Scan(&used); err != nil {
+		return err
+	}
+	if used > 0 {
+		return fmt.Errorf("%w: applications have already been through this stage", ErrInvalidApplication)
+	}
+	_, err = db.Exec("DELETE FROM application_stages WHERE id = ?", id)
+	return err
+}
+
+// firstStage is where new applications start: the earliest undecided
+// stage
+func firstStage(q Querier) (models.ApplicationStage, error) {
+	s, err := scanStage(q.QueryRow(stageSelect + " WHERE outcome IS NULL ORDER BY position, id LIMIT 1"))
+	if err == sql.ErrNoRows {
+		return s, fmt.Errorf("%w: no application stages are configured", ErrInvalidApplication)
+	}
+	return s, err
+}
+
+// --- Document types ---
+
+// ListApplicationDocumentTypes returns the documents applicants upload
+func ListApplicationDocumentTypes(db *sql.DB) ([]models.ApplicationDocumentType, error) {
+	rows, err := db.Query("SELECT id, code, name, is_required, created_at FROM application_document_types ORDER BY is_required DESC, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.ApplicationDocumentType{}
+	for rows.Next() {
+		var t models.ApplicationDocumentType
+		if err := rows.Scan(&t.ID, &t.Code, &t.Name, &t.IsRequired, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// SaveApplicationDocumentType creates a document type when id is 0,
+// otherwise updates it
+func SaveApplicationDocumentType(db *sql.DB, id int64, req models.ApplicationDocumentTypeRequest) (int64, error) {
+	code := strings.TrimSpace(req.Code)
+	if !stageCodePattern.MatchString(code) {
+		return 0, fmt.Errorf("%w: code must be lowercase letters, digits or underscores", ErrInvalidApplication)
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return 0, fmt.Errorf("%w: name is required", ErrInvalidApplication)
+	}
+	if id == 0 {
+		res, err := db.Exec(
+			"INSERT INTO application_document_types (code, name, is_required, created_at) VALUES (?, ?, ?, ?)",
+			code, strings.TrimSpace(req.Name), req.IsRequired, time.Now(),
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	res, err := db.Exec(
+		"UPDATE application_document_types SET code = ?, name = ?, is_required = ? WHERE id = ?",
+		code, strings.TrimSpace(req.Name), req.IsRequired, id,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+// DeleteApplicationDocumentType removes a document type and the uploads
+// of it, returning their file paths for cleanup
+func DeleteApplicationDocumentType(db *sql.DB, id int64) ([]string, error) {
+	paths := []string{}
+	rows, err := db.Query("SELECT file_path FROM application_documents WHERE document_type_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p string
+		if rows.Scan(&p) == nil {
+			paths = append(paths, p)
+		}
+	}
+	rows.Close()
+	res, err := db.Exec("DELETE FROM application_document_types WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return paths, nil
+}
+
+// MissingApplicationDocuments returns the names of required documents an
+// application has not uploaded
+func MissingApplicationDocuments(q Querier, applicationID int64) ([]string, error) {
+	rows, err := q.Query(
+		`SELECT t.name FROM application_document_types t
+		 LEFT JOIN application_documents d ON d.document_type_id = t.id AND d.application_id = ?
+		 WHERE t.is_required = TRUE AND d.id IS NULL
+		 ORDER BY t.name`, applicationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	missing := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		missing = append(missing, name)
+	}
+	return missing, rows.Err()
+}
+
+// SaveApplicationDocument records an upload, replacing an earlier file of
+// the same type. It returns the replaced file's path, if any.
+func SaveApplicationDocument(db *sql.DB, applicationID int64, typeCode, filePath, originalName string) (string, error) {
+	var typeID int64
+	err := db.QueryRow("SELECT id FROM application_document_types WHERE code = ?", typeCode).Scan(&typeID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: unknown document type %q", ErrInvalidApplication, typeCode)
+	}
+	if err != nil {
+		return "", err
+	}
+	var old sql.NullString
+	_ = db.QueryRow(
+		"SELECT file_path FROM application_documents WHERE application_id = ? AND document_type_id = ?", applicationID, typeID,
+	).Scan(&old)
+	_, err = db.Exec(
+		`INSERT INTO application_documents (application_id, document_type_id, file_path, original_name, uploaded_at)
+		 VALUES (?, ?, ?, ?, ?)
+		 ON DUPLICATE KEY UPDATE file_path = VALUES(file_path), original_name = VALUES(original_name), uploaded_at = VALUES(uploaded_at)`,
+		applicationID, typeID, filePath, originalName, time.Now(),
+	)
+	if err != nil {
+		return "", err
+	}
+	_, _ = db.Exec("UPDATE applications SET updated_at = ? WHERE id = ?", time.Now(), applicationID)
+	return old.String, nil
+}
+
+// --- Applications ---
+
+// CreateApplication registers an applicant account and their application
+// in the first stage. An email already used by staff or interns is
+// refused; a returning applicant must use their password.
+func CreateApplication(db *sql.DB, req models.CreateApplicationRequest) (int64, error) {
+	req.FullName = strings.TrimSpace(req.FullName)
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if req.FullName == "" || req.Email == "" || strings.TrimSpace(req.School) == "" || strings.TrimSpace(req.Department) == "" {
+		return 0, fmt.Errorf("%w: full_name, email, school and department are required", ErrInvalidApplication)
+	}
+	if len(req.Password) < 6 {
+		return 0, fmt.Errorf("%w: password must be at least 6 characters", ErrInvalidApplication)
+	}
+	if req.Password != req.ConfirmPassword {
+		return 0, fmt.Errorf("%w: password confirmation does not match", ErrInvalidApplication)
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidApplication)
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidApplication)
+	}
+	if !end.After(start) {
+		return 0, fmt.Errorf("%w: end_date must be after start_date", ErrInvalidApplication)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stage, err := firstStage(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var userID int64
+	var role string
+	var hash sql.NullString
+	err = tx.QueryRow("SELECT id, role, password_hash FROM users WHERE email = ?", req.Email).Scan(&userID, &role, &hash)
+	switch {
+	case err == sql.ErrNoRows:
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return 0, err
+		}
+		res, err := tx.Exec(
+			"INSERT INTO users (name, email, password_hash, role) VALUES (?, ?, ?, ?)",
+			req.FullName, req.Email, string(hashed), models.RoleApplicant,
+		)
+		if err != nil {
+			return 0, err
+		}
+		userID, _ = res.LastInsertId()
+	case err != nil:
+		return 0, err
+	case role == models.RoleApplicant:
+		if !hash.Valid || bcrypt.CompareHashAndPassword([]byte(hash.String), []byte(req.Password)) != nil {
+			return 0, fmt.Errorf("%w: this email has applied before; use the same password", ErrInvalidApplication)
+		}
+		var open int
+		if err := tx.QueryRow(
+			`SELECT COUNT(*) FROM applications a JOIN application_stages s ON a.stage_id = s.id
+			 WHERE a.user_id = ? AND s.outcome IS NULL`, userID,
+		).Scan(&open); err != nil {
+			return 0, err
+		}
+		if open > 0 {
+			return 0, fmt.Errorf("%w: you already have an application in progress", ErrInvalidApplication)
+		}
+	case role == "new_user":
+		// Accounts pre-created by Google sign-in become applicants
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(
+			"UPDATE users SET role = ?, name = ?, password_hash = COALESCE(password_hash, ?) WHERE id = ?",
+			models.RoleApplicant, req.FullName, string(hashed), userID,
+		); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("%w: this email is already registered", ErrInvalidApplication)
+	}
+
+	var institutionID sql.NullInt64
+	if id, ok, err := MatchInstitution(tx, req.School); err == nil && ok {
+		institutionID = sql.NullInt64{Int64: id, Valid: true}
+	}
+	now := time.Now()
+	res, err := tx.Exec(
+		`INSERT INTO applications (user_id, stage_id, full_name, email, phone, school, department, student_id, institution_id,
+		                           start_date, end_date, motivation, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, stage.ID, req.FullName, req.Email, nullString(req.Phone), strings.TrimSpace(req.School),
+		strings.TrimSpace(req.Department), nullString(req.StudentID), institutionID, req.StartDate, req.EndDate,
+		nullString(req.Motivation), now, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+
+	app := models.Application{ID: id, FullName: req.FullName, Email: req.Email, School: req.School,
+		Department: req.Department, StartDate: start, EndDate: end}
+	if err := recordStageChange(db, tx, app, sql.NullInt64{}, stage, "", 0); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, adminID := range adminUserIDs(db) {
+		notify(db, adminID, "Lamaran Magang Baru",
+			fmt.Sprintf("%s (%s) mengajukan lamaran magang.", req.FullName, req.School), fmt.Sprintf("/applications/%d", id))
+	}
+	return id, nil
+}
+
+// recordStageChange logs a stage change and queues the stage's email.
+// The email goes out with the transaction, so it is never sent for a
+// change that rolled back.
+func recordStageChange(db *sql.DB, tx *sql.Tx, app models.Application, from sql.NullInt64, to models.ApplicationStage, note string, changedBy int64) error {
+	queued := false
+	if to.EmailSubject != nil && to.EmailBody != nil {
+		values := applicationEmailValues(db, tx, app, to, note)
+		subject, _ := FillPlaceholders(*to.EmailSubject, values)
+		body, _ := FillPlaceholders(*to.EmailBody, values)
+		if _, err := jobs.Enqueue(tx, JobApplicationEmail,
+			applicationEmailPayload{To: app.Email, Subject: subject, Body: body}, jobs.MaxAttempts(5)); err != nil {
+			return err
+		}
+		queued = true
+	}
+	var changer sql.NullInt64
+	if changedBy > 0 {
+		changer = sql.NullInt64{Int64: changedBy, Valid: true}
+	}
+	_, err := tx.Exec(
+		`INSERT INTO application_stage_history (application_id, from_stage_id, to_stage_id, note, email_queued, changed_by, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		app.ID, from, to.ID, nullString(note), queued, changer, time.Now(),
+	)
+	return err
+}
+
+func applicationEmailValues(db *sql.DB, q Querier, app models.Application, stage models.ApplicationStage, note string) map[string]string {
+	values := map[string]string{
+		"name":         app.FullName,
+		"email":        app.Email,
+		"school":       app.School,
+		"department":   app.Department,
+		"stage":        stage.Name,
+		"note":         strings.TrimSpace(note),
+		"start_date":   FormatDateID(app.StartDate),
+		"end_date":     FormatDateID(app.EndDate),
+		"today":        FormatDateID(time.Now()),
+		"organization": OrganizationName(db),
+	}
+	var startsAt time.Time
+	var location sql.NullString
+	var interviewer string
+	if err := q.QueryRow(
+		`SELECT s.starts_at, s.location, u.name FROM interview_slots s JOIN users u ON s.interviewer_id = u.id
+		 WHERE s.application_id = ? ORDER BY s.starts_at DESC LIMIT 1`, app.ID,
+	).Scan(&startsAt, &location, &interviewer); err == nil {
+		values["interview_date"] = FormatDateID(startsAt)
+		values["interview_time"] = startsAt.Format("15:04")
+		values["interview_location"] = location.String
+		values["interviewer"] = interviewer
+	}
+	return values
+}
+
+const applicationSelect = `SELECT a.id, a.user_id, a.stage_id, s.code, s.name, s.outcome, a.full_name, a.email, a.phone, a.school,
+	                              a.department, a.student_id, a.institution_id, a.start_date, a.end_date, a.motivation,
+	                              a.supervisor_id, su.name, a.intern_id, a.decided_at, a.created_at, a.updated_at
+	                       FROM applications a
+	                       JOIN application_stages s ON a.stage_id = s.id
+	                       LEFT JOIN users su ON a.supervisor_id = su.id`
+
+func scanApplication(row interface{ Scan(...interface{}) error }) (models.Application, error) {
+	var a models.Application
+	var outcome, phone, studentID, motivation, supervisorName sql.NullString
+	var institutionID, supervisorID, internID sql.NullInt64
+	var decidedAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.UserID, &a.StageID, &a.StageCode, &a.StageName, &outcome, &a.FullName, &a.Email, &phone,
+		&a.School, &a.Department, &studentID, &institutionID, &a.StartDate, &a.EndDate, &motivation,
+		&supervisorID, &supervisorName, &internID, &decidedAt, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return a, err
+	}
+	a.Outcome = nullStringPtr(outcome)
+	a.Phone = nullStringPtr(phone)
+	a.StudentID = nullStringPtr(studentID)
+	a.Motivation = nullStringPtr(motivation)
+	a.SupervisorName = nullStringPtr(supervisorName)
+	a.InstitutionID = nullInt64Ptr(institutionID)
+	a.SupervisorID = nullInt64Ptr(supervisorID)
+	a.InternID = nullInt64Ptr(internID)
+	if decidedAt.Valid {
+		a.DecidedAt = &decidedAt.Time
+	}
+	return a, nil
+}
+
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	n := v.Int64
+	return &n
+}
+
+// ListApplications returns applications, newest first, optionally in one
+// stage or matching a name, email or school search
+func ListApplications(db *sql.DB, stageID int64, search string) ([]models.Application, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if stageID > 0 {
+		where = append(where, "a.stage_id = ?")
+		args = append(args, stageID)
+	}
+	if search = strings.TrimSpace(search); search != "" {
+		where = append(where, "(a.full_name LIKE ? OR a.email LIKE ? OR a.school LIKE ?)")
+		like := "%" + search + "%"
+		args = append(args, like, like, like)
+	}
+	rows, err := db.Query(applicationSelect+" WHERE "+strings.Join(where, " AND ")+" ORDER BY a.created_at DESC, a.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.Application{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for i := range list {
+		missing, err := MissingApplicationDocuments(db, list[i].ID)
+		list[i].DocumentsComplete = err == nil && len(missing) == 0
+	}
+	return list, nil
+}
+
+// LatestApplicationForUser returns an applicant's most recent application
+func LatestApplicationForUser(db *sql.DB, userID int64) (int64, error) {
+	var id int64
+	err := db.QueryRow("SELECT id FROM applications WHERE user_id = ? ORDER BY id DESC LIMIT 1", userID).Scan(&id)
+	return id, err
+}
+
+// LoadApplication returns an application with its documents, stage
+// history and interviews
+func LoadApplication(db *sql.DB, id int64) (models.Application, error) {
+	a, err := scanApplication(db.QueryRow(applicationSelect+" WHERE a.id = ?", id))
+	if err != nil {
+		return a, err
+	}
+	missing, err := MissingApplicationDocuments(db, id)
+	if err != nil {
+		return a, err
+	}
+	a.DocumentsComplete = len(missing) == 0
+
+	a.Documents = []models.ApplicationDocument{}
+	rows, err := db.Query(
+		`SELECT d.id, d.document_type_id, t.code, t.name, d.file_path, d.original_name, d.uploaded_at
+		 FROM application_documents d JOIN application_document_types t ON d.document_type_id = t.id
+		 WHERE d.application_id = ? ORDER BY t.name`, id,
+	)
+	if err != nil {
+		return a, err
+	}
+	for rows.Next() {
+		var d models.ApplicationDocument
+		if err := rows.Scan(&d.ID, &d.DocumentTypeID, &d.TypeCode, &d.TypeName, &d.FilePath, &d.OriginalName, &d.UploadedAt); err != nil {
+			rows.Close()
+			return a, err
+		}
+		a.Documents = append(a.Documents, d)
+	}
+	rows.Close()
+
+	a.History = []models.ApplicationHistory{}
+	rows, err = db.Query(
+		`SELECT h.id, fs.name, ts.name, h.note, h.email_queued, u.name, h.created_at
+		 FROM application_stage_history h
+		 LEFT JOIN application_stages fs ON h.from_stage_id = fs.id
+		 JOIN application_stages ts ON h.to_stage_id = ts.id
+		 LEFT JOIN users u ON h.changed_by = u.id
+		 WHERE h.application_id = ? ORDER BY h.created_at, h.id`, id,
+	)
+	if err != nil {
+		return a, err
+	}
+	for rows.Next() {
+		var h models.ApplicationHistory
+		var from, note, changedBy sql.NullString
+		if err := rows.Scan(&h.ID, &from, &h.ToStageName, &note, &h.EmailQueued, &changedBy, &h.CreatedAt); err != nil {
+			rows.Close()
+			return a, err
+		}
+		h.FromStageName = nullStringPtr(from)
+		h.Note = nullStringPtr(note)
+		h.ChangedBy = nullStringPtr(changedBy)
+		a.History = append(a.History, h)
+	}
+	rows.Close()
+
+	a.Interviews, err = ListInterviewSlots(db, InterviewSlotFilter{ApplicationID: id})
+	return a, err
+}
+
+// UpdateApplication sets the internship period, proposed supervisor and
+// institution of an undecided application
+func UpdateApplication(db *sql.DB, id int64, req models.UpdateApplicationRequest) error {
+	a, err := scanApplication(db.QueryRow(applicationSelect+" WHERE a.id = ?", id))
+	if err != nil {
+		return err
+	}
+	if a.Outcome != nil {
+		return fmt.Errorf("%w: the application has already been decided", ErrInvalidApplication)
+	}
+	start, end := a.StartDate, a.EndDate
+	if req.StartDate != "" {
+		if start, err = time.Parse("2006-01-02", req.StartDate); err != nil {
+			return fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidApplication)
+		}
+	}
+	if req.EndDate != "" {
+		if end, err = time.Parse("2006-01-02", req.EndDate); err != nil {
+			return fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidApplication)
+		}
+	}
+	if !end.After(start) {
+		return fmt.Errorf("%w: end_date must be after start_date", ErrInvalidApplication)
+	}
+	supervisorID := a.SupervisorID
+	if req.SupervisorID != nil {
+		if *req.SupervisorID <= 0 {
+			supervisorID = nil
+		} else if err := checkReviewer(db, *req.SupervisorID); err != nil {
+			return fmt.Errorf("%w: the supervisor must be an admin or pembimbing", ErrInvalidApplication)
+		} else {
+			supervisorID = req.SupervisorID
+		}
+	}
+	institutionID := a.InstitutionID
+	if req.InstitutionID != nil {
+		institutionID = req.InstitutionID
+		if *req.InstitutionID <= 0 {
+			institutionID = nil
+		}
+	}
+	_, err = db.Exec(
+		"UPDATE applications SET start_date = ?, end_date = ?, supervisor_id = ?, institution_id = ?, updated_at = ? WHERE id = ?",
+		start.Format("2006-01-02"), end.Format("2006-01-02"), supervisorID, institutionID, time.Now(), id,
+	)
+	return err
+}
+
+// stageTransitions maps each undecided stage to the stages an application
+// may move to from it: the next undecided stage by position, and from the
+// last one (the offer) the accepting stages. Stages must be ordered by
+// position. Rejecting is allowed from any undecided stage.
+func stageTransitions(stages []models.ApplicationStage) map[int64][]int64 {
+	var open, accepting []int64
+	for _, s := range stages {
+		switch {
+		case s.Outcome == nil:
+			open = append(open, s.ID)
+		case *s.Outcome == models.ApplicationAccepted:
+			accepting = append(accepting, s.ID)
+		}
+	}
+	moves := map[int64][]int64{}
+	for i, id := range open {
+		if i+1 < len(open) {
+			moves[id] = []int64{open[i+1]}
+		} else {
+			moves[id] = accepting
+		}
+	}
+	return moves
+}
+
+// MoveApplication moves an undecided application to another stage.
+// Applications move forward one stage at a time and are only accepted from
+// the offer stage; they can be rejected from any stage. Moving forward
+// requires the required documents; rejecting does not. Entering an
+// accepted stage converts the applicant into an active intern.
+func MoveApplication(db *sql.DB, id int64, req models.MoveApplicationRequest, changedBy int64) (models.ApplicationStage, error) {
+	var to models.ApplicationStage
+	var err error
+	switch {
+	case req.StageID > 0:
+		to, err = LoadApplicationStage(db, req.StageID)
+	case strings.TrimSpace(req.StageCode) != "":
+		to, err = scanStage(db.QueryRow(stageSelect+" WHERE code = ?", strings.TrimSpace(req.StageCode)))
+	default:
+		return to, fmt.Errorf("%w: stage_id or stage_code is required", ErrInvalidApplication)
+	}
+	if err == sql.ErrNoRows {
+		return to, fmt.Errorf("%w: stage not found", ErrInvalidApplication)
+	}
+	if err != nil {
+		return to, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return to, err
+	}
+	defer tx.Rollback()
+
+	app, err := scanApplication(tx.QueryRow(applicationSelect+" WHERE a.id = ? FOR UPDATE", id))
+	if err != nil {
+		return to, err
+	}
+	if app.Outcome != nil {
+		return to, fmt.Errorf("%w: the application has already been decided", ErrInvalidApplication)
+	}
+	if app.StageID == to.ID {
+		return to, fmt.Errorf("%w: the application is already in %s", ErrInvalidApplication, to.Name)
+	}
+	rejecting := to.Outcome != nil && *to.Outcome == models.ApplicationRejected
+	if !rejecting {
+		stages, err := ListApplicationStages(db)
+		if err != nil {
+			return to, err
+		}
+		allowed := false
+		for _, next := range stageTransitions(stages)[app.StageID] {
+			allowed = allowed || next == to.ID
+		}
+		if !allowed {
+			return to, fmt.Errorf("%w: an application in %s can't move to %s", ErrInvalidApplication, app.StageName, to.Name)
+		}
+
+		missing, err := MissingApplicationDocuments(tx, id)
+		if err != nil {
+			return to, err
+		}
+		if len(missing) > 0 {
+			return to, fmt.Errorf("%w: missing required documents: %s", ErrInvalidApplication, strings.Join(missing, ", "))
+		}
+	}
+
+	now := time.Now()
+	var decidedAt sql.NullTime
+	if to.Outcome != nil {
+		decidedAt = sql.NullTime{Time: now, Valid: true}
+	}
+	if _, err := tx.Exec(
+		"UPDATE applications SET stage_id = ?, decided_at = ?, updated_at = ? WHERE id = ?", to.ID, decidedAt, now, id,
+	); err != nil {
+		return to, err
+	}
+	if to.Outcome != nil && *to.Outcome == models.ApplicationAccepted {
+		if err := convertApplicant(tx, app); err != nil {
+			return to, err
+		}
+	}
+	if err := recordStageChange(db, tx, app, sql.NullInt64{Int64: app.StageID, Valid: true}, to, req.Note, changedBy); err != nil {
+		return to, err
+	}
+	if err := tx.Commit(); err != nil {
+		return to, err
+	}
+
+	notify(db, app.UserID, "Status Lamaran Magang",
+		fmt.Sprintf("Lamaran Anda kini berada pada tahap %s.", to.Name), "/my-application")
+	return to, nil
+}
+
+// convertApplicant turns an accepted applicant into an active intern. The
+// school resolves to an institution, whose quota must have room.
+func convertApplicant(tx *sql.Tx, app models.Application) error {
+	var institutionID int64
+	if app.InstitutionID != nil {
+		institutionID = *app.InstitutionID
+	} else {
+		id, err := ResolveInstitution(tx, app.School)
+		if err != nil {
+			return err
+		}
+		institutionID = id
+	}
+	if err := CheckInstitutionQuota(tx, institutionID); err != nil {
+		if errors.Is(err, ErrInvalidInstitution) {
+			return fmt.Errorf("%w: %s", ErrInvalidApplication, strings.TrimPrefix(err.Error(), ErrInvalidInstitution.Error()+": "))
+		}
+		return err
+	}
+
+	var phone, studentID sql.NullString
+	if app.Phone != nil {
+		phone = sql.NullString{String: *app.Phone, Valid: true}
+	}
+	if app.StudentID != nil {
+		studentID = sql.NullString{String: *app.StudentID, Valid: true}
+	}
+	res, err := tx.Exec(
+		`INSERT INTO interns (user_id, institution_id, supervisor_id, full_name, student_id, school, department, phone,
+		                      start_date, end_date, status)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'active')`,
+		app.UserID, institutionID, app.SupervisorID, app.FullName, studentID, app.School, app.Department, phone,
+		app.StartDate.Format("2006-01-02"), app.EndDate.Format("2006-01-02"),
+	)
+	if err != nil {
+		return err
+	}
+	internID, _ := res.LastInsertId()
+	if _, err := tx.Exec("UPDATE users SET role = 'intern' WHERE id = ?", app.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE applications SET intern_id = ?, institution_id = ? WHERE id = ?", internID, institutionID, app.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// --- Interviews ---
+
+// InterviewSlotFilter narrows ListInterviewSlots; zero values match all
+type InterviewSlotFilter struct {
+	InterviewerID int64
+	ApplicationID int64
+	OpenOnly      bool // unbooked and in the future
+	From          time.Time
+}
+
+// ListInterviewSlots returns interview slots in time order
+func ListInterviewSlots(db *sql.DB, f InterviewSlotFilter) ([]models.InterviewSlot, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if f.InterviewerID > 0 {
+		where = append(where, "s.interviewer_id = ?")
+		args = append(args, f.InterviewerID)
+	}
+	if f.ApplicationID > 0 {
+		where = append(where, "s.application_id = ?")
+		args = append(args, f.ApplicationID)
+	}
+	if f.OpenOnly {
+		where = append(where, "s.application_id IS NULL AND s.starts_at > ?")
+		args = append(args, time.Now())
+	}
+	if !f.From.IsZero() {
+		where = append(where, "s.starts_at >= ?")
+		args = append(args, f.From)
+	}
+	rows, err := db.Query(
+		`SELECT s.id, s.interviewer_id, u.name, s.starts_at, s.duration_minutes, s.location, s.application_id, a.full_name, s.booked_at
+		 FROM interview_slots s
+		 JOIN users u ON s.interviewer_id = u.id
+		 LEFT JOIN applications a ON s.application_id = a.id
+		 WHERE `+strings.Join(where, " AND ")+`
+		 ORDER BY s.starts_at, s.id`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.InterviewSlot{}
+	for rows.Next() {
+		var s models.InterviewSlot
+		var location, applicant sql.NullString
+		var applicationID sql.NullInt64
+		var bookedAt sql.NullTime
+		if err := rows.Scan(&s.ID, &s.InterviewerID, &s.InterviewerName, &s.StartsAt, &s.DurationMinutes, &location,
+			&applicationID, &applicant, &bookedAt); err != nil {
+			return nil, err
+		}
+		s.Location = nullStringPtr(location)
+		s.ApplicationID = nullInt64Ptr(applicationID)
+		s.ApplicantName = nullStringPtr(applicant)
+		if bookedAt.Valid {
+			s.BookedAt = &bookedAt.Time
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// CreateInterviewSlots offers interview times for an interviewer. Slots
+// must be in the future and may not overlap the interviewer's others.
+func CreateInterviewSlots(db *sql.DB, interviewerID int64, req models.CreateInterviewSlotsRequest, createdBy int64) ([]int64, error) {
+	if err := checkReviewer(db, interviewerID); err != nil {
+		return nil, fmt.Errorf("%w: the interviewer must be an admin or pembimbing", ErrInvalidApplication)
+	}
+	if len(req.StartsAt) == 0 {
+		return nil, fmt.Errorf("%w: starts_at is required", ErrInvalidApplication)
+	}
+	duration := req.DurationMinutes
+	if duration == 0 {
+		duration = 30
+	}
+	if duration < 10 || duration > 240 {
+		return nil, fmt.Errorf("%w: duration_minutes must be between 10 and 240", ErrInvalidApplication)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := []int64{}
+	now := time.Now()
+	for _, v := range req.StartsAt {
+		start, err := time.ParseInLocation("2006-01-02T15:04", v, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: starts_at must be YYYY-MM-DDTHH:MM", ErrInvalidApplication)
+		}
+		if !start.After(now) {
+			return nil, fmt.Errorf("%w: %s is in the past", ErrInvalidApplication, v)
+		}
+		end := start.Add(time.Duration(duration) * time.Minute)
+		var overlapping int
+		if err := tx.QueryRow(
+			`SELECT COUNT(*) FROM interview_slots
+			 WHERE interviewer_id = ? AND starts_at < ? AND DATE_ADD(starts_at, INTERVAL duration_minutes MINUTE) > ?`,
+			interviewerID, end, start,
+		).Scan(&overlapping); err != nil {
+			return nil, err
+		}
+		if overlapping > 0 {
+			return nil, fmt.Errorf("%w: %s overlaps another interview slot", ErrInvalidApplication, v)
+		}
+		res, err := tx.Exec(
+			`INSERT INTO interview_slots (interviewer_id, starts_at, duration_minutes, location, created_by, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			interviewerID, start, duration, nullString(req.Location), createdBy, now,
+		)
+		if err != nil {
+			return nil, err
+		}
+		id, _ := res.LastInsertId()
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+type interviewSlotRow struct {
+	interviewerID     int64
+	interviewerName   string
+	startsAt          time.Time
+	duration          int
+	location          sql.NullString
+	applicationID     sql.NullInt64
+	interviewerAgenda sql.NullInt64
+	applicantAgenda   sql.NullInt64
+}
+
+func lockInterviewSlot(tx *sql.Tx, slotID int64) (interviewSlotRow, error) {
+	var s interviewSlotRow
+	err := tx.QueryRow(
+		`SELECT s.interviewer_id, u.name, s.starts_at, s.duration_minutes, s.location, s.application_id,
+		        s.interviewer_agenda_id, s.applicant_agenda_id
+		 FROM interview_slots s JOIN users u ON s.interviewer_id = u.id
+		 WHERE s.id = ? FOR UPDATE`, slotID,
+	).Scan(&s.interviewerID, &s.interviewerName, &s.startsAt, &s.duration, &s.location, &s.applicationID,
+		&s.interviewerAgenda, &s.applicantAgenda)
+	return s, err
+}
+
+// BookInterview books an open slot for an undecided application and puts
+// the interview on both the interviewer's and the applicant's agenda
+func BookInterview(db *sql.DB, slotID, applicationID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	slot, err := lockInterviewSlot(tx, slotID)
+	if err != nil {
+		return err
+	}
+	if slot.applicationID.Valid {
+		return fmt.Errorf("%w: this slot is already booked", ErrInvalidApplication)
+	}
+	if !slot.startsAt.After(time.Now()) {
+		return fmt.Errorf("%w: this slot has passed", ErrInvalidApplication)
+	}
+	app, err := scanApplication(tx.QueryRow(applicationSelect+" WHERE a.id = ?", applicationID))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: application not found", ErrInvalidApplication)
+	}
+	if err != nil {
+		return err
+	}
+	if app.Outcome != nil {
+		return fmt.Errorf("%w: the application has already been decided", ErrInvalidApplication)
+	}
+	var upcoming int
+	if err := tx.QueryRow(
+		"SELECT COUNT(*) FROM interview_slots WHERE application_id = ? AND starts_at > ?", applicationID, time.Now(),
+	).Scan(&upcoming); err != nil {
+		return err
+	}
+	if upcoming > 0 {
+		return fmt.Errorf("%w: the application already has an upcoming interview", ErrInvalidApplication)
+	}
+
+	date := slot.startsAt.Format("2006-01-02")
+	clock := slot.startsAt.Format("15:04:05")
+	where := ""
+	if slot.location.Valid {
+		where = " di " + slot.location.String
+	}
+	res, err := tx.Exec(
+		"INSERT INTO agendas (user_id, title, description, date, time) VALUES (?, ?, ?, ?, ?)",
+		slot.interviewerID, "Wawancara magang: "+app.FullName,
+		fmt.Sprintf("Wawancara %d menit dengan %s (%s)%s.", slot.duration, app.FullName, app.School, where), date, clock,
+	)
+	if err != nil {
+		return err
+	}
+	interviewerAgenda, _ := res.LastInsertId()
+	res, err = tx.Exec(
+		"INSERT INTO agendas (user_id, title, description, date, time) VALUES (?, ?, ?, ?, ?)",
+		app.UserID, "Wawancara magang "+OrganizationName(db),
+		fmt.Sprintf("Wawancara %d menit dengan %s%s.", slot.duration, slot.interviewerName, where), date, clock,
+	)
+	if err != nil {
+		return err
+	}
+	applicantAgenda, _ := res.LastInsertId()
+
+	if _, err := tx.Exec(
+		`UPDATE interview_slots SET application_id = ?, interviewer_agenda_id = ?, applicant_agenda_id = ?, booked_at = ?
+		 WHERE id = ?`,
+		applicationID, interviewerAgenda, applicantAgenda, time.Now(), slotID,
+	); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	when := FormatDateID(slot.startsAt) + " " + slot.startsAt.Format("15:04")
+	notify(db, slot.interviewerID, "Jadwal Wawancara",
+		fmt.Sprintf("Wawancara dengan %s dijadwalkan pada %s%s.", app.FullName, when, where), fmt.Sprintf("/applications/%d", app.ID))
+	notify(db, app.UserID, "Jadwal Wawancara",
+		fmt.Sprintf("Wawancara Anda dengan %s dijadwalkan pada %s%s.", slot.interviewerName, when, where), "/my-application")
+	return nil
+}
+
+// CancelInterview releases a booked slot and removes it from both agendas
+func CancelInterview(db *sql.DB, slotID int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	slot, err := lockInterviewSlot(tx, slotID)
+	if err != nil {
+		return err
+	}
+	if !slot.applicationID.Valid {
+		return fmt.Errorf("%w: this slot is not booked", ErrInvalidApplication)
+	}
+	var applicantID int64
+	if err := tx.QueryRow("SELECT user_id FROM applications WHERE id = ?", slot.applicationID.Int64).Scan(&applicantID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`UPDATE interview_slots SET application_id = NULL, interviewer_agenda_id = NULL, applicant_agenda_id = NULL, booked_at = NULL
+		 WHERE id = ?`, slotID,
+	); err != nil {
+		return err
+	}
+	for _, agendaID := range []sql.NullInt64{slot.interviewerAgenda, slot.applicantAgenda} {
+		if agendaID.Valid {
+			if _, err := tx.Exec("DELETE FROM agendas WHERE id = ?", agendaID.Int64); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	when := FormatDateID(slot.startsAt) + " " + slot.startsAt.Format("15:04")
+	notify(db, slot.interviewerID, "Wawancara Dibatalkan", "Wawancara pada "+when+" dibatalkan.", "/applications")
+	notify(db, applicantID, "Wawancara Dibatalkan",
+		"Wawancara Anda pada "+when+" dibatalkan. Silakan pilih jadwal lain.", "/my-application")
+	return nil
+}
+
+// DeleteInterviewSlot removes a slot nobody has booked
+func DeleteInterviewSlot(db *sql.DB, slotID int64) error {
+	res, err := db.Exec("DELETE FROM interview_slots WHERE id = ? AND application_id IS NULL", slotID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM interview_slots WHERE id = ?", slotID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return sql.ErrNoRows
+		}
+		return fmt.Errorf("%w: cancel the booking before deleting the slot", ErrInvalidApplication)
+	}
+	return nil
+}
+
+// InterviewSlotOwner returns the interviewer of a slot
+func InterviewSlotOwner(db *sql.DB, slotID int64) (int64, error) {
+	var id int64
+	err := db.QueryRow("SELECT interviewer_id FROM interview_slots WHERE id = ?", slotID).Scan(&id)
+	return id, err
+}
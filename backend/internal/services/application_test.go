@@ -0,0 +1,24 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"dsi_interna_sys/internal/models"
+)
+
+func TestStageTransitions(t *testing.T) {
+	accepted, rejected := models.ApplicationAccepted, models.ApplicationRejected
+	stages := []models.ApplicationStage{
+		{ID: 1, Code: "applied"},
+		{ID: 2, Code: "screening"},
+		{ID: 3, Code: "interview"},
+		{ID: 4, Code: "offered"},
+		{ID: 5, Code: "accepted", Outcome: &accepted},
+		{ID: 6, Code: "rejected", Outcome: &rejected},
+	}
+	want := map[int64][]int64{1: {2}, 2: {3}, 3: {4}, 4: {5}}
+	if got := stageTransitions(stages); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stageTransitions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"dsi_interna_sys/internal/holiday"
+)
+
+var holidayCache = struct {
+	mu   sync.RWMutex
+	data map[int]map[string]bool
+}{
+	data: map[int]map[string]bool{},
+}
+
+// WorkCalendar is the office schedule from the workdays and
+// manual_off_date settings
+type WorkCalendar struct {
+	Workdays      map[time.Weekday]bool
+	ManualOffDate *time.Time
+}
+
+// DefaultWorkdays is Monday to Saturday
+func DefaultWorkdays() map[time.Weekday]bool {
+	return map[time.Weekday]bool{
+		time.Monday:    true,
+		time.Tuesday:   true,
+		time.Wednesday: true,
+		time.Thursday:  true,
+		time.Friday:    true,
+		time.Saturday:  true,
+	}
+}
+
+// ParseWorkdays reads a comma separated list of day numbers or English or
+// Indonesian day names
+func ParseWorkdays(val string) map[time.Weekday]bool {
+	parsed := map[time.Weekday]bool{}
+	if strings.TrimSpace(val) == "" {
+		return parsed
+	}
+
+	for _, part := range strings.Split(val, ",") {
+		p := strings.TrimSpace(strings.ToLower(part))
+		switch p {
+		case "0", "7", "sun", "sunday", "minggu":
+			parsed[time.Sunday] = true
+		case "1", "mon", "monday", "senin":
+			parsed[time.Monday] = true
+		case "2", "tue", "tuesday", "selasa":
+			parsed[time.Tuesday] = true
+		case "3", "wed", "wednesday", "rabu":
+			parsed[time.Wednesday] = true
+		case "4", "thu", "thursday", "kamis":
+			parsed[time.Thursday] = true
+		case "5", "fri", "friday", "jumat", "jum'at", "jum’at":
+			parsed[time.Friday] = true
+		case "6", "sat", "saturday", "sabtu":
+			parsed[time.Saturday] = true
+		}
+	}
+	return parsed
+}
+
+// LoadWorkCalendar reads the calendar settings, defaulting to Monday to
+// Saturday
+func LoadWorkCalendar(db *sql.DB) WorkCalendar {
+	cal := WorkCalendar{
+		Workdays: DefaultWorkdays(),
+	}
+
+	rows, err := db.Query("SELECT `key`, `value` FROM settings WHERE `key` IN ('workdays', 'manual_off_date')")
+	if err != nil {
+		return cal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			continue
+		}
+		switch k {
+		case "workdays":
+			if parsed := ParseWorkdays(v); len(parsed) > 0 {
+				cal.Workdays = parsed
+			}
+		case "manual_off_date":
+			if strings.TrimSpace(v) != "" {
+				if t, e := time.Parse("2006-01-02", v); e == nil {
+					cal.ManualOffDate = &t
+				}
+			}
+		}
+	}
+
+	return cal
+}
+
+// IsOffDay reports whether t is a weekend, the manual off day or a public
+// holiday
+func (c WorkCalendar) IsOffDay(t time.Time) bool {
+	dateStr := t.Format("2006-01-02")
+
+	if c.ManualOffDate != nil && dateStr == c.ManualOffDate.Format("2006-01-02") {
+		return true
+	}
+
+	if c.Workdays != nil {
+		if !c.Workdays[t.Weekday()] {
+			return true
+		}
+	} else if t.Weekday() == time.Sunday {
+		return true
+	}
+
+	return IsHolidayDate(t)
+}
+
+// IsHolidayDate reports whether t is a national holiday. Holidays are
+// fetched once per year and cached.
+func IsHolidayDate(t time.Time) bool {
+	year := t.Year()
+
+	holidayCache.mu.RLock()
+	if m, ok := holidayCache.data[year]; ok {
+		defer holidayCache.mu.RUnlock()
+		_, exists := m[t.Format("2006-01-02")]
+		return exists
+	}
+	holidayCache.mu.RUnlock()
+
+	hols, _ := holiday.GetHolidays(year)
+	m := make(map[string]bool, len(hols))
+	for _, h := range hols {
+		m[h.Date] = true
+	}
+	holidayCache.mu.Lock()
+	holidayCache.data[year] = m
+	holidayCache.mu.Unlock()
+	return m[t.Format("2006-01-02")]
+}
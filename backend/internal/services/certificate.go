@@ -0,0 +1,186 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/models"
+)
+
+// SignatureFingerprintLen is how many hex characters of the signature are
+// printed on the certificate and carried in the QR code link.
+const SignatureFingerprintLen = 16
+
+// ErrSigningKeyMissing is returned when CERTIFICATE_SIGNING_KEY is not
+// configured. Nothing is signed or verified without it.
+var ErrSigningKeyMissing = errors.New("certificate signing key is not configured")
+
+// CheckSigningKey fails when documents cannot be signed
+func CheckSigningKey() error {
+	if config.Loaded == nil || config.Loaded.App.CertificateSigningKey == "" {
+		return ErrSigningKeyMissing
+	}
+	return nil
+}
+
+// CertificateFields are the values covered by a certificate signature.
+// Changing any of them (e.g. editing the name on a PDF) breaks verification.
+type CertificateFields struct {
+	Number      string
+	InternID    int64
+	HolderName  string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	IssueDate   time.Time
+	FinalScore  sql.NullFloat64
+}
+
+// Payload is the canonical string that gets signed
+func (f CertificateFields) Payload() string {
+	score := ""
+	if f.FinalScore.Valid {
+		score = fmt.Sprintf("%.2f", f.FinalScore.Float64)
+	}
+	return strings.Join([]string{
+		f.Number,
+		fmt.Sprintf("%d", f.InternID),
+		strings.TrimSpace(f.HolderName),
+		f.PeriodStart.Format("2006-01-02"),
+		f.PeriodEnd.Format("2006-01-02"),
+		f.IssueDate.Format("2006-01-02"),
+		score,
+	}, "|")
+}
+
+// SignCertificatePayload returns the hex HMAC-SHA256 of a payload
+func SignCertificatePayload(payload string) (string, error) {
+	if err := CheckSigningKey(); err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(config.Loaded.App.CertificateSigningKey))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// LoadCertificateFields reads the signed fields of a certificate
+func LoadCertificateFields(db Querier, certID int64) (CertificateFields, error) {
+	var f CertificateFields
+	err := db.QueryRow(
+		`SELECT c.certificate_number, c.intern_id, i.full_name, i.start_date, i.end_date, c.issue_date, c.final_score
+		 FROM certificates c
+		 JOIN interns i ON c.intern_id = i.id
+		 WHERE c.id = ?`, certID,
+	).Scan(&f.Number, &f.InternID, &f.HolderName, &f.PeriodStart, &f.PeriodEnd, &f.IssueDate, &f.FinalScore)
+	return f, err
+}
+
+// SignCertificate computes and stores the signature of a certificate. Pass
+// the issuing transaction so a certificate is never issued unsigned.
+func SignCertificate(db Querier, certID int64) (string, error) {
+	fields, err := LoadCertificateFields(db, certID)
+	if err != nil {
+		return "", err
+	}
+	signature, err := SignCertificatePayload(fields.Payload())
+	if err != nil {
+		return "", err
+	}
+	if _, err := db.Exec(
+		"UPDATE certificates SET signature = ?, signed_at = ? WHERE id = ?", signature, time.Now(), certID,
+	); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// CertificateVerifyURL is the public link encoded in the certificate QR code
+func CertificateVerifyURL(number, signature string) string {
+	segments := strings.Split(number, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	link := strings.TrimRight(config.Loaded.App.PublicURL, "/") + "/api/verify/" + strings.Join(segments, "/")
+	if len(signature) >= SignatureFingerprintLen {
+		link += "?sig=" + signature[:SignatureFingerprintLen]
+	}
+	return link
+}
+
+// VerifyCertificate looks a certificate up by number and checks its
+// signature against the current database values. fingerprint is the
+// optional signature prefix carried by the QR code.
+func VerifyCertificate(db *sql.DB, number, fingerprint string) (*models.CertificateVerification, error) {
+	var certID int64
+	var status string
+	var signature, school, revokedReason sql.NullString
+	var revokedAt sql.NullTime
+	err := db.QueryRow(
+		`SELECT c.id, c.status, c.signature, c.revoked_at, c.revoked_reason, i.school
+		 FROM certificates c
+		 JOIN interns i ON c.intern_id = i.id
+		 WHERE c.certificate_number = ?`, number,
+	).Scan(&certID, &status, &signature, &revokedAt, &revokedReason, &school)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := LoadCertificateFields(db, certID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CertificateVerification{
+		CertificateNumber: fields.Number,
+		HolderName:        fields.HolderName,
+		School:            school.String,
+		PeriodStart:       fields.PeriodStart,
+		PeriodEnd:         fields.PeriodEnd,
+		IssueDate:         fields.IssueDate,
+	}
+
+	expected, err := SignCertificatePayload(fields.Payload())
+	if err != nil {
+		return nil, err
+	}
+	result.SignatureValid = signature.Valid && hmac.Equal([]byte(signature.String), []byte(expected))
+	fingerprint = strings.ToLower(strings.TrimSpace(fingerprint))
+	fingerprintOK := fingerprint == "" ||
+		(signature.Valid && len(fingerprint) <= len(signature.String) &&
+			hmac.Equal([]byte(fingerprint), []byte(signature.String[:len(fingerprint)])))
+
+	switch {
+	case status == models.CertificateRevoked:
+		result.Status = "revoked"
+		result.Message = "This certificate has been revoked"
+		if revokedAt.Valid {
+			t := revokedAt.Time
+			result.RevokedAt = &t
+		}
+		if revokedReason.Valid {
+			reason := revokedReason.String
+			result.RevokedReason = &reason
+		}
+	case status == models.CertificateReissued:
+		result.Status = "reissued"
+		result.Message = "This certificate has been replaced by a newer certificate"
+	case !signature.Valid:
+		result.Status = "unsigned"
+		result.Message = "Certificate was issued before signing was introduced; contact us to confirm"
+	case !result.SignatureValid || !fingerprintOK:
+		result.Status = "invalid"
+		result.Message = "Certificate data does not match the issued signature"
+	default:
+		result.Status = "valid"
+		result.Message = "Certificate is valid"
+	}
+
+	return result, nil
+}
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+var (
+	ErrCertificateExists     = errors.New("intern already has a draft or issued certificate")
+	ErrCertificateTransition = errors.New("certificate is not in a state that allows this action")
+	ErrCertificateRevoked    = errors.New("certificate has been revoked")
+	ErrCertificateNotIssued  = errors.New("certificate has not been issued yet")
+)
+
+// NotEligibleError is returned when the issuance rules reject an intern
+type NotEligibleError struct {
+	Eligibility models.CertificateEligibility
+}
+
+func (e *NotEligibleError) Error() string {
+	return "intern is not eligible for a certificate: " + strings.Join(e.Eligibility.Reasons, "; ")
+}
+
+// CertificateRules are the admin-configurable numbering and issuance
+// settings. Numbers look like <Prefix>/<year>/<zero padded sequence>.
+type CertificateRules struct {
+	Prefix            string
+	Padding           int
+	MinAttendanceRate float64 // percent, 0 disables the rule
+	MinFinalScore     float64 // 0 disables the rule
+}
+
+// LoadCertificateRules reads the certificate settings, falling back to the
+// legacy MG-DSI/<year>/<nnnn> scheme without issuance rules.
+func LoadCertificateRules(db *sql.DB) CertificateRules {
+	rules := CertificateRules{Prefix: "MG-DSI", Padding: 4}
+
+	rows, err := db.Query(
+		"SELECT `key`, `value` FROM settings WHERE `key` IN " +
+			"('certificate_number_prefix', 'certificate_number_padding', 'certificate_min_attendance_rate', 'certificate_min_final_score')",
+	)
+	if err != nil {
+		return rules
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "certificate_number_prefix":
+			if value != "" {
+				rules.Prefix = value
+			}
+		case "certificate_number_padding":
+			if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= 10 {
+				rules.Padding = n
+			}
+		case "certificate_min_attendance_rate":
+			if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+				rules.MinAttendanceRate = f
+			}
+		case "certificate_min_final_score":
+			if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+				rules.MinFinalScore = f
+			}
+		}
+	}
+	return rules
+}
+
+// FormatCertificateNumber renders a certificate number from its parts
+func FormatCertificateNumber(prefix string, year, sequence, padding int) string {
+	return fmt.Sprintf("%s/%d/%0*d", strings.TrimRight(prefix, "/"), year, padding, sequence)
+}
+
+// CertificateFinalScore is the score printed on a certificate: the
+// intern's weighted final grade under the current grading policy
+func CertificateFinalScore(db *sql.DB, internID int64) sql.NullFloat64 {
+	grade, err := ComputeGrade(db, internID)
+	if err != nil || grade.FinalScore == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *grade.FinalScore, Valid: true}
+}
+
+// CheckCertificateEligibility applies the issuance rules to an intern
+func CheckCertificateEligibility(db *sql.DB, internID int64) (models.CertificateEligibility, error) {
+	rules := LoadCertificateRules(db)
+	result := models.CertificateEligibility{
+		InternID:          internID,
+		MinAttendanceRate: rules.MinAttendanceRate,
+		MinFinalScore:     rules.MinFinalScore,
+		Reasons:           []string{},
+	}
+
+	if err := db.QueryRow("SELECT status FROM interns WHERE id = ?", internID).Scan(&result.InternStatus); err != nil {
+		return result, err
+	}
+	if result.InternStatus != "completed" {
+		result.Reasons = append(result.Reasons, "Internship has not been completed")
+	}
+
+	var total, present int64
+	_ = db.QueryRow(
+		`SELECT COUNT(*), COALESCE(SUM(CASE WHEN status IN ('present', 'late') THEN 1 ELSE 0 END), 0)
+		 FROM attendances WHERE intern_id = ?`, internID,
+	).Scan(&total, &present)
+	if total > 0 {
+		result.AttendanceRate = float64(present) / float64(total) * 100
+	}
+	if rules.MinAttendanceRate > 0 && result.AttendanceRate < rules.MinAttendanceRate {
+		result.Reasons = append(result.Reasons, fmt.Sprintf(
+			"Attendance rate %.1f%% is below the required %.1f%%", result.AttendanceRate, rules.MinAttendanceRate))
+	}
+
+	score := CertificateFinalScore(db, internID)
+	if score.Valid {
+		s := score.Float64
+		result.FinalScore = &s
+	}
+	if rules.MinFinalScore > 0 {
+		if !score.Valid {
+			result.Reasons = append(result.Reasons, "Intern has no final score yet")
+		} else if score.Float64 < rules.MinFinalScore {
+			result.Reasons = append(result.Reasons, fmt.Sprintf(
+				"Final score %.1f is below the required %.1f", score.Float64, rules.MinFinalScore))
+		}
+	}
+
+	result.Eligible = len(result.Reasons) == 0
+	return result, nil
+}
+
+// CreateCertificateDraft starts a certificate for an intern. Drafts have no
+// number yet; one is allocated when the draft is issued.
+func CreateCertificateDraft(db *sql.DB, internID, actorID int64, remarks string) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the intern so two drafts can't be created concurrently
+	var id int64
+	if err := tx.QueryRow("SELECT id FROM interns WHERE id = ? FOR UPDATE", internID).Scan(&id); err != nil {
+		return 0, err
+	}
+	if active, err := hasActiveCertificate(tx, internID); err != nil {
+		return 0, err
+	} else if active {
+		return 0, ErrCertificateExists
+	}
+
+	res, err := tx.Exec(
+		`INSERT INTO certificates (intern_id, status, issue_date, final_score, remarks, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		internID, models.CertificateDraft, time.Now(), CertificateFinalScore(db, internID),
+		nullString(remarks), nullActor(actorID),
+	)
+	if err != nil {
+		return 0, err
+	}
+	certID, _ := res.LastInsertId()
+	if err := recordCertificateEvent(tx, certID, "draft", "", models.CertificateDraft, "", actorID); err != nil {
+		return 0, err
+	}
+	return certID, tx.Commit()
+}
+
+// IssueCertificate checks the issuance rules, allocates the next number of
+// the yearly sequence and signs a draft certificate.
+func IssueCertificate(db *sql.DB, certID, actorID int64) error {
+	if err := CheckSigningKey(); err != nil {
+		return err
+	}
+	var internID int64
+	var status string
+	if err := db.QueryRow("SELECT intern_id, status FROM certificates WHERE id = ?", certID).Scan(&internID, &status); err != nil {
+		return err
+	}
+	if status != models.CertificateDraft {
+		return ErrCertificateTransition
+	}
+
+	eligibility, err := CheckCertificateEligibility(db, internID)
+	if err != nil {
+		return err
+	}
+	if !eligibility.Eligible {
+		return &NotEligibleError{Eligibility: eligibility}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT status FROM certificates WHERE id = ? FOR UPDATE", certID).Scan(&status); err != nil {
+		return err
+	}
+	if status != models.CertificateDraft {
+		return ErrCertificateTransition
+	}
+	if err := issueInTx(tx, certID, internID, actorID, LoadCertificateRules(db), CertificateFinalScore(db, internID)); err != nil {
+		return err
+	}
+	if _, err := SignCertificate(tx, certID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeCertificate withdraws an issued certificate. The number keeps
+// resolving on the verify endpoint so anyone checking it sees the revocation.
+func RevokeCertificate(db *sql.DB, certID, actorID int64, reason string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var internID int64
+	var status string
+	if err := tx.QueryRow(
+		"SELECT intern_id, status FROM certificates WHERE id = ? FOR UPDATE", certID,
+	).Scan(&internID, &status); err != nil {
+		return err
+	}
+	if status != models.CertificateIssued {
+		return ErrCertificateTransition
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE certificates SET status = ?, revoked_at = ?, revoked_by = ?, revoked_reason = ? WHERE id = ?`,
+		models.CertificateRevoked, time.Now(), nullActor(actorID), reason, certID,
+	); err != nil {
+		return err
+	}
+	if err := recordCertificateEvent(tx, certID, "revoke", status, models.CertificateRevoked, reason, actorID); err != nil {
+		return err
+	}
+	if err := syncInternCertificate(tx, internID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReissueCertificate supersedes an issued certificate with a new one that
+// gets a fresh number and signature, e.g. after correcting the intern's name.
+func ReissueCertificate(db *sql.DB, certID, actorID int64, reason string) (int64, error) {
+	if err := CheckSigningKey(); err != nil {
+		return 0, err
+	}
+	var internID int64
+	var status string
+	var remarks sql.NullString
+	if err := db.QueryRow(
+		"SELECT intern_id, status, remarks FROM certificates WHERE id = ?", certID,
+	).Scan(&internID, &status, &remarks); err != nil {
+		return 0, err
+	}
+	if status != models.CertificateIssued {
+		return 0, ErrCertificateTransition
+	}
+
+	eligibility, err := CheckCertificateEligibility(db, internID)
+	if err != nil {
+		return 0, err
+	}
+	if !eligibility.Eligible {
+		return 0, &NotEligibleError{Eligibility: eligibility}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow("SELECT status FROM certificates WHERE id = ? FOR UPDATE", certID).Scan(&status); err != nil {
+		return 0, err
+	}
+	if status != models.CertificateIssued {
+		return 0, ErrCertificateTransition
+	}
+
+	if _, err := tx.Exec("UPDATE certificates SET status = ? WHERE id = ?", models.CertificateReissued, certID); err != nil {
+		return 0, err
+	}
+	if err := recordCertificateEvent(tx, certID, "reissue", status, models.CertificateReissued, reason, actorID); err != nil {
+		return 0, err
+	}
+
+	score := CertificateFinalScore(db, internID)
+	res, err := tx.Exec(
+		`INSERT INTO certificates (intern_id, status, issue_date, final_score, remarks, reissued_from, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		internID, models.CertificateDraft, time.Now(), score, remarks, certID, nullActor(actorID),
+	)
+	if err != nil {
+		return 0, err
+	}
+	newID, _ := res.LastInsertId()
+	if err := recordCertificateEvent(tx, newID, "draft", "", models.CertificateDraft, reason, actorID); err != nil {
+		return 0, err
+	}
+	if err := issueInTx(tx, newID, internID, actorID, LoadCertificateRules(db), score); err != nil {
+		return 0, err
+	}
+	if _, err := SignCertificate(tx, newID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+// EnsureIssuedCertificate returns the intern's issued certificate, drafting
+// and issuing one first when needed. A revoked certificate is not silently
+// replaced; an admin has to draft a new one explicitly.
+func EnsureIssuedCertificate(db *sql.DB, internID, actorID int64) (int64, error) {
+	var certID int64
+	var status string
+	err := db.QueryRow(
+		`SELECT id, status FROM certificates WHERE intern_id = ? AND status <> 'reissued'
+		 ORDER BY id DESC LIMIT 1`, internID,
+	).Scan(&certID, &status)
+	if err == nil && status == models.CertificateRevoked {
+		return 0, ErrCertificateRevoked
+	}
+	if err == sql.ErrNoRows {
+		certID, err = CreateCertificateDraft(db, internID, actorID, "")
+		if err != nil {
+			return 0, err
+		}
+		status = models.CertificateDraft
+	} else if err != nil {
+		return 0, err
+	}
+
+	if status == models.CertificateDraft {
+		if err := IssueCertificate(db, certID, actorID); err != nil {
+			return 0, err
+		}
+	}
+	return certID, nil
+}
+
+// IssuedCertificateID returns the intern's current issued certificate
+// without drafting or issuing one
+func IssuedCertificateID(db *sql.DB, internID int64) (int64, error) {
+	var certID int64
+	var status string
+	err := db.QueryRow(
+		`SELECT id, status FROM certificates WHERE intern_id = ? AND status <> 'reissued'
+		 ORDER BY id DESC LIMIT 1`, internID,
+	).Scan(&certID, &status)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, ErrCertificateNotIssued
+	case err != nil:
+		return 0, err
+	case status == models.CertificateRevoked:
+		return 0, ErrCertificateRevoked
+	case status != models.CertificateIssued:
+		return 0, ErrCertificateNotIssued
+	}
+	return certID, nil
+}
+
+// issueInTx numbers and issues a draft certificate inside tx
+func issueInTx(tx *sql.Tx, certID, internID, actorID int64, rules CertificateRules, score sql.NullFloat64) error {
+	now := time.Now()
+	year := now.Year()
+
+	if _, err := tx.Exec(
+		"INSERT INTO certificate_sequences (year, last_value) VALUES (?, 0) ON DUPLICATE KEY UPDATE year = year", year,
+	); err != nil {
+		return err
+	}
+	var sequence int
+	if err := tx.QueryRow(
+		"SELECT last_value FROM certificate_sequences WHERE year = ? FOR UPDATE", year,
+	).Scan(&sequence); err != nil {
+		return err
+	}
+
+	// Skip numbers already taken, e.g. by legacy certificates or after the
+	// prefix was changed back to an earlier value
+	var number string
+	for {
+		sequence++
+		number = FormatCertificateNumber(rules.Prefix, year, sequence, rules.Padding)
+		var exists int
+		if err := tx.QueryRow(
+			"SELECT COUNT(*) FROM certificates WHERE certificate_number = ?", number,
+		).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			break
+		}
+	}
+
+	if _, err := tx.Exec(
+		"UPDATE certificate_sequences SET last_value = ? WHERE year = ?", sequence, year,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE certificates
+		 SET certificate_number = ?, sequence_year = ?, sequence_no = ?, status = ?, issue_date = ?,
+		     final_score = ?, issued_at = ?, issued_by = ?
+		 WHERE id = ?`,
+		number, year, sequence, models.CertificateIssued, now, score, now, nullActor(actorID), certID,
+	); err != nil {
+		return err
+	}
+	if err := recordCertificateEvent(tx, certID, "issue", models.CertificateDraft, models.CertificateIssued, "", actorID); err != nil {
+		return err
+	}
+	return syncInternCertificate(tx, internID)
+}
+
+func hasActiveCertificate(tx *sql.Tx, internID int64) (bool, error) {
+	var count int
+	err := tx.QueryRow(
+		"SELECT COUNT(*) FROM certificates WHERE intern_id = ? AND status IN ('draft', 'issued')", internID,
+	).Scan(&count)
+	return count > 0, err
+}
+
+// syncInternCertificate mirrors the current issued certificate onto the
+// intern row, clearing it when there is none.
+func syncInternCertificate(tx *sql.Tx, internID int64) error {
+	var number sql.NullString
+	var issuedAt sql.NullTime
+	err := tx.QueryRow(
+		`SELECT certificate_number, issued_at FROM certificates
+		 WHERE intern_id = ? AND status = 'issued' ORDER BY id DESC LIMIT 1`, internID,
+	).Scan(&number, &issuedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	_, err = tx.Exec(
+		"UPDATE interns SET certificate_number = ?, certificate_issued_at = ? WHERE id = ?", number, issuedAt, internID,
+	)
+	return err
+}
+
+func recordCertificateEvent(tx *sql.Tx, certID int64, action, from, to, reason string, actorID int64) error {
+	_, err := tx.Exec(
+		`INSERT INTO certificate_events (certificate_id, action, from_status, to_status, reason, actor_id)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		certID, action, nullString(from), to, nullString(reason), nullActor(actorID),
+	)
+	return err
+}
+
+func nullActor(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
+
+func nullString(value string) sql.NullString {
+	value = strings.TrimSpace(value)
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+)
+
+func TestFormatCertificateNumber(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		year     int
+		sequence int
+		padding  int
+		want     string
+	}{
+		{"MG-DSI", 2026, 7, 4, "MG-DSI/2026/0007"},
+		{"MG-DSI/", 2026, 7, 4, "MG-DSI/2026/0007"},
+		{"MG-DSI//", 2026, 7, 4, "MG-DSI/2026/0007"},
+		{"CERT", 2027, 1, 3, "CERT/2027/001"},
+		{"CERT", 2026, 12345, 4, "CERT/2026/12345"},
+		{"CERT", 2026, 42, 0, "CERT/2026/42"},
+	}
+	for _, tt := range tests {
+		got := FormatCertificateNumber(tt.prefix, tt.year, tt.sequence, tt.padding)
+		if got != tt.want {
+			t.Errorf("FormatCertificateNumber(%q, %d, %d, %d) = %q, want %q",
+				tt.prefix, tt.year, tt.sequence, tt.padding, got, tt.want)
+		}
+	}
+}
+
+func TestCertificatePayload(t *testing.T) {
+	fields := CertificateFields{
+		Number:      "MG-DSI/2026/0007",
+		InternID:    42,
+		HolderName:  "  Siti Rahmawati ",
+		PeriodStart: time.Date(2026, time.February, 2, 0, 0, 0, 0, time.Local),
+		PeriodEnd:   time.Date(2026, time.July, 31, 0, 0, 0, 0, time.Local),
+		IssueDate:   time.Date(2026, time.August, 3, 15, 4, 5, 0, time.Local),
+		FinalScore:  sql.NullFloat64{Float64: 87.456, Valid: true},
+	}
+	want := "MG-DSI/2026/0007|42|Siti Rahmawati|2026-02-02|2026-07-31|2026-08-03|87.46"
+	if got := fields.Payload(); got != want {
+		t.Fatalf("Payload() = %q, want %q", got, want)
+	}
+
+	fields.FinalScore = sql.NullFloat64{}
+	want = "MG-DSI/2026/0007|42|Siti Rahmawati|2026-02-02|2026-07-31|2026-08-03|"
+	if got := fields.Payload(); got != want {
+		t.Fatalf("Payload() without score = %q, want %q", got, want)
+	}
+}
+
+func TestSignCertificatePayload(t *testing.T) {
+	prev := config.Loaded
+	t.Cleanup(func() { config.Loaded = prev })
+
+	config.Loaded = &config.Config{}
+	if _, err := SignCertificatePayload("payload"); !errors.Is(err, ErrSigningKeyMissing) {
+		t.Fatalf("without a key err = %v, want ErrSigningKeyMissing", err)
+	}
+
+	config.Loaded.App.CertificateSigningKey = "secret"
+	a, err := SignCertificatePayload("MG-DSI/2026/0007|42|Siti Rahmawati|2026-02-02|2026-07-31|2026-08-03|87.46")
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	b, _ := SignCertificatePayload("MG-DSI/2026/0007|42|Siti Rahmawati|2026-02-02|2026-07-31|2026-08-03|87.46")
+	if a != b || len(a) != 64 {
+		t.Fatalf("signature = %q, want a stable hex SHA-256 HMAC", a)
+	}
+	if c, _ := SignCertificatePayload("MG-DSI/2026/0007|42|Siti Rahmawati|2026-02-02|2026-07-31|2026-08-03|97.46"); c == a {
+		t.Fatalf("changing the score did not change the signature")
+	}
+}
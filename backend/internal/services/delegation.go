@@ -0,0 +1,284 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+var ErrInvalidDelegation = errors.New("invalid delegation")
+
+// CreateDelegation records that delegateID covers for supervisorID from
+// start to end inclusive, and tells the delegate
+func CreateDelegation(db *sql.DB, supervisorID int64, req models.CreateDelegationRequest, createdBy int64) (int64, error) {
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidDelegation)
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidDelegation)
+	}
+	if end.Before(start) {
+		return 0, fmt.Errorf("%w: end_date cannot be before start_date", ErrInvalidDelegation)
+	}
+	if req.EndDate < time.Now().Format("2006-01-02") {
+		return 0, fmt.Errorf("%w: the period has already ended", ErrInvalidDelegation)
+	}
+	if req.DelegateID == supervisorID {
+		return 0, fmt.Errorf("%w: a supervisor cannot delegate to themselves", ErrInvalidDelegation)
+	}
+	if err := checkReviewer(db, supervisorID); err != nil {
+		return 0, fmt.Errorf("%w: only admins and pembimbing can delegate", ErrInvalidDelegation)
+	}
+	if err := checkReviewer(db, req.DelegateID); err != nil {
+		return 0, fmt.Errorf("%w: the delegate must be an admin or pembimbing", ErrInvalidDelegation)
+	}
+
+	var overlapping int
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM supervisor_delegations
+		 WHERE supervisor_id = ? AND status = 'active' AND start_date <= ? AND end_date >= ?`,
+		supervisorID, req.EndDate, req.StartDate,
+	).Scan(&overlapping); err != nil {
+		return 0, err
+	}
+	if overlapping > 0 {
+		return 0, fmt.Errorf("%w: an absence already overlaps this period", ErrInvalidDelegation)
+	}
+	// Coverage does not chain, so the delegate must be around themselves
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM supervisor_delegations
+		 WHERE supervisor_id = ? AND status = 'active' AND start_date <= ? AND end_date >= ?`,
+		req.DelegateID, req.EndDate, req.StartDate,
+	).Scan(&overlapping); err != nil {
+		return 0, err
+	}
+	if overlapping > 0 {
+		return 0, fmt.Errorf("%w: the delegate is also away during this period", ErrInvalidDelegation)
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO supervisor_delegations (supervisor_id, delegate_id, start_date, end_date, reason, status, created_by, created_at)
+		 VALUES (?, ?, ?, ?, ?, 'active', ?, ?)`,
+		supervisorID, req.DelegateID, req.StartDate, req.EndDate, nullString(req.Reason), createdBy, time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+
+	var supervisorName string
+	_ = db.QueryRow("SELECT name FROM users WHERE id = ?", supervisorID).Scan(&supervisorName)
+	notify(db, req.DelegateID, "Penugasan Pengganti Pembimbing",
+		fmt.Sprintf("Anda menggantikan %s pada %s - %s. Notifikasi, persetujuan dan review intern beliau akan diteruskan kepada Anda.",
+			supervisorName, FormatDateID(start), FormatDateID(end)), "/delegations")
+	return id, nil
+}
+
+// CancelDelegation ends an active delegation early
+func CancelDelegation(db *sql.DB, id int64) error {
+	res, err := db.Exec(
+		"UPDATE supervisor_delegations SET status = 'cancelled', cancelled_at = ? WHERE id = ? AND status = 'active'",
+		time.Now(), id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const delegationSelect = `SELECT d.id, d.supervisor_id, su.name, d.delegate_id, du.name, d.start_date, d.end_date, d.reason,
+	                             d.status, d.created_by, d.created_at, d.cancelled_at
+	                      FROM supervisor_delegations d
+	                      JOIN users su ON d.supervisor_id = su.id
+	                      JOIN users du ON d.delegate_id = du.id`
+
+// LoadDelegation returns one delegation
+func LoadDelegation(db *sql.DB, id int64) (models.SupervisorDelegation, error) {
+	return scanDelegation(db.QueryRow(delegationSelect+" WHERE d.id = ?", id))
+}
+
+// ListDelegations returns delegations, latest period first. A userID
+// limits them to those where the user is the supervisor or the delegate.
+func ListDelegations(db *sql.DB, userID int64, includeCancelled bool) ([]models.SupervisorDelegation, error) {
+	where := []string{}
+	args := []interface{}{}
+	if userID > 0 {
+		where = append(where, "(d.supervisor_id = ? OR d.delegate_id = ?)")
+		args = append(args, userID, userID)
+	}
+	if !includeCancelled {
+		where = append(where, "d.status = 'active'")
+	}
+	query := delegationSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY d.start_date DESC, d.id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.SupervisorDelegation{}
+	for rows.Next() {
+		d, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func scanDelegation(row interface{ Scan(...interface{}) error }) (models.SupervisorDelegation, error) {
+	var d models.SupervisorDelegation
+	var reason sql.NullString
+	var cancelled sql.NullTime
+	if err := row.Scan(&d.ID, &d.SupervisorID, &d.SupervisorName, &d.DelegateID, &d.DelegateName, &d.StartDate, &d.EndDate,
+		&reason, &d.Status, &d.CreatedBy, &d.CreatedAt, &cancelled); err != nil {
+		return d, err
+	}
+	d.Reason = nullStringPtr(reason)
+	if cancelled.Valid {
+		d.CancelledAt = &cancelled.Time
+	}
+	today := time.Now().Format("2006-01-02")
+	d.InEffect = d.Status == models.DelegationActive &&
+		d.StartDate.Format("2006-01-02") <= today && d.EndDate.Format("2006-01-02") >= today
+	return d, nil
+}
+
+// ActiveDelegate returns the delegation covering supervisorID on the day
+// of at, if any
+func ActiveDelegate(db *sql.DB, supervisorID int64, at time.Time) (delegationID, delegateID int64, ok bool) {
+	day := at.Format("2006-01-02")
+	err := db.QueryRow(
+		`SELECT id, delegate_id FROM supervisor_delegations
+		 WHERE supervisor_id = ? AND status = 'active' AND start_date <= ? AND end_date >= ?
+		 ORDER BY id DESC LIMIT 1`,
+		supervisorID, day, day,
+	).Scan(&delegationID, &delegateID)
+	return delegationID, delegateID, err == nil
+}
+
+// CoveringDelegation returns the delegation under which actorID may act
+// for ownerID on the day of at, if any
+func CoveringDelegation(db *sql.DB, actorID, ownerID int64, at time.Time) (int64, bool) {
+	delegationID, delegateID, ok := ActiveDelegate(db, ownerID, at)
+	if !ok || delegateID != actorID {
+		return 0, false
+	}
+	return delegationID, true
+}
+
+// CoveredSupervisors returns the supervisors delegateID covers for on the
+// day of at
+func CoveredSupervisors(db *sql.DB, delegateID int64, at time.Time) []int64 {
+	day := at.Format("2006-01-02")
+	ids := []int64{}
+	rows, err := db.Query(
+		`SELECT DISTINCT supervisor_id FROM supervisor_delegations
+		 WHERE delegate_id = ? AND status = 'active' AND start_date <= ? AND end_date >= ?`,
+		delegateID, day, day,
+	)
+	if err != nil {
+		log.Printf("Error selecting supervisors covered by %d: %v", delegateID, err)
+		return ids
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// NoteDelegatedAction logs an action actorID took on an entity owned by
+// ownerID's supervision, when the actor did so as ownerID's delegate.
+// Actions by the owner themselves or by admins outside a delegation are
+// not logged.
+func NoteDelegatedAction(db *sql.DB, actorID, ownerID int64, action, entityType string, entityID int64) {
+	if actorID == ownerID {
+		return
+	}
+	delegationID, ok := CoveringDelegation(db, actorID, ownerID, time.Now())
+	if !ok {
+		return
+	}
+	if _, err := db.Exec(
+		`INSERT INTO delegated_actions (delegation_id, actor_id, on_behalf_of, action, entity_type, entity_id, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		delegationID, actorID, ownerID, action, entityType, entityID, time.Now(),
+	); err != nil {
+		log.Printf("Error logging delegated action %s on %s %d: %v", action, entityType, entityID, err)
+	}
+}
+
+// ListDelegatedActions returns the actions taken under a delegation, or
+// every delegation when delegationID is 0, newest first. A userID limits
+// them to actions the user took or that were taken on their behalf.
+func ListDelegatedActions(db *sql.DB, delegationID, userID int64, limit int) ([]models.DelegatedAction, error) {
+	query := `SELECT a.id, a.delegation_id, a.actor_id, au.name, a.on_behalf_of, ou.name, a.action, a.entity_type, a.entity_id, a.created_at
+	          FROM delegated_actions a
+	          JOIN users au ON a.actor_id = au.id
+	          JOIN users ou ON a.on_behalf_of = ou.id
+	          WHERE 1 = 1`
+	args := []interface{}{}
+	if delegationID > 0 {
+		query += " AND a.delegation_id = ?"
+		args = append(args, delegationID)
+	}
+	if userID > 0 {
+		query += " AND (a.actor_id = ? OR a.on_behalf_of = ?)"
+		args = append(args, userID, userID)
+	}
+	query += " ORDER BY a.created_at DESC, a.id DESC LIMIT " + strconv.Itoa(limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.DelegatedAction{}
+	for rows.Next() {
+		var a models.DelegatedAction
+		if err := rows.Scan(&a.ID, &a.DelegationID, &a.ActorID, &a.ActorName, &a.OnBehalfOf, &a.OnBehalfOfName,
+			&a.Action, &a.EntityType, &a.EntityID, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// ForwardNotification copies a notification for an absent user to their
+// delegate, naming whom it was meant for
+func ForwardNotification(db *sql.DB, userID int64, typ, title, message string, link sql.NullString) {
+	_, delegateID, ok := ActiveDelegate(db, userID, time.Now())
+	if !ok {
+		return
+	}
+	var name string
+	_ = db.QueryRow("SELECT name FROM users WHERE id = ?", userID).Scan(&name)
+	if _, err := db.Exec(
+		`INSERT INTO notifications (user_id, type, title, message, link, is_read, created_at)
+		 VALUES (?, ?, ?, ?, ?, FALSE, ?)`,
+		delegateID, typ, title+" (a.n. "+name+")", message, link, time.Now(),
+	); err != nil {
+		log.Printf("Error forwarding notification for user %d to delegate %d: %v", userID, delegateID, err)
+	}
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z0-9_]+)\s*\}\}`)
+
+var indonesianMonths = []string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// FormatDateID formats a date the way it is written on official letters,
+// e.g. "19 Oktober 2026"
+func FormatDateID(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), indonesianMonths[t.Month()-1], t.Year())
+}
+
+// FillPlaceholders substitutes {{key}} placeholders. The second result is
+// false when the text has placeholders and all of them are empty, so
+// optional lines such as "Nilai Akhir: {{final_score}}" can be skipped.
+func FillPlaceholders(text string, values map[string]string) (string, bool) {
+	matches, filled := 0, 0
+	result := placeholderPattern.ReplaceAllStringFunc(text, func(m string) string {
+		key := placeholderPattern.FindStringSubmatch(m)[1]
+		matches++
+		if v := values[key]; v != "" {
+			filled++
+			return v
+		}
+		return ""
+	})
+	return result, matches == 0 || filled > 0
+}
+
+// OrganizationName is the organization_name setting, or the app name
+func OrganizationName(db *sql.DB) string {
+	var name string
+	if err := db.QueryRow("SELECT `value` FROM settings WHERE `key` = 'organization_name'").Scan(&name); err == nil && strings.TrimSpace(name) != "" {
+		return strings.TrimSpace(name)
+	}
+	return config.Loaded.App.Name
+}
+
+// DocumentValues collects the placeholder values for an intern. Certificate
+// fields are only filled when the intern has an issued certificate.
+func DocumentValues(db *sql.DB, internID int64) (map[string]string, error) {
+	var fullName, school, department string
+	var nis, supervisorName, email sql.NullString
+	var startDate, endDate time.Time
+	err := db.QueryRow(
+		`SELECT i.full_name, i.nis, i.school, i.department, su.name, u.email, i.start_date, i.end_date
+		 FROM interns i
+		 JOIN users u ON i.user_id = u.id
+		 LEFT JOIN users su ON i.supervisor_id = su.id
+		 WHERE i.id = ?`, internID,
+	).Scan(&fullName, &nis, &school, &department, &supervisorName, &email, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{
+		"intern_name":     fullName,
+		"nis":             nis.String,
+		"school":          school,
+		"department":      department,
+		"email":           email.String,
+		"supervisor_name": supervisorName.String,
+		"start_date":      FormatDateID(startDate),
+		"end_date":        FormatDateID(endDate),
+		"period":          FormatDateID(startDate) + " - " + FormatDateID(endDate),
+		"today":           FormatDateID(time.Now()),
+		"organization":    OrganizationName(db),
+	}
+
+	var certNumber string
+	var issueDate time.Time
+	var finalScore sql.NullFloat64
+	var signature sql.NullString
+	err = db.QueryRow(
+		`SELECT certificate_number, issue_date, final_score, signature FROM certificates
+		 WHERE intern_id = ? AND status = 'issued' ORDER BY id DESC LIMIT 1`, internID,
+	).Scan(&certNumber, &issueDate, &finalScore, &signature)
+	if err == nil {
+		values["certificate_number"] = certNumber
+		values["issue_date"] = FormatDateID(issueDate)
+		if finalScore.Valid {
+			values["final_score"] = strconv.FormatFloat(finalScore.Float64, 'f', 1, 64)
+			band := LoadGradingPolicy(db).BandFor(finalScore.Float64)
+			values["grade_letter"] = band.Letter
+			values["grade_predicate"] = band.Predicate
+		}
+		values["verify_url"] = CertificateVerifyURL(certNumber, signature.String)
+		if len(signature.String) >= SignatureFingerprintLen {
+			values["signature_fingerprint"] = signature.String[:SignatureFingerprintLen]
+		}
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// ResolveDocumentTemplate picks the template to render: an explicit id, the
+// active default template of the type, or the built-in layout.
+func ResolveDocumentTemplate(db *sql.DB, docType string, templateID int64) (models.DocumentTemplate, error) {
+	if templateID > 0 {
+		tpl, err := LoadDocumentTemplate(db, templateID)
+		if err != nil {
+			return tpl, err
+		}
+		if tpl.DocumentType != docType {
+			return tpl, fmt.Errorf("template %d is a %s template", templateID, tpl.DocumentType)
+		}
+		return tpl, nil
+	}
+
+	var id int64
+	err := db.QueryRow(
+		`SELECT id FROM document_templates WHERE document_type = ? AND is_active = 1
+		 ORDER BY is_default DESC, updated_at DESC LIMIT 1`, docType,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return DefaultDocumentTemplate(docType), nil
+	}
+	if err != nil {
+		return models.DocumentTemplate{}, err
+	}
+	return LoadDocumentTemplate(db, id)
+}
+
+// LoadDocumentTemplate reads one stored template
+func LoadDocumentTemplate(db *sql.DB, id int64) (models.DocumentTemplate, error) {
+	var t models.DocumentTemplate
+	var background, logo, signature, signatoryName, signatoryTitle sql.NullString
+	var layout []byte
+	var createdBy sql.NullInt64
+	err := db.QueryRow(
+		`SELECT id, name, document_type, orientation, page_size, background_path, logo_path, signature_path,
+		        signatory_name, signatory_title, layout, is_default, is_active, created_by, created_at, updated_at
+		 FROM document_templates WHERE id = ?`, id,
+	).Scan(&t.ID, &t.Name, &t.DocumentType, &t.Orientation, &t.PageSize, &background, &logo, &signature,
+		&signatoryName, &signatoryTitle, &layout, &t.IsDefault, &t.IsActive, &createdBy, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return t, err
+	}
+	t.BackgroundPath = nullStringPtr(background)
+	t.LogoPath = nullStringPtr(logo)
+	t.SignaturePath = nullStringPtr(signature)
+	t.SignatoryName = nullStringPtr(signatoryName)
+	t.SignatoryTitle = nullStringPtr(signatoryTitle)
+	if createdBy.Valid {
+		t.CreatedBy = &createdBy.Int64
+	}
+	_ = json.Unmarshal(layout, &t.Layout)
+	if t.Layout.Elements == nil {
+		t.Layout.Elements = []models.DocumentElement{}
+	}
+	return t, nil
+}
+
+// RenderDocument draws a template with the given placeholder values.
+// Elements with y = 0 continue below the previous element.
+func RenderDocument(tpl models.DocumentTemplate, values map[string]string) (*gofpdf.Fpdf, error) {
+	orientation := "P"
+	if tpl.Orientation == "L" {
+		orientation = "L"
+	}
+	pageSize := tpl.PageSize
+	if pageSize == "" {
+		pageSize = "A4"
+	}
+
+	pdf := gofpdf.New(orientation, "mm", pageSize, "")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pageW, pageH := pdf.GetPageSize()
+
+	if path := documentAssetPath(tpl.BackgroundPath); path != "" {
+		pdf.ImageOptions(path, 0, 0, pageW, pageH, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+	}
+
+	// Template values win over computed ones for the signatory
+	if tpl.SignatoryName != nil && *tpl.SignatoryName != "" {
+		values["signatory_name"] = *tpl.SignatoryName
+	}
+	if tpl.SignatoryTitle != nil && *tpl.SignatoryTitle != "" {
+		values["signatory_title"] = *tpl.SignatoryTitle
+	}
+
+	for i, el := range tpl.Layout.Elements {
+		y := el.Y
+		if y == 0 {
+			y = pdf.GetY()
+		}
+
+		switch el.Type {
+		case "text":
+			text, ok := FillPlaceholders(el.Text, values)
+			if !ok {
+				continue
+			}
+			font := el.Font
+			if font == "" {
+				font = "Helvetica"
+			}
+			size := el.Size
+			if size == 0 {
+				size = 12
+			}
+			align := el.Align
+			if align == "" {
+				align = "L"
+			}
+			pdf.SetFont(font, el.Style, size)
+			r, g, b := parseHexColor(el.Color)
+			pdf.SetTextColor(r, g, b)
+			pdf.SetXY(el.X, y)
+			if el.LineHeight > 0 {
+				width := el.W
+				if width == 0 {
+					width = pageW - el.X - 20
+				}
+				pdf.MultiCell(width, el.LineHeight, tr(text), "", align, false)
+			} else {
+				height := el.H
+				if height == 0 {
+					height = size * 0.5
+				}
+				pdf.CellFormat(el.W, height, tr(text), "", 2, align, false, 0, "")
+			}
+
+		case "image":
+			var path string
+			switch el.Source {
+			case "logo":
+				path = documentAssetPath(tpl.LogoPath)
+			case "signature":
+				path = documentAssetPath(tpl.SignaturePath)
+			}
+			if path == "" {
+				continue
+			}
+			pdf.ImageOptions(path, el.X, y, el.W, el.H, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+			pdf.SetY(y + el.H)
+
+		case "qr":
+			link := values["verify_url"]
+			if link == "" {
+				continue
+			}
+			png, err := utils.QRCodePNG(link, 256)
+			if err != nil {
+				return nil, err
+			}
+			size := el.W
+			if size == 0 {
+				size = 30
+			}
+			name := fmt.Sprintf("qr-%d", i)
+			pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+			pdf.ImageOptions(name, el.X, y, size, size, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+			pdf.SetY(y + size)
+
+		case "line":
+			width := el.W
+			if width == 0 {
+				width = pageW - el.X - 20
+			}
+			r, g, b := parseHexColor(el.Color)
+			pdf.SetDrawColor(r, g, b)
+			pdf.Line(el.X, y, el.X+width, y)
+			pdf.SetY(y + 1)
+		}
+	}
+
+	return pdf, pdf.Error()
+}
+
+// RenderCertificate renders an issued certificate with the certificate
+// template. Certificates issued before signing existed are signed first.
+func RenderCertificate(db *sql.DB, certID int64) (*gofpdf.Fpdf, error) {
+	var internID int64
+	var signature sql.NullString
+	if err := db.QueryRow(
+		"SELECT intern_id, signature FROM certificates WHERE id = ?", certID,
+	).Scan(&internID, &signature); err != nil {
+		return nil, err
+	}
+	if !signature.Valid {
+		if _, err := SignCertificate(db, certID); err != nil {
+			return nil, err
+		}
+	}
+
+	tpl, err := ResolveDocumentTemplate(db, models.DocumentCertificate, 0)
+	if err != nil {
+		return nil, err
+	}
+	values, err := DocumentValues(db, internID)
+	if err != nil {
+		return nil, err
+	}
+	return RenderDocument(tpl, values)
+}
+
+// DefaultDocumentTemplate is the built-in layout used until an admin
+// uploads a template for the document type.
+func DefaultDocumentTemplate(docType string) models.DocumentTemplate {
+	tpl := models.DocumentTemplate{
+		Name:         "Bawaan",
+		DocumentType: docType,
+		Orientation:  "P",
+		PageSize:     "A4",
+		IsActive:     true,
+		BuiltIn:      true,
+	}
+
+	if docType == models.DocumentCertificate {
+		tpl.Orientation = "L"
+		tpl.Layout.Elements = []models.DocumentElement{
+			{Type: "text", Text: "Sertifikat Magang", X: 20, Y: 25, Size: 26, Style: "B", H: 20},
+			{Type: "text", Text: "Diberikan kepada:", X: 20, Y: 50, Size: 14, H: 10},
+			{Type: "text", Text: "{{intern_name}}", X: 20, Size: 22, Style: "B", H: 14},
+			{Type: "text", Text: "{{school}}", X: 20, Size: 12, H: 8},
+			{Type: "text", Text: "Periode magang: {{period}}", X: 20, Y: 100, Size: 12, H: 8},
+			{Type: "text", Text: "Nomor Sertifikat: {{certificate_number}}", X: 20, Size: 12, H: 8},
+			{Type: "text", Text: "Tanggal: {{issue_date}}", X: 20, Size: 12, H: 8},
+			{Type: "text", Text: "Nilai Akhir: {{final_score}}", X: 20, Size: 11, H: 8},
+			{Type: "text", Text: "Predikat: {{grade_letter}} - {{grade_predicate}}", X: 20, Size: 11, H: 8},
+			{Type: "text", Text: "{{signatory_title}}", X: 180, Y: 130, W: 60, Size: 11, Align: "C", H: 6},
+			{Type: "image", Source: "signature", X: 195, Y: 137, W: 30, H: 15},
+			{Type: "text", Text: "{{signatory_name}}", X: 180, Y: 155, W: 60, Size: 11, Style: "B", Align: "C", H: 6},
+			{Type: "qr", X: 242, Y: 150, W: 40},
+			{Type: "text", Text: "Verifikasi keaslian: {{verify_url}}", X: 15, Y: 185, Size: 8, H: 4},
+			{Type: "text", Text: "Sidik tanda tangan: {{signature_fingerprint}}", X: 15, Size: 8, H: 4},
+		}
+		return tpl
+	}
+
+	header := []models.DocumentElement{
+		{Type: "image", Source: "logo", X: 20, Y: 15, W: 20, H: 20},
+		{Type: "text", Text: "{{organization}}", X: 45, Y: 20, Size: 14, Style: "B", H: 8},
+		{Type: "line", X: 20, Y: 38},
+	}
+	identity := "Nama: {{intern_name}}\nNIS/NIM: {{nis}}\nAsal Sekolah/Kampus: {{school}}\nJurusan: {{department}}"
+	footer := []models.DocumentElement{
+		{Type: "text", Text: "{{today}}", X: 120, Y: 210, W: 70, Size: 11, H: 6},
+		{Type: "text", Text: "{{signatory_title}}", X: 120, W: 70, Size: 11, H: 6},
+		{Type: "image", Source: "signature", X: 125, Y: 225, W: 35, H: 18},
+		{Type: "text", Text: "{{signatory_name}}", X: 120, Y: 248, W: 70, Size: 11, Style: "BU", H: 6},
+	}
+
+	var title, opening, body string
+	switch docType {
+	case models.DocumentAcceptanceLetter:
+		title = "SURAT PENERIMAAN MAGANG"
+		opening = "Yang bertanda tangan di bawah ini menerangkan bahwa:"
+		body = "Diterima untuk melaksanakan kegiatan magang di {{organization}} pada periode {{period}} " +
+			"dengan pembimbing {{supervisor_name}}.\n\nDemikian surat ini dibuat untuk dipergunakan sebagaimana mestinya."
+	case models.DocumentCompletionLetter:
+		title = "SURAT KETERANGAN SELESAI MAGANG"
+		opening = "Yang bertanda tangan di bawah ini menerangkan bahwa:"
+		body = "Telah menyelesaikan kegiatan magang di {{organization}} pada periode {{period}} dengan baik." +
+			"\n\nDemikian surat keterangan ini dibuat untuk dipergunakan sebagaimana mestinya."
+	case models.DocumentRecommendationLetter:
+		title = "SURAT REKOMENDASI"
+		opening = "Saya, {{supervisor_name}}, selaku pembimbing magang di {{organization}}, merekomendasikan:"
+		body = "Yang telah melaksanakan magang pada periode {{period}}. Selama masa magang yang bersangkutan " +
+			"menunjukkan kinerja dan sikap yang baik sehingga layak untuk direkomendasikan."
+	}
+
+	tpl.Layout.Elements = append(header,
+		models.DocumentElement{Type: "text", Text: title, X: 20, Y: 50, Size: 14, Style: "BU", Align: "C", H: 8},
+		models.DocumentElement{Type: "text", Text: "Nomor: {{letter_number}}", X: 20, Size: 11, Align: "C", H: 6},
+		models.DocumentElement{Type: "text", Text: opening, X: 20, Y: 75, Size: 11, LineHeight: 6},
+		models.DocumentElement{Type: "text", Text: identity, X: 30, Y: 90, Size: 11, LineHeight: 7},
+		models.DocumentElement{Type: "text", Text: body, X: 20, Y: 125, Size: 11, Align: "J", LineHeight: 6},
+		models.DocumentElement{Type: "text", Text: "{{notes}}", X: 20, Y: 165, Size: 11, Align: "J", LineHeight: 6},
+	)
+	tpl.Layout.Elements = append(tpl.Layout.Elements, footer...)
+	return tpl
+}
+
+// documentAssetPath returns the absolute path of an uploaded template image,
+// or "" when it is not set or missing on disk.
+func documentAssetPath(relative *string) string {
+	if relative == nil || *relative == "" {
+		return ""
+	}
+	path := filepath.Join(config.Loaded.Upload.Dir, *relative)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+func parseHexColor(value string) (int, int, int) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) != 6 {
+		return 31, 41, 55
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return 31, 41, 55
+	}
+	return int(rgb >> 16 & 0xff), int(rgb >> 8 & 0xff), int(rgb & 0xff)
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
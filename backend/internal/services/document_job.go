@@ -0,0 +1,281 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/jobs"
+	"dsi_interna_sys/internal/models"
+)
+
+// EnqueueDocumentJob queues the queue job that renders a batch document job
+func EnqueueDocumentJob(db jobs.Execer, documentJobID int64) error {
+	_, err := jobs.Enqueue(db, JobDocumentBatch, documentJobPayload{DocumentJobID: documentJobID}, jobs.MaxAttempts(3))
+	return err
+}
+
+type documentJobPayload struct {
+	DocumentJobID int64 `json:"document_job_id"`
+}
+
+// handleDocumentJob runs one batch job. A retry continues with the items
+// that are still pending.
+func handleDocumentJob(db *sql.DB) func(context.Context, documentJobPayload, *models.Job) error {
+	return func(ctx context.Context, p documentJobPayload, job *models.Job) error {
+		res, err := db.Exec(
+			"UPDATE document_jobs SET status = 'running', started_at = COALESCE(started_at, ?) WHERE id = ? AND status IN ('queued', 'running')",
+			time.Now(), p.DocumentJobID,
+		)
+		if err != nil {
+			return err
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return nil // cancelled or already finished
+		}
+
+		if err := runDocumentJob(ctx, db, p.DocumentJobID); err != nil {
+			if ctx.Err() == nil && job.Attempts >= job.MaxAttempts {
+				_, _ = db.Exec(
+					"UPDATE document_jobs SET status = 'failed', error = ?, finished_at = ? WHERE id = ? AND status = 'running'",
+					err.Error(), time.Now(), p.DocumentJobID,
+				)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// DocumentJobDir is where the rendered files of a job are collected
+// before they are zipped
+func DocumentJobDir(jobID int64) string {
+	return filepath.Join(config.Loaded.Upload.Dir, "document_jobs", strconv.FormatInt(jobID, 10))
+}
+
+func runDocumentJob(ctx context.Context, db *sql.DB, jobID int64) error {
+	var kind string
+	var requestedBy int64
+	if err := db.QueryRow(
+		"SELECT kind, requested_by FROM document_jobs WHERE id = ?", jobID,
+	).Scan(&kind, &requestedBy); err != nil {
+		return err
+	}
+
+	dir := DocumentJobDir(jobID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	type item struct {
+		ID       int64
+		InternID int64
+		Name     string
+	}
+	rows, err := db.Query(
+		`SELECT dji.id, dji.intern_id, i.full_name
+		 FROM document_job_items dji
+		 JOIN interns i ON dji.intern_id = i.id
+		 WHERE dji.job_id = ? AND dji.status = 'pending'
+		 ORDER BY dji.id ASC`, jobID,
+	)
+	if err != nil {
+		return err
+	}
+	var items []item
+	for rows.Next() {
+		var it item
+		if err := rows.Scan(&it.ID, &it.InternID, &it.Name); err == nil {
+			items = append(items, it)
+		}
+	}
+	rows.Close()
+
+	for _, it := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Stop between items when the job was cancelled
+		var status string
+		if err := db.QueryRow("SELECT status FROM document_jobs WHERE id = ?", jobID).Scan(&status); err != nil {
+			return err
+		}
+		if status == models.DocumentJobCancelled {
+			return nil
+		}
+
+		itemStatus, succeeded, failed := "done", 1, 0
+		var itemError sql.NullString
+		if err := renderDocumentJobItem(db, dir, kind, it.InternID, it.Name, requestedBy); err != nil {
+			itemStatus, succeeded, failed = "failed", 0, 1
+			itemError = sql.NullString{String: err.Error(), Valid: true}
+		}
+
+		if _, err := db.Exec(
+			"UPDATE document_job_items SET status = ?, error = ?, processed_at = ? WHERE id = ?",
+			itemStatus, itemError, time.Now(), it.ID,
+		); err != nil {
+			return err
+		}
+		if _, err := db.Exec(
+			`UPDATE document_jobs SET processed = processed + 1, succeeded = succeeded + ?, failed = failed + ?
+			 WHERE id = ?`, succeeded, failed, jobID,
+		); err != nil {
+			return err
+		}
+	}
+
+	resultPath := filepath.Join("document_jobs", fmt.Sprintf("job_%d.zip", jobID))
+	if err := writeDocumentJobZip(db, jobID, dir, filepath.Join(config.Loaded.Upload.Dir, resultPath)); err != nil {
+		return err
+	}
+	_ = os.RemoveAll(dir)
+
+	if _, err := db.Exec(
+		"UPDATE document_jobs SET status = 'completed', result_path = ?, finished_at = ? WHERE id = ? AND status = 'running'",
+		resultPath, time.Now(), jobID,
+	); err != nil {
+		return err
+	}
+
+	if err := SendNotification(
+		db, requestedBy, models.NotificationDocumentJob, "Dokumen Siap Diunduh",
+		fmt.Sprintf("Pembuatan dokumen massal #%d telah selesai.", jobID),
+		"/document-jobs/"+strconv.FormatInt(jobID, 10),
+	); err != nil {
+		log.Printf("Error notifying user %d about document job %d: %v", requestedBy, jobID, err)
+	}
+	return nil
+}
+
+func renderDocumentJobItem(db *sql.DB, dir, kind string, internID int64, name string, actorID int64) error {
+	base := fmt.Sprintf("%04d_%s.pdf", internID, fileSafeName(name))
+	var problems []string
+
+	if kind == models.DocumentJobCertificates || kind == models.DocumentJobBoth {
+		if err := renderJobCertificate(db, filepath.Join(dir, "sertifikat", base), internID, actorID); err != nil {
+			problems = append(problems, "certificate: "+err.Error())
+		}
+	}
+	if kind == models.DocumentJobReports || kind == models.DocumentJobBoth {
+		if err := renderJobReport(db, filepath.Join(dir, "laporan", base), internID); err != nil {
+			problems = append(problems, "report: "+err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func renderJobCertificate(db *sql.DB, path string, internID, actorID int64) error {
+	certID, err := IssuedCertificateID(db, internID)
+	if err != nil {
+		return err
+	}
+	pdf, err := RenderCertificate(db, certID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return pdf.OutputFileAndClose(path)
+}
+
+func renderJobReport(db *sql.DB, path string, internID int64) error {
+	pdf, _, err := RenderInternReport(db, internID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return pdf.OutputFileAndClose(path)
+}
+
+// writeDocumentJobZip packs every rendered file plus a list of the interns
+// whose documents could not be generated
+func writeDocumentJobZip(db *sql.DB, jobID int64, dir, zipPath string) error {
+	if err := os.MkdirAll(filepath.Dir(zipPath), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		dst, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+	if err != nil {
+		zw.Close()
+		return err
+	}
+
+	rows, err := db.Query(
+		`SELECT i.full_name, dji.error FROM document_job_items dji
+		 JOIN interns i ON dji.intern_id = i.id
+		 WHERE dji.job_id = ? AND dji.status = 'failed' ORDER BY i.full_name`, jobID,
+	)
+	if err == nil {
+		var lines []string
+		for rows.Next() {
+			var name string
+			var reason sql.NullString
+			if rows.Scan(&name, &reason) == nil {
+				lines = append(lines, name+": "+reason.String)
+			}
+		}
+		rows.Close()
+		if len(lines) > 0 {
+			if f, err := zw.Create("gagal.txt"); err == nil {
+				_, _ = f.Write([]byte(strings.Join(lines, "\n") + "\n"))
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+func fileSafeName(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "file"
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, value)
+}
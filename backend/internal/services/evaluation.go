@@ -0,0 +1,313 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+var (
+	ErrEvaluationState  = errors.New("evaluation cannot be changed in its current state")
+	ErrEvaluationClosed = errors.New("evaluation cycle is closed")
+	ErrEmptyCohort      = errors.New("no active interns match the cohort")
+)
+
+// OpenEvaluationCycle creates a cycle and an evaluation for every active
+// intern in the cohort, each tied to the rubric version that applies to
+// the intern. Interns are notified once the cycle is saved.
+func OpenEvaluationCycle(db *sql.DB, req models.CreateEvaluationCycleRequest, selfDue, supervisorDue time.Time, createdBy int64) (int64, int, error) {
+	where := []string{"status = 'active'"}
+	args := []interface{}{}
+	c := req.Cohort
+	if s := strings.TrimSpace(c.School); s != "" {
+		where = append(where, "school = ?")
+		args = append(args, s)
+	}
+	if d := strings.TrimSpace(c.Department); d != "" {
+		where = append(where, "department = ?")
+		args = append(args, d)
+	}
+	if c.SupervisorID > 0 {
+		where = append(where, "supervisor_id = ?")
+		args = append(args, c.SupervisorID)
+	}
+	if c.StartDateFrom != "" {
+		where = append(where, "start_date >= ?")
+		args = append(args, c.StartDateFrom)
+	}
+	if c.StartDateTo != "" {
+		where = append(where, "start_date <= ?")
+		args = append(args, c.StartDateTo)
+	}
+	if len(c.InternIDs) > 0 {
+		where = append(where, "id IN (?"+strings.Repeat(", ?", len(c.InternIDs)-1)+")")
+		for _, id := range c.InternIDs {
+			args = append(args, id)
+		}
+	}
+
+	rows, err := db.Query("SELECT id, user_id FROM interns WHERE "+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	type target struct{ internID, userID, versionID int64 }
+	var targets []target
+	for rows.Next() {
+		var t target
+		if rows.Scan(&t.internID, &t.userID) == nil {
+			targets = append(targets, t)
+		}
+	}
+	rows.Close()
+	if len(targets) == 0 {
+		return 0, 0, ErrEmptyCohort
+	}
+
+	for i := range targets {
+		version, err := ResolveRubricVersion(db, targets[i].internID)
+		if err != nil {
+			return 0, 0, err
+		}
+		targets[i].versionID = version.ID
+	}
+
+	cohort, err := json.Marshal(c)
+	if err != nil {
+		return 0, 0, err
+	}
+	cycleType := req.Type
+	if cycleType == "" {
+		cycleType = "custom"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO evaluation_cycles (name, type, description, self_due_date, supervisor_due_date, cohort, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		strings.TrimSpace(req.Name), cycleType, nullString(req.Description),
+		selfDue.Format("2006-01-02"), supervisorDue.Format("2006-01-02"), string(cohort), nullActor(createdBy),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+	cycleID, _ := res.LastInsertId()
+
+	for _, t := range targets {
+		if _, err := tx.Exec(
+			"INSERT INTO evaluations (cycle_id, intern_id, rubric_version_id) VALUES (?, ?, ?)",
+			cycleID, t.internID, t.versionID,
+		); err != nil {
+			return 0, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	for _, t := range targets {
+		notify(db, t.userID, "Evaluasi "+strings.TrimSpace(req.Name),
+			"Silakan isi penilaian diri Anda sebelum "+FormatDateID(selfDue)+".", "/evaluations")
+	}
+	return cycleID, len(targets), nil
+}
+
+// SaveEvaluationScores replaces the self or supervisor scores of an
+// evaluation
+func SaveEvaluationScores(tx *sql.Tx, evaluationID int64, source string, scores []models.AssessmentScore) error {
+	if _, err := tx.Exec("DELETE FROM evaluation_scores WHERE evaluation_id = ? AND source = ?", evaluationID, source); err != nil {
+		return err
+	}
+	for _, s := range scores {
+		if _, err := tx.Exec(
+			"INSERT INTO evaluation_scores (evaluation_id, criterion_id, source, score) VALUES (?, ?, ?, ?)",
+			evaluationID, s.CriterionID, source, s.Score,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadEvaluationScores returns the scores of an evaluation by source and
+// criterion code
+func LoadEvaluationScores(db *sql.DB, evaluationID int64) (map[string]map[string]float64, error) {
+	rows, err := db.Query(
+		`SELECT s.source, c.code, s.score
+		 FROM evaluation_scores s
+		 JOIN rubric_criteria c ON s.criterion_id = c.id
+		 WHERE s.evaluation_id = ?`, evaluationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := map[string]map[string]float64{
+		models.EvaluationSourceSelf:       {},
+		models.EvaluationSourceSupervisor: {},
+	}
+	for rows.Next() {
+		var source, code string
+		var score float64
+		if err := rows.Scan(&source, &code, &score); err != nil {
+			continue
+		}
+		if scores[source] == nil {
+			scores[source] = map[string]float64{}
+		}
+		scores[source][code] = score
+	}
+	return scores, rows.Err()
+}
+
+// SignOffEvaluation locks an evaluation the supervisor has scored, records
+// the supervisor's scores as an assessment and releases the result to the
+// intern
+func SignOffEvaluation(db *sql.DB, evaluationID, actorID int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var internID, versionID int64
+	var status, cycleName, cycleStatus string
+	var comments, strengths, improvements sql.NullString
+	if err := tx.QueryRow(
+		`SELECT e.intern_id, e.rubric_version_id, e.status, c.name, c.status,
+		        e.supervisor_comments, e.strengths, e.improvements
+		 FROM evaluations e
+		 JOIN evaluation_cycles c ON e.cycle_id = c.id
+		 WHERE e.id = ? FOR UPDATE`, evaluationID,
+	).Scan(&internID, &versionID, &status, &cycleName, &cycleStatus, &comments, &strengths, &improvements); err != nil {
+		return 0, err
+	}
+	if cycleStatus == "closed" {
+		return 0, ErrEvaluationClosed
+	}
+	if status != models.EvaluationInReview {
+		return 0, ErrEvaluationState
+	}
+
+	rubric, err := LoadRubricVersion(db, versionID)
+	if err != nil {
+		return 0, err
+	}
+	scores, err := LoadEvaluationScores(db, evaluationID)
+	if err != nil {
+		return 0, err
+	}
+	result, err := ScoreRubric(rubric, scores[models.EvaluationSourceSupervisor])
+	if err != nil {
+		return 0, err
+	}
+
+	legacy := LegacyAssessmentScores(rubric, result.Scores)
+	res, err := tx.Exec(
+		`INSERT INTO assessments (intern_id, rubric_version_id, assessed_by, score, rubric_score, category, category_label,
+		                          aspect, quality_score, speed_score, initiative_score, teamwork_score, communication_score,
+		                          strengths, improvements, comments, assessment_date)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		internID, rubric.ID, actorID, result.Percent, result.RubricScore, result.Category.Category, result.Category.Label,
+		cycleName, legacy["quality"], legacy["speed"], legacy["initiative"], legacy["teamwork"], legacy["communication"],
+		strengths, improvements, comments, time.Now().Format("2006-01-02"),
+	)
+	if err != nil {
+		return 0, err
+	}
+	assessmentID, _ := res.LastInsertId()
+	if err := SaveAssessmentScores(tx, assessmentID, result.Scores); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE evaluations SET status = ?, signed_off_at = NOW(), signed_off_by = ?, assessment_id = ? WHERE id = ?`,
+		models.EvaluationSignedOff, actorID, assessmentID, evaluationID,
+	); err != nil {
+		return 0, err
+	}
+	return assessmentID, tx.Commit()
+}
+
+// LegacyAssessmentScores fills the five original score columns, which
+// older reports still average, when a 0-100 rubric uses their codes
+func LegacyAssessmentScores(rubric *models.RubricVersion, scores []models.AssessmentScore) map[string]interface{} {
+	legacy := map[string]interface{}{}
+	if rubric.ScaleMin != 0 || rubric.ScaleMax != 100 {
+		return legacy
+	}
+	for _, sc := range scores {
+		switch sc.Code {
+		case "quality", "speed", "initiative", "teamwork", "communication":
+			legacy[sc.Code] = int(math.Round(sc.Score))
+		}
+	}
+	return legacy
+}
+
+// SendEvaluationReminders nudges interns with an overdue self-assessment
+// and supervisors with overdue evaluations in open cycles, at most once a
+// day per evaluation
+func SendEvaluationReminders(db *sql.DB, now time.Time) {
+	today := now.Format("2006-01-02")
+	rows, err := db.Query(
+		`SELECT e.id, e.status, c.name, c.self_due_date, c.supervisor_due_date, i.user_id, i.supervisor_id, iu.name
+		 FROM evaluations e
+		 JOIN evaluation_cycles c ON e.cycle_id = c.id
+		 JOIN interns i ON e.intern_id = i.id
+		 LEFT JOIN users iu ON i.user_id = iu.id
+		 WHERE c.status = 'open'
+		   AND (e.last_reminded_at IS NULL OR DATE(e.last_reminded_at) < ?)
+		   AND ((e.status = 'pending_self' AND c.self_due_date < ?)
+		     OR (e.status IN ('pending_self', 'pending_supervisor', 'in_review') AND c.supervisor_due_date < ?))`,
+		today, today, today,
+	)
+	if err != nil {
+		log.Printf("Error selecting overdue evaluations: %v", err)
+		return
+	}
+	type reminder struct {
+		id                     int64
+		status, cycle          string
+		selfDue, supervisorDue time.Time
+		internUser             int64
+		supervisor             sql.NullInt64
+		internName             sql.NullString
+	}
+	var due []reminder
+	for rows.Next() {
+		var r reminder
+		if err := rows.Scan(&r.id, &r.status, &r.cycle, &r.selfDue, &r.supervisorDue, &r.internUser, &r.supervisor, &r.internName); err == nil {
+			due = append(due, r)
+		}
+	}
+	rows.Close()
+
+	for _, r := range due {
+		link := "/evaluations/" + strconv.FormatInt(r.id, 10)
+		if r.status == models.EvaluationPendingSelf && r.selfDue.Format("2006-01-02") < today {
+			notify(db, r.internUser, "Penilaian Diri Terlambat",
+				fmt.Sprintf("Penilaian diri untuk %s melewati batas waktu %s. Segera isi penilaian Anda.", r.cycle, FormatDateID(r.selfDue)), link)
+		}
+		if r.supervisor.Valid && r.supervisorDue.Format("2006-01-02") < today {
+			notify(db, r.supervisor.Int64, "Evaluasi Intern Terlambat",
+				fmt.Sprintf("Evaluasi %s untuk %s melewati batas waktu %s.", r.cycle, r.internName.String, FormatDateID(r.supervisorDue)), link)
+		}
+		if _, err := db.Exec("UPDATE evaluations SET last_reminded_at = NOW() WHERE id = ?", r.id); err != nil {
+			log.Printf("Error marking evaluation %d reminded: %v", r.id, err)
+		}
+	}
+}
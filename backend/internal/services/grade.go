@@ -0,0 +1,261 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"dsi_interna_sys/internal/models"
+)
+
+var ErrInvalidGradingPolicy = errors.New("invalid grading policy")
+
+// LoadGradingPolicy reads the grading_policy setting, falling back to the
+// default policy when it is missing or unreadable
+func LoadGradingPolicy(db *sql.DB) models.GradingPolicy {
+	var raw string
+	if err := db.QueryRow("SELECT `value` FROM settings WHERE `key` = 'grading_policy'").Scan(&raw); err != nil {
+		return models.DefaultGradingPolicy
+	}
+	var p models.GradingPolicy
+	if err := json.Unmarshal([]byte(raw), &p); err != nil || ValidateGradingPolicy(p) != nil {
+		return models.DefaultGradingPolicy
+	}
+	return p
+}
+
+// ValidateGradingPolicy checks weights, penalty and scale. Errors wrap
+// ErrInvalidGradingPolicy.
+func ValidateGradingPolicy(p models.GradingPolicy) error {
+	w := p.Weights
+	if w.Tasks < 0 || w.Attendance < 0 || w.Assessments < 0 {
+		return fmt.Errorf("%w: weights cannot be negative", ErrInvalidGradingPolicy)
+	}
+	if w.Tasks+w.Attendance+w.Assessments <= 0 {
+		return fmt.Errorf("%w: at least one weight must be positive", ErrInvalidGradingPolicy)
+	}
+	if p.PunctualityShare < 0 || p.PunctualityShare > 100 {
+		return fmt.Errorf("%w: punctuality_share must be between 0 and 100", ErrInvalidGradingPolicy)
+	}
+	if p.LatePenalty < 0 || p.LatePenalty > 100 {
+		return fmt.Errorf("%w: late_penalty must be between 0 and 100", ErrInvalidGradingPolicy)
+	}
+	if len(p.Scale) == 0 {
+		return fmt.Errorf("%w: at least one grade band is required", ErrInvalidGradingPolicy)
+	}
+	seen := map[float64]bool{}
+	for _, b := range p.Scale {
+		if strings.TrimSpace(b.Letter) == "" || strings.TrimSpace(b.Predicate) == "" {
+			return fmt.Errorf("%w: every grade band needs a letter and a predicate", ErrInvalidGradingPolicy)
+		}
+		if b.MinScore < 0 || b.MinScore > 100 {
+			return fmt.Errorf("%w: min_score of %q must be between 0 and 100", ErrInvalidGradingPolicy, b.Letter)
+		}
+		if seen[b.MinScore] {
+			return fmt.Errorf("%w: duplicate min_score %s", ErrInvalidGradingPolicy, formatScore(b.MinScore))
+		}
+		seen[b.MinScore] = true
+	}
+	return nil
+}
+
+// SaveGradingPolicy stores a validated policy in the settings table
+func SaveGradingPolicy(db *sql.DB, p models.GradingPolicy) error {
+	if err := ValidateGradingPolicy(p); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		"INSERT INTO settings (`key`, `value`, `type`, description) VALUES ('grading_policy', ?, 'json', 'Final grade weights, late penalty and grade scale') "+
+			"ON DUPLICATE KEY UPDATE `value` = VALUES(`value`)",
+		string(raw),
+	)
+	return err
+}
+
+// ComputeGrade computes an intern's final grade with the current policy
+func ComputeGrade(db *sql.DB, internID int64) (models.GradeBreakdown, error) {
+	return GradeIntern(db, LoadGradingPolicy(db), internID)
+}
+
+// GradeIntern computes an intern's final grade from approved task scores
+// (less the late penalty), attendance rate and punctuality, and the
+// average company assessment, all on a 0-100 scale
+func GradeIntern(db *sql.DB, policy models.GradingPolicy, internID int64) (models.GradeBreakdown, error) {
+	g := models.GradeBreakdown{InternID: internID, Policy: policy}
+	if err := db.QueryRow("SELECT full_name FROM interns WHERE id = ?", internID).Scan(&g.InternName); err != nil {
+		return g, err
+	}
+
+	tasks, err := gradeTasks(db, policy, internID)
+	if err != nil {
+		return g, err
+	}
+	attendance, err := gradeAttendance(db, policy, internID)
+	if err != nil {
+		return g, err
+	}
+	assessments, err := gradeAssessments(db, internID)
+	if err != nil {
+		return g, err
+	}
+	tasks.Weight = policy.Weights.Tasks
+	attendance.Weight = policy.Weights.Attendance
+	assessments.Weight = policy.Weights.Assessments
+	g.Components = []models.GradeComponent{tasks, attendance, assessments}
+	finalizeGrade(&g)
+	return g, nil
+}
+
+// finalizeGrade reweights the components that have a score and sets the
+// final score and its grade band
+func finalizeGrade(g *models.GradeBreakdown) {
+	var totalWeight float64
+	for _, c := range g.Components {
+		if c.Score != nil {
+			totalWeight += c.Weight
+		}
+	}
+	if totalWeight <= 0 {
+		return
+	}
+
+	var final float64
+	for i := range g.Components {
+		c := &g.Components[i]
+		if c.Score == nil {
+			continue
+		}
+		c.EffectiveWeight = roundScore(c.Weight / totalWeight * 100)
+		final += *c.Score * c.Weight / totalWeight
+	}
+	final = roundScore(final)
+	band := g.Policy.BandFor(final)
+	g.FinalScore = &final
+	g.Letter = band.Letter
+	g.Predicate = band.Predicate
+}
+
+func gradeTasks(db *sql.DB, policy models.GradingPolicy, internID int64) (models.GradeComponent, error) {
+	c := models.GradeComponent{Key: models.GradeComponentTasks, Label: "Tugas"}
+	rows, err := db.Query(
+		"SELECT score, is_late FROM tasks WHERE intern_id = ? AND status = 'completed' AND score IS NOT NULL", internID,
+	)
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+
+	var graded, late int
+	var raw, adjusted float64
+	for rows.Next() {
+		var score float64
+		var isLate bool
+		if err := rows.Scan(&score, &isLate); err != nil {
+			return c, err
+		}
+		graded++
+		raw += score
+		if isLate {
+			late++
+			score = math.Max(0, score-policy.LatePenalty)
+		}
+		adjusted += score
+	}
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+
+	c.Details = map[string]interface{}{"graded_tasks": graded, "late_tasks": late}
+	if graded > 0 {
+		s := roundScore(adjusted / float64(graded))
+		c.Score = &s
+		c.Details["average_score"] = roundScore(raw / float64(graded))
+		c.Details["late_penalty"] = policy.LatePenalty
+	}
+	return c, nil
+}
+
+func gradeAttendance(db *sql.DB, policy models.GradingPolicy, internID int64) (models.GradeComponent, error) {
+	c := models.GradeComponent{Key: models.GradeComponentAttendance, Label: "Kehadiran"}
+	var total, present, late int64
+	if err := db.QueryRow(
+		`SELECT COUNT(*),
+		        COALESCE(SUM(CASE WHEN status IN ('present', 'late') THEN 1 ELSE 0 END), 0),
+		        COALESCE(SUM(CASE WHEN status = 'late' THEN 1 ELSE 0 END), 0)
+		 FROM attendances WHERE intern_id = ?`, internID,
+	).Scan(&total, &present, &late); err != nil {
+		return c, err
+	}
+
+	c.Details = map[string]interface{}{"total_days": total, "present_days": present, "late_days": late}
+	if total == 0 {
+		return c, nil
+	}
+	rate := float64(present) / float64(total) * 100
+	punctuality := 0.0
+	if present > 0 {
+		punctuality = float64(present-late) / float64(present) * 100
+	}
+	share := policy.PunctualityShare / 100
+	s := roundScore(rate*(1-share) + punctuality*share)
+	c.Score = &s
+	c.Details["attendance_rate"] = roundScore(rate)
+	c.Details["punctuality_rate"] = roundScore(punctuality)
+	return c, nil
+}
+
+func gradeAssessments(db *sql.DB, internID int64) (models.GradeComponent, error) {
+	c := models.GradeComponent{Key: models.GradeComponentAssessments, Label: "Penilaian"}
+	rows, err := db.Query(
+		`SELECT a.score, a.rubric_score, rv.scale_min, rv.scale_max
+		 FROM assessments a
+		 LEFT JOIN rubric_versions rv ON a.rubric_version_id = rv.id
+		 WHERE a.intern_id = ? AND a.source = 'company'`, internID,
+	)
+	if err != nil {
+		return c, err
+	}
+	defer rows.Close()
+
+	var count int64
+	var total float64
+	for rows.Next() {
+		var score float64
+		var rubricScore, scaleMin, scaleMax sql.NullFloat64
+		if err := rows.Scan(&score, &rubricScore, &scaleMin, &scaleMax); err != nil {
+			return c, err
+		}
+		count++
+		total += assessmentPercent(score, rubricScore, scaleMin, scaleMax)
+	}
+	if err := rows.Err(); err != nil {
+		return c, err
+	}
+	c.Details = map[string]interface{}{"assessments": count}
+	if count > 0 {
+		s := roundScore(total / float64(count))
+		c.Score = &s
+	}
+	return c, nil
+}
+
+// assessmentPercent puts an assessment on the 0-100 scale of the other
+// grade components: its rubric score relative to the rubric's scale, or the
+// stored percentage when it has no rubric score
+func assessmentPercent(score float64, rubricScore, scaleMin, scaleMax sql.NullFloat64) float64 {
+	if rubricScore.Valid && scaleMin.Valid && scaleMax.Valid && scaleMax.Float64 > scaleMin.Float64 {
+		score = (rubricScore.Float64 - scaleMin.Float64) / (scaleMax.Float64 - scaleMin.Float64) * 100
+	}
+	return math.Min(100, math.Max(0, score))
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*100) / 100
+}
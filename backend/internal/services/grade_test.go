@@ -0,0 +1,98 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"dsi_interna_sys/internal/models"
+)
+
+func TestDefaultGradeBands(t *testing.T) {
+	tests := []struct {
+		score  float64
+		letter string
+	}{
+		{100, "A"},
+		{85, "A"},
+		{84.99, "B"},
+		{75, "B"},
+		{74.99, "C"},
+		{60, "C"},
+		{59.99, "D"},
+		{0, "D"},
+		{-1, "D"},
+	}
+	for _, tt := range tests {
+		if got := models.DefaultGradingPolicy.BandFor(tt.score).Letter; got != tt.letter {
+			t.Errorf("BandFor(%v) = %s, want %s", tt.score, got, tt.letter)
+		}
+	}
+}
+
+func TestFinalizeGrade(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name          string
+		tasks         *float64
+		attendance    *float64
+		assessments   *float64
+		wantFinal     *float64
+		wantLetter    string
+		wantEffective [3]float64
+	}{
+		{"all components", score(80), score(90), score(70), score(78), "B", [3]float64{40, 20, 40}},
+		{"no assessments reweights the rest", score(80), score(90), nil, score(83.33), "B", [3]float64{66.67, 33.33, 0}},
+		{"exactly on a band boundary", score(85), score(85), score(85), score(85), "A", [3]float64{40, 20, 40}},
+		{"only assessments", nil, nil, score(59.99), score(59.99), "D", [3]float64{0, 0, 100}},
+		{"no data", nil, nil, nil, nil, "", [3]float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := models.DefaultGradingPolicy
+			g := models.GradeBreakdown{Policy: policy, Components: []models.GradeComponent{
+				{Key: models.GradeComponentTasks, Weight: policy.Weights.Tasks, Score: tt.tasks},
+				{Key: models.GradeComponentAttendance, Weight: policy.Weights.Attendance, Score: tt.attendance},
+				{Key: models.GradeComponentAssessments, Weight: policy.Weights.Assessments, Score: tt.assessments},
+			}}
+			finalizeGrade(&g)
+			switch {
+			case tt.wantFinal == nil && g.FinalScore != nil:
+				t.Fatalf("final score = %v, want none", *g.FinalScore)
+			case tt.wantFinal != nil && (g.FinalScore == nil || *g.FinalScore != *tt.wantFinal):
+				t.Fatalf("final score = %v, want %v", g.FinalScore, *tt.wantFinal)
+			}
+			if g.Letter != tt.wantLetter {
+				t.Fatalf("letter = %q, want %q", g.Letter, tt.wantLetter)
+			}
+			for i, c := range g.Components {
+				if c.EffectiveWeight != tt.wantEffective[i] {
+					t.Fatalf("%s effective weight = %v, want %v", c.Key, c.EffectiveWeight, tt.wantEffective[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAssessmentPercent(t *testing.T) {
+	valid := func(v float64) sql.NullFloat64 { return sql.NullFloat64{Float64: v, Valid: true} }
+	none := sql.NullFloat64{}
+	tests := []struct {
+		name                  string
+		score                 float64
+		rubricScore, min, max sql.NullFloat64
+		want                  float64
+	}{
+		{"1-5 rubric", 75, valid(4), valid(1), valid(5), 75},
+		{"1-5 rubric uses the unrounded rubric score", 67, valid(3.7), valid(1), valid(5), 67.5},
+		{"0-100 rubric", 85, valid(85), valid(0), valid(100), 85},
+		{"no rubric score falls back to the percentage", 72, none, none, none, 72},
+		{"empty scale falls back to the percentage", 72, valid(3), valid(3), valid(3), 72},
+		{"percentage above 100 is capped", 120, none, none, none, 100},
+		{"negative percentage is floored", -5, none, none, none, 0},
+	}
+	for _, tt := range tests {
+		if got := assessmentPercent(tt.score, tt.rubricScore, tt.min, tt.max); got != tt.want {
+			t.Errorf("%s: assessmentPercent = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
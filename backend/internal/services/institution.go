@@ -0,0 +1,815 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"dsi_interna_sys/internal/models"
+)
+
+var (
+	ErrInvalidInstitution = errors.New("invalid institution")
+	ErrInstitutionInUse   = errors.New("institution still has interns or accounts")
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Common abbreviations in Indonesian school names, expanded before names
+// are compared
+var institutionAbbreviations = map[string]string{
+	"univ":   "universitas",
+	"unv":    "universitas",
+	"inst":   "institut",
+	"poltek": "politeknik",
+	"n":      "negeri",
+	"neg":    "negeri",
+	"smkn":   "smk negeri",
+	"sman":   "sma negeri",
+	"smpn":   "smp negeri",
+}
+
+// Words that say what kind of institution it is but not which one. They
+// are dropped when looking for likely duplicates.
+var institutionGenericWords = map[string]bool{
+	"universitas": true,
+	"institut":    true,
+	"politeknik":  true,
+	"sekolah":     true,
+	"tinggi":      true,
+	"akademi":     true,
+}
+
+// NormalizeInstitutionName folds case, punctuation and common
+// abbreviations, so "Univ. Diponegoro" and "UNIVERSITAS DIPONEGORO"
+// compare equal
+func NormalizeInstitutionName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, f := range fields {
+		if full, ok := institutionAbbreviations[f]; ok {
+			fields[i] = full
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// institutionKey is the distinguishing part of a normalized name, used to
+// suggest institutions that may be the same
+func institutionKey(name string) string {
+	words := []string{}
+	for _, w := range strings.Fields(NormalizeInstitutionName(name)) {
+		if !institutionGenericWords[w] {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// MatchInstitution finds the institution a free-text school name refers
+// to, by alias or by normalized name or short name
+func MatchInstitution(q Querier, name string) (int64, bool, error) {
+	normalized := NormalizeInstitutionName(name)
+	if normalized == "" {
+		return 0, false, nil
+	}
+	var id int64
+	err := q.QueryRow("SELECT institution_id FROM institution_aliases WHERE normalized_name = ?", normalized).Scan(&id)
+	if err == nil {
+		return id, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+
+	rows, err := q.Query("SELECT id, name, short_name FROM institutions ORDER BY id")
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var instName string
+		var shortName sql.NullString
+		if err := rows.Scan(&id, &instName, &shortName); err != nil {
+			return 0, false, err
+		}
+		if institutionNameMatches(normalized, instName, shortName) {
+			return id, true, nil
+		}
+	}
+	return 0, false, rows.Err()
+}
+
+// institutionNameMatches reports whether an already normalized school name
+// is an institution's name or short name
+func institutionNameMatches(normalized, name string, shortName sql.NullString) bool {
+	return NormalizeInstitutionName(name) == normalized ||
+		(shortName.Valid && NormalizeInstitutionName(shortName.String) == normalized)
+}
+
+// ResolveInstitution returns the institution a free-text school name
+// refers to, creating one when none matches
+func ResolveInstitution(q Querier, name string) (int64, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("%w: name is required", ErrInvalidInstitution)
+	}
+	id, ok, err := MatchInstitution(q, name)
+	if err != nil || ok {
+		return id, err
+	}
+	now := time.Now()
+	res, err := q.Exec("INSERT INTO institutions (name, created_at, updated_at) VALUES (?, ?, ?)", name, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// CheckInstitutionQuota fails when an institution already has as many
+// active interns as its quota allows. The institution row stays locked
+// until tx ends, so concurrent activations are counted one after another.
+func CheckInstitutionQuota(tx *sql.Tx, institutionID int64) error {
+	var quota sql.NullInt64
+	err := tx.QueryRow("SELECT quota FROM institutions WHERE id = ? FOR UPDATE", institutionID).Scan(&quota)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: institution not found", ErrInvalidInstitution)
+	}
+	if err != nil {
+		return err
+	}
+	if !quota.Valid {
+		return nil
+	}
+	var active int64
+	if err := tx.QueryRow(
+		"SELECT COUNT(*) FROM interns WHERE institution_id = ? AND status = 'active'", institutionID,
+	).Scan(&active); err != nil {
+		return err
+	}
+	if quota.Valid && active >= quota.Int64 {
+		return fmt.Errorf("%w: the institution's quota of %d active interns is full", ErrInvalidInstitution, quota.Int64)
+	}
+	return nil
+}
+
+const institutionSelect = `SELECT inst.id, inst.name, inst.short_name, inst.address, inst.phone, inst.email, inst.website, inst.quota,
+	                              inst.created_at, inst.updated_at,
+	                              (SELECT COUNT(*) FROM interns WHERE institution_id = inst.id AND status = 'active'),
+	                              (SELECT COUNT(*) FROM interns WHERE institution_id = inst.id)
+	                       FROM institutions inst`
+
+func scanInstitution(row interface{ Scan(...interface{}) error }) (models.Institution, error) {
+	var inst models.Institution
+	var shortName, address, phone, email, website sql.NullString
+	var quota sql.NullInt64
+	var createdAt, updatedAt sql.NullTime
+	if err := row.Scan(&inst.ID, &inst.Name, &shortName, &address, &phone, &email, &website, &quota,
+		&createdAt, &updatedAt, &inst.ActiveInterns, &inst.TotalInterns); err != nil {
+		return inst, err
+	}
+	inst.ShortName = nullStringPtr(shortName)
+	inst.Address = nullStringPtr(address)
+	inst.Phone = nullStringPtr(phone)
+	inst.Email = nullStringPtr(email)
+	inst.Website = nullStringPtr(website)
+	inst.CreatedAt = createdAt.Time
+	inst.UpdatedAt = updatedAt.Time
+	if quota.Valid {
+		q := int(quota.Int64)
+		remaining := q - inst.ActiveInterns
+		if remaining < 0 {
+			remaining = 0
+		}
+		inst.Quota = &q
+		inst.QuotaRemaining = &remaining
+	}
+	return inst, nil
+}
+
+// ListInstitutions returns institutions by name with their intern counts
+// and current agreement, optionally filtered by a name search
+func ListInstitutions(db *sql.DB, search string) ([]models.Institution, error) {
+	query := institutionSelect
+	args := []interface{}{}
+	if search = strings.TrimSpace(search); search != "" {
+		query += ` WHERE inst.name LIKE ? OR inst.short_name LIKE ?
+		           OR inst.id IN (SELECT institution_id FROM institution_aliases WHERE alias LIKE ?)`
+		like := "%" + search + "%"
+		args = append(args, like, like, like)
+	}
+	query += " ORDER BY inst.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.Institution{}
+	for rows.Next() {
+		inst, err := scanInstitution(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, inst)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	active, err := activeAgreements(db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if a, ok := active[list[i].ID]; ok {
+			list[i].ActiveAgreement = &a
+		}
+	}
+	return list, nil
+}
+
+// LoadInstitution returns an institution with its agreements, contacts
+// and aliases
+func LoadInstitution(db *sql.DB, id int64) (models.Institution, error) {
+	inst, err := scanInstitution(db.QueryRow(institutionSelect+" WHERE inst.id = ?", id))
+	if err != nil {
+		return inst, err
+	}
+	if inst.Agreements, err = ListAgreements(db, id); err != nil {
+		return inst, err
+	}
+	for i := range inst.Agreements {
+		if inst.Agreements[i].Status == models.AgreementActive {
+			a := inst.Agreements[i]
+			inst.ActiveAgreement = &a
+			break
+		}
+	}
+	if inst.Contacts, err = ListContacts(db, id); err != nil {
+		return inst, err
+	}
+	inst.Aliases = []models.InstitutionAlias{}
+	rows, err := db.Query("SELECT id, alias FROM institution_aliases WHERE institution_id = ? ORDER BY alias", id)
+	if err != nil {
+		return inst, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a models.InstitutionAlias
+		if err := rows.Scan(&a.ID, &a.Alias); err != nil {
+			return inst, err
+		}
+		inst.Aliases = append(inst.Aliases, a)
+	}
+	return inst, rows.Err()
+}
+
+// SaveInstitution creates an institution when id is 0, otherwise updates
+// it. Aliases are replaced when given.
+func SaveInstitution(db *sql.DB, id int64, req models.InstitutionRequest) (int64, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return 0, fmt.Errorf("%w: name is required", ErrInvalidInstitution)
+	}
+	if req.Quota != nil && *req.Quota < 0 {
+		return 0, fmt.Errorf("%w: quota cannot be negative", ErrInvalidInstitution)
+	}
+	// Another institution of the same name is a duplicate in the making
+	if other, ok, err := MatchInstitution(db, name); err != nil {
+		return 0, err
+	} else if ok && other != id {
+		return 0, fmt.Errorf("%w: %q already matches institution %d", ErrInvalidInstitution, name, other)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if id == 0 {
+		res, err := tx.Exec(
+			`INSERT INTO institutions (name, short_name, address, phone, email, website, quota, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			name, nullString(req.ShortName), nullString(req.Address), nullString(req.Phone), nullString(req.Email),
+			nullString(req.Website), req.Quota, now, now,
+		)
+		if err != nil {
+			return 0, err
+		}
+		id, _ = res.LastInsertId()
+	} else {
+		res, err := tx.Exec(
+			`UPDATE institutions SET name = ?, short_name = ?, address = ?, phone = ?, email = ?, website = ?, quota = ?, updated_at = ?
+			 WHERE id = ?`,
+			name, nullString(req.ShortName), nullString(req.Address), nullString(req.Phone), nullString(req.Email),
+			nullString(req.Website), req.Quota, now, id,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			var exists int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM institutions WHERE id = ?", id).Scan(&exists); err != nil {
+				return 0, err
+			}
+			if exists == 0 {
+				return 0, sql.ErrNoRows
+			}
+		}
+	}
+
+	if req.Aliases != nil {
+		if _, err := tx.Exec("DELETE FROM institution_aliases WHERE institution_id = ?", id); err != nil {
+			return 0, err
+		}
+		for _, alias := range req.Aliases {
+			if err := addAlias(tx, id, alias, false); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return id, tx.Commit()
+}
+
+// addAlias records another name for an institution. With move, an alias
+// already pointing at another institution is taken over; otherwise that
+// is an error.
+func addAlias(q Querier, institutionID int64, alias string, move bool) error {
+	alias = strings.TrimSpace(alias)
+	normalized := NormalizeInstitutionName(alias)
+	if normalized == "" {
+		return nil
+	}
+	var owner int64
+	err := q.QueryRow("SELECT institution_id FROM institution_aliases WHERE normalized_name = ?", normalized).Scan(&owner)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = q.Exec(
+			"INSERT INTO institution_aliases (institution_id, alias, normalized_name, created_at) VALUES (?, ?, ?, ?)",
+			institutionID, alias, normalized, time.Now(),
+		)
+		return err
+	case err != nil:
+		return err
+	case owner == institutionID:
+		return nil
+	case !move:
+		return fmt.Errorf("%w: alias %q already belongs to institution %d", ErrInvalidInstitution, alias, owner)
+	}
+	_, err = q.Exec("UPDATE institution_aliases SET institution_id = ? WHERE normalized_name = ?", institutionID, normalized)
+	return err
+}
+
+// DeleteInstitution removes an institution no intern or account refers to
+func DeleteInstitution(db *sql.DB, id int64) error {
+	var inUse int
+	if err := db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM interns WHERE institution_id = ?) + (SELECT COUNT(*) FROM users WHERE institution_id = ?)`,
+		id, id,
+	).Scan(&inUse); err != nil {
+		return err
+	}
+	if inUse > 0 {
+		return ErrInstitutionInUse
+	}
+	res, err := db.Exec("DELETE FROM institutions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func agreementStatus(start, end time.Time, now time.Time) string {
+	today := now.Format("2006-01-02")
+	switch {
+	case start.Format("2006-01-02") > today:
+		return models.AgreementUpcoming
+	case end.Format("2006-01-02") < today:
+		return models.AgreementExpired
+	}
+	return models.AgreementActive
+}
+
+const agreementSelect = `SELECT id, institution_id, mou_number, title, start_date, end_date, notes, created_at, updated_at
+	                     FROM institution_agreements`
+
+func scanAgreement(row interface{ Scan(...interface{}) error }) (models.InstitutionAgreement, error) {
+	var a models.InstitutionAgreement
+	var title, notes sql.NullString
+	if err := row.Scan(&a.ID, &a.InstitutionID, &a.MouNumber, &title, &a.StartDate, &a.EndDate, &notes,
+		&a.CreatedAt, &a.UpdatedAt); err != nil {
+		return a, err
+	}
+	a.Title = nullStringPtr(title)
+	a.Notes = nullStringPtr(notes)
+	a.Status = agreementStatus(a.StartDate, a.EndDate, time.Now())
+	return a, nil
+}
+
+// ListAgreements returns an institution's agreements, latest first
+func ListAgreements(db *sql.DB, institutionID int64) ([]models.InstitutionAgreement, error) {
+	rows, err := db.Query(agreementSelect+" WHERE institution_id = ? ORDER BY end_date DESC, id DESC", institutionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.InstitutionAgreement{}
+	for rows.Next() {
+		a, err := scanAgreement(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+// LoadAgreement returns one agreement
+func LoadAgreement(db *sql.DB, id int64) (models.InstitutionAgreement, error) {
+	return scanAgreement(db.QueryRow(agreementSelect+" WHERE id = ?", id))
+}
+
+// activeAgreements maps institutions to the agreement in force today, the
+// one ending last when several are
+func activeAgreements(db *sql.DB) (map[int64]models.InstitutionAgreement, error) {
+	today := time.Now().Format("2006-01-02")
+	rows, err := db.Query(agreementSelect+" WHERE start_date <= ? AND end_date >= ? ORDER BY end_date", today, today)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	active := map[int64]models.InstitutionAgreement{}
+	for rows.Next() {
+		a, err := scanAgreement(rows)
+		if err != nil {
+			return nil, err
+		}
+		active[a.InstitutionID] = a
+	}
+	return active, rows.Err()
+}
+
+// SaveAgreement creates an agreement when id is 0, otherwise updates it
+func SaveAgreement(db *sql.DB, institutionID, id int64, req models.InstitutionAgreementRequest, createdBy int64) (int64, error) {
+	if strings.TrimSpace(req.MouNumber) == "" {
+		return 0, fmt.Errorf("%w: mou_number is required", ErrInvalidInstitution)
+	}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: start_date must be YYYY-MM-DD", ErrInvalidInstitution)
+	}
+	end, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		return 0, fmt.Errorf("%w: end_date must be YYYY-MM-DD", ErrInvalidInstitution)
+	}
+	if end.Before(start) {
+		return 0, fmt.Errorf("%w: end_date cannot be before start_date", ErrInvalidInstitution)
+	}
+
+	now := time.Now()
+	if id == 0 {
+		res, err := db.Exec(
+			`INSERT INTO institution_agreements (institution_id, mou_number, title, start_date, end_date, notes, created_by, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			institutionID, strings.TrimSpace(req.MouNumber), nullString(req.Title), req.StartDate, req.EndDate,
+			nullString(req.Notes), createdBy, now, now,
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	res, err := db.Exec(
+		`UPDATE institution_agreements SET mou_number = ?, title = ?, start_date = ?, end_date = ?, notes = ?, updated_at = ?
+		 WHERE id = ? AND institution_id = ?`,
+		strings.TrimSpace(req.MouNumber), nullString(req.Title), req.StartDate, req.EndDate, nullString(req.Notes), now,
+		id, institutionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return id, nil
+}
+
+// DeleteAgreement removes one of an institution's agreements
+func DeleteAgreement(db *sql.DB, institutionID, id int64) error {
+	return deleteInstitutionRow(db, "institution_agreements", institutionID, id)
+}
+
+// DeleteContact removes one of an institution's contact persons
+func DeleteContact(db *sql.DB, institutionID, id int64) error {
+	return deleteInstitutionRow(db, "institution_contacts", institutionID, id)
+}
+
+func deleteInstitutionRow(db *sql.DB, table string, institutionID, id int64) error {
+	res, err := db.Exec("DELETE FROM "+table+" WHERE id = ? AND institution_id = ?", id, institutionID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListContacts returns an institution's contact persons, primary first
+func ListContacts(db *sql.DB, institutionID int64) ([]models.InstitutionContact, error) {
+	rows, err := db.Query(
+		`SELECT id, institution_id, name, position, email, phone, is_primary, created_at
+		 FROM institution_contacts WHERE institution_id = ?
+		 ORDER BY is_primary DESC, name`, institutionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.InstitutionContact{}
+	for rows.Next() {
+		var c models.InstitutionContact
+		var position, email, phone sql.NullString
+		if err := rows.Scan(&c.ID, &c.InstitutionID, &c.Name, &position, &email, &phone, &c.IsPrimary, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.Position = nullStringPtr(position)
+		c.Email = nullStringPtr(email)
+		c.Phone = nullStringPtr(phone)
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// SaveContact creates a contact when id is 0, otherwise updates it. A
+// primary contact replaces the previous one.
+func SaveContact(db *sql.DB, institutionID, id int64, req models.InstitutionContactRequest) (int64, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return 0, fmt.Errorf("%w: name is required", ErrInvalidInstitution)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if req.IsPrimary {
+		if _, err := tx.Exec("UPDATE institution_contacts SET is_primary = FALSE WHERE institution_id = ?", institutionID); err != nil {
+			return 0, err
+		}
+	}
+	if id == 0 {
+		res, err := tx.Exec(
+			`INSERT INTO institution_contacts (institution_id, name, position, email, phone, is_primary, created_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			institutionID, strings.TrimSpace(req.Name), nullString(req.Position), nullString(req.Email), nullString(req.Phone),
+			req.IsPrimary, time.Now(),
+		)
+		if err != nil {
+			return 0, err
+		}
+		id, _ = res.LastInsertId()
+	} else {
+		res, err := tx.Exec(
+			`UPDATE institution_contacts SET name = ?, position = ?, email = ?, phone = ?, is_primary = ?
+			 WHERE id = ? AND institution_id = ?`,
+			strings.TrimSpace(req.Name), nullString(req.Position), nullString(req.Email), nullString(req.Phone), req.IsPrimary,
+			id, institutionID,
+		)
+		if err != nil {
+			return 0, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return 0, sql.ErrNoRows
+		}
+	}
+	return id, tx.Commit()
+}
+
+// ListSchoolNames returns the free-text school names on interns with the
+// institution each group is linked to. Names not yet linked, or linked to
+// an institution whose name does not match, come with suggestions.
+func ListSchoolNames(db *sql.DB) ([]models.SchoolName, error) {
+	rows, err := db.Query(
+		`SELECT TRIM(i.school), COUNT(*), i.institution_id, inst.name
+		 FROM interns i
+		 LEFT JOIN institutions inst ON i.institution_id = inst.id
+		 WHERE i.school IS NOT NULL AND TRIM(i.school) != ''
+		 GROUP BY TRIM(i.school), i.institution_id, inst.name
+		 ORDER BY TRIM(i.school)`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.SchoolName{}
+	for rows.Next() {
+		var s models.SchoolName
+		var institutionID sql.NullInt64
+		var institutionName sql.NullString
+		if err := rows.Scan(&s.School, &s.Interns, &institutionID, &institutionName); err != nil {
+			return nil, err
+		}
+		if institutionID.Valid {
+			id := institutionID.Int64
+			s.InstitutionID = &id
+		}
+		s.InstitutionName = nullStringPtr(institutionName)
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	institutions, err := ListInstitutions(db, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		s := &list[i]
+		if s.InstitutionName != nil && NormalizeInstitutionName(*s.InstitutionName) == NormalizeInstitutionName(s.School) {
+			continue
+		}
+		if id, ok, err := MatchInstitution(db, s.School); err == nil && ok {
+			s.Suggested = append(s.Suggested, id)
+		}
+		key := institutionKey(s.School)
+		for _, inst := range institutions {
+			if key != "" && institutionKey(inst.Name) == key && !containsID(s.Suggested, inst.ID) {
+				s.Suggested = append(s.Suggested, inst.ID)
+			}
+		}
+	}
+	return list, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignSchool links the interns with a free-text school name to an
+// institution and keeps the name as an alias for future registrations
+func AssignSchool(db *sql.DB, school string, institutionID int64) (int64, error) {
+	school = strings.TrimSpace(school)
+	if school == "" {
+		return 0, fmt.Errorf("%w: school is required", ErrInvalidInstitution)
+	}
+	var exists int
+	if err := db.QueryRow("SELECT COUNT(*) FROM institutions WHERE id = ?", institutionID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, fmt.Errorf("%w: institution not found", ErrInvalidInstitution)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("UPDATE interns SET institution_id = ? WHERE TRIM(school) = ?", institutionID, school)
+	if err != nil {
+		return 0, err
+	}
+	moved, _ := res.RowsAffected()
+	if err := addAlias(tx, institutionID, school, true); err != nil {
+		return 0, err
+	}
+	return moved, tx.Commit()
+}
+
+// DuplicateInstitutionGroups returns groups of institutions whose names
+// differ only in form, e.g. "Univ. Diponegoro" and "Universitas
+// Diponegoro"
+func DuplicateInstitutionGroups(db *sql.DB) ([]models.DuplicateInstitutions, error) {
+	institutions, err := ListInstitutions(db, "")
+	if err != nil {
+		return nil, err
+	}
+	byKey := map[string][]models.Institution{}
+	for _, inst := range institutions {
+		key := institutionKey(inst.Name)
+		if key == "" {
+			continue
+		}
+		byKey[key] = append(byKey[key], inst)
+	}
+	groups := []models.DuplicateInstitutions{}
+	for key, list := range byKey {
+		if len(list) > 1 {
+			groups = append(groups, models.DuplicateInstitutions{Key: key, Institutions: list})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups, nil
+}
+
+// MergeInstitutions folds the sources into the target: their interns,
+// campus accounts, agreements, contacts and aliases move over, their names
+// become aliases of the target, and the sources are deleted
+func MergeInstitutions(db *sql.DB, targetID int64, sourceIDs []int64, mergedBy int64) (int64, error) {
+	if len(sourceIDs) == 0 {
+		return 0, fmt.Errorf("%w: source_ids is required", ErrInvalidInstitution)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM institutions WHERE id = ?", targetID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, sql.ErrNoRows
+	}
+	var total int64
+	now := time.Now()
+	for _, sourceID := range sourceIDs {
+		if sourceID == targetID {
+			return 0, fmt.Errorf("%w: cannot merge an institution into itself", ErrInvalidInstitution)
+		}
+		var name string
+		var shortName sql.NullString
+		err := tx.QueryRow("SELECT name, short_name FROM institutions WHERE id = ?", sourceID).Scan(&name, &shortName)
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("%w: institution %d not found", ErrInvalidInstitution, sourceID)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		res, err := tx.Exec("UPDATE interns SET institution_id = ? WHERE institution_id = ?", targetID, sourceID)
+		if err != nil {
+			return 0, err
+		}
+		moved, _ := res.RowsAffected()
+		total += moved
+		// The target keeps its own primary contact
+		var hasPrimary int
+		if err := tx.QueryRow(
+			"SELECT COUNT(*) FROM institution_contacts WHERE institution_id = ? AND is_primary = TRUE", targetID,
+		).Scan(&hasPrimary); err != nil {
+			return 0, err
+		}
+		if hasPrimary > 0 {
+			if _, err := tx.Exec("UPDATE institution_contacts SET is_primary = FALSE WHERE institution_id = ?", sourceID); err != nil {
+				return 0, err
+			}
+		}
+		for _, q := range []string{
+			"UPDATE users SET institution_id = ? WHERE institution_id = ?",
+			"UPDATE institution_agreements SET institution_id = ? WHERE institution_id = ?",
+			"UPDATE institution_contacts SET institution_id = ? WHERE institution_id = ?",
+			"UPDATE institution_aliases SET institution_id = ? WHERE institution_id = ?",
+			"UPDATE rubric_assignments SET institution_id = ? WHERE institution_id = ?",
+		} {
+			if _, err := tx.Exec(q, targetID, sourceID); err != nil {
+				return 0, err
+			}
+		}
+		if err := addAlias(tx, targetID, name, true); err != nil {
+			return 0, err
+		}
+		if shortName.Valid {
+			if err := addAlias(tx, targetID, shortName.String, true); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO institution_merges (source_id, source_name, target_id, interns_moved, merged_by, merged_at)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			sourceID, name, targetID, moved, mergedBy, now,
+		); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec("DELETE FROM institutions WHERE id = ?", sourceID); err != nil {
+			return 0, err
+		}
+	}
+	return total, tx.Commit()
+}
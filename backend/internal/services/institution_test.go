@@ -0,0 +1,74 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestNormalizeInstitutionName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Universitas Diponegoro", "universitas diponegoro"},
+		{"UNIVERSITAS DIPONEGORO", "universitas diponegoro"},
+		{"Univ. Diponegoro", "universitas diponegoro"},
+		{"  univ  diponegoro  ", "universitas diponegoro"},
+		{"Poltek N. Semarang", "politeknik negeri semarang"},
+		{"Politeknik Negeri Semarang", "politeknik negeri semarang"},
+		{"SMKN 1 Semarang", "smk negeri 1 semarang"},
+		{"SMK Negeri 1, Semarang", "smk negeri 1 semarang"},
+		{"Inst. Teknologi Sepuluh-Nopember", "institut teknologi sepuluh nopember"},
+		{"Universitas Katolik Soegijapranata (UNIKA)", "universitas katolik soegijapranata unika"},
+		{"", ""},
+		{" .,- ", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeInstitutionName(tt.name); got != tt.want {
+			t.Errorf("NormalizeInstitutionName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInstitutionNameMatches(t *testing.T) {
+	short := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	tests := []struct {
+		school    string
+		instName  string
+		shortName sql.NullString
+		want      bool
+	}{
+		{"Univ. Diponegoro", "Universitas Diponegoro", short("UNDIP"), true},
+		{"universitas diponegoro", "Universitas Diponegoro", sql.NullString{}, true},
+		{"Undip", "Universitas Diponegoro", short("UNDIP"), true},
+		{"U.N.D.I.P", "Universitas Diponegoro", short("UNDIP"), false},
+		{"SMKN 1 Semarang", "SMK Negeri 1 Semarang", sql.NullString{}, true},
+		{"SMKN 2 Semarang", "SMK Negeri 1 Semarang", sql.NullString{}, false},
+		{"Universitas Negeri Semarang", "Universitas Semarang", short("USM"), false},
+		{"Diponegoro", "Universitas Diponegoro", sql.NullString{}, false},
+	}
+	for _, tt := range tests {
+		got := institutionNameMatches(NormalizeInstitutionName(tt.school), tt.instName, tt.shortName)
+		if got != tt.want {
+			t.Errorf("institutionNameMatches(%q, %q, %q) = %v, want %v", tt.school, tt.instName, tt.shortName.String, got, tt.want)
+		}
+	}
+}
+
+func TestInstitutionKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Universitas Diponegoro", "diponegoro"},
+		{"Univ. Diponegoro", "diponegoro"},
+		{"Sekolah Tinggi Ilmu Statistik", "ilmu statistik"},
+		{"Politeknik Negeri Semarang", "negeri semarang"},
+		{"Universitas", ""},
+	}
+	for _, tt := range tests {
+		if got := institutionKey(tt.name); got != tt.want {
+			t.Errorf("institutionKey(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/jobs"
+	"dsi_interna_sys/internal/models"
+)
+
+// Background job types
+const (
+	JobAgendaReminders      = "agenda.reminders"
+	JobPublishAnnouncements = "announcements.publish"
+	JobTaskMaintenance      = "tasks.maintenance"
+	JobDocumentBatch        = "document_job"
+	JobReportDrafts         = "reports.auto_draft"
+	JobLogbookReminders     = "logbook.reminders"
+	JobEvaluationReminders  = "evaluations.reminders"
+	JobRiskScoring          = "interns.risk_scoring"
+	JobRollupRefresh        = "dashboard.rollup_refresh"
+	JobRollupReconcile      = "dashboard.rollup_reconcile"
+	JobReviewSLA            = "tasks.review_sla"
+	JobApplicationEmail     = "applications.email"
+	JobPruneJobs            = "jobs.prune"
+)
+
+// RegisterJobs wires the application's job handlers and recurring
+// schedules into the queue
+func RegisterJobs(q *jobs.Queue, db *sql.DB) error {
+	q.Register(JobAgendaReminders, func(ctx context.Context, job *models.Job) error {
+		checkAgendas(db)
+		return nil
+	})
+	q.Register(JobPublishAnnouncements, func(ctx context.Context, job *models.Job) error {
+		publishDueAnnouncements(db)
+		return nil
+	})
+	q.Register(JobTaskMaintenance, func(ctx context.Context, job *models.Job) error {
+		ExpandRecurringTasks(db)
+		ReleaseScheduledTasks(db)
+		return nil
+	})
+	q.Register(JobReportDrafts, func(ctx context.Context, job *models.Job) error {
+		AutoDraftReports(db, time.Now())
+		return nil
+	})
+	q.Register(JobLogbookReminders, func(ctx context.Context, job *models.Job) error {
+		SendLogbookReminders(db, time.Now())
+		return nil
+	})
+	q.Register(JobEvaluationReminders, func(ctx context.Context, job *models.Job) error {
+		SendEvaluationReminders(db, time.Now())
+		return nil
+	})
+	q.Register(JobRiskScoring, func(ctx context.Context, job *models.Job) error {
+		RefreshRiskScores(db, time.Now())
+		return nil
+	})
+	q.Register(JobRollupReconcile, func(ctx context.Context, job *models.Job) error {
+		ReconcileDashboardRollups(db, time.Now())
+		return nil
+	})
+	jobs.Handle(q, JobRollupRefresh, handleRollupRefresh(db))
+	q.Register(JobReviewSLA, func(ctx context.Context, job *models.Job) error {
+		CheckReviewSLA(db, time.Now())
+		return nil
+	})
+	jobs.Handle(q, JobApplicationEmail, handleApplicationEmail)
+	q.Register(JobPruneJobs, func(ctx context.Context, job *models.Job) error {
+		pruneJobs(db, time.Now())
+		return nil
+	})
+	// Batch documents are heavy; one at a time per replica keeps them from
+	// competing with API requests for the database
+	jobs.Handle(q, JobDocumentBatch, handleDocumentJob(db), jobs.MaxConcurrent(1))
+
+	schedules := []struct{ name, spec, jobType string }{
+		{"agenda-reminders", "* * * * *", JobAgendaReminders},
+		{"publish-announcements", "* * * * *", JobPublishAnnouncements},
+		{"task-maintenance", "*/5 * * * *", JobTaskMaintenance},
+		{"report-drafts", "0 1 * * *", JobReportDrafts},
+		{"logbook-reminders", "0 16 * * *", JobLogbookReminders},
+		{"evaluation-reminders", "0 8 * * *", JobEvaluationReminders},
+		{"risk-scoring", "30 1 * * *", JobRiskScoring},
+		{"dashboard-rollups", "5 0 * * *", JobRollupReconcile},
+		{"review-sla", "15 * * * *", JobReviewSLA},
+		{"prune-jobs", "45 2 * * *", JobPruneJobs},
+	}
+	for _, s := range schedules {
+		if err := q.Schedule(s.name, s.spec, s.jobType, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneJobs removes finished jobs older than the configured retention
+func pruneJobs(db *sql.DB, now time.Time) {
+	days := 14
+	if config.Loaded != nil && config.Loaded.Jobs.RetentionDays > 0 {
+		days = config.Loaded.Jobs.RetentionDays
+	}
+	removed, err := jobs.Prune(db, now.AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Error pruning finished jobs: %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("Pruned %d finished jobs older than %d days", removed, days)
+	}
+}
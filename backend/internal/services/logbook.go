@@ -0,0 +1,321 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+// LoadLogbookTasks returns the linked tasks of each entry, keyed by entry id
+func LoadLogbookTasks(db *sql.DB, entryIDs []int64) (map[int64][]models.LogbookTask, error) {
+	tasks := map[int64][]models.LogbookTask{}
+	if len(entryIDs) == 0 {
+		return tasks, nil
+	}
+
+	args := make([]interface{}, len(entryIDs))
+	for i, id := range entryIDs {
+		args[i] = id
+	}
+	rows, err := db.Query(
+		`SELECT lt.entry_id, t.id, t.title, t.status
+		 FROM logbook_entry_tasks lt
+		 JOIN tasks t ON lt.task_id = t.id
+		 WHERE lt.entry_id IN (?`+strings.Repeat(", ?", len(entryIDs)-1)+`)
+		 ORDER BY t.title`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entryID int64
+		var t models.LogbookTask
+		if err := rows.Scan(&entryID, &t.ID, &t.Title, &t.Status); err != nil {
+			continue
+		}
+		tasks[entryID] = append(tasks[entryID], t)
+	}
+	return tasks, nil
+}
+
+// logbookDay is one row of the monthly logbook
+type logbookDay struct {
+	date       time.Time
+	entry      *models.LogbookEntry
+	attendance string
+}
+
+// RenderLogbookPDF prints an intern's logbook for the month containing
+// month. Every workday of the internship in that month gets a row so
+// missing entries are visible to the school.
+func RenderLogbookPDF(db *sql.DB, internID int64, month time.Time) (*gofpdf.Fpdf, error) {
+	var startDate, endDate time.Time
+	if err := db.QueryRow("SELECT start_date, end_date FROM interns WHERE id = ?", internID).Scan(&startDate, &endDate); err != nil {
+		return nil, err
+	}
+	values, err := DocumentValues(db, internID)
+	if err != nil {
+		return nil, err
+	}
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.Local)
+	last := first.AddDate(0, 1, -1)
+
+	entries := map[string]*models.LogbookEntry{}
+	var entryIDs []int64
+	rows, err := db.Query(
+		`SELECT id, date, activities, hours, status, acknowledged_at
+		 FROM logbook_entries
+		 WHERE intern_id = ? AND date BETWEEN ? AND ?`,
+		internID, first.Format("2006-01-02"), last.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var e models.LogbookEntry
+		var ackAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.Date, &e.Activities, &e.Hours, &e.Status, &ackAt); err != nil {
+			continue
+		}
+		if ackAt.Valid {
+			e.AcknowledgedAt = &ackAt.Time
+		}
+		entries[e.Date.Format("2006-01-02")] = &e
+		entryIDs = append(entryIDs, e.ID)
+	}
+	rows.Close()
+
+	tasks, err := LoadLogbookTasks(db, entryIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance := map[string]string{}
+	rows, err = db.Query(
+		`SELECT date, status, check_in_time, check_out_time FROM attendances
+		 WHERE intern_id = ? AND date BETWEEN ? AND ?`,
+		internID, first.Format("2006-01-02"), last.Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var status string
+		var checkIn, checkOut sql.NullTime
+		if err := rows.Scan(&date, &status, &checkIn, &checkOut); err != nil {
+			continue
+		}
+		label := attendanceLabels[status]
+		if label == "" {
+			label = status
+		}
+		if checkIn.Valid {
+			label = checkIn.Time.Format("15:04")
+			if checkOut.Valid {
+				label += " - " + checkOut.Time.Format("15:04")
+			}
+		}
+		attendance[date.Format("2006-01-02")] = label
+	}
+	rows.Close()
+
+	cal := LoadWorkCalendar(db)
+	var days []logbookDay
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		if key < startDate.Format("2006-01-02") || key > endDate.Format("2006-01-02") {
+			continue
+		}
+		entry := entries[key]
+		if entry == nil && cal.IsOffDay(d) {
+			continue
+		}
+		if entry != nil {
+			entry.Tasks = tasks[entry.ID]
+		}
+		days = append(days, logbookDay{date: d, entry: entry, attendance: attendance[key]})
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(false, 15)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, tr(values["organization"]), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 8, tr("Jurnal Harian Magang"), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 6, tr(indonesianMonths[first.Month()-1]+" "+fmt.Sprint(first.Year())), "", 1, "C", false, 0, "")
+	pdf.Ln(3)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range [][2]string{
+		{"Nama", values["intern_name"]},
+		{"NIS/NIM", values["nis"]},
+		{"Sekolah/Kampus", values["school"]},
+		{"Divisi", values["department"]},
+		{"Pembimbing", values["supervisor_name"]},
+	} {
+		pdf.CellFormat(35, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(": "+row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	widths := []float64{8, 28, 24, 84, 12, 24}
+	header := []string{"No", "Tanggal", "Kehadiran", "Kegiatan", "Jam", "Paraf"}
+	drawHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, h := range header {
+			pdf.CellFormat(widths[i], 7, h, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+	drawHeader()
+
+	const lineHeight = 4.5
+	var totalHours float64
+	var filled, acknowledged int
+	for i, day := range days {
+		activity := "-"
+		hours := ""
+		sign := ""
+		if day.entry != nil {
+			filled++
+			totalHours += day.entry.Hours
+			activity = day.entry.Activities
+			if len(day.entry.Tasks) > 0 {
+				titles := make([]string, len(day.entry.Tasks))
+				for j, t := range day.entry.Tasks {
+					titles[j] = t.Title
+				}
+				activity += "\nTugas: " + strings.Join(titles, ", ")
+			}
+			hours = fmt.Sprintf("%.1f", day.entry.Hours)
+			if day.entry.Status == models.LogbookAcknowledged {
+				acknowledged++
+				sign = "Diketahui"
+			}
+		}
+		attendanceText := day.attendance
+		if attendanceText == "" {
+			attendanceText = "-"
+		}
+
+		cells := []string{fmt.Sprint(i + 1), FormatDateID(day.date), attendanceText, activity, hours, sign}
+		aligns := []string{"C", "L", "C", "L", "C", "C"}
+		lines := 1
+		for j, text := range cells {
+			if n := len(pdf.SplitLines([]byte(tr(text)), widths[j]-2)); n > lines {
+				lines = n
+			}
+		}
+		height := float64(lines)*lineHeight + 2
+
+		if pdf.GetY()+height > 280 {
+			pdf.AddPage()
+			drawHeader()
+		}
+		x, y := pdf.GetXY()
+		for j, text := range cells {
+			pdf.Rect(x, y, widths[j], height, "D")
+			pdf.SetXY(x+1, y+1)
+			pdf.MultiCell(widths[j]-2, lineHeight, tr(text), "", aligns[j], false)
+			x += widths[j]
+		}
+		pdf.SetXY(15, y+height)
+	}
+
+	pdf.Ln(4)
+	if pdf.GetY() > 230 {
+		pdf.AddPage()
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Hari kerja: %d    Terisi: %d    Diketahui pembimbing: %d    Total jam: %.1f",
+		len(days), filled, acknowledged, totalHours), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	top := pdf.GetY()
+	pdf.SetXY(15, top)
+	pdf.CellFormat(80, 6, "Peserta Magang", "", 2, "C", false, 0, "")
+	pdf.Ln(18)
+	pdf.SetX(15)
+	pdf.CellFormat(80, 6, tr(values["intern_name"]), "", 0, "C", false, 0, "")
+
+	pdf.SetXY(115, top)
+	pdf.CellFormat(80, 6, "Mengetahui, Pembimbing", "", 2, "C", false, 0, "")
+	pdf.Ln(18)
+	pdf.SetX(115)
+	pdf.CellFormat(80, 6, tr(values["supervisor_name"]), "", 0, "C", false, 0, "")
+
+	return pdf, nil
+}
+
+// attendanceLabels describes days without a check-in
+var attendanceLabels = map[string]string{
+	"absent":     "Alpa",
+	"sick":       "Sakit",
+	"permission": "Izin",
+	"on_leave":   "Cuti",
+	"excused":    "Izin",
+}
+
+// SendLogbookReminders notifies active interns who have not written
+// today's logbook entry. Off days and days the intern is away (sick,
+// permission, leave) are skipped.
+func SendLogbookReminders(db *sql.DB, now time.Time) {
+	var enabled string
+	if err := db.QueryRow("SELECT `value` FROM settings WHERE `key` = 'logbook_reminder_enabled'").Scan(&enabled); err == nil && strings.TrimSpace(enabled) != "true" {
+		return
+	}
+	if LoadWorkCalendar(db).IsOffDay(now) {
+		return
+	}
+
+	today := now.Format("2006-01-02")
+	rows, err := db.Query(
+		`SELECT i.user_id FROM interns i
+		 WHERE i.status = 'active' AND i.start_date <= ? AND i.end_date >= ?
+		   AND NOT EXISTS (SELECT 1 FROM logbook_entries l WHERE l.intern_id = i.id AND l.date = ?)
+		   AND NOT EXISTS (
+		       SELECT 1 FROM attendances a
+		       WHERE a.intern_id = i.id AND a.date = ? AND a.status IN ('sick', 'permission', 'on_leave', 'excused')
+		   )`,
+		today, today, today, today,
+	)
+	if err != nil {
+		log.Printf("Error selecting interns for logbook reminders: %v", err)
+		return
+	}
+	var users []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			users = append(users, id)
+		}
+	}
+	rows.Close()
+
+	for _, userID := range users {
+		if err := SendNotification(
+			db, userID, "info", "Jurnal Harian Belum Diisi",
+			"Anda belum mengisi jurnal harian untuk hari ini. Silakan isi sebelum pulang.",
+			"/logbook",
+		); err != nil {
+			log.Printf("Error sending logbook reminder to user %d: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// SendNotification inserts a notification and forwards a copy to the user's
+// delegate while they are away. An empty link is stored as NULL.
+func SendNotification(db *sql.DB, userID int64, typ, title, message, link string) error {
+	if _, err := db.Exec(
+		`INSERT INTO notifications (user_id, type, title, message, link, is_read, created_at)
+		 VALUES (?, ?, ?, ?, ?, FALSE, ?)`,
+		userID, typ, title, message, nullString(link), time.Now(),
+	); err != nil {
+		return err
+	}
+	ForwardNotification(db, userID, typ, title, message, nullString(link))
+	return nil
+}
+
+// notify inserts an info notification, logging failures
+func notify(db *sql.DB, userID int64, title, message, link string) {
+	if err := SendNotification(db, userID, "info", title, message, link); err != nil {
+		log.Printf("Error notifying user %d: %v", userID, err)
+	}
+}
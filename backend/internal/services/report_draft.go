@@ -0,0 +1,406 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/models"
+)
+
+var ErrReportExists = errors.New("a report for this period already exists")
+
+var reportTypeLabels = map[string]string{
+	"weekly":  "Mingguan",
+	"monthly": "Bulanan",
+	"final":   "Akhir",
+}
+
+// ReportPeriod returns the week (Monday to Sunday) or calendar month that
+// contains ref
+func ReportPeriod(reportType string, ref time.Time) (time.Time, time.Time) {
+	day := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, ref.Location())
+	if reportType == "monthly" {
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return start, start.AddDate(0, 1, -1)
+	}
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	start := day.AddDate(0, 0, -offset)
+	return start, start.AddDate(0, 0, 6)
+}
+
+// FinalReportPeriod is the whole internship of the intern
+func FinalReportPeriod(db *sql.DB, internID int64) (time.Time, time.Time, error) {
+	var start, end time.Time
+	err := db.QueryRow("SELECT start_date, end_date FROM interns WHERE id = ?", internID).Scan(&start, &end)
+	return start, end, err
+}
+
+// FindReport returns the id of the intern's report of this type and period
+func FindReport(db *sql.DB, internID int64, reportType string, start, end time.Time) (int64, bool) {
+	var id int64
+	err := db.QueryRow(
+		"SELECT id FROM reports WHERE intern_id = ? AND type = ? AND period_start = ? AND period_end = ? ORDER BY id LIMIT 1",
+		internID, reportType, start.Format("2006-01-02"), end.Format("2006-01-02"),
+	).Scan(&id)
+	return id, err == nil
+}
+
+// CollectReportActivity summarizes attendance, tasks and assessments of an
+// intern between start and end (inclusive)
+func CollectReportActivity(db *sql.DB, internID int64, start, end time.Time) (models.ReportActivity, error) {
+	activity := models.ReportActivity{
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   end.Format("2006-01-02"),
+		Tasks:       models.ReportTaskSummary{Items: []models.ReportTaskItem{}},
+		Assessments: models.ReportAssessmentSummary{Items: []models.ReportAssessmentItem{}},
+	}
+	from, to := activity.PeriodStart, activity.PeriodEnd
+	// Upper bound for DATETIME columns
+	until := end.AddDate(0, 0, 1)
+
+	checkIn := "08:30:00"
+	if config.Loaded != nil && config.Loaded.Office.CheckInTime != "" {
+		checkIn = config.Loaded.Office.CheckInTime
+	}
+
+	att := &activity.Attendance
+	var total int
+	var minutes, lateMinutes sql.NullFloat64
+	if err := db.QueryRow(
+		`SELECT COUNT(*),
+		        COALESCE(SUM(status = 'present'), 0),
+		        COALESCE(SUM(status = 'late'), 0),
+		        COALESCE(SUM(status = 'absent'), 0),
+		        COALESCE(SUM(status IN ('sick', 'permission', 'on_leave', 'excused')), 0),
+		        SUM(CASE WHEN check_in_time IS NOT NULL AND check_out_time IS NOT NULL
+		                 THEN TIMESTAMPDIFF(MINUTE, check_in_time, check_out_time) END),
+		        SUM(CASE WHEN status = 'late' AND check_in_time IS NOT NULL
+		                 THEN GREATEST(TIMESTAMPDIFF(MINUTE, TIMESTAMP(date, ?), check_in_time), 0) END)
+		 FROM attendances
+		 WHERE intern_id = ? AND date BETWEEN ? AND ?`,
+		checkIn, internID, from, to,
+	).Scan(&total, &att.Present, &att.Late, &att.Absent, &att.Excused, &minutes, &lateMinutes); err != nil {
+		return activity, err
+	}
+	att.Hours = math.Round(minutes.Float64/60*10) / 10
+	att.LateMinutes = int(lateMinutes.Float64)
+	if total > 0 {
+		att.Rate = math.Round(float64(att.Present+att.Late)/float64(total)*1000) / 10
+	}
+
+	tasks := &activity.Tasks
+	rows, err := db.Query(
+		`SELECT t.id, t.title, COALESCE(t.completed_at, t.approved_at), t.score, t.is_late,
+		        (SELECT COUNT(*) FROM task_reviews r WHERE r.task_id = t.id AND r.action = 'revision')
+		 FROM tasks t
+		 WHERE t.intern_id = ? AND t.status = 'completed'
+		   AND COALESCE(t.completed_at, t.approved_at) >= ? AND COALESCE(t.completed_at, t.approved_at) < ?
+		 ORDER BY COALESCE(t.completed_at, t.approved_at) ASC`,
+		internID, start, until,
+	)
+	if err != nil {
+		return activity, err
+	}
+	scoreSum, scored := 0, 0
+	for rows.Next() {
+		var item models.ReportTaskItem
+		var score sql.NullInt64
+		if err := rows.Scan(&item.ID, &item.Title, &item.CompletedAt, &score, &item.IsLate, &item.Revisions); err != nil {
+			continue
+		}
+		if score.Valid {
+			v := int(score.Int64)
+			item.Score = &v
+			scoreSum += v
+			scored++
+		}
+		tasks.Completed++
+		if item.IsLate {
+			tasks.CompletedLate++
+		}
+		tasks.Items = append(tasks.Items, item)
+	}
+	rows.Close()
+	if scored > 0 {
+		avg := math.Round(float64(scoreSum)/float64(scored)*10) / 10
+		tasks.AverageScore = &avg
+	}
+
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM tasks
+		 WHERE intern_id = ? AND status IN ('in_progress', 'submitted', 'revision') AND start_date <= ?`,
+		internID, to,
+	).Scan(&tasks.InProgress); err != nil {
+		return activity, err
+	}
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM task_reviews r
+		 JOIN tasks t ON r.task_id = t.id
+		 WHERE t.intern_id = ? AND r.action = 'revision' AND r.reviewed_at >= ? AND r.reviewed_at < ?`,
+		internID, start, until,
+	).Scan(&tasks.Revisions); err != nil {
+		return activity, err
+	}
+
+	assessments := &activity.Assessments
+	rows, err = db.Query(
+		`SELECT aspect, score, assessment_date, COALESCE(notes, '')
+		 FROM assessments
+		 WHERE intern_id = ? AND assessment_date BETWEEN ? AND ?
+		 ORDER BY assessment_date ASC`,
+		internID, from, to,
+	)
+	if err != nil {
+		return activity, err
+	}
+	defer rows.Close()
+	scoreSum = 0
+	for rows.Next() {
+		var item models.ReportAssessmentItem
+		if err := rows.Scan(&item.Aspect, &item.Score, &item.Date, &item.Notes); err != nil {
+			continue
+		}
+		scoreSum += item.Score
+		assessments.Items = append(assessments.Items, item)
+	}
+	assessments.Count = len(assessments.Items)
+	if assessments.Count > 0 {
+		avg := math.Round(float64(scoreSum)/float64(assessments.Count)*10) / 10
+		assessments.AverageScore = &avg
+	}
+
+	return activity, nil
+}
+
+// ReportDraftTitle names a generated report, e.g. "Laporan Bulanan Januari 2026"
+func ReportDraftTitle(reportType string, start, end time.Time) string {
+	switch reportType {
+	case "monthly":
+		return fmt.Sprintf("Laporan Bulanan %s %d", indonesianMonths[start.Month()-1], start.Year())
+	case "final":
+		return "Laporan Akhir Magang"
+	}
+	return fmt.Sprintf("Laporan Mingguan %s - %s", FormatDateID(start), FormatDateID(end))
+}
+
+// ComposeReportDraft writes the editable report text for an activity summary
+func ComposeReportDraft(reportType string, activity models.ReportActivity) string {
+	start, _ := time.Parse("2006-01-02", activity.PeriodStart)
+	end, _ := time.Parse("2006-01-02", activity.PeriodEnd)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Periode: %s - %s\n\n", FormatDateID(start), FormatDateID(end))
+
+	att := activity.Attendance
+	b.WriteString("KEHADIRAN\n")
+	fmt.Fprintf(&b, "- Hadir tepat waktu: %d hari\n", att.Present)
+	fmt.Fprintf(&b, "- Terlambat: %d hari", att.Late)
+	if att.LateMinutes > 0 {
+		fmt.Fprintf(&b, " (total %d menit)", att.LateMinutes)
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "- Tidak hadir: %d hari\n", att.Absent)
+	if att.Excused > 0 {
+		fmt.Fprintf(&b, "- Izin/sakit/cuti: %d hari\n", att.Excused)
+	}
+	fmt.Fprintf(&b, "- Total jam kerja: %s jam\n", strconv.FormatFloat(att.Hours, 'f', -1, 64))
+	fmt.Fprintf(&b, "- Tingkat kehadiran: %s%%\n\n", strconv.FormatFloat(att.Rate, 'f', -1, 64))
+
+	tasks := activity.Tasks
+	b.WriteString("TUGAS\n")
+	fmt.Fprintf(&b, "- Tugas selesai: %d", tasks.Completed)
+	if tasks.CompletedLate > 0 {
+		fmt.Fprintf(&b, " (%d terlambat)", tasks.CompletedLate)
+	}
+	b.WriteString("\n")
+	if tasks.AverageScore != nil {
+		fmt.Fprintf(&b, "- Rata-rata nilai tugas: %s\n", strconv.FormatFloat(*tasks.AverageScore, 'f', -1, 64))
+	}
+	fmt.Fprintf(&b, "- Revisi diminta: %d kali\n", tasks.Revisions)
+	fmt.Fprintf(&b, "- Tugas masih berjalan: %d\n", tasks.InProgress)
+	for _, t := range tasks.Items {
+		line := fmt.Sprintf("  * %s (selesai %s", t.Title, FormatDateID(t.CompletedAt))
+		if t.Score != nil {
+			line += fmt.Sprintf(", nilai %d", *t.Score)
+		}
+		if t.IsLate {
+			line += ", terlambat"
+		}
+		if t.Revisions > 0 {
+			line += fmt.Sprintf(", %d revisi", t.Revisions)
+		}
+		b.WriteString(line + ")\n")
+	}
+	b.WriteString("\n")
+
+	assessments := activity.Assessments
+	if assessments.Count > 0 {
+		b.WriteString("PENILAIAN\n")
+		for _, a := range assessments.Items {
+			fmt.Fprintf(&b, "- %s: %d (%s)", a.Aspect, a.Score, FormatDateID(a.Date))
+			if a.Notes != "" {
+				fmt.Fprintf(&b, " - %s", a.Notes)
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "- Rata-rata: %s\n\n", strconv.FormatFloat(*assessments.AverageScore, 'f', -1, 64))
+	}
+
+	b.WriteString("KEGIATAN DAN PEMBELAJARAN\n- \n\n")
+	b.WriteString("KENDALA\n- \n")
+	if reportType != "final" {
+		b.WriteString("\nRENCANA PERIODE BERIKUTNYA\n- \n")
+	} else {
+		b.WriteString("\nKESIMPULAN\n- \n")
+	}
+	return b.String()
+}
+
+// CreateReportDraft generates a draft report for the period. It fails with
+// ErrReportExists when the intern already has a report for it.
+func CreateReportDraft(db *sql.DB, internID int64, reportType string, start, end time.Time, createdBy int64) (int64, error) {
+	if _, exists := FindReport(db, internID, reportType, start, end); exists {
+		return 0, ErrReportExists
+	}
+
+	activity, err := CollectReportActivity(db, internID, start, end)
+	if err != nil {
+		return 0, err
+	}
+	summary, _ := json.Marshal(activity)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`INSERT INTO reports (intern_id, created_by, title, content, type, period_start, period_end, status, auto_generated, activity_summary)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, 'draft', TRUE, ?)`,
+		internID, createdBy, ReportDraftTitle(reportType, start, end), ComposeReportDraft(reportType, activity),
+		reportType, start.Format("2006-01-02"), end.Format("2006-01-02"), string(summary),
+	)
+	if err != nil {
+		return 0, err
+	}
+	reportID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := RecordReportEvent(tx, reportID, models.ReportActionCreate, "", models.ReportDraft, "generated from activity data", createdBy); err != nil {
+		return 0, err
+	}
+	return reportID, tx.Commit()
+}
+
+// AutoDraftReports creates drafts for the most recently finished week and
+// month, and final drafts for internships that ended in the last two
+// weeks, depending on the report_auto_draft_* settings. Existing reports
+// are left alone, so running it again is harmless.
+func AutoDraftReports(db *sql.DB, now time.Time) {
+	enabled := map[string]bool{}
+	rows, err := db.Query(
+		"SELECT `key`, `value` FROM settings WHERE `key` IN ('report_auto_draft_weekly', 'report_auto_draft_monthly', 'report_auto_draft_final')",
+	)
+	if err != nil {
+		log.Printf("Error loading report draft settings: %v", err)
+		return
+	}
+	for rows.Next() {
+		var key, value string
+		if rows.Scan(&key, &value) == nil {
+			enabled[strings.TrimPrefix(key, "report_auto_draft_")] = strings.TrimSpace(value) == "true"
+		}
+	}
+	rows.Close()
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if enabled["weekly"] {
+		start, end := ReportPeriod("weekly", today.AddDate(0, 0, -7))
+		autoDraftPeriod(db, "weekly", start, end)
+	}
+	if enabled["monthly"] {
+		start, end := ReportPeriod("monthly", time.Date(today.Year(), today.Month(), 0, 0, 0, 0, 0, today.Location()))
+		autoDraftPeriod(db, "monthly", start, end)
+	}
+	if enabled["final"] {
+		autoDraftFinal(db, today)
+	}
+}
+
+// autoDraftPeriod drafts a report for every intern whose internship
+// overlaps the period
+func autoDraftPeriod(db *sql.DB, reportType string, start, end time.Time) {
+	rows, err := db.Query(
+		`SELECT id, user_id FROM interns
+		 WHERE status IN ('active', 'completed') AND start_date <= ? AND end_date >= ?`,
+		end.Format("2006-01-02"), start.Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Printf("Error selecting interns for %s report drafts: %v", reportType, err)
+		return
+	}
+	createDraftsFor(db, rows, reportType, func(int64) (time.Time, time.Time) { return start, end })
+}
+
+func autoDraftFinal(db *sql.DB, today time.Time) {
+	rows, err := db.Query(
+		`SELECT id, user_id FROM interns
+		 WHERE status IN ('active', 'completed') AND end_date BETWEEN ? AND ?`,
+		today.AddDate(0, 0, -14).Format("2006-01-02"), today.AddDate(0, 0, -1).Format("2006-01-02"),
+	)
+	if err != nil {
+		log.Printf("Error selecting interns for final report drafts: %v", err)
+		return
+	}
+	createDraftsFor(db, rows, "final", func(internID int64) (time.Time, time.Time) {
+		start, end, _ := FinalReportPeriod(db, internID)
+		return start, end
+	})
+}
+
+// createDraftsFor drafts a report for each (intern id, user id) row. Drafts
+// belong to the intern, who completes and submits them.
+func createDraftsFor(db *sql.DB, rows *sql.Rows, reportType string, period func(int64) (time.Time, time.Time)) {
+	type target struct{ internID, userID int64 }
+	var targets []target
+	for rows.Next() {
+		var t target
+		if rows.Scan(&t.internID, &t.userID) == nil {
+			targets = append(targets, t)
+		}
+	}
+	rows.Close()
+
+	for _, t := range targets {
+		start, end := period(t.internID)
+		if start.IsZero() {
+			continue
+		}
+		id, err := CreateReportDraft(db, t.internID, reportType, start, end, t.userID)
+		if err == ErrReportExists {
+			continue
+		}
+		if err != nil {
+			log.Printf("Error drafting %s report for intern %d: %v", reportType, t.internID, err)
+			continue
+		}
+
+		if err := SendNotification(
+			db, t.userID, "info", "Draf Laporan "+reportTypeLabels[reportType],
+			"Draf laporan Anda telah dibuat dari data aktivitas. Silakan lengkapi dan kirimkan.",
+			"/reports/"+strconv.FormatInt(id, 10),
+		); err != nil {
+			log.Printf("Error notifying intern %d about report draft: %v", t.internID, err)
+		}
+	}
+}
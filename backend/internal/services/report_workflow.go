@@ -0,0 +1,398 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/config"
+	"dsi_interna_sys/internal/models"
+	"dsi_interna_sys/internal/utils"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+var (
+	ErrReportTransition = errors.New("report cannot take this step in its current status")
+	ErrReportLocked     = errors.New("report is locked; only drafts and reports sent back for revision can be edited")
+)
+
+// reportTransitions lists the statuses each action starts from and the
+// status it leads to
+var reportTransitions = map[string]struct {
+	from []string
+	to   string
+}{
+	models.ReportActionSubmit:          {[]string{models.ReportDraft, models.ReportRevisionRequested}, models.ReportSubmitted},
+	models.ReportActionRequestRevision: {[]string{models.ReportSubmitted}, models.ReportRevisionRequested},
+	models.ReportActionApprove:         {[]string{models.ReportSubmitted}, models.ReportApproved},
+	models.ReportActionCountersign:     {[]string{models.ReportApproved}, models.ReportCountersigned},
+}
+
+// ReportEditable reports whether content may still change in this status
+func ReportEditable(status string) bool {
+	return status == models.ReportDraft || status == models.ReportRevisionRequested
+}
+
+// ReportSigned reports whether the report carries an approval signature
+func ReportSigned(status string) bool {
+	return status == models.ReportApproved || status == models.ReportCountersigned
+}
+
+// TransitionReport applies a workflow action and records it. A note given
+// with a revision request or approval becomes the report feedback. The
+// previous status is returned for notifications.
+func TransitionReport(db *sql.DB, reportID int64, action string, actorID int64, note string) (string, error) {
+	rule, ok := reportTransitions[action]
+	if !ok {
+		return "", ErrReportTransition
+	}
+	if ReportSigned(rule.to) {
+		if err := CheckSigningKey(); err != nil {
+			return "", err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	status, err := TransitionReportTx(tx, reportID, action, actorID, note)
+	if err != nil {
+		return status, err
+	}
+	if err := tx.Commit(); err != nil {
+		return status, err
+	}
+
+	if ReportSigned(rule.to) {
+		if _, err := SignReport(db, reportID); err != nil {
+			return status, err
+		}
+	}
+	return status, nil
+}
+
+// TransitionReportTx applies a workflow action inside the caller's
+// transaction. Approval signatures are left to TransitionReport, which signs
+// once the transaction commits.
+func TransitionReportTx(tx *sql.Tx, reportID int64, action string, actorID int64, note string) (string, error) {
+	rule, ok := reportTransitions[action]
+	if !ok {
+		return "", ErrReportTransition
+	}
+
+	var status string
+	if err := tx.QueryRow("SELECT status FROM reports WHERE id = ? FOR UPDATE", reportID).Scan(&status); err != nil {
+		return "", err
+	}
+	allowed := false
+	for _, from := range rule.from {
+		allowed = allowed || from == status
+	}
+	if !allowed {
+		return status, ErrReportTransition
+	}
+
+	now := time.Now()
+	sets := []string{"status = ?"}
+	args := []interface{}{rule.to}
+	switch action {
+	case models.ReportActionSubmit:
+		sets = append(sets, "submitted_at = ?", "submitted_by = ?")
+		args = append(args, now, actorID)
+	case models.ReportActionRequestRevision, models.ReportActionApprove:
+		sets = append(sets, "reviewed_at = ?", "reviewed_by = ?")
+		args = append(args, now, actorID)
+		if strings.TrimSpace(note) != "" {
+			sets = append(sets, "feedback = ?")
+			args = append(args, strings.TrimSpace(note))
+		}
+	case models.ReportActionCountersign:
+		sets = append(sets, "countersigned_at = ?", "countersigned_by = ?")
+		args = append(args, now, actorID)
+	}
+	args = append(args, reportID)
+	if _, err := tx.Exec("UPDATE reports SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...); err != nil {
+		return status, err
+	}
+	if err := RecordReportEvent(tx, reportID, action, status, rule.to, note, actorID); err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// RecordReportEvent appends a step to the report history
+func RecordReportEvent(tx *sql.Tx, reportID int64, action, from, to, note string, actorID int64) error {
+	_, err := tx.Exec(
+		`INSERT INTO report_events (report_id, action, from_status, to_status, note, actor_id)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		reportID, action, nullString(from), to, nullString(note), nullActor(actorID),
+	)
+	return err
+}
+
+// reportSignatureFields are the values an approval signature covers
+type reportSignatureFields struct {
+	ID              int64
+	InternID        int64
+	Type            string
+	Title           string
+	Content         string
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	ReviewedBy      sql.NullInt64
+	ReviewedAt      sql.NullTime
+	CountersignedBy sql.NullInt64
+	CountersignedAt sql.NullTime
+}
+
+func (f reportSignatureFields) payload() string {
+	contentHash := sha256.Sum256([]byte(f.Title + "\n" + f.Content))
+	stamp := func(t sql.NullTime) string {
+		if !t.Valid {
+			return ""
+		}
+		return t.Time.UTC().Format(time.RFC3339)
+	}
+	return strings.Join([]string{
+		"report",
+		strconv.FormatInt(f.ID, 10),
+		strconv.FormatInt(f.InternID, 10),
+		f.Type,
+		f.PeriodStart.Format("2006-01-02"),
+		f.PeriodEnd.Format("2006-01-02"),
+		hex.EncodeToString(contentHash[:]),
+		strconv.FormatInt(f.ReviewedBy.Int64, 10),
+		stamp(f.ReviewedAt),
+		strconv.FormatInt(f.CountersignedBy.Int64, 10),
+		stamp(f.CountersignedAt),
+	}, "|")
+}
+
+func loadReportSignatureFields(db *sql.DB, reportID int64) (reportSignatureFields, error) {
+	var f reportSignatureFields
+	err := db.QueryRow(
+		`SELECT id, intern_id, type, title, content, period_start, period_end,
+		        reviewed_by, reviewed_at, countersigned_by, countersigned_at
+		 FROM reports WHERE id = ?`, reportID,
+	).Scan(&f.ID, &f.InternID, &f.Type, &f.Title, &f.Content, &f.PeriodStart, &f.PeriodEnd,
+		&f.ReviewedBy, &f.ReviewedAt, &f.CountersignedBy, &f.CountersignedAt)
+	return f, err
+}
+
+// SignReport signs the approved content and sign-offs of a report with the
+// certificate signing key
+func SignReport(db *sql.DB, reportID int64) (string, error) {
+	fields, err := loadReportSignatureFields(db, reportID)
+	if err != nil {
+		return "", err
+	}
+	signature, err := SignCertificatePayload(fields.payload())
+	if err != nil {
+		return "", err
+	}
+	if _, err := db.Exec("UPDATE reports SET signature = ? WHERE id = ?", signature, reportID); err != nil {
+		return "", err
+	}
+	return signature, nil
+}
+
+// ReportVerifyURL is the public link printed on signed report PDFs
+func ReportVerifyURL(reportID int64, signature string) string {
+	link := strings.TrimRight(config.Loaded.App.PublicURL, "/") + "/api/verify-report/" + strconv.FormatInt(reportID, 10)
+	if len(signature) >= SignatureFingerprintLen {
+		link += "?sig=" + signature[:SignatureFingerprintLen]
+	}
+	return link
+}
+
+// VerifyReport checks the stored signature of a report against its
+// current content. fingerprint is the optional prefix from the QR code.
+func VerifyReport(db *sql.DB, reportID int64, fingerprint string) (*models.ReportVerification, error) {
+	var status, internName string
+	var signature, approvedBy, countersignedBy sql.NullString
+	err := db.QueryRow(
+		`SELECT r.status, r.signature, i.full_name, ru.name, cu.name
+		 FROM reports r
+		 JOIN interns i ON r.intern_id = i.id
+		 LEFT JOIN users ru ON r.reviewed_by = ru.id
+		 LEFT JOIN users cu ON r.countersigned_by = cu.id
+		 WHERE r.id = ?`, reportID,
+	).Scan(&status, &signature, &internName, &approvedBy, &countersignedBy)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := loadReportSignatureFields(db, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ReportVerification{
+		ReportID:     reportID,
+		Title:        fields.Title,
+		Type:         fields.Type,
+		InternName:   internName,
+		PeriodStart:  fields.PeriodStart,
+		PeriodEnd:    fields.PeriodEnd,
+		ReportStatus: status,
+	}
+	if ReportSigned(status) {
+		result.ApprovedBy = approvedBy.String
+		if fields.ReviewedAt.Valid {
+			result.ApprovedAt = &fields.ReviewedAt.Time
+		}
+	}
+	if status == models.ReportCountersigned {
+		result.CountersignedBy = countersignedBy.String
+		if fields.CountersignedAt.Valid {
+			result.CountersignedAt = &fields.CountersignedAt.Time
+		}
+	}
+
+	expected, err := SignCertificatePayload(fields.payload())
+	if err != nil {
+		return nil, err
+	}
+	result.SignatureValid = signature.Valid && hmac.Equal([]byte(signature.String), []byte(expected))
+	fingerprint = strings.ToLower(strings.TrimSpace(fingerprint))
+	fingerprintOK := fingerprint == "" ||
+		(signature.Valid && len(fingerprint) <= len(signature.String) &&
+			hmac.Equal([]byte(fingerprint), []byte(signature.String[:len(fingerprint)])))
+
+	switch {
+	case !ReportSigned(status) || !signature.Valid:
+		result.Status = "unsigned"
+		result.Message = "This report has not been approved"
+	case !result.SignatureValid || !fingerprintOK:
+		result.Status = "modified"
+		result.Message = "This document does not match the approved report"
+	default:
+		result.Status = "valid"
+		result.Message = "This report was approved and has not been modified"
+	}
+	return result, nil
+}
+
+// RenderSignedReport renders an approved report with its sign-offs, the
+// signature fingerprint and a verification QR code
+func RenderSignedReport(db *sql.DB, reportID int64) (*gofpdf.Fpdf, error) {
+	var internID int64
+	var status string
+	var signature, approvedBy, countersignedBy sql.NullString
+	if err := db.QueryRow(
+		`SELECT r.intern_id, r.status, r.signature, ru.name, cu.name
+		 FROM reports r
+		 LEFT JOIN users ru ON r.reviewed_by = ru.id
+		 LEFT JOIN users cu ON r.countersigned_by = cu.id
+		 WHERE r.id = ?`, reportID,
+	).Scan(&internID, &status, &signature, &approvedBy, &countersignedBy); err != nil {
+		return nil, err
+	}
+	if !ReportSigned(status) {
+		return nil, ErrReportTransition
+	}
+	if !signature.Valid {
+		sig, err := SignReport(db, reportID)
+		if err != nil {
+			return nil, err
+		}
+		signature = sql.NullString{String: sig, Valid: true}
+	}
+
+	fields, err := loadReportSignatureFields(db, reportID)
+	if err != nil {
+		return nil, err
+	}
+	values, err := DocumentValues(db, internID)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, tr(values["organization"]), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.MultiCell(0, 8, tr(fields.Title), "", "C", false)
+	pdf.Ln(3)
+
+	pdf.SetFont("Helvetica", "", 10)
+	rows := [][2]string{
+		{"Nama", values["intern_name"]},
+		{"NIS/NIM", values["nis"]},
+		{"Sekolah/Kampus", values["school"]},
+		{"Divisi", values["department"]},
+		{"Jenis Laporan", reportTypeLabels[fields.Type]},
+		{"Periode", FormatDateID(fields.PeriodStart) + " - " + FormatDateID(fields.PeriodEnd)},
+	}
+	for _, row := range rows {
+		pdf.CellFormat(40, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, tr(": "+row[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+	pdf.Line(20, pdf.GetY(), 190, pdf.GetY())
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.MultiCell(0, 5, tr(fields.Content), "", "L", false)
+	pdf.Ln(8)
+
+	// Sign-off block
+	if pdf.GetY() > 230 {
+		pdf.AddPage()
+	}
+	top := pdf.GetY()
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetXY(20, top)
+	pdf.CellFormat(80, 6, "Disetujui oleh Pembimbing", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(80, 6, tr(approvedBy.String), "", 2, "L", false, 0, "")
+	if fields.ReviewedAt.Valid {
+		pdf.CellFormat(80, 6, FormatDateID(fields.ReviewedAt.Time), "", 2, "L", false, 0, "")
+	}
+
+	pdf.SetXY(110, top)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(80, 6, "Disahkan oleh Admin", "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	if status == models.ReportCountersigned {
+		pdf.CellFormat(80, 6, tr(countersignedBy.String), "", 2, "L", false, 0, "")
+		if fields.CountersignedAt.Valid {
+			pdf.CellFormat(80, 6, FormatDateID(fields.CountersignedAt.Time), "", 2, "L", false, 0, "")
+		}
+	} else {
+		pdf.CellFormat(80, 6, "Belum disahkan", "", 2, "L", false, 0, "")
+	}
+
+	// Verification
+	link := ReportVerifyURL(reportID, signature.String)
+	png, err := utils.QRCodePNG(link, 256)
+	if err != nil {
+		return nil, err
+	}
+	qrY := top + 24
+	pdf.RegisterImageOptionsReader("report-qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	pdf.ImageOptions("report-qr", 20, qrY, 25, 25, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	pdf.SetXY(48, qrY+4)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(0, 4, fmt.Sprintf(
+		"Tanda tangan digital: %s\nPindai kode QR atau buka tautan berikut untuk memverifikasi keaslian laporan:\n%s",
+		strings.ToUpper(signature.String[:SignatureFingerprintLen]), link,
+	), "", "L", false)
+
+	return pdf, pdf.Error()
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+// ReviewerExpr is the user who reviews a task: the reviewer an admin
+// reassigned it to, else its assigner
+const ReviewerExpr = "COALESCE(t.reviewer_id, t.assigner_id, t.assigned_by)"
+
+var ErrInvalidReviewer = errors.New("reviewer must be an admin or pembimbing")
+
+const (
+	defaultReviewSLAHours      = 48
+	defaultReviewReminderHours = 24
+)
+
+// LoadReviewSLA reads the review_sla_hours and review_reminder_hours
+// settings. The reminder always comes before the SLA breach.
+func LoadReviewSLA(db *sql.DB) models.ReviewSLA {
+	sla := models.ReviewSLA{SLAHours: defaultReviewSLAHours, ReminderHours: defaultReviewReminderHours}
+	rows, err := db.Query("SELECT `key`, `value` FROM settings WHERE `key` IN ('review_sla_hours', 'review_reminder_hours')")
+	if err != nil {
+		return sla
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key, value string
+		if rows.Scan(&key, &value) != nil {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			continue
+		}
+		switch key {
+		case "review_sla_hours":
+			sla.SLAHours = n
+		case "review_reminder_hours":
+			sla.ReminderHours = n
+		}
+	}
+	if sla.ReminderHours >= sla.SLAHours {
+		sla.ReminderHours = sla.SLAHours / 2
+	}
+	return sla
+}
+
+// PendingReviews lists submitted tasks waiting for review, oldest first.
+// A reviewerID of 0 lists every reviewer's queue.
+func PendingReviews(db *sql.DB, sla models.ReviewSLA, reviewerID int64, now time.Time) ([]models.PendingReview, error) {
+	query := `SELECT t.id, t.title, i.id, COALESCE(iu.name, i.full_name), ` + ReviewerExpr + `, ru.name,
+	                 s.version, s.submitted_at, s.review_reminded_at, s.review_escalated_at
+	          FROM tasks t
+	          JOIN interns i ON t.intern_id = i.id
+	          LEFT JOIN users iu ON i.user_id = iu.id
+	          JOIN task_submissions s ON s.task_id = t.id
+	               AND s.version = (SELECT MAX(version) FROM task_submissions WHERE task_id = t.id)
+	          LEFT JOIN users ru ON ` + ReviewerExpr + ` = ru.id
+	          WHERE t.status = 'submitted'`
+	args := []interface{}{}
+	if reviewerID > 0 {
+		query += " AND " + ReviewerExpr + " = ?"
+		args = append(args, reviewerID)
+	}
+	query += " ORDER BY s.submitted_at ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.PendingReview{}
+	for rows.Next() {
+		var p models.PendingReview
+		var reviewer sql.NullInt64
+		var reviewerName sql.NullString
+		var reminded, escalated sql.NullTime
+		if err := rows.Scan(&p.TaskID, &p.Title, &p.InternID, &p.InternName, &reviewer, &reviewerName,
+			&p.Version, &p.SubmittedAt, &reminded, &escalated); err != nil {
+			return nil, err
+		}
+		if reviewer.Valid {
+			p.ReviewerID = &reviewer.Int64
+		}
+		p.ReviewerName = reviewerName.String
+		if reminded.Valid {
+			p.RemindedAt = &reminded.Time
+		}
+		if escalated.Valid {
+			p.EscalatedAt = &escalated.Time
+		}
+		p.DueAt = p.SubmittedAt.Add(time.Duration(sla.SLAHours) * time.Hour)
+		p.WaitingHours = roundScore(now.Sub(p.SubmittedAt).Hours())
+		p.Breached = now.After(p.DueAt)
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// CheckReviewSLA reminds reviewers of submissions waiting past the
+// reminder mark and alerts admins once a submission breaches the SLA, at
+// most once per submission each
+func CheckReviewSLA(db *sql.DB, now time.Time) {
+	sla := LoadReviewSLA(db)
+	pending, err := PendingReviews(db, sla, 0, now)
+	if err != nil {
+		log.Printf("Error selecting pending reviews: %v", err)
+		return
+	}
+
+	var admins []int64
+	for _, p := range pending {
+		link := "/tasks/" + strconv.FormatInt(p.TaskID, 10)
+		waited := now.Sub(p.SubmittedAt)
+
+		if p.RemindedAt == nil && p.ReviewerID != nil && waited >= time.Duration(sla.ReminderHours)*time.Hour {
+			notify(db, *p.ReviewerID, "Tugas Menunggu Review",
+				fmt.Sprintf("Tugas \"%s\" dari %s menunggu review sejak %s. Batas review %d jam.",
+					p.Title, p.InternName, FormatDateID(p.SubmittedAt), sla.SLAHours), link)
+			markSubmission(db, p, "review_reminded_at", now)
+		}
+
+		if p.EscalatedAt == nil && p.Breached {
+			if admins == nil {
+				admins = adminUserIDs(db)
+			}
+			reviewer := p.ReviewerName
+			if reviewer == "" {
+				reviewer = "belum ditentukan"
+			}
+			for _, id := range admins {
+				notify(db, id, "Review Tugas Melewati Batas",
+					fmt.Sprintf("Tugas \"%s\" dari %s belum direview lebih dari %d jam (reviewer: %s). Pertimbangkan untuk mengalihkan reviewer.",
+						p.Title, p.InternName, sla.SLAHours, reviewer), link)
+			}
+			markSubmission(db, p, "review_escalated_at", now)
+		}
+	}
+}
+
+func markSubmission(db *sql.DB, p models.PendingReview, column string, at time.Time) {
+	if _, err := db.Exec(
+		"UPDATE task_submissions SET "+column+" = ? WHERE task_id = ? AND version = ?", at, p.TaskID, p.Version,
+	); err != nil {
+		log.Printf("Error marking submission %d/v%d: %v", p.TaskID, p.Version, err)
+	}
+}
+
+func adminUserIDs(db *sql.DB) []int64 {
+	ids := []int64{}
+	rows, err := db.Query("SELECT id FROM users WHERE role = 'admin'")
+	if err != nil {
+		log.Printf("Error selecting admins: %v", err)
+		return ids
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ReviewerSLAStats measures time-to-review (submission to review) of the
+// reviews given between from and to, inclusive, and adds each reviewer's
+// current queue. A reviewerID of 0 covers every reviewer.
+func ReviewerSLAStats(db *sql.DB, sla models.ReviewSLA, reviewerID int64, from, to, now time.Time) ([]models.ReviewerSLAStats, error) {
+	query := `SELECT r.reviewer_id, u.name, COUNT(*),
+	                 COALESCE(SUM(TIMESTAMPDIFF(MINUTE, s.submitted_at, r.reviewed_at) <= ?), 0),
+	                 AVG(TIMESTAMPDIFF(MINUTE, s.submitted_at, r.reviewed_at)) / 60,
+	                 MAX(TIMESTAMPDIFF(MINUTE, s.submitted_at, r.reviewed_at)) / 60
+	          FROM task_reviews r
+	          JOIN task_submissions s ON r.submission_id = s.id
+	          JOIN users u ON r.reviewer_id = u.id
+	          WHERE r.reviewed_at >= ? AND r.reviewed_at < ?`
+	args := []interface{}{sla.SLAHours * 60, from.Format("2006-01-02"), to.AddDate(0, 0, 1).Format("2006-01-02")}
+	if reviewerID > 0 {
+		query += " AND r.reviewer_id = ?"
+		args = append(args, reviewerID)
+	}
+	query += " GROUP BY r.reviewer_id, u.name"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	byReviewer := map[int64]*models.ReviewerSLAStats{}
+	for rows.Next() {
+		var s models.ReviewerSLAStats
+		var avg, max sql.NullFloat64
+		if err := rows.Scan(&s.ReviewerID, &s.ReviewerName, &s.Reviews, &s.WithinSLA, &avg, &max); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Breached = s.Reviews - s.WithinSLA
+		if avg.Valid {
+			v := roundScore(avg.Float64)
+			s.AvgHours = &v
+		}
+		if max.Valid {
+			v := roundScore(max.Float64)
+			s.MaxHours = &v
+		}
+		if s.Reviews > 0 {
+			v := roundScore(float64(s.WithinSLA) / float64(s.Reviews) * 100)
+			s.ComplianceRate = &v
+		}
+		byReviewer[s.ReviewerID] = &s
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pending, err := PendingReviews(db, sla, reviewerID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pending {
+		if p.ReviewerID == nil {
+			continue
+		}
+		s, ok := byReviewer[*p.ReviewerID]
+		if !ok {
+			s = &models.ReviewerSLAStats{ReviewerID: *p.ReviewerID, ReviewerName: p.ReviewerName}
+			byReviewer[*p.ReviewerID] = s
+		}
+		s.Pending++
+		if p.Breached {
+			s.PendingBreached++
+		}
+		if s.OldestPendingHrs == nil || p.WaitingHours > *s.OldestPendingHrs {
+			v := p.WaitingHours
+			s.OldestPendingHrs = &v
+		}
+	}
+
+	stats := make([]models.ReviewerSLAStats, 0, len(byReviewer))
+	for _, s := range byReviewer {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		bi, bj := stats[i].Breached+stats[i].PendingBreached, stats[j].Breached+stats[j].PendingBreached
+		if bi != bj {
+			return bi > bj
+		}
+		return stats[i].ReviewerName < stats[j].ReviewerName
+	})
+	return stats, nil
+}
+
+// ReassignReviewer makes reviewerID the reviewer of a task, records the
+// change and notifies the new reviewer. A waiting submission gets a fresh
+// reminder from the new reviewer's side.
+func ReassignReviewer(db *sql.DB, taskID, reviewerID, changedBy int64, reason string) error {
+	if err := checkReviewer(db, reviewerID); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var title, status string
+	var current sql.NullInt64
+	if err := tx.QueryRow(
+		"SELECT t.title, t.status, "+ReviewerExpr+" FROM tasks t WHERE t.id = ? FOR UPDATE", taskID,
+	).Scan(&title, &status, &current); err != nil {
+		return err
+	}
+	if current.Valid && current.Int64 == reviewerID {
+		return nil
+	}
+	if _, err := tx.Exec("UPDATE tasks SET reviewer_id = ? WHERE id = ?", reviewerID, taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO task_reviewer_changes (task_id, from_reviewer_id, to_reviewer_id, changed_by, reason, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		taskID, current, reviewerID, changedBy, nullString(reason), time.Now(),
+	); err != nil {
+		return err
+	}
+	if status == "submitted" {
+		if _, err := tx.Exec(
+			`UPDATE task_submissions SET review_reminded_at = NULL
+			 WHERE task_id = ? AND version = (SELECT v FROM (SELECT MAX(version) AS v FROM task_submissions WHERE task_id = ?) latest)`,
+			taskID, taskID,
+		); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	notify(db, reviewerID, "Anda Ditunjuk Sebagai Reviewer",
+		fmt.Sprintf("Anda ditunjuk untuk mereview tugas \"%s\".", title), "/tasks/"+strconv.FormatInt(taskID, 10))
+	return nil
+}
+
+// ReassignReviews moves every open task reviewed by fromID to reviewerID
+// and returns how many tasks moved
+func ReassignReviews(db *sql.DB, fromID, reviewerID, changedBy int64, reason string) (int, error) {
+	if err := checkReviewer(db, reviewerID); err != nil {
+		return 0, err
+	}
+	rows, err := db.Query(
+		"SELECT t.id FROM tasks t WHERE "+ReviewerExpr+" = ? AND t.status NOT IN ('completed', 'cancelled')", fromID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	moved := 0
+	for _, id := range ids {
+		if err := ReassignReviewer(db, id, reviewerID, changedBy, reason); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// LoadReviewerChanges returns a task's reviewer reassignments, newest first
+func LoadReviewerChanges(db *sql.DB, taskID int64) ([]models.TaskReviewerChange, error) {
+	rows, err := db.Query(
+		`SELECT id, task_id, from_reviewer_id, to_reviewer_id, changed_by, reason, created_at
+		 FROM task_reviewer_changes WHERE task_id = ? ORDER BY created_at DESC, id DESC`, taskID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := []models.TaskReviewerChange{}
+	for rows.Next() {
+		var c models.TaskReviewerChange
+		var from sql.NullInt64
+		var reason sql.NullString
+		if err := rows.Scan(&c.ID, &c.TaskID, &from, &c.ToReviewerID, &c.ChangedBy, &reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if from.Valid {
+			c.FromReviewerID = &from.Int64
+		}
+		if reason.Valid {
+			c.Reason = &reason.String
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+func checkReviewer(db *sql.DB, userID int64) error {
+	var role string
+	if err := db.QueryRow("SELECT role FROM users WHERE id = ?", userID).Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrInvalidReviewer
+		}
+		return err
+	}
+	switch strings.ToLower(role) {
+	case "admin", "super_admin", "pembimbing", "supervisor":
+		return nil
+	}
+	return ErrInvalidReviewer
+}
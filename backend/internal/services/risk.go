@@ -0,0 +1,365 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+const defaultRiskThreshold = 50
+
+// RiskThreshold is the score at which an intern counts as high risk and
+// their supervisor is notified (setting risk_alert_threshold)
+func RiskThreshold(db *sql.DB) int {
+	var value string
+	if err := db.QueryRow("SELECT `value` FROM settings WHERE `key` = 'risk_alert_threshold'").Scan(&value); err != nil {
+		return defaultRiskThreshold
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n > 0 && n <= 100 {
+		return n
+	}
+	return defaultRiskThreshold
+}
+
+// RiskLevel maps a score to low, medium (half the threshold) or high
+func RiskLevel(score, threshold int) string {
+	switch {
+	case score >= threshold:
+		return models.RiskHigh
+	case score*2 >= threshold:
+		return models.RiskMedium
+	default:
+		return models.RiskLow
+	}
+}
+
+// RefreshRiskScores recomputes the risk score of every active intern and
+// notifies the supervisor of each intern who crossed the threshold since
+// the last run
+func RefreshRiskScores(db *sql.DB, now time.Time) {
+	threshold := RiskThreshold(db)
+	cal := LoadWorkCalendar(db)
+
+	rows, err := db.Query(
+		`SELECT i.id, i.full_name, i.supervisor_id, i.start_date, i.end_date, r.score
+		 FROM interns i
+		 LEFT JOIN intern_risk_scores r ON r.intern_id = i.id
+		 WHERE i.status = 'active'`,
+	)
+	if err != nil {
+		log.Printf("Error selecting interns for risk scoring: %v", err)
+		return
+	}
+	type target struct {
+		intern   riskIntern
+		previous sql.NullInt64
+	}
+	var targets []target
+	for rows.Next() {
+		var t target
+		if err := rows.Scan(&t.intern.id, &t.intern.name, &t.intern.supervisorID, &t.intern.start, &t.intern.end, &t.previous); err == nil {
+			targets = append(targets, t)
+		}
+	}
+	rows.Close()
+
+	for _, t := range targets {
+		reasons, err := riskSignals(db, t.intern, now, cal)
+		if err != nil {
+			log.Printf("Error scoring risk of intern %d: %v", t.intern.id, err)
+			continue
+		}
+		score := 0
+		for _, r := range reasons {
+			score += r.Points
+		}
+		if score > 100 {
+			score = 100
+		}
+
+		raw, _ := json.Marshal(reasons)
+		if _, err := db.Exec(
+			`INSERT INTO intern_risk_scores (intern_id, score, level, reasons, computed_at) VALUES (?, ?, ?, ?, ?)
+			 ON DUPLICATE KEY UPDATE score = VALUES(score), level = VALUES(level), reasons = VALUES(reasons), computed_at = VALUES(computed_at)`,
+			t.intern.id, score, RiskLevel(score, threshold), string(raw), now,
+		); err != nil {
+			log.Printf("Error saving risk score of intern %d: %v", t.intern.id, err)
+			continue
+		}
+
+		crossed := score >= threshold && (!t.previous.Valid || int(t.previous.Int64) < threshold)
+		if crossed && t.intern.supervisorID.Valid {
+			messages := make([]string, 0, len(reasons))
+			for _, r := range reasons {
+				messages = append(messages, r.Message)
+			}
+			notify(db, t.intern.supervisorID.Int64, "Intern Berisiko",
+				fmt.Sprintf("%s memiliki skor risiko %d: %s.", t.intern.name, score, strings.Join(messages, "; ")),
+				"/interns/"+strconv.FormatInt(t.intern.id, 10))
+		}
+	}
+
+	if _, err := db.Exec(
+		"DELETE r FROM intern_risk_scores r JOIN interns i ON r.intern_id = i.id WHERE i.status <> 'active'",
+	); err != nil {
+		log.Printf("Error clearing risk scores of inactive interns: %v", err)
+	}
+}
+
+type riskIntern struct {
+	id           int64
+	name         string
+	supervisorID sql.NullInt64
+	start, end   sql.NullTime
+}
+
+// inInternship reports whether a YYYY-MM-DD day falls in the internship
+func (i riskIntern) inInternship(day string) bool {
+	if i.start.Valid && day < i.start.Time.Format("2006-01-02") {
+		return false
+	}
+	if i.end.Valid && day > i.end.Time.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// riskSignals evaluates each early-warning signal for an intern
+func riskSignals(db *sql.DB, intern riskIntern, now time.Time, cal WorkCalendar) ([]models.RiskReason, error) {
+	reasons := []models.RiskReason{}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	todayStr := today.Format("2006-01-02")
+
+	// Attendance over the last four weeks
+	statuses := map[string]string{}
+	rows, err := db.Query(
+		"SELECT date, status FROM attendances WHERE intern_id = ? AND date BETWEEN ? AND ?",
+		intern.id, today.AddDate(0, 0, -28).Format("2006-01-02"), todayStr,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var date time.Time
+		var status string
+		if rows.Scan(&date, &status) == nil {
+			statuses[date.Format("2006-01-02")] = status
+		}
+	}
+	rows.Close()
+
+	// Consecutive absences, counting back from yesterday. Leave days
+	// neither count nor break the streak.
+	absences := 0
+	for d := today.AddDate(0, 0, -1); d.After(today.AddDate(0, 0, -22)); d = d.AddDate(0, 0, -1) {
+		day := d.Format("2006-01-02")
+		if !intern.inInternship(day) {
+			break
+		}
+		status, ok := statuses[day]
+		if !ok {
+			if cal.IsOffDay(d) {
+				continue
+			}
+			status = "absent"
+		}
+		if status == "absent" {
+			absences++
+			continue
+		}
+		if status == "present" || status == "late" {
+			break
+		}
+	}
+	if absences >= 2 {
+		points := 15
+		if absences >= 5 {
+			points = 30
+		} else if absences >= 3 {
+			points = 25
+		}
+		reasons = append(reasons, models.RiskReason{
+			Code: "consecutive_absences", Points: points,
+			Message: fmt.Sprintf("Tidak hadir %d hari kerja berturut-turut", absences),
+		})
+	}
+
+	// Lateness over the last two weeks against the two weeks before
+	split := today.AddDate(0, 0, -14).Format("2006-01-02")
+	var recentPresent, recentLate, earlierPresent, earlierLate int
+	for day, status := range statuses {
+		if status != "present" && status != "late" {
+			continue
+		}
+		if day >= split {
+			recentPresent++
+			if status == "late" {
+				recentLate++
+			}
+		} else {
+			earlierPresent++
+			if status == "late" {
+				earlierLate++
+			}
+		}
+	}
+	if recentPresent >= 3 {
+		recent := float64(recentLate) / float64(recentPresent)
+		earlier := 0.0
+		if earlierPresent > 0 {
+			earlier = float64(earlierLate) / float64(earlierPresent)
+		}
+		if recent >= 0.3 && (recent-earlier >= 0.15 || recent >= 0.5) {
+			reasons = append(reasons, models.RiskReason{
+				Code: "lateness_trend", Points: 15,
+				Message: fmt.Sprintf("Terlambat %.0f%% dalam 2 minggu terakhir (sebelumnya %.0f%%)", recent*100, earlier*100),
+			})
+		}
+	}
+
+	// Overdue and revision-heavy tasks
+	var overdue, revisionHeavy int
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM tasks
+		 WHERE intern_id = ? AND (status = 'overdue'
+		    OR (status IN ('pending', 'in_progress', 'revision') AND deadline IS NOT NULL AND deadline < ?))`,
+		intern.id, todayStr,
+	).Scan(&overdue); err != nil {
+		return nil, err
+	}
+	if overdue > 0 {
+		reasons = append(reasons, models.RiskReason{
+			Code: "overdue_tasks", Points: min(overdue*5, 20),
+			Message: fmt.Sprintf("%d tugas melewati tenggat", overdue),
+		})
+	}
+	if err := db.QueryRow(
+		`SELECT COUNT(*) FROM (
+		     SELECT t.id FROM tasks t
+		     JOIN task_reviews r ON r.task_id = t.id AND r.action = 'revision'
+		     WHERE t.intern_id = ? AND t.status NOT IN ('completed', 'cancelled')
+		     GROUP BY t.id HAVING COUNT(*) >= 2
+		 ) heavy`, intern.id,
+	).Scan(&revisionHeavy); err != nil {
+		return nil, err
+	}
+	if revisionHeavy > 0 {
+		reasons = append(reasons, models.RiskReason{
+			Code: "revision_heavy_tasks", Points: min(revisionHeavy*5, 15),
+			Message: fmt.Sprintf("%d tugas direvisi berulang kali", revisionHeavy),
+		})
+	}
+
+	// The latest assessment against the one before it
+	rows, err = db.Query(
+		"SELECT score FROM assessments WHERE intern_id = ? AND source = 'company' ORDER BY assessment_date DESC, id DESC LIMIT 2", intern.id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var scores []float64
+	for rows.Next() {
+		var s float64
+		if rows.Scan(&s) == nil {
+			scores = append(scores, s)
+		}
+	}
+	rows.Close()
+	if len(scores) > 0 {
+		points := 0
+		message := ""
+		if len(scores) == 2 && scores[0] <= scores[1]-10 {
+			points += 10
+			message = fmt.Sprintf("Nilai penilaian turun dari %.0f ke %.0f", scores[1], scores[0])
+		}
+		if scores[0] < 60 {
+			points += 10
+			if message == "" {
+				message = fmt.Sprintf("Nilai penilaian terakhir %.0f", scores[0])
+			}
+		}
+		if points > 0 {
+			reasons = append(reasons, models.RiskReason{Code: "falling_assessments", Points: points, Message: message})
+		}
+	}
+
+	// Weekly reports not submitted for the last four complete weeks
+	missed := 0
+	thisWeek := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	for w := 1; w <= 4; w++ {
+		start := thisWeek.AddDate(0, 0, -7*w)
+		end := start.AddDate(0, 0, 6)
+		if !intern.inInternship(start.Format("2006-01-02")) && !intern.inInternship(end.Format("2006-01-02")) {
+			continue
+		}
+		var count int
+		if err := db.QueryRow(
+			`SELECT COUNT(*) FROM reports
+			 WHERE intern_id = ? AND type = 'weekly' AND status <> 'draft'
+			   AND period_start <= ? AND period_end >= ?`,
+			intern.id, end.Format("2006-01-02"), start.Format("2006-01-02"),
+		).Scan(&count); err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			missed++
+		}
+	}
+	if missed > 0 {
+		reasons = append(reasons, models.RiskReason{
+			Code: "missed_reports", Points: min(missed*5, 15),
+			Message: fmt.Sprintf("%d laporan mingguan belum dikirim", missed),
+		})
+	}
+
+	return reasons, nil
+}
+
+// LoadRiskScores returns stored risk scores at or above minScore, highest
+// first, optionally limited to one supervisor's interns
+func LoadRiskScores(db *sql.DB, supervisorID int64, minScore, limit int) ([]models.InternRisk, error) {
+	query := `SELECT r.intern_id, i.full_name, i.supervisor_id, su.name, r.score, r.level, r.reasons, r.computed_at
+		FROM intern_risk_scores r
+		JOIN interns i ON r.intern_id = i.id
+		LEFT JOIN users su ON i.supervisor_id = su.id
+		WHERE i.status = 'active' AND r.score >= ?`
+	args := []interface{}{minScore}
+	if supervisorID > 0 {
+		query += " AND i.supervisor_id = ?"
+		args = append(args, supervisorID)
+	}
+	query += " ORDER BY r.score DESC, i.full_name LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.InternRisk{}
+	for rows.Next() {
+		var r models.InternRisk
+		var supervisor sql.NullInt64
+		var supervisorName sql.NullString
+		var reasons []byte
+		if err := rows.Scan(&r.InternID, &r.InternName, &supervisor, &supervisorName, &r.Score, &r.Level, &reasons, &r.ComputedAt); err != nil {
+			return nil, err
+		}
+		if supervisor.Valid {
+			r.SupervisorID = &supervisor.Int64
+		}
+		r.SupervisorName = supervisorName.String
+		if err := json.Unmarshal(reasons, &r.Reasons); err != nil || r.Reasons == nil {
+			r.Reasons = []models.RiskReason{}
+		}
+		list = append(list, r)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/jobs"
+	"dsi_interna_sys/internal/models"
+)
+
+// rollupRefreshPayload names the intern and attendance dates whose
+// dashboard rollups are stale
+type rollupRefreshPayload struct {
+	InternID int64    `json:"intern_id"`
+	Dates    []string `json:"dates,omitempty"` // YYYY-MM-DD
+}
+
+// QueueRollupRefresh schedules a refresh of an intern's dashboard rollup
+// and of the daily attendance rollups of the given dates. A refresh of the
+// same intern and dates that is still pending covers this one. Failures are
+// logged; the nightly reconciliation catches up.
+func QueueRollupRefresh(db jobs.Execer, internID int64, dates ...time.Time) {
+	p := rollupRefreshPayload{InternID: internID}
+	for _, d := range dates {
+		p.Dates = append(p.Dates, d.Format("2006-01-02"))
+	}
+	key := fmt.Sprintf("%s:%d:%s", JobRollupRefresh, internID, strings.Join(p.Dates, ","))
+	if _, err := jobs.Enqueue(db, JobRollupRefresh, p, jobs.MaxAttempts(3), jobs.DedupeKey(key)); err != nil {
+		log.Printf("Error queueing dashboard rollup refresh for intern %d: %v", internID, err)
+	}
+}
+
+// QueueTaskRollupRefresh schedules a dashboard refresh for the intern a
+// task belongs to
+func QueueTaskRollupRefresh(db *sql.DB, taskID int64) {
+	var internID int64
+	if err := db.QueryRow("SELECT intern_id FROM tasks WHERE id = ?", taskID).Scan(&internID); err != nil {
+		log.Printf("Error finding intern of task %d for dashboard rollup: %v", taskID, err)
+		return
+	}
+	QueueRollupRefresh(db, internID)
+}
+
+func handleRollupRefresh(db *sql.DB) func(context.Context, rollupRefreshPayload, *models.Job) error {
+	return func(ctx context.Context, p rollupRefreshPayload, job *models.Job) error {
+		if p.InternID > 0 {
+			if err := RefreshInternRollup(db, p.InternID, time.Now()); err != nil {
+				return err
+			}
+		}
+		for _, d := range p.Dates {
+			if err := RefreshAttendanceRollup(db, d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// RefreshInternRollup recomputes an intern's task counts and attendance
+// over the 30 days up to now
+func RefreshInternRollup(db *sql.DB, internID int64, now time.Time) error {
+	_, err := db.Exec(
+		`INSERT INTO intern_dashboard_rollups (intern_id, tasks_total, tasks_pending, tasks_in_progress, tasks_submitted,
+		                                       tasks_revision, tasks_completed, tasks_completed_late, tasks_open_late,
+		                                       attendance_days_30d, present_days_30d, refreshed_at)
+		 SELECT i.id,
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status = 'pending'),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status = 'in_progress'),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status = 'submitted'),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status = 'revision'),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status = 'completed'),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status = 'completed' AND is_late = 1),
+		        (SELECT COUNT(*) FROM tasks WHERE intern_id = i.id AND status <> 'completed' AND is_late = 1),
+		        (SELECT COUNT(*) FROM attendances WHERE intern_id = i.id AND date >= ?),
+		        (SELECT COUNT(*) FROM attendances WHERE intern_id = i.id AND date >= ? AND status IN ('present', 'late')),
+		        ?
+		 FROM interns i WHERE i.id = ?
+		 ON DUPLICATE KEY UPDATE
+		     tasks_total = VALUES(tasks_total), tasks_pending = VALUES(tasks_pending),
+		     tasks_in_progress = VALUES(tasks_in_progress), tasks_submitted = VALUES(tasks_submitted),
+		     tasks_revision = VALUES(tasks_revision), tasks_completed = VALUES(tasks_completed),
+		     tasks_completed_late = VALUES(tasks_completed_late), tasks_open_late = VALUES(tasks_open_late),
+		     attendance_days_30d = VALUES(attendance_days_30d), present_days_30d = VALUES(present_days_30d),
+		     refreshed_at = VALUES(refreshed_at)`,
+		now.AddDate(0, 0, -30).Format("2006-01-02"), now.AddDate(0, 0, -30).Format("2006-01-02"), now, internID,
+	)
+	return err
+}
+
+// RefreshAttendanceRollup recomputes the per-supervisor attendance counts
+// of one day (YYYY-MM-DD)
+func RefreshAttendanceRollup(db *sql.DB, date string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM attendance_daily_rollups WHERE date = ?", date); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO attendance_daily_rollups (date, supervisor_id, present, late, absent, sick, permission, on_leave, total, refreshed_at)
+		 SELECT a.date, COALESCE(i.supervisor_id, 0),
+		        SUM(a.status = 'present'), SUM(a.status = 'late'), SUM(a.status = 'absent'),
+		        SUM(a.status = 'sick'), SUM(a.status = 'permission'), SUM(a.status IN ('on_leave', 'excused')), COUNT(*), ?
+		 FROM attendances a
+		 JOIN interns i ON a.intern_id = i.id
+		 WHERE a.date = ?
+		 GROUP BY a.date, COALESCE(i.supervisor_id, 0)`,
+		time.Now(), date,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReconcileDashboardRollups rebuilds every intern's rollup and the daily
+// attendance rollups of the last 35 days. It runs nightly to roll the
+// 30-day window forward and to pick up changes no event refreshed, such as
+// admin edits or interns moving to another supervisor.
+func ReconcileDashboardRollups(db *sql.DB, now time.Time) {
+	rows, err := db.Query("SELECT id FROM interns")
+	if err != nil {
+		log.Printf("Error selecting interns for rollup reconciliation: %v", err)
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		if err := RefreshInternRollup(db, id, now); err != nil {
+			log.Printf("Error refreshing dashboard rollup of intern %d: %v", id, err)
+		}
+	}
+	for d := now.AddDate(0, 0, -35); !d.After(now); d = d.AddDate(0, 0, 1) {
+		if err := RefreshAttendanceRollup(db, d.Format("2006-01-02")); err != nil {
+			log.Printf("Error refreshing attendance rollup of %s: %v", d.Format("2006-01-02"), err)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"dsi_interna_sys/internal/jobs"
+	"dsi_interna_sys/internal/models"
+)
+
+var (
+	ErrRubricNotFound = errors.New("no active rubric applies to this intern")
+	ErrInvalidRubric  = errors.New("invalid rubric")
+	ErrInvalidScores  = errors.New("invalid scores")
+)
+
+// ValidateRubricVersion checks the scale, criteria and thresholds of a
+// rubric version before it is saved. Errors wrap ErrInvalidRubric.
+func ValidateRubricVersion(v models.RubricVersion) error {
+	if v.ScaleMax <= v.ScaleMin {
+		return fmt.Errorf("%w: scale_max must be greater than scale_min", ErrInvalidRubric)
+	}
+	if len(v.Criteria) == 0 {
+		return fmt.Errorf("%w: at least one criterion is required", ErrInvalidRubric)
+	}
+	codes := map[string]bool{}
+	for _, c := range v.Criteria {
+		if strings.TrimSpace(c.Code) == "" || strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("%w: every criterion needs a code and a name", ErrInvalidRubric)
+		}
+		if codes[c.Code] {
+			return fmt.Errorf("%w: duplicate criterion code %q", ErrInvalidRubric, c.Code)
+		}
+		codes[c.Code] = true
+		if c.Weight <= 0 {
+			return fmt.Errorf("%w: weight of %q must be positive", ErrInvalidRubric, c.Code)
+		}
+	}
+	if len(v.Thresholds) == 0 {
+		return fmt.Errorf("%w: at least one category threshold is required", ErrInvalidRubric)
+	}
+	categories := map[string]bool{}
+	for _, t := range v.Thresholds {
+		if strings.TrimSpace(t.Category) == "" || strings.TrimSpace(t.Label) == "" {
+			return fmt.Errorf("%w: every threshold needs a category and a label", ErrInvalidRubric)
+		}
+		if categories[t.Category] {
+			return fmt.Errorf("%w: duplicate category %q", ErrInvalidRubric, t.Category)
+		}
+		categories[t.Category] = true
+		if t.MinScore < v.ScaleMin || t.MinScore > v.ScaleMax {
+			return fmt.Errorf("%w: threshold %q is outside the scale", ErrInvalidRubric, t.Category)
+		}
+	}
+	return nil
+}
+
+// SaveRubricVersion stores a new version of a rubric with its criteria
+func SaveRubricVersion(tx *sql.Tx, rubricID int64, version int, v models.RubricVersion, createdBy int64) (int64, error) {
+	thresholds, err := json.Marshal(v.Thresholds)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(
+		`INSERT INTO rubric_versions (rubric_id, version, scale_min, scale_max, thresholds, created_by)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		rubricID, version, v.ScaleMin, v.ScaleMax, string(thresholds), nullActor(createdBy),
+	)
+	if err != nil {
+		return 0, err
+	}
+	versionID, _ := res.LastInsertId()
+
+	for i, c := range v.Criteria {
+		order := c.SortOrder
+		if order == 0 {
+			order = i + 1
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO rubric_criteria (version_id, code, name, description, weight, sort_order)
+			 VALUES (?, ?, ?, ?, ?, ?)`,
+			versionID, strings.TrimSpace(c.Code), strings.TrimSpace(c.Name), nullString(c.Description), c.Weight, order,
+		); err != nil {
+			return 0, err
+		}
+	}
+	return versionID, nil
+}
+
+// LoadRubricVersion loads a rubric version with its criteria
+func LoadRubricVersion(db *sql.DB, versionID int64) (*models.RubricVersion, error) {
+	var v models.RubricVersion
+	var thresholds []byte
+	if err := db.QueryRow(
+		`SELECT v.id, v.rubric_id, r.name, v.version, v.scale_min, v.scale_max, v.thresholds, v.created_at
+		 FROM rubric_versions v
+		 JOIN rubrics r ON v.rubric_id = r.id
+		 WHERE v.id = ?`, versionID,
+	).Scan(&v.ID, &v.RubricID, &v.RubricName, &v.Version, &v.ScaleMin, &v.ScaleMax, &thresholds, &v.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(thresholds, &v.Thresholds); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(
+		`SELECT id, code, name, description, weight, sort_order
+		 FROM rubric_criteria WHERE version_id = ?
+		 ORDER BY sort_order, id`, versionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	v.Criteria = []models.RubricCriterion{}
+	for rows.Next() {
+		var c models.RubricCriterion
+		var description sql.NullString
+		if err := rows.Scan(&c.ID, &c.Code, &c.Name, &description, &c.Weight, &c.SortOrder); err != nil {
+			return nil, err
+		}
+		c.Description = description.String
+		v.Criteria = append(v.Criteria, c)
+	}
+	return &v, rows.Err()
+}
+
+// LoadCurrentRubricVersion loads the latest version of an active rubric
+func LoadCurrentRubricVersion(db *sql.DB, rubricID int64) (*models.RubricVersion, error) {
+	var versionID int64
+	err := db.QueryRow(
+		`SELECT v.id FROM rubrics r
+		 JOIN rubric_versions v ON v.rubric_id = r.id AND v.version = r.current_version
+		 WHERE r.id = ? AND r.is_active = TRUE`, rubricID,
+	).Scan(&versionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrRubricNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return LoadRubricVersion(db, versionID)
+}
+
+// ResolveRubricVersion picks the rubric for an intern: an assignment
+// matching both institution and department first, then institution, then
+// department, then the default rubric. Legacy assignments still waiting
+// for an institution are skipped.
+func ResolveRubricVersion(db *sql.DB, internID int64) (*models.RubricVersion, error) {
+	var institutionID sql.NullInt64
+	var department sql.NullString
+	if err := db.QueryRow("SELECT institution_id, department FROM interns WHERE id = ?", internID).Scan(&institutionID, &department); err != nil {
+		return nil, err
+	}
+
+	var rubricID int64
+	err := db.QueryRow(
+		`SELECT r.id FROM rubric_assignments ra
+		 JOIN rubrics r ON ra.rubric_id = r.id
+		 WHERE r.is_active = TRUE
+		   AND (ra.institution_id IS NOT NULL OR ra.department IS NOT NULL)
+		   AND ra.legacy_school IS NULL
+		   AND (ra.institution_id IS NULL OR ra.institution_id = ?)
+		   AND (ra.department IS NULL OR ra.department = ?)
+		 ORDER BY (ra.institution_id IS NOT NULL AND ra.department IS NOT NULL) DESC, (ra.institution_id IS NOT NULL) DESC, r.id
+		 LIMIT 1`,
+		institutionID, strings.TrimSpace(department.String),
+	).Scan(&rubricID)
+	if err == sql.ErrNoRows {
+		err = db.QueryRow(
+			"SELECT id FROM rubrics WHERE is_default = TRUE AND is_active = TRUE ORDER BY id LIMIT 1",
+		).Scan(&rubricID)
+	}
+	if err == sql.ErrNoRows {
+		return nil, ErrRubricNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return LoadCurrentRubricVersion(db, rubricID)
+}
+
+// RubricResult is an assessment scored against a rubric version
+type RubricResult struct {
+	RubricScore float64
+	Percent     int
+	Category    models.RubricThreshold
+	Scores      []models.AssessmentScore
+}
+
+// ScoreRubric checks that every criterion has a score on the rubric's
+// scale and computes the weighted score, its 0-100 percentage and the
+// category. Errors wrap ErrInvalidScores, or ErrInvalidRubric when the
+// rubric itself can't be scored (an empty scale or no weighted criteria).
+func ScoreRubric(v *models.RubricVersion, scores map[string]float64) (RubricResult, error) {
+	var result RubricResult
+	if v.ScaleMax <= v.ScaleMin {
+		return result, fmt.Errorf("%w: scale_max must be greater than scale_min", ErrInvalidRubric)
+	}
+	var weighted, totalWeight float64
+	for _, c := range v.Criteria {
+		score, ok := scores[c.Code]
+		if !ok {
+			return result, fmt.Errorf("%w: %s is required", ErrInvalidScores, c.Code)
+		}
+		if score < v.ScaleMin || score > v.ScaleMax {
+			return result, fmt.Errorf("%w: %s must be between %s and %s", ErrInvalidScores, c.Code,
+				formatScore(v.ScaleMin), formatScore(v.ScaleMax))
+		}
+		weighted += score * c.Weight
+		totalWeight += c.Weight
+		result.Scores = append(result.Scores, models.AssessmentScore{
+			CriterionID: c.ID, Code: c.Code, Name: c.Name, Weight: c.Weight, Score: score,
+		})
+	}
+	for code := range scores {
+		if !rubricHasCriterion(v, code) {
+			return result, fmt.Errorf("%w: %s is not a criterion of this rubric", ErrInvalidScores, code)
+		}
+	}
+	if totalWeight <= 0 {
+		return result, fmt.Errorf("%w: the rubric has no weighted criteria", ErrInvalidRubric)
+	}
+
+	result.RubricScore = math.Round(weighted/totalWeight*100) / 100
+	result.Percent = int(math.Round((result.RubricScore - v.ScaleMin) / (v.ScaleMax - v.ScaleMin) * 100))
+	result.Category = models.CategoryFor(result.RubricScore, v.Thresholds)
+	return result, nil
+}
+
+func rubricHasCriterion(v *models.RubricVersion, code string) bool {
+	for _, c := range v.Criteria {
+		if c.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func formatScore(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
+
+// SaveAssessmentScores replaces the criterion scores of an assessment
+func SaveAssessmentScores(db jobs.Execer, assessmentID int64, scores []models.AssessmentScore) error {
+	if _, err := db.Exec("DELETE FROM assessment_scores WHERE assessment_id = ?", assessmentID); err != nil {
+		return err
+	}
+	for _, s := range scores {
+		if _, err := db.Exec(
+			"INSERT INTO assessment_scores (assessment_id, criterion_id, score) VALUES (?, ?, ?)",
+			assessmentID, s.CriterionID, s.Score,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadAssessmentScores returns the criterion scores of each assessment,
+// keyed by assessment id
+func LoadAssessmentScores(db *sql.DB, assessmentIDs []int64) (map[int64][]models.AssessmentScore, error) {
+	scores := map[int64][]models.AssessmentScore{}
+	if len(assessmentIDs) == 0 {
+		return scores, nil
+	}
+
+	args := make([]interface{}, len(assessmentIDs))
+	for i, id := range assessmentIDs {
+		args[i] = id
+	}
+	rows, err := db.Query(
+		`SELECT s.assessment_id, c.id, c.code, c.name, c.weight, s.score
+		 FROM assessment_scores s
+		 JOIN rubric_criteria c ON s.criterion_id = c.id
+		 WHERE s.assessment_id IN (?`+strings.Repeat(", ?", len(assessmentIDs)-1)+`)
+		 ORDER BY c.sort_order, c.id`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var assessmentID int64
+		var s models.AssessmentScore
+		if err := rows.Scan(&assessmentID, &s.CriterionID, &s.Code, &s.Name, &s.Weight, &s.Score); err != nil {
+			continue
+		}
+		scores[assessmentID] = append(scores[assessmentID], s)
+	}
+	return scores, nil
+}
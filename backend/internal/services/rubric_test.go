@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"dsi_interna_sys/internal/models"
+)
+
+func TestScoreRubric(t *testing.T) {
+	thresholds := []models.RubricThreshold{
+		{Category: "very_good", Label: "Sangat Baik", MinScore: 4.25},
+		{Category: "good", Label: "Baik", MinScore: 3.5},
+		{Category: "not_good", Label: "Tidak Baik", MinScore: 2.5},
+		{Category: "very_bad", Label: "Sangat Tidak Baik", MinScore: 1},
+	}
+	criteria := func(weights ...float64) []models.RubricCriterion {
+		list := []models.RubricCriterion{}
+		for i, w := range weights {
+			code := string(rune('a' + i))
+			list = append(list, models.RubricCriterion{ID: int64(i + 1), Code: code, Name: code, Weight: w})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name         string
+		scaleMin     float64
+		scaleMax     float64
+		criteria     []models.RubricCriterion
+		scores       map[string]float64
+		wantScore    float64
+		wantPercent  int
+		wantCategory string
+		wantErr      error
+	}{
+		{"equal weights", 1, 5, criteria(1, 1), map[string]float64{"a": 4, "b": 5}, 4.5, 88, "very_good", nil},
+		{"uneven weights", 1, 5, criteria(3, 1), map[string]float64{"a": 2, "b": 4}, 2.5, 38, "not_good", nil},
+		{"weights need not sum to one", 1, 5, criteria(30, 10), map[string]float64{"a": 2, "b": 4}, 2.5, 38, "not_good", nil},
+		{"score rounded to two decimals", 1, 5, criteria(1, 1, 1), map[string]float64{"a": 1, "b": 2, "c": 2}, 1.67, 17, "very_bad", nil},
+		{"bottom of scale is 0 percent", 1, 5, criteria(1, 2), map[string]float64{"a": 1, "b": 1}, 1, 0, "very_bad", nil},
+		{"top of scale is 100 percent", 1, 5, criteria(1, 2), map[string]float64{"a": 5, "b": 5}, 5, 100, "very_good", nil},
+		{"0-100 scale", 0, 100, criteria(1, 1), map[string]float64{"a": 85, "b": 70}, 77.5, 78, "very_good", nil},
+		{"missing criterion", 1, 5, criteria(1, 1), map[string]float64{"a": 4}, 0, 0, "", ErrInvalidScores},
+		{"unknown criterion", 1, 5, criteria(1), map[string]float64{"a": 4, "z": 3}, 0, 0, "", ErrInvalidScores},
+		{"below scale", 1, 5, criteria(1), map[string]float64{"a": 0}, 0, 0, "", ErrInvalidScores},
+		{"above scale", 1, 5, criteria(1), map[string]float64{"a": 5.5}, 0, 0, "", ErrInvalidScores},
+		{"zero total weight", 1, 5, criteria(0, 0), map[string]float64{"a": 4, "b": 4}, 0, 0, "", ErrInvalidRubric},
+		{"no criteria", 1, 5, criteria(), map[string]float64{}, 0, 0, "", ErrInvalidRubric},
+		{"empty scale", 3, 3, criteria(1), map[string]float64{"a": 3}, 0, 0, "", ErrInvalidRubric},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &models.RubricVersion{ScaleMin: tt.scaleMin, ScaleMax: tt.scaleMax, Criteria: tt.criteria, Thresholds: thresholds}
+			got, err := ScoreRubric(v, tt.scores)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ScoreRubric error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScoreRubric error: %v", err)
+			}
+			if got.RubricScore != tt.wantScore || got.Percent != tt.wantPercent || got.Category.Category != tt.wantCategory {
+				t.Fatalf("ScoreRubric = %v / %d%% / %s, want %v / %d%% / %s",
+					got.RubricScore, got.Percent, got.Category.Category, tt.wantScore, tt.wantPercent, tt.wantCategory)
+			}
+			if len(got.Scores) != len(tt.criteria) {
+				t.Fatalf("ScoreRubric returned %d criterion scores, want %d", len(got.Scores), len(tt.criteria))
+			}
+		})
+	}
+}
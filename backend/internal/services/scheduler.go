@@ -6,15 +6,6 @@ import (
 	"time"
 )
 
-func StartAgendaScheduler(db *sql.DB) {
-	ticker := time.NewTicker(1 * time.Minute)
-	go func() {
-		for range ticker.C {
-			checkAgendas(db)
-		}
-	}()
-}
-
 func checkAgendas(db *sql.DB) {
 	// Look for agendas in the next 15 minutes that haven't been notified
 	now := time.Now()
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dsi_interna_sys/internal/models"
+)
+
+var ErrInvalidSupervisor = errors.New("invalid supervisor")
+
+// SupervisedInternCond limits an interns query aliased i to the interns a
+// user supervises in any role, including the interns of supervisors they
+// cover for today. Its arguments are SupervisedInternArgs.
+const SupervisedInternCond = `(i.supervisor_id = ? OR i.id IN (SELECT intern_id FROM intern_supervisors WHERE user_id = ?)
+	OR i.supervisor_id IN (SELECT supervisor_id FROM supervisor_delegations
+	                       WHERE delegate_id = ? AND status = 'active' AND start_date <= ? AND end_date >= ?))`
+
+// SupervisedInternArgs are the arguments of SupervisedInternCond
+func SupervisedInternArgs(userID int64) []interface{} {
+	day := time.Now().Format("2006-01-02")
+	return []interface{}{userID, userID, userID, day, day}
+}
+
+// LoadInternSupervisors returns an intern's primary supervisor followed by
+// their co-supervisors
+func LoadInternSupervisors(db *sql.DB, internID int64) ([]models.InternSupervisor, error) {
+	rows, err := db.Query(
+		`SELECT u.id, u.name, u.email, u.role, 'primary', NULL, 0
+		 FROM interns i JOIN users u ON i.supervisor_id = u.id
+		 WHERE i.id = ?
+		 UNION ALL
+		 SELECT u.id, u.name, u.email, u.role, s.role, s.assigned_at, 1
+		 FROM intern_supervisors s JOIN users u ON s.user_id = u.id
+		 WHERE s.intern_id = ?
+		 ORDER BY 7, 5, 2`,
+		internID, internID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.InternSupervisor{}
+	for rows.Next() {
+		var s models.InternSupervisor
+		var assignedAt sql.NullTime
+		var order int
+		if err := rows.Scan(&s.UserID, &s.Name, &s.Email, &s.UserRole, &s.Role, &assignedAt, &order); err != nil {
+			return nil, err
+		}
+		if assignedAt.Valid {
+			s.AssignedAt = &assignedAt.Time
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+// SetInternSupervisors replaces an intern's supervisors. The primary
+// becomes the intern's supervisor_id; secondary supervisors must be admins
+// or pembimbing, and academic ones either of those or a campus supervisor
+// of the intern's institution.
+func SetInternSupervisors(db *sql.DB, internID int64, req models.SetInternSupervisorsRequest, assignedBy int64) error {
+	var institutionID int64
+	if err := db.QueryRow("SELECT institution_id FROM interns WHERE id = ?", internID).Scan(&institutionID); err != nil {
+		return err
+	}
+
+	var primary sql.NullInt64
+	seen := map[int64]bool{}
+	for _, s := range req.Supervisors {
+		if s.UserID <= 0 {
+			return fmt.Errorf("%w: user_id is required", ErrInvalidSupervisor)
+		}
+		if seen[s.UserID] {
+			return fmt.Errorf("%w: user %d is listed more than once", ErrInvalidSupervisor, s.UserID)
+		}
+		seen[s.UserID] = true
+
+		switch s.Role {
+		case models.SupervisorRolePrimary:
+			if primary.Valid {
+				return fmt.Errorf("%w: an intern can only have one primary supervisor", ErrInvalidSupervisor)
+			}
+			if err := checkReviewer(db, s.UserID); err != nil {
+				return fmt.Errorf("%w: the primary supervisor must be an admin or pembimbing", ErrInvalidSupervisor)
+			}
+			primary = sql.NullInt64{Int64: s.UserID, Valid: true}
+		case models.SupervisorRoleSecondary:
+			if err := checkReviewer(db, s.UserID); err != nil {
+				return fmt.Errorf("%w: a secondary supervisor must be an admin or pembimbing", ErrInvalidSupervisor)
+			}
+		case models.SupervisorRoleAcademic:
+			if err := checkReviewer(db, s.UserID); err != nil {
+				campusInstitution, ok := CampusInstitution(db, s.UserID)
+				if !ok || campusInstitution != institutionID {
+					return fmt.Errorf("%w: an academic supervisor must be a campus supervisor of the intern's institution", ErrInvalidSupervisor)
+				}
+			}
+		default:
+			return fmt.Errorf("%w: role must be primary, secondary or academic", ErrInvalidSupervisor)
+		}
+	}
+
+	previous := map[int64]string{}
+	if current, err := LoadInternSupervisors(db, internID); err == nil {
+		for _, s := range current {
+			previous[s.UserID] = s.Role
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE interns SET supervisor_id = ? WHERE id = ?", primary, internID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM intern_supervisors WHERE intern_id = ?", internID); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, s := range req.Supervisors {
+		if s.Role == models.SupervisorRolePrimary {
+			continue
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO intern_supervisors (intern_id, user_id, role, assigned_by, assigned_at) VALUES (?, ?, ?, ?, ?)",
+			internID, s.UserID, s.Role, assignedBy, now,
+		); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	var internName string
+	_ = db.QueryRow("SELECT full_name FROM interns WHERE id = ?", internID).Scan(&internName)
+	for _, s := range req.Supervisors {
+		if previous[s.UserID] == s.Role {
+			continue
+		}
+		notify(db, s.UserID, "Penugasan Pembimbing",
+			fmt.Sprintf("Anda ditetapkan sebagai pembimbing %s untuk %s.", supervisorRoleLabel(s.Role), internName), "/interns")
+	}
+	return nil
+}
+
+func supervisorRoleLabel(role string) string {
+	switch role {
+	case models.SupervisorRolePrimary:
+		return "utama"
+	case models.SupervisorRoleSecondary:
+		return "pendamping"
+	default:
+		return "akademik"
+	}
+}
+
+// IsCoSupervisor tells whether userID is a secondary or academic
+// supervisor of the intern
+func IsCoSupervisor(db *sql.DB, userID, internID int64) bool {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM intern_supervisors WHERE intern_id = ? AND user_id = ?", internID, userID).Scan(&n)
+	return err == nil && n > 0
+}
+
+// CampusInstitution returns the institution of a campus supervisor account
+func CampusInstitution(db *sql.DB, userID int64) (int64, bool) {
+	var role string
+	var institutionID sql.NullInt64
+	if err := db.QueryRow("SELECT role, institution_id FROM users WHERE id = ?", userID).Scan(&role, &institutionID); err != nil {
+		return 0, false
+	}
+	if strings.ToLower(role) != models.RoleCampusSupervisor || !institutionID.Valid {
+		return 0, false
+	}
+	return institutionID.Int64, true
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"strconv"
+	"time"
+)
+
+// ReleaseScheduledTasks moves scheduled tasks whose start date has arrived
+// and whose prerequisites are all completed to pending.
+func ReleaseScheduledTasks(db *sql.DB) {
+	rows, err := db.Query("SELECT id FROM tasks WHERE status = 'scheduled' AND start_date <= ?", time.Now())
+	if err != nil {
+		log.Printf("Error checking scheduled tasks: %v", err)
+		return
+	}
+	releaseTasks(db, rows)
+}
+
+// ReleaseDependents is called when a task completes so tasks blocked by it
+// can start without waiting for the next scheduler tick.
+func ReleaseDependents(db *sql.DB, taskID int64) {
+	rows, err := db.Query(
+		`SELECT t.id FROM task_dependencies d
+		 JOIN tasks t ON d.task_id = t.id
+		 WHERE d.depends_on_task_id = ? AND t.status = 'scheduled' AND t.start_date <= ?`,
+		taskID, time.Now(),
+	)
+	if err != nil {
+		log.Printf("Error checking dependents of task %d: %v", taskID, err)
+		return
+	}
+	releaseTasks(db, rows)
+}
+
+// ReleaseTask releases a single scheduled task if it is ready to start
+func ReleaseTask(db *sql.DB, taskID int64) {
+	releaseTaskIDs(db, []int64{taskID})
+}
+
+// HasOpenPrerequisites reports whether any task that taskID depends on is not completed yet
+func HasOpenPrerequisites(db *sql.DB, taskID int64) (bool, error) {
+	var open int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM task_dependencies d
+		 JOIN tasks p ON d.depends_on_task_id = p.id
+		 WHERE d.task_id = ? AND p.status != 'completed'`, taskID,
+	).Scan(&open)
+	return open > 0, err
+}
+
+func releaseTasks(db *sql.DB, rows *sql.Rows) {
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	releaseTaskIDs(db, ids)
+}
+
+func releaseTaskIDs(db *sql.DB, ids []int64) {
+	for _, id := range ids {
+		blocked, err := HasOpenPrerequisites(db, id)
+		if err != nil || blocked {
+			continue
+		}
+
+		res, err := db.Exec("UPDATE tasks SET status = 'pending' WHERE id = ? AND status = 'scheduled' AND start_date <= ?", id, time.Now())
+		if err != nil {
+			log.Printf("Error releasing task %d: %v", id, err)
+			continue
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			continue
+		}
+
+		// Scheduled tasks are not announced on creation, so notify the intern now
+		var internID, userID int64
+		var title string
+		if err := db.QueryRow(
+			`SELECT i.id, i.user_id, t.title FROM tasks t JOIN interns i ON t.intern_id = i.id WHERE t.id = ?`, id,
+		).Scan(&internID, &userID, &title); err != nil {
+			continue
+		}
+		QueueRollupRefresh(db, internID)
+		if err := SendNotification(
+			db, userID, "task_assigned", "Tugas Baru: "+title, "Tugas Anda sudah dapat dikerjakan.", "/tasks/"+strconv.FormatInt(id, 10),
+		); err != nil {
+			log.Printf("Error notifying user %d about task %d: %v", userID, id, err)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemplateInstance describes one expansion of a task template
+type TemplateInstance struct {
+	TemplateID   int64
+	RecurrenceID int64 // 0 when instantiated by hand
+	AssignedBy   int64
+	AssignToAll  bool
+	InternIDs    []int64
+	BaseDate     time.Time // offsets are applied to this date
+}
+
+var ErrNoInterns = errors.New("no interns found for assignment")
+
+// templateExpansion is what instantiateTemplateTx created
+type templateExpansion struct {
+	AssignmentID int64
+	Title        string
+	Scheduled    bool
+	UserIDs      []int64 // parallel to TaskIDs
+	TaskIDs      []int64
+}
+
+// InstantiateTemplate creates a task assignment with one task per intern
+// from a template, copying its checklist onto every task.
+func InstantiateTemplate(db *sql.DB, inst TemplateInstance) (int64, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	exp, err := instantiateTemplateTx(tx, inst)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	exp.announce(db)
+	return exp.AssignmentID, len(exp.TaskIDs), nil
+}
+
+// instantiateTemplateTx does the work of InstantiateTemplate inside the
+// caller's transaction
+func instantiateTemplateTx(tx *sql.Tx, inst TemplateInstance) (templateExpansion, error) {
+	var exp templateExpansion
+	var tpl struct {
+		Title              string
+		Description        sql.NullString
+		SubmissionMethod   string
+		Priority           string
+		StartOffsetDays    int
+		DeadlineOffsetDays sql.NullInt64
+		DeadlineTime       sql.NullString
+		Checklist          sql.NullString
+	}
+	err := tx.QueryRow(
+		`SELECT title, description, submission_method, priority, start_offset_days, deadline_offset_days, deadline_time, checklist
+		 FROM task_templates WHERE id = ?`, inst.TemplateID,
+	).Scan(&tpl.Title, &tpl.Description, &tpl.SubmissionMethod, &tpl.Priority, &tpl.StartOffsetDays,
+		&tpl.DeadlineOffsetDays, &tpl.DeadlineTime, &tpl.Checklist)
+	if err != nil {
+		return exp, err
+	}
+
+	var checklist []string
+	if tpl.Checklist.Valid {
+		_ = json.Unmarshal([]byte(tpl.Checklist.String), &checklist)
+	}
+
+	base := time.Date(inst.BaseDate.Year(), inst.BaseDate.Month(), inst.BaseDate.Day(), 0, 0, 0, 0, time.Local)
+	startDate := base.AddDate(0, 0, tpl.StartOffsetDays)
+	var deadline sql.NullTime
+	if tpl.DeadlineOffsetDays.Valid {
+		deadline = sql.NullTime{Time: base.AddDate(0, 0, int(tpl.DeadlineOffsetDays.Int64)), Valid: true}
+	}
+	isScheduled := startDate.After(time.Now())
+	status := "pending"
+	if isScheduled {
+		status = "scheduled"
+	}
+
+	type internRow struct {
+		ID     int64
+		UserID int64
+	}
+	var interns []internRow
+	var rows *sql.Rows
+	if inst.AssignToAll {
+		rows, err = tx.Query("SELECT id, user_id FROM interns WHERE status = 'active'")
+	} else {
+		if len(inst.InternIDs) == 0 {
+			return exp, ErrNoInterns
+		}
+		args := make([]interface{}, 0, len(inst.InternIDs))
+		for _, id := range inst.InternIDs {
+			args = append(args, id)
+		}
+		rows, err = tx.Query(
+			"SELECT id, user_id FROM interns WHERE status = 'active' AND id IN (?"+strings.Repeat(",?", len(args)-1)+")", args...,
+		)
+	}
+	if err != nil {
+		return exp, err
+	}
+	for rows.Next() {
+		var it internRow
+		if err := rows.Scan(&it.ID, &it.UserID); err == nil {
+			interns = append(interns, it)
+		}
+	}
+	rows.Close()
+	if len(interns) == 0 {
+		return exp, ErrNoInterns
+	}
+
+	var recurrenceID sql.NullInt64
+	if inst.RecurrenceID > 0 {
+		recurrenceID = sql.NullInt64{Int64: inst.RecurrenceID, Valid: true}
+	}
+	res, err := tx.Exec(
+		`INSERT INTO task_assignments (title, description, assigned_by, priority, start_date, deadline, deadline_time, assign_to_all, template_id, recurrence_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		tpl.Title, tpl.Description, inst.AssignedBy, tpl.Priority, startDate, deadline, tpl.DeadlineTime, inst.AssignToAll,
+		inst.TemplateID, recurrenceID,
+	)
+	if err != nil {
+		return exp, err
+	}
+	assignmentID, _ := res.LastInsertId()
+
+	taskIDs := make([]int64, 0, len(interns))
+	for _, it := range interns {
+		if _, err := tx.Exec(
+			"INSERT INTO task_assignment_interns (task_assignment_id, intern_id) VALUES (?, ?)", assignmentID, it.ID,
+		); err != nil {
+			return exp, err
+		}
+
+		res, err := tx.Exec(
+			`INSERT INTO tasks (task_assignment_id, title, description, submission_method, intern_id, assigned_by, priority, status, start_date, deadline, deadline_time, is_unscheduled, assigner_id)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?)`,
+			assignmentID, tpl.Title, tpl.Description, tpl.SubmissionMethod, it.ID, inst.AssignedBy, tpl.Priority, status,
+			startDate, deadline, tpl.DeadlineTime, inst.AssignedBy,
+		)
+		if err != nil {
+			return exp, err
+		}
+		taskID, _ := res.LastInsertId()
+		taskIDs = append(taskIDs, taskID)
+		QueueRollupRefresh(tx, it.ID)
+
+		for i, item := range checklist {
+			if _, err := tx.Exec(
+				"INSERT INTO task_checklist_items (task_id, title, position) VALUES (?, ?, ?)", taskID, item, i,
+			); err != nil {
+				return exp, err
+			}
+		}
+	}
+
+	exp.AssignmentID = assignmentID
+	exp.Title = tpl.Title
+	exp.Scheduled = isScheduled
+	for _, it := range interns {
+		exp.UserIDs = append(exp.UserIDs, it.UserID)
+	}
+	exp.TaskIDs = taskIDs
+	return exp, nil
+}
+
+// announce notifies the interns of their new tasks. Scheduled tasks are
+// announced by ReleaseScheduledTasks once they start.
+func (exp templateExpansion) announce(db *sql.DB) {
+	if exp.Scheduled {
+		return
+	}
+	for i, userID := range exp.UserIDs {
+		if err := SendNotification(
+			db, userID, "task_assigned", "Tugas Baru: "+exp.Title,
+			"Anda mendapat tugas baru. Silakan cek detail tugas Anda.",
+			"/tasks/"+strconv.FormatInt(exp.TaskIDs[i], 10),
+		); err != nil {
+			log.Printf("Error notifying user %d about task %d: %v", userID, exp.TaskIDs[i], err)
+		}
+	}
+}
+
+// ExpandRecurringTasks instantiates every active recurrence that is due.
+// Missed runs (e.g. while the server was down) are collapsed into one run
+// instead of flooding interns with back-dated tasks.
+func ExpandRecurringTasks(db *sql.DB) {
+	today := truncateDay(time.Now())
+
+	rows, err := db.Query(
+		`SELECT id, template_id, frequency, weekday, day_of_month, assign_to, intern_ids, ends_on, next_run_on, created_by
+		 FROM task_recurrences WHERE is_active = 1 AND next_run_on <= ?`, today,
+	)
+	if err != nil {
+		log.Printf("Error checking task recurrences: %v", err)
+		return
+	}
+
+	type recurrence struct {
+		ID         int64
+		TemplateID int64
+		Frequency  string
+		Weekday    sql.NullInt64
+		DayOfMonth sql.NullInt64
+		AssignTo   string
+		InternIDs  sql.NullString
+		EndsOn     sql.NullTime
+		NextRunOn  time.Time
+		CreatedBy  int64
+	}
+	var due []recurrence
+	for rows.Next() {
+		var rc recurrence
+		if err := rows.Scan(&rc.ID, &rc.TemplateID, &rc.Frequency, &rc.Weekday, &rc.DayOfMonth, &rc.AssignTo,
+			&rc.InternIDs, &rc.EndsOn, &rc.NextRunOn, &rc.CreatedBy); err == nil {
+			due = append(due, rc)
+		}
+	}
+	rows.Close()
+
+	for _, rc := range due {
+		runOn := truncateDay(rc.NextRunOn)
+		if recurrenceEnded(runOn, rc.EndsOn) {
+			_, _ = db.Exec("UPDATE task_recurrences SET is_active = 0 WHERE id = ?", rc.ID)
+			continue
+		}
+
+		next := runOn
+		for !next.After(today) {
+			next = NextOccurrence(rc.Frequency, nullIntPtr(rc.Weekday), nullIntPtr(rc.DayOfMonth), next)
+		}
+
+		var internIDs []int64
+		if rc.InternIDs.Valid {
+			_ = json.Unmarshal([]byte(rc.InternIDs.String), &internIDs)
+		}
+		exp, claimed, err := expandRecurrence(db, rc.ID, rc.NextRunOn, next, TemplateInstance{
+			TemplateID:   rc.TemplateID,
+			RecurrenceID: rc.ID,
+			AssignedBy:   rc.CreatedBy,
+			AssignToAll:  rc.AssignTo == "all",
+			InternIDs:    internIDs,
+			BaseDate:     runOn,
+		})
+		if err != nil {
+			log.Printf("Error expanding task recurrence %d: %v", rc.ID, err)
+			continue
+		}
+		if claimed {
+			exp.announce(db)
+		}
+	}
+}
+
+// expandRecurrence claims a due run by advancing next_run_on to next and
+// instantiates the template in the same transaction. A concurrent
+// scheduler's claim waits on the row lock and then matches nothing
+// (claimed is false); a failed expansion leaves the run due for a retry.
+// A run with no active interns to assign is skipped rather than retried,
+// so the recurrence moves on to its next date.
+func expandRecurrence(db *sql.DB, recurrenceID int64, nextRunOn, next time.Time, inst TemplateInstance) (templateExpansion, bool, error) {
+	var exp templateExpansion
+	tx, err := db.Begin()
+	if err != nil {
+		return exp, false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE task_recurrences SET next_run_on = ?, last_run_on = ? WHERE id = ? AND next_run_on = ?",
+		next, inst.BaseDate, recurrenceID, nextRunOn,
+	)
+	if err != nil {
+		return exp, false, err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exp, false, nil
+	}
+	exp, err = instantiateTemplateTx(tx, inst)
+	if err == ErrNoInterns {
+		log.Printf("Skipping run %s of task recurrence %d: no active interns to assign", inst.BaseDate.Format("2006-01-02"), recurrenceID)
+		return exp, false, tx.Commit()
+	}
+	if err != nil {
+		return exp, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return exp, false, err
+	}
+	return exp, true, nil
+}
+
+// NextOccurrence returns the first date strictly after `after` that matches the rule.
+// Weekly rules use weekday (0 = Sunday), monthly rules use dayOfMonth (1-28);
+// a later day is moved to the last day of shorter months.
+func NextOccurrence(frequency string, weekday, dayOfMonth *int, after time.Time) time.Time {
+	day := truncateDay(after)
+	switch frequency {
+	case "weekly":
+		target := int(day.Weekday())
+		if weekday != nil {
+			target = *weekday
+		}
+		diff := (target - int(day.Weekday()) + 7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		return day.AddDate(0, 0, diff)
+	case "monthly":
+		dom := day.Day()
+		if dayOfMonth != nil {
+			dom = *dayOfMonth
+		}
+		candidate := dayInMonth(day.Year(), day.Month(), dom, day.Location())
+		if !candidate.After(day) {
+			candidate = dayInMonth(day.Year(), day.Month()+1, dom, day.Location())
+		}
+		return candidate
+	default:
+		return day.AddDate(0, 0, 1)
+	}
+}
+
+// dayInMonth returns the given day of the month, or the month's last day
+// when the month is shorter
+func dayInMonth(year int, month time.Month, day int, loc *time.Location) time.Time {
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day(); day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// recurrenceEnded reports whether a run on runOn falls after the
+// recurrence's end date
+func recurrenceEnded(runOn time.Time, endsOn sql.NullTime) bool {
+	return endsOn.Valid && truncateDay(runOn).After(truncateDay(endsOn.Time))
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	i := int(v.Int64)
+	return &i
+}
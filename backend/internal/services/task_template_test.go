@@ -0,0 +1,61 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		name       string
+		frequency  string
+		weekday    *int
+		dayOfMonth *int
+		after      time.Time
+		want       time.Time
+	}{
+		{"daily", "daily", nil, nil, date(2026, time.March, 10), date(2026, time.March, 11)},
+		{"daily ignores time of day", "daily", nil, nil, time.Date(2026, time.March, 10, 23, 30, 0, 0, time.Local), date(2026, time.March, 11)},
+		{"daily across year end", "daily", nil, nil, date(2026, time.December, 31), date(2027, time.January, 1)},
+		{"weekly later in the week", "weekly", intPtr(int(time.Friday)), nil, date(2026, time.March, 10), date(2026, time.March, 13)},
+		{"weekly earlier in the week", "weekly", intPtr(int(time.Monday)), nil, date(2026, time.March, 10), date(2026, time.March, 16)},
+		{"weekly same weekday rolls a week", "weekly", intPtr(int(time.Tuesday)), nil, date(2026, time.March, 10), date(2026, time.March, 17)},
+		{"weekly without weekday keeps the day", "weekly", nil, nil, date(2026, time.March, 10), date(2026, time.March, 17)},
+		{"monthly later this month", "monthly", nil, intPtr(15), date(2026, time.March, 10), date(2026, time.March, 15)},
+		{"monthly same day rolls a month", "monthly", nil, intPtr(10), date(2026, time.March, 10), date(2026, time.April, 10)},
+		{"monthly across year end", "monthly", nil, intPtr(5), date(2026, time.December, 20), date(2027, time.January, 5)},
+		{"monthly 31st clamps to February", "monthly", nil, intPtr(31), date(2026, time.January, 31), date(2026, time.February, 28)},
+		{"monthly 31st clamps to leap February", "monthly", nil, intPtr(31), date(2028, time.January, 31), date(2028, time.February, 29)},
+		{"monthly 31st after clamped February", "monthly", nil, intPtr(31), date(2026, time.February, 28), date(2026, time.March, 31)},
+		{"monthly 31st in a 30-day month", "monthly", nil, intPtr(31), date(2026, time.April, 1), date(2026, time.April, 30)},
+	}
+	for _, tt := range tests {
+		if got := NextOccurrence(tt.frequency, tt.weekday, tt.dayOfMonth, tt.after); !got.Equal(tt.want) {
+			t.Errorf("%s: NextOccurrence = %s, want %s", tt.name, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestRecurrenceEnded(t *testing.T) {
+	endsOn := sql.NullTime{Time: time.Date(2026, time.March, 31, 0, 0, 0, 0, time.Local), Valid: true}
+	tests := []struct {
+		name   string
+		runOn  time.Time
+		endsOn sql.NullTime
+		want   bool
+	}{
+		{"before end date", time.Date(2026, time.March, 30, 0, 0, 0, 0, time.Local), endsOn, false},
+		{"on end date", time.Date(2026, time.March, 31, 0, 0, 0, 0, time.Local), endsOn, false},
+		{"on end date with time of day", time.Date(2026, time.March, 31, 18, 0, 0, 0, time.Local), endsOn, false},
+		{"after end date", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.Local), endsOn, true},
+		{"no end date", time.Date(2030, time.January, 1, 0, 0, 0, 0, time.Local), sql.NullTime{}, false},
+	}
+	for _, tt := range tests {
+		if got := recurrenceEnded(tt.runOn, tt.endsOn); got != tt.want {
+			t.Errorf("%s: recurrenceEnded = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
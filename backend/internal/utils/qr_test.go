@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+func TestQRCodePNGEmbedsInPDF(t *testing.T) {
+	png, err := QRCodePNG("https://example.com/verify/CERT-2026-0001?sig=abc", 256)
+	if err != nil {
+		t.Fatalf("QRCodePNG error: %v", err)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.RegisterImageOptionsReader("qr", gofpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(png))
+	pdf.ImageOptions("qr", 20, 20, 30, 30, false, gofpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	if err := pdf.Output(io.Discard); err != nil {
+		t.Fatalf("Output error: %v", err)
+	}
+}